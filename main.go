@@ -1,22 +1,85 @@
 package main
 
 import (
+	"os"
+	"time"
+
 	"github.com/gin-gonic/gin"
+	"github.com/mgr1054/go-ticket/pkg/clock"
+	"github.com/mgr1054/go-ticket/pkg/config"
 	"github.com/mgr1054/go-ticket/pkg/controller"
 	"github.com/mgr1054/go-ticket/pkg/db"
+	"github.com/mgr1054/go-ticket/pkg/entry"
+	"github.com/mgr1054/go-ticket/pkg/keyring"
+	"github.com/mgr1054/go-ticket/pkg/mail"
 	"github.com/mgr1054/go-ticket/pkg/middleware"
+	"github.com/mgr1054/go-ticket/pkg/payment"
+	"github.com/mgr1054/go-ticket/pkg/store"
+	"github.com/mgr1054/go-ticket/pkg/sweeper"
 	"github.com/mgr1054/go-ticket/pkg/utils"
 	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
 
 	_ "github.com/mgr1054/go-ticket/pkg/docs"
+	// event timezones must resolve in images without a zoneinfo database
+	_ "time/tzdata"
 
 	swaggerFiles "github.com/swaggo/files"
    	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
+var cfg config.Config
+
 func init() {
-	db.Connect()
-	utils.InitAdmin()
+	var err error
+	cfg, err = config.Load(os.Getenv("CONFIG_FILE"))
+	if err != nil {
+		log.Fatalln(err)
+	}
+	log.Info("Running in ", cfg.Environment, " mode")
+}
+
+// creates the configured mailer, only smtp delivers mails to the users
+func openMailer(cfg config.Mail) mail.Mailer {
+	switch cfg.Provider {
+	case "smtp":
+		return mail.NewSMTP(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.From)
+	case "file":
+		mailer, err := mail.NewFile(cfg.Dir, cfg.From)
+		if err != nil {
+			log.Fatalln("Could not open the mail directory: ", err)
+		}
+		return mailer
+	}
+	return mail.NewMemory()
+}
+
+// opens the SQL database of the configured backend
+func openDB(cfg config.Config) *gorm.DB {
+	switch cfg.Storage.Backend {
+	case "sqlite":
+		return db.ConnectSQLite(cfg.Storage.SQLitePath)
+	case "postgres":
+		return db.Connect(cfg.Database)
+	}
+	log.Fatalln("Storage backend", cfg.Storage.Backend, "has no SQL database")
+	return nil
+}
+
+// selects the storage backend: postgres, sqlite or memory
+func openStore(cfg config.Config) *store.Store {
+	if cfg.Storage.Backend == "memory" {
+		log.Info("Using in-memory storage, data is lost on restart")
+		return store.NewMemory()
+	}
+
+	gormDB := openDB(cfg)
+	if cfg.Storage.AutoMigrate {
+		if err := db.MigrateUp(gormDB); err != nil {
+			log.Fatalln(err)
+		}
+	}
+	return store.NewGorm(gormDB)
 }
 
 // @title Go-Ticket API
@@ -30,38 +93,144 @@ func init() {
 // @host localhost:8080
 // @BasePath /api
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(os.Args[2:])
+		return
+	}
+
 	log.Info("Starting API server")
+
+	stores := openStore(cfg)
+	utils.InitAdmin(stores.Users, cfg.Admin)
+
+	clk := clock.System{}
+
+	keys, err := keyring.New(stores.SigningKeys, cfg.Auth)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if _, err := keys.Rotate(clk.Now()); err != nil {
+		log.Fatalln("Could not load the signing keys: ", err)
+	}
+
+	payments := payment.NewMock(cfg.Payments.WebhookSecret, cfg.Payments.MockWebhookURL, cfg.Payments.MockDelay.Duration)
+	if cfg.Environment == config.Production {
+		log.Warn("The mock payment provider does not charge anything")
+	}
+	if cfg.Environment == config.Production && cfg.Mail.Provider != "smtp" {
+		log.Warn("The ", cfg.Mail.Provider, " mail provider does not deliver verification mails")
+	}
+
+	sweep := sweeper.New(clk, cfg.Holds.SweepInterval.Duration)
+	// also loads the keys other instances created
+	sweep.Add("rotate signing keys", keys.Rotate)
+	sweep.Add("expire holds", stores.Holds.ExpireAll)
+	sweep.Add("fail pending payments", func(now time.Time) (int64, error) {
+		return stores.Payments.ExpirePending(now, cfg.Payments.PendingTimeout.Duration)
+	})
+	sweep.Add("expire transfers", stores.Transfers.ExpireAll)
+	sweep.Add("withdraw resale listings", stores.Listings.WithdrawStarted)
+	sweep.Add("purge expired tokens", stores.Tokens.Purge)
+	ctrl := controller.New(stores, cfg, clk, payments, entry.NewSigner(cfg.Entry.SigningSecret), openMailer(cfg.Mail))
+	// runs last so it offers everything the other jobs gave back, mails the offered users
+	sweep.Add("offer waitlist tickets", ctrl.OfferWaitlists)
+	go sweep.Run(nil)
 	
 	router := gin.Default()
 
-	url := ginSwagger.URL("http://localhost:8080/swagger/doc.json")
+	url := ginSwagger.URL(cfg.Server.SwaggerURL)
 
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler, url))
+	router.GET("/.well-known/jwks.json", ctrl.GetJWKS)
 
 	api := router.Group("/api") 
 	{
 		api.GET("/", controller.Health)
-		api.POST("/token", controller.GenerateToken)
-		api.POST("/user/register", controller.RegisterUser)
+		api.POST("/token", ctrl.GenerateToken)
+		api.POST("/token/refresh", ctrl.RefreshToken)
+		api.POST("/logout", middlewares.Auth(stores.Tokens), ctrl.Logout)
+		api.POST("/user/register", ctrl.RegisterUser)
+		api.GET("/user/verify", ctrl.VerifyEmail)
+		api.POST("/user/verify/resend", ctrl.ResendVerification)
+		api.POST("/payments/webhook", ctrl.PaymentWebhook)
+		api.GET("/entry/public-key", ctrl.GetEntryPublicKey)
 
-		secured := api.Group("/secured").Use(middlewares.Auth())
+		secured := api.Group("/secured").Use(middlewares.Auth(stores.Tokens))
 		{
-			secured.GET("/events", controller.GetEvents)
-			secured.GET("/events/:id", controller.GetEventByID)
-			secured.GET("/events/location/:location", controller.GetEventByLocation)
-			secured.GET("/events/date/:date", controller.GetEventByDate)
-			secured.POST("/events", controller.CreateEvent)
-			secured.PUT("/events/:id", controller.UpdateEventById)
-			secured.DELETE("/events/:id", controller.DeleteEventById)
-			secured.GET("/tickets/:id", controller.CreateTicket)
-			secured.GET("/tickets/event/:id", controller.GetTicketsByEvent)
-			secured.DELETE("/tickets/:id", controller.DeleteTicketById)
-			secured.GET("/tickets/user", controller.GetTickets)
-			secured.GET("/user/:id", controller.GetUserById)
-			secured.PUT("/user/:id", controller.UpdateUserById)
-			secured.DELETE("/user/:id", controller.DelteUserById)
+			secured.GET("/events", ctrl.GetEvents)
+			secured.GET("/events/:id", ctrl.GetEventByID)
+			secured.GET("/events/location/:location", ctrl.GetEventByLocation)
+			// deprecated, /events?from=&to= replaces it
+			secured.GET("/events/date/:date", ctrl.GetEventByDate)
+			secured.POST("/events", ctrl.CreateEvent)
+			secured.PUT("/events/:id", ctrl.UpdateEventById)
+			secured.DELETE("/events/:id", ctrl.DeleteEventById)
+			secured.POST("/events/:id/cancel", ctrl.CancelEvent)
+			secured.GET("/venues", ctrl.GetVenues)
+			secured.GET("/venues/:id", ctrl.GetVenueById)
+			secured.POST("/venues", ctrl.CreateVenue)
+			secured.PUT("/venues/:id", ctrl.UpdateVenueById)
+			secured.DELETE("/venues/:id", ctrl.DeleteVenueById)
+			secured.GET("/venues/:id/seats", ctrl.GetVenueSeats)
+			secured.PUT("/venues/:id/seats", ctrl.SetVenueSeats)
+			secured.GET("/events/:id/seats", ctrl.GetEventSeats)
+			secured.POST("/events/:id/seats", ctrl.ResetEventSeats)
+			secured.GET("/events/:id/ticket-types", ctrl.GetTicketTypes)
+			secured.POST("/events/:id/ticket-types", ctrl.CreateTicketType)
+			secured.PUT("/ticket-types/:id", ctrl.UpdateTicketType)
+			secured.DELETE("/ticket-types/:id", ctrl.DeleteTicketType)
+			secured.GET("/events/:id/presale-codes", ctrl.GetPresaleCodes)
+			secured.POST("/events/:id/presale-codes", ctrl.CreatePresaleCode)
+			secured.PUT("/presale-codes/:id", ctrl.UpdatePresaleCode)
+			secured.DELETE("/presale-codes/:id", ctrl.DeletePresaleCode)
+			secured.GET("/promotions", ctrl.GetPromotions)
+			secured.GET("/promotions/:id", ctrl.GetPromotionById)
+			secured.POST("/promotions", ctrl.CreatePromotion)
+			secured.PUT("/promotions/:id", ctrl.UpdatePromotionById)
+			secured.DELETE("/promotions/:id", ctrl.DeletePromotionById)
+			secured.GET("/events/:id/waitlist", ctrl.GetEventWaitlist)
+			secured.POST("/events/:id/waitlist", ctrl.JoinWaitlist)
+			secured.GET("/waitlist", ctrl.GetWaitlist)
+			secured.DELETE("/waitlist/:id", ctrl.LeaveWaitlist)
+			secured.GET("/events/:id/refund-policy", ctrl.GetRefundPolicy)
+			secured.PUT("/events/:id/refund-policy", ctrl.SetRefundPolicy)
+			secured.GET("/tickets/:id", ctrl.CreateTicket)
+			secured.GET("/tickets/event/:id", ctrl.GetTicketsByEvent)
+			secured.DELETE("/tickets/:id", ctrl.DeleteTicketById)
+			secured.GET("/tickets/:id/pass", ctrl.GetTicketPass)
+			secured.GET("/tickets/:id/qr", ctrl.GetTicketQRCode)
+			secured.GET("/tickets/:id/pdf", ctrl.GetTicketPDF)
+			secured.GET("/tickets/:id/checkins", ctrl.GetTicketCheckIns)
+			secured.POST("/tickets/:id/transfer", ctrl.TransferTicket)
+			secured.GET("/tickets/:id/transfers", ctrl.GetTicketTransfers)
+			secured.GET("/transfers", ctrl.GetTransfers)
+			secured.POST("/transfers/:id/accept", ctrl.AcceptTransfer)
+			secured.POST("/transfers/:id/decline", ctrl.DeclineTransfer)
+			secured.DELETE("/transfers/:id", ctrl.CancelTransfer)
+			secured.POST("/tickets/:id/listing", ctrl.CreateListing)
+			secured.GET("/events/:id/listings", ctrl.GetEventListings)
+			secured.GET("/listings", ctrl.GetListings)
+			secured.DELETE("/listings/:id", ctrl.WithdrawListing)
+			secured.GET("/payouts", ctrl.GetPayouts)
+			secured.POST("/checkin", ctrl.CheckIn)
+			secured.POST("/checkin/sync", ctrl.SyncCheckIns)
+			secured.GET("/events/:id/snapshot", ctrl.GetScannerSnapshot)
+			secured.GET("/events/:id/checkins", ctrl.GetEventCheckIns)
+			secured.GET("/tickets/user", ctrl.GetTickets)
+			secured.POST("/orders", ctrl.CreateOrder)
+			secured.GET("/orders", ctrl.GetOrders)
+			secured.GET("/orders/:id", ctrl.GetOrderById)
+			secured.POST("/holds", ctrl.CreateHold)
+			secured.GET("/holds", ctrl.GetHolds)
+			secured.POST("/holds/:id/confirm", ctrl.ConfirmHold)
+			secured.DELETE("/holds/:id", ctrl.ReleaseHold)
+			secured.POST("/refunds", ctrl.CreateRefund)
+			secured.GET("/refunds", ctrl.GetRefunds)
+			secured.GET("/user/:id", ctrl.GetUserById)
+			secured.PUT("/user/:id", ctrl.UpdateUserById)
+			secured.DELETE("/user/:id", ctrl.DelteUserById)
 		}
 	}
 
-	router.Run("0.0.0.0:8080")
+	router.Run(cfg.Server.Address)
 }
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/mgr1054/go-ticket/pkg/db"
+	log "github.com/sirupsen/logrus"
+)
+
+const migrateUsage = `usage: go-ticket migrate <command>
+
+commands:
+  up         apply all pending migrations
+  down [n]   roll back the last n migrations (default 1)
+  status     list migrations and when they were applied`
+
+// handles the migrate subcommand against the configured SQL database
+func runMigrate(args []string) {
+	if len(args) < 1 {
+		fmt.Println(migrateUsage)
+		os.Exit(2)
+	}
+	if cfg.Storage.Backend == "memory" {
+		log.Fatalln("Migrations are not available for the memory backend")
+	}
+
+	gormDB := openDB(cfg)
+
+	switch args[0] {
+	case "up":
+		if err := db.MigrateUp(gormDB); err != nil {
+			log.Fatalln(err)
+		}
+		log.Info("Database is up to date")
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				log.Fatalln("Number of migrations to roll back must be a positive integer")
+			}
+			steps = n
+		}
+		if err := db.MigrateDown(gormDB, steps); err != nil {
+			log.Fatalln(err)
+		}
+
+	case "status":
+		status, err := db.GetMigrationStatus(gormDB)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		for _, m := range status {
+			applied := "pending"
+			if m.AppliedAt != nil {
+				applied = "applied " + m.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d  %-30s %s\n", m.Version, m.Name, applied)
+		}
+
+	default:
+		fmt.Println(migrateUsage)
+		os.Exit(2)
+	}
+}
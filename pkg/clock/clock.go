@@ -0,0 +1,40 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// source of the current time, replaced by a Fake in tests
+type Clock interface {
+	Now() time.Time
+}
+
+// reads the time of the operating system
+type System struct{}
+
+func (System) Now() time.Time {
+	return time.Now()
+}
+
+// clock that only moves when told to
+type Fake struct {
+	mu	sync.Mutex
+	now	time.Time
+}
+
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now}
+}
+
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+}
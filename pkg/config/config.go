@@ -0,0 +1,483 @@
+package config
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mgr1054/go-ticket/pkg/models"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	Development = "development"
+	Production = "production"
+
+	// insecure values that are only acceptable during development
+	DefaultJWTSecret = "supersecretkey"
+	DefaultAdminPassword = "p"
+	DefaultDatabasePassword = "p"
+	DefaultWebhookSecret = "mock-webhook-secret"
+	DefaultEntrySecret = "dev-entry-signing-secret"
+	DefaultVerificationSecret = "dev-verification-secret"
+)
+
+// complete runtime configuration, every field can be set in the config file
+// and overridden by the environment variable named in its env tag
+type Config struct {
+	Environment	string		`yaml:"environment" toml:"environment" env:"ENVIRONMENT"`
+	Server		Server		`yaml:"server" toml:"server"`
+	Storage		Storage		`yaml:"storage" toml:"storage"`
+	Database	Database	`yaml:"database" toml:"database"`
+	Auth		Auth		`yaml:"auth" toml:"auth"`
+	Entry		Entry		`yaml:"entry" toml:"entry"`
+	Admin		Admin		`yaml:"admin" toml:"admin"`
+	Holds		Holds		`yaml:"holds" toml:"holds"`
+	Waitlist	Waitlist	`yaml:"waitlist" toml:"waitlist"`
+	Transfers	Transfers	`yaml:"transfers" toml:"transfers"`
+	Payments	Payments	`yaml:"payments" toml:"payments"`
+	Refunds		Refunds		`yaml:"refunds" toml:"refunds"`
+	Mail		Mail		`yaml:"mail" toml:"mail"`
+	Verification	Verification	`yaml:"verification" toml:"verification"`
+}
+
+type Server struct {
+	Address		string		`yaml:"address" toml:"address" env:"SERVER_ADDRESS"`
+	SwaggerURL	string		`yaml:"swagger_url" toml:"swagger_url" env:"SWAGGER_URL"`
+}
+
+type Storage struct {
+	Backend		string		`yaml:"backend" toml:"backend" env:"STORAGE"`
+	SQLitePath	string		`yaml:"sqlite_path" toml:"sqlite_path" env:"SQLITE_PATH"`
+	// applies pending schema migrations when the server starts
+	AutoMigrate	bool		`yaml:"auto_migrate" toml:"auto_migrate" env:"AUTO_MIGRATE"`
+}
+
+type Database struct {
+	Host		string		`yaml:"host" toml:"host" env:"DB_HOST"`
+	Port		int			`yaml:"port" toml:"port" env:"DB_PORT"`
+	User		string		`yaml:"user" toml:"user" env:"DB_USER"`
+	Password	string		`yaml:"password" toml:"password" env:"DB_PASSWORD"`
+	Name		string		`yaml:"name" toml:"name" env:"DB_NAME"`
+	SSLMode		string		`yaml:"sslmode" toml:"sslmode" env:"DB_SSLMODE"`
+}
+
+type Auth struct {
+	// encrypts the private keys that sign access tokens in the database, changing it
+	// starts a new signing key while the old ones keep verifying
+	JWTSecret	string		`yaml:"jwt_secret" toml:"jwt_secret" env:"JWT_SECRET"`
+	// RS256 or EdDSA, a new algorithm starts a new signing key
+	Algorithm	string		`yaml:"algorithm" toml:"algorithm" env:"JWT_ALGORITHM"`
+	// how long a key signs tokens before its successor takes over
+	KeyRotation	Duration	`yaml:"key_rotation" toml:"key_rotation" env:"JWT_KEY_ROTATION"`
+	// how long the successor is published before it signs, so verifiers know it in time
+	KeyPrepublish	Duration	`yaml:"key_prepublish" toml:"key_prepublish" env:"JWT_KEY_PREPUBLISH"`
+	// lifetime of access tokens, revoked tokens stay on the revocation list as long
+	AccessTokenTTL	Duration	`yaml:"access_token_ttl" toml:"access_token_ttl" env:"ACCESS_TOKEN_TTL"`
+	// refresh tokens expire when they are not exchanged within this time
+	RefreshTokenTTL	Duration	`yaml:"refresh_token_ttl" toml:"refresh_token_ttl" env:"REFRESH_TOKEN_TTL"`
+}
+
+type Entry struct {
+	// seed of the Ed25519 key that signs the entry passes of tickets, changing it
+	// invalidates all passes handed out so far
+	SigningSecret	string		`yaml:"signing_secret" toml:"signing_secret" env:"ENTRY_SIGNING_SECRET"`
+}
+
+type Admin struct {
+	Email		string		`yaml:"email" toml:"email" env:"ADMIN_EMAIL"`
+	Password	string		`yaml:"password" toml:"password" env:"ADMIN_PASSWORD"`
+}
+
+type Holds struct {
+	// how long reserved tickets are kept before they are released again
+	TTL				Duration	`yaml:"ttl" toml:"ttl" env:"HOLD_TTL"`
+	SweepInterval	Duration	`yaml:"sweep_interval" toml:"sweep_interval" env:"HOLD_SWEEP_INTERVAL"`
+}
+
+type Waitlist struct {
+	// how long waitlisted users have to buy the tickets offered to them
+	OfferTTL		Duration	`yaml:"offer_ttl" toml:"offer_ttl" env:"WAITLIST_OFFER_TTL"`
+}
+
+type Transfers struct {
+	// how long recipients have to accept a ticket, transfers end at the start of the event anyway
+	TTL				Duration	`yaml:"ttl" toml:"ttl" env:"TRANSFER_TTL"`
+}
+
+type Payments struct {
+	Provider		string		`yaml:"provider" toml:"provider" env:"PAYMENT_PROVIDER"`
+	Currency		string		`yaml:"currency" toml:"currency" env:"PAYMENT_CURRENCY"`
+	WebhookSecret	string		`yaml:"webhook_secret" toml:"webhook_secret" env:"PAYMENT_WEBHOOK_SECRET"`
+	// payments without a result after this time are failed and their tickets given back
+	PendingTimeout	Duration	`yaml:"pending_timeout" toml:"pending_timeout" env:"PAYMENT_PENDING_TIMEOUT"`
+	// where the mock provider delivers its webhooks and how long it waits for delayed results
+	MockWebhookURL	string		`yaml:"mock_webhook_url" toml:"mock_webhook_url" env:"PAYMENT_MOCK_WEBHOOK_URL"`
+	MockDelay		Duration	`yaml:"mock_delay" toml:"mock_delay" env:"PAYMENT_MOCK_DELAY"`
+}
+
+// refund policy of events that have no policy of their own
+type Refunds struct {
+	// cancellations at least this many days before the event are refunded in full
+	FullRefundDays			int		`yaml:"full_refund_days" toml:"full_refund_days" env:"REFUND_FULL_DAYS"`
+	// later cancellations at least this many days before the event get the partial percentage back
+	PartialRefundDays		int		`yaml:"partial_refund_days" toml:"partial_refund_days" env:"REFUND_PARTIAL_DAYS"`
+	PartialRefundPercent	int		`yaml:"partial_refund_percent" toml:"partial_refund_percent" env:"REFUND_PARTIAL_PERCENT"`
+}
+
+type Mail struct {
+	// smtp, file or memory
+	Provider		string		`yaml:"provider" toml:"provider" env:"MAIL_PROVIDER"`
+	From			string		`yaml:"from" toml:"from" env:"MAIL_FROM"`
+	// directory the file mailer writes the mails to
+	Dir				string		`yaml:"dir" toml:"dir" env:"MAIL_DIR"`
+	SMTPHost		string		`yaml:"smtp_host" toml:"smtp_host" env:"MAIL_SMTP_HOST"`
+	SMTPPort		int			`yaml:"smtp_port" toml:"smtp_port" env:"MAIL_SMTP_PORT"`
+	// mails are sent unauthenticated without username
+	SMTPUsername	string		`yaml:"smtp_username" toml:"smtp_username" env:"MAIL_SMTP_USERNAME"`
+	SMTPPassword	string		`yaml:"smtp_password" toml:"smtp_password" env:"MAIL_SMTP_PASSWORD"`
+}
+
+type Verification struct {
+	// signs the verification links, changing it invalidates all links sent so far
+	Secret		string		`yaml:"secret" toml:"secret" env:"VERIFICATION_SECRET"`
+	// how long a verification link works
+	TTL			Duration	`yaml:"ttl" toml:"ttl" env:"VERIFICATION_TTL"`
+	// address of the verification endpoint as users reach it, the token is added as query parameter
+	URL			string		`yaml:"url" toml:"url" env:"VERIFICATION_URL"`
+}
+
+// time.Duration that is written as "10m" or "1h30m" in files and environment
+type Duration struct {
+	time.Duration
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	d.Duration = parsed
+	return nil
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// returns the configuration used when nothing else is set,
+// matches the docker compose setup
+func Default() Config {
+	return Config{
+		Environment: Development,
+		Server: Server{
+			Address: "0.0.0.0:8080",
+			SwaggerURL: "http://localhost:8080/swagger/doc.json",
+		},
+		Storage: Storage{
+			Backend: "postgres",
+			SQLitePath: "go-ticket.db",
+			AutoMigrate: true,
+		},
+		Database: Database{
+			Host: "database",
+			Port: 5432,
+			User: "admin",
+			Password: DefaultDatabasePassword,
+			Name: "postgres",
+			SSLMode: "disable",
+		},
+		Auth: Auth{
+			JWTSecret: DefaultJWTSecret,
+			Algorithm: "EdDSA",
+			KeyRotation: Duration{30 * 24 * time.Hour},
+			KeyPrepublish: Duration{time.Hour},
+			AccessTokenTTL: Duration{15 * time.Minute},
+			RefreshTokenTTL: Duration{30 * 24 * time.Hour},
+		},
+		Entry: Entry{
+			SigningSecret: DefaultEntrySecret,
+		},
+		Admin: Admin{
+			Email: "admin@go-ticket.com",
+			Password: DefaultAdminPassword,
+		},
+		Holds: Holds{
+			TTL: Duration{10 * time.Minute},
+			SweepInterval: Duration{time.Minute},
+		},
+		Waitlist: Waitlist{
+			OfferTTL: Duration{30 * time.Minute},
+		},
+		Transfers: Transfers{
+			TTL: Duration{48 * time.Hour},
+		},
+		Payments: Payments{
+			Provider: "mock",
+			Currency: "EUR",
+			WebhookSecret: DefaultWebhookSecret,
+			PendingTimeout: Duration{30 * time.Minute},
+			MockWebhookURL: "http://localhost:8080/api/payments/webhook",
+			MockDelay: Duration{2 * time.Second},
+		},
+		Refunds: Refunds{
+			FullRefundDays: 7,
+			PartialRefundDays: 0,
+			PartialRefundPercent: 0,
+		},
+		Mail: Mail{
+			Provider: "file",
+			From: "Go Ticket <no-reply@go-ticket.com>",
+			Dir: "mail",
+			SMTPPort: 587,
+		},
+		Verification: Verification{
+			Secret: DefaultVerificationSecret,
+			TTL: Duration{24 * time.Hour},
+			URL: "http://localhost:8080/api/user/verify",
+		},
+	}
+}
+
+// builds the configuration from defaults, the optional file at path
+// (.yaml, .yml or .toml) and the environment, in that order
+func Load(path string) (Config, error) {
+	cfg := Default()
+
+	if path != "" {
+		if err := loadFile(path, &cfg); err != nil {
+			return cfg, err
+		}
+	}
+
+	if err := loadEnv(reflect.ValueOf(&cfg).Elem()); err != nil {
+		return cfg, err
+	}
+
+	return cfg, cfg.Validate()
+}
+
+func loadFile(path string, cfg *Config) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("could not read config file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, cfg)
+	case ".toml":
+		err = toml.Unmarshal(content, cfg)
+	default:
+		return fmt.Errorf("unsupported config file type %q", filepath.Ext(path))
+	}
+	if err != nil {
+		return fmt.Errorf("could not parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// overrides every field carrying an env tag whose variable is set
+func loadEnv(v reflect.Value) error {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+		tag := v.Type().Field(i).Tag.Get("env")
+
+		if unmarshaler, ok := field.Addr().Interface().(encoding.TextUnmarshaler); ok {
+			value, set := os.LookupEnv(tag)
+			if tag == "" || !set {
+				continue
+			}
+			if err := unmarshaler.UnmarshalText([]byte(value)); err != nil {
+				return fmt.Errorf("%s is invalid: %w", tag, err)
+			}
+			continue
+		}
+
+		if field.Kind() == reflect.Struct && tag == "" {
+			if err := loadEnv(field); err != nil {
+				return err
+			}
+			continue
+		}
+
+		value, ok := os.LookupEnv(tag)
+		if tag == "" || !ok {
+			continue
+		}
+
+		switch field.Kind() {
+		case reflect.String:
+			field.SetString(value)
+		case reflect.Int:
+			parsed, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("%s must be an integer", tag)
+			}
+			field.SetInt(int64(parsed))
+		case reflect.Bool:
+			parsed, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("%s must be true or false", tag)
+			}
+			field.SetBool(parsed)
+		default:
+			return fmt.Errorf("%s has an unsupported type", tag)
+		}
+	}
+	return nil
+}
+
+// checks the configuration for missing or invalid values, in production
+// the insecure development defaults are rejected as well
+func (cfg Config) Validate() error {
+	var problems []string
+
+	if cfg.Environment != Development && cfg.Environment != Production {
+		problems = append(problems, fmt.Sprintf("environment must be %q or %q", Development, Production))
+	}
+	if cfg.Server.Address == "" {
+		problems = append(problems, "server address is required")
+	}
+
+	switch cfg.Storage.Backend {
+	case "postgres":
+		if cfg.Database.Host == "" || cfg.Database.User == "" || cfg.Database.Name == "" {
+			problems = append(problems, "database host, user and name are required for postgres")
+		}
+		if cfg.Database.Port < 1 || cfg.Database.Port > 65535 {
+			problems = append(problems, "database port must be between 1 and 65535")
+		}
+	case "sqlite":
+		if cfg.Storage.SQLitePath == "" {
+			problems = append(problems, "sqlite path is required for sqlite")
+		}
+	case "memory":
+	default:
+		problems = append(problems, fmt.Sprintf("unknown storage backend %q", cfg.Storage.Backend))
+	}
+
+	if cfg.Auth.JWTSecret == "" {
+		problems = append(problems, "jwt secret is required")
+	}
+	if cfg.Auth.AccessTokenTTL.Duration <= 0 || cfg.Auth.RefreshTokenTTL.Duration <= cfg.Auth.AccessTokenTTL.Duration {
+		problems = append(problems, "access token ttl must be positive and the refresh token ttl longer")
+	}
+	if cfg.Auth.Algorithm != "RS256" && cfg.Auth.Algorithm != "EdDSA" {
+		problems = append(problems, fmt.Sprintf("unknown jwt algorithm %q, use RS256 or EdDSA", cfg.Auth.Algorithm))
+	}
+	// every instance reloads the keys when it sweeps and has to know a key before it signs
+	if cfg.Auth.KeyPrepublish.Duration <= cfg.Holds.SweepInterval.Duration || cfg.Auth.KeyRotation.Duration <= cfg.Auth.KeyPrepublish.Duration {
+		problems = append(problems, "jwt key prepublish must be longer than the sweep interval and the key rotation longer still")
+	}
+	if cfg.Entry.SigningSecret == "" {
+		problems = append(problems, "entry signing secret is required")
+	}
+	if cfg.Admin.Email == "" || cfg.Admin.Password == "" {
+		problems = append(problems, "admin email and password are required")
+	}
+
+	if cfg.Holds.TTL.Duration <= 0 || cfg.Holds.SweepInterval.Duration <= 0 {
+		problems = append(problems, "hold ttl and sweep interval must be positive")
+	}
+	if cfg.Waitlist.OfferTTL.Duration <= 0 {
+		problems = append(problems, "waitlist offer ttl must be positive")
+	}
+	if cfg.Transfers.TTL.Duration <= 0 {
+		problems = append(problems, "transfer ttl must be positive")
+	}
+
+	if cfg.Payments.Provider != "mock" {
+		problems = append(problems, fmt.Sprintf("unknown payment provider %q", cfg.Payments.Provider))
+	}
+	if !models.ValidCurrency(cfg.Payments.Currency) {
+		problems = append(problems, fmt.Sprintf("payment currency %q is not a supported ISO 4217 code", cfg.Payments.Currency))
+	}
+	if cfg.Payments.WebhookSecret == "" {
+		problems = append(problems, "payment webhook secret is required")
+	}
+	if cfg.Payments.PendingTimeout.Duration <= 0 {
+		problems = append(problems, "payment pending timeout must be positive")
+	}
+
+	if cfg.Refunds.PartialRefundDays < 0 || cfg.Refunds.FullRefundDays < cfg.Refunds.PartialRefundDays {
+		problems = append(problems, "refund days must not be negative and the full refund days must not be less than the partial refund days")
+	}
+	if cfg.Refunds.PartialRefundPercent < 0 || cfg.Refunds.PartialRefundPercent > 100 {
+		problems = append(problems, "partial refund percent must be between 0 and 100")
+	}
+
+	switch cfg.Mail.Provider {
+	case "smtp":
+		if cfg.Mail.SMTPHost == "" {
+			problems = append(problems, "mail smtp host is required for smtp")
+		}
+		if cfg.Mail.SMTPPort < 1 || cfg.Mail.SMTPPort > 65535 {
+			problems = append(problems, "mail smtp port must be between 1 and 65535")
+		}
+	case "file":
+		if cfg.Mail.Dir == "" {
+			problems = append(problems, "mail dir is required for file")
+		}
+	case "memory":
+	default:
+		problems = append(problems, fmt.Sprintf("unknown mail provider %q", cfg.Mail.Provider))
+	}
+	if _, err := mail.ParseAddress(cfg.Mail.From); err != nil {
+		problems = append(problems, fmt.Sprintf("mail from %q is not an email address", cfg.Mail.From))
+	}
+
+	if cfg.Verification.Secret == "" {
+		problems = append(problems, "verification secret is required")
+	}
+	if cfg.Verification.TTL.Duration <= 0 {
+		problems = append(problems, "verification ttl must be positive")
+	}
+	if link, err := url.Parse(cfg.Verification.URL); err != nil || link.Scheme == "" || link.Host == "" {
+		problems = append(problems, "verification url must be an absolute url")
+	}
+
+	if cfg.Environment == Production {
+		if cfg.Auth.JWTSecret == DefaultJWTSecret {
+			problems = append(problems, "the default jwt secret must not be used in production")
+		}
+		if len(cfg.Auth.JWTSecret) < 32 {
+			problems = append(problems, "jwt secret must be at least 32 characters in production")
+		}
+		if cfg.Entry.SigningSecret == DefaultEntrySecret {
+			problems = append(problems, "the default entry signing secret must not be used in production")
+		}
+		if len(cfg.Entry.SigningSecret) < 32 {
+			problems = append(problems, "entry signing secret must be at least 32 characters in production")
+		}
+		if cfg.Verification.Secret == DefaultVerificationSecret {
+			problems = append(problems, "the default verification secret must not be used in production")
+		}
+		if len(cfg.Verification.Secret) < 32 {
+			problems = append(problems, "verification secret must be at least 32 characters in production")
+		}
+		if cfg.Storage.Backend == "postgres" && cfg.Database.Password == DefaultDatabasePassword {
+			problems = append(problems, "the default database password must not be used in production")
+		}
+		if cfg.Admin.Password == DefaultAdminPassword {
+			problems = append(problems, "the default admin password must not be used in production")
+		}
+		if cfg.Payments.WebhookSecret == DefaultWebhookSecret {
+			problems = append(problems, "the default payment webhook secret must not be used in production")
+		}
+	}
+
+	if len(problems) > 0 {
+		return errors.New("invalid configuration: " + strings.Join(problems, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+// production configuration with every secret replaced
+func productionConfig() Config {
+	cfg := Default()
+	cfg.Environment = Production
+	cfg.Database.Password = "a-database-password"
+	cfg.Auth.JWTSecret = strings.Repeat("j", 32)
+	cfg.Entry.SigningSecret = strings.Repeat("e", 32)
+	cfg.Verification.Secret = strings.Repeat("v", 32)
+	cfg.Admin.Password = "an-admin-password"
+	cfg.Payments.WebhookSecret = "a-webhook-secret"
+	return cfg
+}
+
+func TestValidateSecrets(t *testing.T) {
+	if err := Default().Validate(); err != nil {
+		t.Fatalf("Validate of the development defaults = %v, want nil", err)
+	}
+	if err := productionConfig().Validate(); err != nil {
+		t.Fatalf("Validate of the production config = %v, want nil", err)
+	}
+
+	tests := []struct {
+		name	string
+		change	func(cfg *Config)
+		problem	string
+	}{
+		{"missing jwt secret", func(cfg *Config) { cfg.Auth.JWTSecret = "" }, "jwt secret is required"},
+		{"default jwt secret", func(cfg *Config) { cfg.Auth.JWTSecret = DefaultJWTSecret }, "default jwt secret"},
+		{"short jwt secret", func(cfg *Config) { cfg.Auth.JWTSecret = "short" }, "jwt secret must be at least 32 characters"},
+		{"missing entry secret", func(cfg *Config) { cfg.Entry.SigningSecret = "" }, "entry signing secret is required"},
+		{"default entry secret", func(cfg *Config) { cfg.Entry.SigningSecret = DefaultEntrySecret }, "default entry signing secret"},
+		{"short entry secret", func(cfg *Config) { cfg.Entry.SigningSecret = "short" }, "entry signing secret must be at least 32 characters"},
+		{"missing verification secret", func(cfg *Config) { cfg.Verification.Secret = "" }, "verification secret is required"},
+		{"default verification secret", func(cfg *Config) { cfg.Verification.Secret = DefaultVerificationSecret }, "default verification secret"},
+		{"short verification secret", func(cfg *Config) { cfg.Verification.Secret = "short" }, "verification secret must be at least 32 characters"},
+		{"missing admin password", func(cfg *Config) { cfg.Admin.Password = "" }, "admin email and password are required"},
+		{"default admin password", func(cfg *Config) { cfg.Admin.Password = DefaultAdminPassword }, "default admin password"},
+		{"missing webhook secret", func(cfg *Config) { cfg.Payments.WebhookSecret = "" }, "payment webhook secret is required"},
+		{"default webhook secret", func(cfg *Config) { cfg.Payments.WebhookSecret = DefaultWebhookSecret }, "default payment webhook secret"},
+		{"default database password", func(cfg *Config) { cfg.Database.Password = DefaultDatabasePassword }, "default database password"},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			cfg := productionConfig()
+			test.change(&cfg)
+			err := cfg.Validate()
+			if err == nil || !strings.Contains(err.Error(), test.problem) {
+				t.Errorf("Validate = %v, want %q", err, test.problem)
+			}
+		})
+	}
+
+	// the database password only matters for Postgres
+	cfg := productionConfig()
+	cfg.Storage.Backend = "sqlite"
+	cfg.Database.Password = DefaultDatabasePassword
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate of sqlite with the default database password = %v, want nil", err)
+	}
+}
@@ -0,0 +1,394 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mgr1054/go-ticket/pkg/entry"
+	"github.com/mgr1054/go-ticket/pkg/models"
+	"github.com/mgr1054/go-ticket/pkg/store"
+	"github.com/mgr1054/go-ticket/pkg/utils"
+)
+
+type CheckInRequest struct {
+	EventID		uint		`json:"event_id" binding:"required" example:"1"`
+	// entry code of the ticket or the signed entry pass its QR code encodes
+	Code		string		`json:"code" binding:"required" example:"GT1.1.1.code.signature"`
+	Gate		string		`json:"gate" binding:"required" example:"North 2"`
+	// in (default) admits the ticket, out scans it out at events that allow re-entry
+	Direction	string		`json:"direction" example:"in"`
+}
+
+// returns the entry code a scanned code stands for, signed passes are verified
+// and have to be issued for the event
+func (ctrl *Controller) entryCode(scanned string, eventID uint) (string, error) {
+	if !strings.Contains(scanned, ".") {
+		return scanned, nil
+	}
+	pass, err := entry.Verify(ctrl.passes.PublicKey(), scanned)
+	if err != nil {
+		return "", err
+	}
+	if pass.EventID != eventID {
+		return "", store.ErrWrongEvent
+	}
+	return pass.Code, nil
+}
+
+// @Summary 		Check In Ticket
+// @Description		Scans a ticket at a gate of its event; each ticket is admitted exactly once, also when it is scanned at
+// @Description		several gates at the same time; scanning a ticket that is inside is answered with the time and gate it was admitted at.
+// @Description		At events with the re-entry policy scan_out tickets scanned out with direction out are admitted again
+// @Description		allowed: scanner, admin
+// @ID				check-in-ticket
+// @Tags 			check-in
+// @Accept			json
+// @Produce 		json
+// @Param			scan body CheckInRequest true "Check In Ticket"
+// @Success 		200 {object} models.CheckIn
+// @Failure			400 {string} json "{"error": "Could not check in the ticket"}"
+// @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
+// @Failure			403 {string} json "{"error": "Invalid entry pass"}"
+// @Failure			404 {string} json "{"error": "Unknown ticket code"}"
+// @Failure			409 {string} json "{"error": "Ticket was already admitted", "admitted_at": "2022-10-11T18:31:02Z", "gate": "North 2"}"
+// @Failure			409 {string} json "{"error": "Ticket is for another event"}"
+// @Failure			409 {string} json "{"error": "Ticket is cancelled or not paid"}"
+// @Failure			409 {string} json "{"error": "Ticket is listed for resale"}"
+// @Failure			409 {string} json "{"error": "The event does not allow re-entry"}"
+// @Failure			409 {string} json "{"error": "Ticket is not inside the event"}"
+// @Failure			500 {string} json "{"error": "Could not check in the ticket"}"
+// @Router 			/secured/checkin [post]
+func (ctrl *Controller) CheckIn (c *gin.Context) {
+
+	if err := utils.CheckUserType(c, "scanner"); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
+		return
+	}
+
+	var request CheckInRequest
+	if err := c.ShouldBindJSON(&request); err != nil || (request.Direction != "" && request.Direction != "in" && request.Direction != "out") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Could not check in the ticket"})
+		return
+	}
+
+	scan := models.CheckIn{EventID: request.EventID, Gate: strings.TrimSpace(request.Gate), ScannedAt: ctrl.clock.Now()}
+	if scanner, err := ctrl.store.Users.GetByUsername(c.GetString("username")); err == nil {
+		scan.ScannerID = &scanner.ID
+	}
+
+	code, err := ctrl.entryCode(strings.TrimSpace(request.Code), request.EventID)
+	if err == nil {
+		if request.Direction == "out" {
+			scan, err = ctrl.store.CheckIns.Exit(scan, code)
+		} else {
+			scan, err = ctrl.store.CheckIns.Admit(scan, code)
+		}
+	}
+
+	switch {
+	case errors.Is(err, entry.ErrInvalidPass):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Invalid entry pass"})
+		return
+	case errors.Is(err, store.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown ticket code"})
+		return
+	case errors.Is(err, store.ErrAlreadyAdmitted):
+		// scan is the check-in that let the ticket in
+		c.JSON(http.StatusConflict, gin.H{"error": "Ticket was already admitted", "admitted_at": scan.ScannedAt, "gate": scan.Gate})
+		return
+	case errors.Is(err, store.ErrWrongEvent):
+		c.JSON(http.StatusConflict, gin.H{"error": "Ticket is for another event"})
+		return
+	case errors.Is(err, store.ErrTicketNotIssued):
+		c.JSON(http.StatusConflict, gin.H{"error": "Ticket is cancelled or not paid"})
+		return
+	case errors.Is(err, store.ErrTicketListed):
+		c.JSON(http.StatusConflict, gin.H{"error": "Ticket is listed for resale"})
+		return
+	case errors.Is(err, store.ErrReentryNotAllowed):
+		c.JSON(http.StatusConflict, gin.H{"error": "The event does not allow re-entry"})
+		return
+	case errors.Is(err, store.ErrNotAdmitted):
+		c.JSON(http.StatusConflict, gin.H{"error": "Ticket is not inside the event"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not check in the ticket"})
+		return
+	}
+
+	c.JSON(http.StatusOK, scan)
+}
+
+// @Summary 		Get Event Check-In Stats
+// @Description		Sends the live number of issued, admitted and inside tickets of the event and the admissions per gate
+// @Description		allowed: scanner, admin
+// @ID				get-event-check-in-stats
+// @Tags 			check-in
+// @Produce 		json
+// @Success 		200 {object} models.CheckInStats
+// @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
+// @Failure			404 {string} json "{"error": "Event not found"}"
+// @Failure			500 {string} json "{"error": "Could not get the check-ins"}"
+// @Router 			/secured/events/{id}/checkins [get]
+func (ctrl *Controller) GetEventCheckIns (c *gin.Context) {
+
+	if err := utils.CheckUserType(c, "scanner"); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
+		return
+	}
+
+	id, ok := paramID(c, "id")
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+
+	stats, err := ctrl.store.CheckIns.Stats(id)
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not get the check-ins"})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// @Summary 		Get Ticket Check-Ins
+// @Description		Gives back all scans of the ticket at the door in the order they happened, rejected duplicates included
+// @Description		allowed: user, admin
+// @ID				get-ticket-check-ins
+// @Tags 			check-in
+// @Produce 		json
+// @Success 		200 {object} []models.CheckIn
+// @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
+// @Failure			404 {string} json "{"error": "Ticket not found"}"
+// @Failure			500 {string} json "{"error": "Could not get the check-ins"}"
+// @Router 			/secured/tickets/{id}/checkins [get]
+func (ctrl *Controller) GetTicketCheckIns (c *gin.Context) {
+
+	if err := utils.CheckUserType(c, "user"); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
+		return
+	}
+
+	id, ok := paramID(c, "id")
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ticket not found"})
+		return
+	}
+
+	ticket, err := ctrl.store.Tickets.Get(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ticket not found"})
+		return
+	}
+
+	if !ctrl.ownsOrIsAdmin(c, ticket.UserID) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
+		return
+	}
+
+	checkIns, err := ctrl.store.CheckIns.ListByTicket(ticket.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not get the check-ins"})
+		return
+	}
+
+	c.JSON(http.StatusOK, checkIns)
+}
+
+type OfflineScanRequest struct {
+	// entry code of the ticket or the signed entry pass its QR code encodes
+	Code		string		`json:"code" binding:"required" example:"GT1.1.1.code.signature"`
+	Gate		string		`json:"gate" binding:"required" example:"North 2"`
+	// in (default) or out
+	Direction	string		`json:"direction" example:"in"`
+	// time of the scan on the scanner
+	ScannedAt	time.Time	`json:"scanned_at" binding:"required" example:"2022-10-11T18:31:02Z"`
+}
+
+type SyncRequest struct {
+	EventID		uint		`json:"event_id" binding:"required" example:"1"`
+	Scans		[]OfflineScanRequest `json:"scans" binding:"required,min=1,max=10000,dive"`
+}
+
+// message of the errors that reject a scanned code, empty for other errors
+func scanError(err error) string {
+	switch {
+	case errors.Is(err, entry.ErrInvalidPass):
+		return "Invalid entry pass"
+	case errors.Is(err, store.ErrNotFound):
+		return "Unknown ticket code"
+	case errors.Is(err, store.ErrWrongEvent):
+		return "Ticket is for another event"
+	case errors.Is(err, store.ErrTicketNotIssued):
+		return "Ticket is cancelled or not paid"
+	case errors.Is(err, store.ErrTicketListed):
+		return "Ticket is listed for resale"
+	}
+	return ""
+}
+
+// @Summary 		Get Scanner Snapshot
+// @Description		Sends a signed snapshot of the entry codes of all tickets that admit at the event, so scanners keep admitting
+// @Description		tickets without network. The snapshot reads GTS1.<payload>.<signature>, the payload is the gzip compressed JSON
+// @Description		{"event_id", "taken_at", "reentry", "codes", "used"} in unpadded base64url and is signed with the entry pass key
+// @Description		allowed: scanner, admin
+// @ID				get-scanner-snapshot
+// @Tags 			check-in
+// @Produce 		json
+// @Success 		200 {string} json "{"event_id": 1, "taken_at": "2022-10-11T17:00:00Z", "tickets": 35000, "snapshot": "GTS1.payload.signature"}"
+// @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
+// @Failure			404 {string} json "{"error": "Event not found"}"
+// @Failure			500 {string} json "{"error": "Could not create the snapshot"}"
+// @Router 			/secured/events/{id}/snapshot [get]
+func (ctrl *Controller) GetScannerSnapshot (c *gin.Context) {
+
+	if err := utils.CheckUserType(c, "scanner"); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
+		return
+	}
+
+	id, ok := paramID(c, "id")
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+
+	event, err := ctrl.store.Events.Get(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+
+	tickets, err := ctrl.store.CheckIns.Admissible(event.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not create the snapshot"})
+		return
+	}
+
+	snapshot := entry.Snapshot{
+		EventID: event.ID,
+		TakenAt: ctrl.clock.Now().UTC(),
+		Reentry: event.ReentryPolicy == models.ReentryScanOut,
+		Codes: []string{},
+		Used: []string{},
+	}
+	for _, ticket := range tickets {
+		snapshot.Codes = append(snapshot.Codes, ticket.Code)
+		if ticket.Inside || (ticket.AdmittedAt != nil && !snapshot.Reentry) {
+			snapshot.Used = append(snapshot.Used, ticket.Code)
+		}
+	}
+	// sorted codes let scanners search them
+	sort.Strings(snapshot.Codes)
+	sort.Strings(snapshot.Used)
+
+	encoded, err := ctrl.passes.SignSnapshot(snapshot)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not create the snapshot"})
+		return
+	}
+
+	c.Header("Cache-Control", "private, no-store")
+	c.JSON(http.StatusOK, gin.H{"event_id": event.ID, "taken_at": snapshot.TakenAt, "tickets": len(snapshot.Codes), "snapshot": encoded})
+}
+
+// @Summary 		Sync Offline Scans
+// @Description		Uploads the scans a scanner made without network. The scans of each ticket are replayed with the ones already recorded
+// @Description		in the order they happened: the first scan wins and every later scan of a ticket that is inside is flagged as duplicate,
+// @Description		also recorded ones (listed in reflagged). Uploading a scan again does not record it twice. Each result is admitted,
+// @Description		exited, duplicate (with the admission before it) or rejected with an error
+// @Description		allowed: scanner, admin
+// @ID				sync-offline-scans
+// @Tags 			check-in
+// @Accept			json
+// @Produce 		json
+// @Param			scans body SyncRequest true "Sync Offline Scans"
+// @Success 		200 {string} json "{"results": [{"result": "admitted", "check_in": {}}], "reflagged": []}"
+// @Failure			400 {string} json "{"error": "Could not sync the scans"}"
+// @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
+// @Failure			404 {string} json "{"error": "Event not found"}"
+// @Failure			500 {string} json "{"error": "Could not sync the scans"}"
+// @Router 			/secured/checkin/sync [post]
+func (ctrl *Controller) SyncCheckIns (c *gin.Context) {
+
+	if err := utils.CheckUserType(c, "scanner"); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
+		return
+	}
+
+	var request SyncRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Could not sync the scans"})
+		return
+	}
+
+	var scannerID *uint
+	if scanner, err := ctrl.store.Users.GetByUsername(c.GetString("username")); err == nil {
+		scannerID = &scanner.ID
+	}
+
+	results := make([]gin.H, len(request.Scans))
+	scans := []store.OfflineScan{}
+	// index of each uploaded scan in the request
+	indexes := []int{}
+	for i, scan := range request.Scans {
+		if scan.Direction == "" {
+			scan.Direction = models.DirectionIn
+		}
+		if scan.Direction != models.DirectionIn && scan.Direction != models.DirectionOut {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Could not sync the scans"})
+			return
+		}
+		code, err := ctrl.entryCode(strings.TrimSpace(scan.Code), request.EventID)
+		if err != nil {
+			results[i] = gin.H{"result": models.CheckInRejected, "error": scanError(err)}
+			continue
+		}
+		scans = append(scans, store.OfflineScan{Code: code, Scan: models.CheckIn{
+			EventID: request.EventID,
+			Gate: strings.TrimSpace(scan.Gate),
+			ScannerID: scannerID,
+			Direction: scan.Direction,
+			ScannedAt: scan.ScannedAt,
+		}})
+		indexes = append(indexes, i)
+	}
+
+	synced, reflagged, err := ctrl.store.CheckIns.Sync(request.EventID, scans)
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not sync the scans"})
+		return
+	}
+
+	for j, result := range synced {
+		i := indexes[j]
+		switch {
+		case result.Err != nil:
+			results[i] = gin.H{"result": models.CheckInRejected, "error": scanError(result.Err)}
+		case result.CheckIn.Result == models.CheckInDuplicate:
+			results[i] = gin.H{"result": result.CheckIn.Result, "check_in": result.CheckIn, "admission": result.Admission}
+		case result.CheckIn.Result == models.CheckInRejected:
+			results[i] = gin.H{"result": result.CheckIn.Result, "check_in": result.CheckIn, "error": "Ticket is not inside the event"}
+		default:
+			results[i] = gin.H{"result": result.CheckIn.Result, "check_in": result.CheckIn}
+		}
+	}
+	if reflagged == nil {
+		reflagged = []models.CheckIn{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"results": results, "reflagged": reflagged})
+}
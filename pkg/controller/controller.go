@@ -0,0 +1,36 @@
+package controller
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mgr1054/go-ticket/pkg/clock"
+	"github.com/mgr1054/go-ticket/pkg/config"
+	"github.com/mgr1054/go-ticket/pkg/entry"
+	"github.com/mgr1054/go-ticket/pkg/mail"
+	"github.com/mgr1054/go-ticket/pkg/payment"
+	"github.com/mgr1054/go-ticket/pkg/store"
+)
+
+// holds the dependencies shared by all handlers
+type Controller struct {
+	store		*store.Store
+	cfg			config.Config
+	clock		clock.Clock
+	payments	payment.Provider
+	passes		*entry.Signer
+	mailer		mail.Mailer
+}
+
+func New(s *store.Store, cfg config.Config, clk clock.Clock, payments payment.Provider, passes *entry.Signer, mailer mail.Mailer) *Controller {
+	return &Controller{store: s, cfg: cfg, clock: clk, payments: payments, passes: passes, mailer: mailer}
+}
+
+// parses an id from the url path, ok is false for anything but a positive integer
+func paramID(c *gin.Context, name string) (id uint, ok bool) {
+	parsed, err := strconv.ParseUint(c.Param(name), 10, 64)
+	if err != nil || parsed == 0 {
+		return 0, false
+	}
+	return uint(parsed), true
+}
@@ -1,41 +1,70 @@
 package controller
 
 import (
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/mgr1054/go-ticket/pkg/db"
 	"github.com/mgr1054/go-ticket/pkg/models"
+	"github.com/mgr1054/go-ticket/pkg/store"
 	"github.com/mgr1054/go-ticket/pkg/utils"
+	log "github.com/sirupsen/logrus"
 )
 
 type NewEvent struct {
 	Band_Name	string		`json:"band_name" binding:"required" example:"Deichkind"`
-	Location	string		`json:"location" binding:"required" example:"Olympiastadion"`
-	Price		string		`json:"price" binding:"required" example:"55"`
-	Capacity	int			`json:"capacity" binding:"required" example:"35000"`
-	Date 		string		`json:"date" binding:"required" example:"2022-10-11"`
+	VenueID		uint		`json:"venue_id" example:"1"`
+	// name of an existing venue, used when venue_id is not set
+	Location	string		`json:"location" example:"Olympiastadion"`
+	// {"amount": 5500, "currency": "EUR"} or "55.00 EUR", the currency defaults to the payment currency
+	Price		*models.Money	`json:"price" binding:"required"`
+	// defaults to the default capacity of the venue
+	Capacity	int			`json:"capacity" example:"35000"`
+	// IANA timezone, defaults to the one of the venue; times without offset are wall clock times in it
+	Timezone	string		`json:"timezone" example:"Europe/Berlin"`
+	StartsAt	string		`json:"starts_at" binding:"required" example:"2022-10-11T20:00"`
+	EndsAt		string		`json:"ends_at" binding:"required" example:"2022-10-11T23:00"`
+	// defaults to starts_at
+	DoorsOpenAt	string		`json:"doors_open_at" example:"2022-10-11T18:30"`
+	// tickets are sold right away and until the event ends unless set
+	SalesStart	string		`json:"sales_start" example:"2022-06-01T10:00"`
+	SalesEnd	string		`json:"sales_end" example:"2022-10-11T20:00"`
+	// none (default) admits every ticket once, scan_out lets tickets scanned out at a gate enter again
+	ReentryPolicy string	`json:"reentry_policy" example:"none"`
 }
 
 type EventUpdate struct {
 	Band_Name	string		`json:"band_name"`
+	VenueID		uint		`json:"venue_id"`
 	Location	string		`json:"location"`
-	Price		string		`json:"price"`
-	Capacity	int			`json:"capacity"`
-	Date 		string		`json:"date"`
+	Price		*models.Money	`json:"price"`
+	Capacity	*int		`json:"capacity"`
+	Timezone	string		`json:"timezone"`
+	StartsAt	string		`json:"starts_at"`
+	EndsAt		string		`json:"ends_at"`
+	DoorsOpenAt	string		`json:"doors_open_at"`
+	SalesStart	string		`json:"sales_start"`
+	SalesEnd	string		`json:"sales_end"`
+	ReentryPolicy string	`json:"reentry_policy"`
 }
 
 
 // @Summary 		Get All Events
-// @Description		Sends Array Of Events 
+// @Description		Sends Array Of Events, from and to limit them to events starting in that range ordered by their start.
+// @Description		Dates (2022-10-11) and times without offset (2022-10-11T18:00) are compared with the local time at each venue,
+// @Description		a date as upper bound includes that day; RFC 3339 times with offset are compared as instants
 // @Description		allowed: user, admin
 // @ID				get-events
 // @Tags 			events
 // @Produce 		json
+// @Param			from query string false "Earliest start"
+// @Param			to query string false "Latest start, exclusive"
 // @Success 		200 {object} []models.Event
+// @Failure			400 {string} json "{"error": "Invalid range"}"
 // @Failure			404 {string} json "{"error": "Could not get events"}"
 // @Router 			/secured/events [get]
-func GetEvents (c *gin.Context) {
+func (ctrl *Controller) GetEvents (c *gin.Context) {
 
 	if err := utils.CheckUserType(c, "user"); err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
@@ -43,7 +72,19 @@ func GetEvents (c *gin.Context) {
 	}
 
 	var events []models.Event
-	if err := db.DB.Find(&events).Error; err != nil {
+	var err error
+
+	if c.Query("from") != "" || c.Query("to") != "" {
+		eventRange, ok := parseEventRange(c.Query("from"), c.Query("to"))
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid range"})
+			return
+		}
+		events, err = ctrl.store.Events.FindByStart(eventRange)
+	} else {
+		events, err = ctrl.store.Events.List()
+	}
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Could not get events"})
 		return
 	}
@@ -61,10 +102,12 @@ func GetEvents (c *gin.Context) {
 // @Param			event body NewEvent true "Create Event"
 // @Success 		201 {object} models.Event
 // @Failure			400 {string} json "{"error": "Could not create Event"}"
+// @Failure			400 {string} json "{"error": "Unknown venue"}"
+// @Failure			400 {string} json "{"error": "Invalid re-entry policy, use none or scan_out"}"
 // @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
 // @Failure			500 {string} json "{"error": "Could not create Event"}"
 // @Router 			/secured/events [post]
-func CreateEvent (c *gin.Context) {
+func (ctrl *Controller) CreateEvent (c *gin.Context) {
 
 	if err := utils.CheckUserType(c, "admin"); err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
@@ -77,16 +120,48 @@ func CreateEvent (c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Could not create Event"})
 		return
 	}
+
+	if err := ctrl.normalizePrice(event.Price); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid price: " + err.Error()})
+		return
+	}
+	
+	venue, err := ctrl.resolveVenue(event.VenueID, event.Location)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown venue"})
+		return
+	}
 	
 	newEvent := models.Event{
 		Band_Name: event.Band_Name, 
-		Location: event.Location, 
-		Price: event.Price, 
+		VenueID: venue.ID,
+		Location: venue.Name, 
+		Price: *event.Price, 
 		Capacity: event.Capacity, 
-		Date: event.Date,
+		Timezone: venue.Timezone,
+		ReentryPolicy: event.ReentryPolicy,
+	}
+	if newEvent.ReentryPolicy == "" {
+		newEvent.ReentryPolicy = models.ReentryNone
+	}
+	if models.ValidateReentryPolicy(newEvent.ReentryPolicy) != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid re-entry policy, use none or scan_out"})
+		return
+	}
+	if newEvent.Capacity == 0 {
+		newEvent.Capacity = venue.DefaultCapacity
+	}
+	if newEvent.Capacity <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Could not create Event"})
+		return
+	}
+
+	if err := applyEventTimes(&newEvent, event.Timezone, event.StartsAt, event.EndsAt, event.DoorsOpenAt); err != nil || applySaleWindow(&newEvent, event.SalesStart, event.SalesEnd) != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event times, the timezone must be an IANA name, doors <= start < end and sales start < sales end"})
+		return
 	}
 
-	if err := db.DB.Create(&newEvent).Error; err != nil {
+	if err := ctrl.store.Events.Create(&newEvent); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not create Event"})
 		return
 	} 
@@ -95,7 +170,7 @@ func CreateEvent (c *gin.Context) {
 }
 
 // @Summary 		Get Event By ID
-// @Description		Sends a Event with ID
+// @Description		Sends a Event with ID including its ticket types and the tickets available in each
 // @Description		allowed: user, admin
 // @ID				get-event-by-id
 // @Tags 			events
@@ -103,44 +178,56 @@ func CreateEvent (c *gin.Context) {
 // @Success 		200 {object} models.Event
 // @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
 // @Failure			404 {string} json "{"error": "Event not found"}"
+// @Failure			500 {string} json "{"error": "Could not get Event"}"
 // @Router 			/secured/events/{id} [get]
-func GetEventByID (c *gin.Context) {
+func (ctrl *Controller) GetEventByID (c *gin.Context) {
 
 	if err := utils.CheckUserType(c, "user"); err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
 		return
 	}
 
-	var event models.Event
+	id, ok := paramID(c, "id")
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
 
-	if err := db.DB.Where("id = ?", c.Param("id")).First(&event).Error; err != nil {
+	event, err := ctrl.store.Events.Get(id)
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
 		return
 	}
 
+	event.TicketTypes, err = ctrl.store.TicketTypes.ListByEvent(id, ctrl.clock.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not get Event"})
+		return
+	}
+
 	c.JSON(http.StatusOK, event)
 }
 
 // @Summary 		Get Event By Location
-// @Description		Sends Events for a Location
+// @Description		Sends Events at the venue with the name location, ignoring case
 // @Description		allowed: user, admin
 // @ID				get-event-by-location
 // @Tags 			events
 // @Produce 		json
+// @Param			location path string true "Venue name"
 // @Success 		200 {object} []models.Event
 // @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
 // @Failure			404 {string} json "{"error": "Event not found"}"
-// @Router 			/secured/events/{location} [get]
-func GetEventByLocation (c *gin.Context) {
+// @Router 			/secured/events/location/{location} [get]
+func (ctrl *Controller) GetEventByLocation (c *gin.Context) {
 
 	if err := utils.CheckUserType(c, "user"); err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
 		return
 	}
 
-	var event []models.Event
-
-	if err := db.DB.Where("location = ?", c.Param("location")).Find(&event).Error; err != nil {
+	event, err := ctrl.store.Events.FindByLocation(c.Param("location"))
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
 		return
 	}
@@ -154,25 +241,35 @@ func GetEventByLocation (c *gin.Context) {
 }
 
 // @Summary 		Get Event By Date
-// @Description		Sends Events for a Date
+// @Description		Sends Events taking place on a local date at their venue, same as /secured/events?from={date}&to={date}
+// @Description		Deprecated: kept for existing clients, use the from and to query of /secured/events instead
 // @Description		allowed: user, admin
 // @ID				get-event-by-date
 // @Tags 			events
 // @Produce 		json
+// @Param			date path string true "Local date" example(2022-10-11)
 // @Success 		200 {object} []models.Event
+// @Failure			400 {string} json "{"error": "Invalid date"}"
 // @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
 // @Failure			404 {string} json "{"error": "Event not found"}"
-// @Router 			/secured/events/{date} [get]
-func GetEventByDate (c *gin.Context) {
+// @Deprecated
+// @Router 			/secured/events/date/{date} [get]
+func (ctrl *Controller) GetEventByDate (c *gin.Context) {
 
 	if err := utils.CheckUserType(c, "user"); err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
 		return
 	}
 
-	var event []models.Event
+	date := c.Param("date")
+	if _, err := time.Parse("2006-01-02", date); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date"})
+		return
+	}
+	eventRange, _ := parseEventRange(date, date)
 
-	if err := db.DB.Where("date = ?", c.Param("date")).Find(&event).Error; err != nil {
+	event, err := ctrl.store.Events.FindByStart(eventRange)
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
 		return
 	}
@@ -186,27 +283,37 @@ func GetEventByDate (c *gin.Context) {
 }
 
 // @Summary 		Update Event By ID
-// @Description		Updates Event with given ID
+// @Description		Updates Event with given ID, times without offset are wall clock times in the (new) timezone of the event
 // @Description		allowed: admin
 // @ID				update-event-by-id
 // @Tags 			events
 // @Produce 		json
 // @Success 		200 {object} models.Event
 // @Failure			400 {string} json "{"error": "Event could not be updated with provided data"}"
+// @Failure			400 {string} json "{"error": "Invalid re-entry policy, use none or scan_out"}"
+// @Failure			400 {string} json "{"error": "Capacity must be positive"}"
 // @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
 // @Failure			404 {string} json "{"error": "Event not found"}"
+// @Failure			409 {string} json "{"error": "Seats of the event are already sold, its venue cannot change"}"
+// @Failure			409 {string} json "{"error": "Capacity is below the quotas of the ticket types"}"
+// @Failure			409 {string} json "{"error": "Capacity is below the tickets already sold or held"}"
 // @Failure			500 {string} json "{"error": "Could not update Event"}"
 // @Router 			/secured/events/{id} [put]
-func UpdateEventById (c *gin.Context) {
+func (ctrl *Controller) UpdateEventById (c *gin.Context) {
 
 	if err := utils.CheckUserType(c, "admin"); err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
 		return
 	}
 	
-	var event models.Event
+	id, ok := paramID(c, "id")
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
 
-	if err := db.DB.Where("id = ?", c.Param("id")).First(&event).Error; err != nil {
+	current, err := ctrl.store.Events.Get(id)
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
 		return
 	}
@@ -218,22 +325,76 @@ func UpdateEventById (c *gin.Context) {
         return
     }
 
-	if err:= db.DB.Model(&event).Updates(models.Event{
+	changes := models.Event{
 		Band_Name: updateEvent.Band_Name, 
-		Location: updateEvent.Location, 
-		Price: updateEvent.Price, 
-		Capacity: updateEvent.Capacity, 
-		Date: updateEvent.Date,
-	}).Error; err != nil {
+		ReentryPolicy: updateEvent.ReentryPolicy,
+	}
+	if updateEvent.Capacity != nil {
+		if *updateEvent.Capacity <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Capacity must be positive"})
+			return
+		}
+		changes.Capacity = *updateEvent.Capacity
+	}
+	if models.ValidateReentryPolicy(changes.ReentryPolicy) != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid re-entry policy, use none or scan_out"})
+		return
+	}
+
+	if updateEvent.VenueID != 0 || updateEvent.Location != "" {
+		venue, err := ctrl.resolveVenue(updateEvent.VenueID, updateEvent.Location)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown venue"})
+			return
+		}
+		changes.VenueID = venue.ID
+		changes.Location = venue.Name
+	}
+
+	// the new times are checked together with the ones that stay
+	if err := applyEventTimes(&current, updateEvent.Timezone, updateEvent.StartsAt, updateEvent.EndsAt, updateEvent.DoorsOpenAt); err != nil || applySaleWindow(&current, updateEvent.SalesStart, updateEvent.SalesEnd) != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event times, the timezone must be an IANA name, doors <= start < end and sales start < sales end"})
+		return
+	}
+	changes.Timezone = current.Timezone
+	changes.StartsAt = current.StartsAt
+	changes.EndsAt = current.EndsAt
+	changes.DoorsOpenAt = current.DoorsOpenAt
+	changes.StartsLocal = current.StartsLocal
+	changes.SalesStart = current.SalesStart
+	changes.SalesEnd = current.SalesEnd
+	if updateEvent.Price != nil {
+		if err := ctrl.normalizePrice(updateEvent.Price); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid price: " + err.Error()})
+			return
+		}
+		changes.Price = *updateEvent.Price
+	}
+
+	event, err := ctrl.store.Events.Update(id, changes, ctrl.clock.Now())
+	switch {
+	case errors.Is(err, store.ErrSeatsSold):
+		c.JSON(http.StatusConflict, gin.H{"error": "Seats of the event are already sold, its venue cannot change"})
+		return
+	case errors.Is(err, store.ErrQuotaConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": "Capacity is below the quotas of the ticket types"})
+		return
+	case errors.Is(err, store.ErrCapacityConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": "Capacity is below the tickets already sold or held"})
+		return
+	case err != nil:
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not update Event"})
         return
 	}
 
+	// a higher capacity or a sale window opening frees tickets for the waitlist
+	ctrl.offerWaitlist(event.ID)
+
 	c.JSON(http.StatusOK, event)
 }
 
 // @Summary 		Delete Event By ID
-// @Description		Deletes Event with given ID
+// @Description		Deletes Event with given ID, events with tickets stay on record and have to be cancelled instead
 // @Description		allowed: admin
 // @ID				delete-event-by-id
 // @Tags 			events
@@ -241,26 +402,252 @@ func UpdateEventById (c *gin.Context) {
 // @Success 		200 {string} json "{"message": "Event deleted"}"
 // @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
 // @Failure			404 {string} json "{"error": "Event not found!"}"
+// @Failure			409 {string} json "{"error": "Event has tickets, cancel it instead"}"
 // @Failure			500 {string} json "{"error": "Could not create Event"}"
 // @Router 			/secured/events/{id} [delete]
-func DeleteEventById (c *gin.Context) {
+func (ctrl *Controller) DeleteEventById (c *gin.Context) {
 
 	if err := utils.CheckUserType(c, "admin"); err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
 		return
 	}
 	
-	var event models.Event
+	id, ok := paramID(c, "id")
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found!"})
+		return
+	}
 
-	if err := db.DB.Where("id = ?", c.Param("id")).First(&event).Error; err != nil {
+	if _, err := ctrl.store.Events.Get(id); err != nil {
         c.JSON(http.StatusNotFound, gin.H{"error": "Event not found!"})
         return
     }
 
-	if err := db.DB.Delete(&event).Error; err != nil {
+	err := ctrl.store.Events.Delete(id)
+	switch {
+	case errors.Is(err, store.ErrEventInUse):
+		c.JSON(http.StatusConflict, gin.H{"error": "Event has tickets, cancel it instead"})
+		return
+	case err != nil:
         c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not create Event"})
         return
     }
 
 	c.JSON(http.StatusOK, gin.H{"message": "Event deleted"})
-}
\ No newline at end of file
+}
+
+// @Summary 		Cancel Event By ID
+// @Description		Ends the ticket sale of the event, takes its resale listings off the marketplace and cancels
+// @Description		every issued ticket with a full refund; the event and its tickets stay on record. Tickets
+// @Description		already used at the door or reserved by a resale buyer are skipped and reported
+// @Description		allowed: admin
+// @ID				cancel-event-by-id
+// @Tags 			events
+// @Produce 		json
+// @Success 		200 {string} json "{"message": "Event cancelled", "refunds": [], "skipped": []}"
+// @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
+// @Failure			404 {string} json "{"error": "Event not found!"}"
+// @Failure			500 {string} json "{"error": "Could not cancel Event"}"
+// @Router 			/secured/events/{id}/cancel [post]
+func (ctrl *Controller) CancelEvent (c *gin.Context) {
+
+	if err := utils.CheckUserType(c, "admin"); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
+		return
+	}
+
+	id, ok := paramID(c, "id")
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found!"})
+		return
+	}
+
+	event, err := ctrl.store.Events.Get(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found!"})
+		return
+	}
+
+	// no new tickets are sold, a sale that has not started yet ends before it opens
+	now := ctrl.clock.Now()
+	changes := models.Event{SalesEnd: &now}
+	if event.SalesStart != nil && !event.SalesStart.Before(now) {
+		start := now.Add(-time.Second)
+		changes.SalesStart = &start
+	}
+	if _, err := ctrl.store.Events.Update(id, changes, now); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not cancel Event"})
+		return
+	}
+
+	listings, err := ctrl.store.Listings.ListByEvent(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not cancel Event"})
+		return
+	}
+	for _, listing := range listings {
+		if _, err := ctrl.store.Listings.Withdraw(listing.ID, now); err != nil && !errors.Is(err, store.ErrListingClosed) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not cancel Event"})
+			return
+		}
+	}
+
+	tickets, err := ctrl.store.Tickets.ListByEvent(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not cancel Event"})
+		return
+	}
+
+	refunds, skipped := []models.Refund{}, []uint{}
+	for _, ticket := range tickets {
+		if ticket.Status != models.TicketIssued {
+			continue
+		}
+		amount, err := ctrl.refundableAmount(ticket)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not cancel Event"})
+			return
+		}
+		refund := models.Refund{
+			Amount: amount,
+			Currency: ticket.Price.Currency,
+			Reason: models.RefundReasonEventCancelled,
+		}
+
+		err = ctrl.store.Tickets.Cancel(ticket.ID, &refund, now)
+		switch {
+		case errors.Is(err, store.ErrTicketNotIssued), errors.Is(err, store.ErrTicketUsed), errors.Is(err, store.ErrTicketListed):
+			skipped = append(skipped, ticket.ID)
+			continue
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not cancel Event"})
+			return
+		}
+
+		// the ticket stays cancelled when the provider fails, admins can retry with a manual refund
+		if refund, err = ctrl.issueRefund(refund); err != nil {
+			log.Error("Refund ", refund.ID, " of cancelled event ", id, " could not be recorded: ", err)
+		}
+		refunds = append(refunds, refund)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Event cancelled", "refunds": refunds, "skipped": skipped})
+}
+
+// fills in the default currency and validates the price of an event
+func (ctrl *Controller) normalizePrice(price *models.Money) error {
+	if price.Currency == "" {
+		price.Currency = ctrl.cfg.Payments.Currency
+	}
+	return price.Validate()
+}
+
+// sets the timezone and the given times of the event, empty values keep the
+// current ones, and validates them together; doors move along with the start
+func applyEventTimes(event *models.Event, timezone string, startsAt string, endsAt string, doorsOpenAt string) error {
+	previousStart := event.StartsAt
+
+	if timezone != "" {
+		event.Timezone = timezone
+	}
+	zone, err := event.Zone()
+	if err != nil {
+		return models.ErrInvalidEventTimes
+	}
+
+	for _, field := range []struct {
+		value	string
+		target	*time.Time
+	}{
+		{startsAt, &event.StartsAt},
+		{endsAt, &event.EndsAt},
+		{doorsOpenAt, &event.DoorsOpenAt},
+	} {
+		if field.value == "" {
+			continue
+		}
+		if *field.target, err = models.ParseEventTime(field.value, zone); err != nil {
+			return err
+		}
+	}
+
+	if doorsOpenAt == "" && !previousStart.IsZero() {
+		event.DoorsOpenAt = event.DoorsOpenAt.Add(event.StartsAt.Sub(previousStart))
+	}
+
+	return event.NormalizeTimes()
+}
+
+// sets the given bounds of the sale window in the timezone of the event, empty
+// values keep the current ones, and validates all times again
+func applySaleWindow(event *models.Event, salesStart string, salesEnd string) error {
+	zone, err := event.Zone()
+	if err != nil {
+		return models.ErrInvalidEventTimes
+	}
+
+	for _, field := range []struct {
+		value	string
+		target	**time.Time
+	}{
+		{salesStart, &event.SalesStart},
+		{salesEnd, &event.SalesEnd},
+	} {
+		if field.value == "" {
+			continue
+		}
+		parsed, err := models.ParseEventTime(field.value, zone)
+		if err != nil {
+			return err
+		}
+		*field.target = &parsed
+	}
+
+	return event.NormalizeTimes()
+}
+
+// parses the bounds of an event query, both have to be either local (dates or
+// times without offset) or instants (RFC 3339), a date as upper bound includes the day
+func parseEventRange(from string, to string) (store.EventRange, bool) {
+	var eventRange store.EventRange
+	kinds := map[bool]bool{}
+
+	for _, bound := range []struct {
+		value	string
+		target	*time.Time
+		upper	bool
+	}{
+		{from, &eventRange.From, false},
+		{to, &eventRange.To, true},
+	} {
+		if bound.value == "" {
+			continue
+		}
+
+		if date, err := time.Parse("2006-01-02", bound.value); err == nil {
+			if bound.upper {
+				date = date.AddDate(0, 0, 1)
+			}
+			*bound.target = date
+			kinds[true] = true
+			continue
+		}
+		if instant, err := time.Parse(time.RFC3339, bound.value); err == nil {
+			*bound.target = instant
+			kinds[false] = true
+			continue
+		}
+		local, err := models.ParseEventTime(bound.value, time.UTC)
+		if err != nil {
+			return eventRange, false
+		}
+		*bound.target = local
+		kinds[true] = true
+	}
+
+	if len(kinds) != 1 {
+		return eventRange, false
+	}
+	eventRange.Local = kinds[true]
+	return eventRange, true
+}
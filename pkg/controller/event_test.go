@@ -0,0 +1,111 @@
+package controller_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/mgr1054/go-ticket/pkg/models"
+	"github.com/mgr1054/go-ticket/pkg/payment"
+)
+
+// an event with tickets cannot be deleted, cancelling it refunds every issued ticket
+// in full and ends the sale
+func TestCancelEventRefundsTickets(t *testing.T) {
+	s := newTestServer(t)
+	event := s.createEvent(t, 10)
+
+	status, orderID := s.order(t, event.ID, 2, payment.MockCardOK)
+	if status != http.StatusCreated {
+		t.Fatalf("order got status %d, want %d", status, http.StatusCreated)
+	}
+
+	if status := s.call(t, http.MethodDelete, fmt.Sprintf("/api/secured/events/%d", event.ID), s.adminToken, nil, nil); status != http.StatusConflict {
+		t.Errorf("delete got status %d, want %d", status, http.StatusConflict)
+	}
+	if status := s.call(t, http.MethodDelete, fmt.Sprintf("/api/secured/user/%d", s.user.ID), s.adminToken, nil, nil); status != http.StatusConflict {
+		t.Errorf("delete of the buyer got status %d, want %d", status, http.StatusConflict)
+	}
+
+	var result struct {
+		Refunds	[]models.Refund	`json:"refunds"`
+		Skipped	[]uint			`json:"skipped"`
+	}
+	if status := s.call(t, http.MethodPost, fmt.Sprintf("/api/secured/events/%d/cancel", event.ID), s.adminToken, nil, &result); status != http.StatusOK {
+		t.Fatalf("cancel got status %d, want %d", status, http.StatusOK)
+	}
+	if len(result.Refunds) != 2 || len(result.Skipped) != 0 {
+		t.Fatalf("cancel refunded %d tickets and skipped %v, want 2 and none", len(result.Refunds), result.Skipped)
+	}
+	for _, refund := range result.Refunds {
+		if refund.Amount != event.Price.Amount || refund.Status != models.RefundSucceeded || refund.Reason != models.RefundReasonEventCancelled {
+			t.Errorf("refund %+v, want %d succeeded for the cancelled event", refund, event.Price.Amount)
+		}
+	}
+	if refunds := s.payments.Refunds(); len(refunds) != 2 {
+		t.Errorf("provider paid %d refunds, want 2", len(refunds))
+	}
+
+	if order := s.getOrder(t, orderID); len(order.Tickets) != 2 {
+		t.Errorf("order has %d tickets after the cancellation, want 2 on record", len(order.Tickets))
+	}
+	if status, _ := s.order(t, event.ID, 1, payment.MockCardOK); status != http.StatusConflict {
+		t.Errorf("order after the cancellation got status %d, want %d", status, http.StatusConflict)
+	}
+}
+
+// the deprecated date route still finds the events on a local date
+func TestGetEventByDate(t *testing.T) {
+	s := newTestServer(t)
+	event := s.createEvent(t, 10)
+	date := event.StartsLocal[:len("2006-01-02")]
+
+	var events []models.Event
+	if status := s.call(t, http.MethodGet, "/api/secured/events/date/"+date, s.token, nil, &events); status != http.StatusOK {
+		t.Fatalf("got status %d, want %d", status, http.StatusOK)
+	}
+	if len(events) != 1 || events[0].ID != event.ID {
+		t.Errorf("got %d events, want event %d", len(events), event.ID)
+	}
+
+	if status := s.call(t, http.MethodGet, "/api/secured/events/date/tomorrow", s.token, nil, nil); status != http.StatusBadRequest {
+		t.Errorf("invalid date got status %d, want %d", status, http.StatusBadRequest)
+	}
+}
+
+// the capacity of an event stays positive and covers the tickets already sold
+func TestUpdateEventCapacity(t *testing.T) {
+	s := newTestServer(t)
+	event := s.createEvent(t, 10)
+	if status, _ := s.order(t, event.ID, 3, payment.MockCardOK); status != http.StatusCreated {
+		t.Fatalf("order got status %d, want %d", status, http.StatusCreated)
+	}
+
+	path := fmt.Sprintf("/api/secured/events/%d", event.ID)
+	tests := []struct {
+		name		string
+		capacity	int
+		want		int
+	}{
+		{"zero", 0, http.StatusBadRequest},
+		{"negative", -5, http.StatusBadRequest},
+		{"below sold", 2, http.StatusConflict},
+		{"sold", 3, http.StatusOK},
+		{"raised", 20, http.StatusOK},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if status := s.call(t, http.MethodPut, path, s.adminToken, map[string]int{"capacity": test.capacity}, nil); status != test.want {
+				t.Errorf("got status %d, want %d", status, test.want)
+			}
+		})
+	}
+
+	var updated models.Event
+	if status := s.call(t, http.MethodPut, path, s.adminToken, map[string]string{"band_name": "Renamed"}, &updated); status != http.StatusOK {
+		t.Fatalf("update without a capacity got status %d, want %d", status, http.StatusOK)
+	}
+	if updated.Capacity != 20 {
+		t.Errorf("capacity %d after an update without one, want 20", updated.Capacity)
+	}
+}
@@ -0,0 +1,176 @@
+package controller_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mgr1054/go-ticket/pkg/clock"
+	"github.com/mgr1054/go-ticket/pkg/config"
+	"github.com/mgr1054/go-ticket/pkg/controller"
+	"github.com/mgr1054/go-ticket/pkg/entry"
+	"github.com/mgr1054/go-ticket/pkg/keyring"
+	"github.com/mgr1054/go-ticket/pkg/mail"
+	middlewares "github.com/mgr1054/go-ticket/pkg/middleware"
+	"github.com/mgr1054/go-ticket/pkg/models"
+	"github.com/mgr1054/go-ticket/pkg/payment"
+	"github.com/mgr1054/go-ticket/pkg/store"
+	"github.com/mgr1054/go-ticket/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	log.SetLevel(log.WarnLevel)
+	os.Exit(m.Run())
+}
+
+// mock provider that remembers the refunds it paid
+type refundRecorder struct {
+	*payment.Mock
+	mu		sync.Mutex
+	refunds	[]payment.Refund
+}
+
+func (p *refundRecorder) Refund(intentID string, amount int64) (payment.Refund, error) {
+	refund, err := p.Mock.Refund(intentID, amount)
+	if err == nil {
+		p.mu.Lock()
+		p.refunds = append(p.refunds, refund)
+		p.mu.Unlock()
+	}
+	return refund, err
+}
+
+func (p *refundRecorder) Refunds() []payment.Refund {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]payment.Refund(nil), p.refunds...)
+}
+
+// api server with the registration, order and webhook routes, the mock provider
+// delivers its webhooks to it
+type testServer struct {
+	*httptest.Server
+	cfg			config.Config
+	clock		*clock.Fake
+	store		*store.Store
+	payments	*refundRecorder
+	mailer		*mail.Memory
+	token		string
+	adminToken	string
+	user		models.User
+	// status of every processed webhook
+	webhooks	chan int
+}
+
+func newTestServer(t *testing.T) *testServer {
+	cfg := config.Default()
+	cfg.Payments.MockDelay = config.Duration{Duration: 200 * time.Millisecond}
+
+	router := gin.New()
+	s := &testServer{
+		Server: httptest.NewServer(router),
+		cfg: cfg,
+		clock: clock.NewFake(time.Now()),
+		store: store.NewMemory(),
+		mailer: mail.NewMemory(),
+		webhooks: make(chan int, 8),
+	}
+	t.Cleanup(s.Close)
+	s.payments = &refundRecorder{Mock: payment.NewMock(cfg.Payments.WebhookSecret, s.URL+"/api/payments/webhook", cfg.Payments.MockDelay.Duration)}
+
+	keys, err := keyring.New(s.store.SigningKeys, cfg.Auth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := keys.Rotate(time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	verifiedAt := s.clock.Now()
+	s.user = models.User{Name: "Max", Username: "mgr", Email: "mgr@example.com", Password: "hash", Role: "user", VerifiedAt: &verifiedAt}
+	if err := s.store.Users.Create(&s.user); err != nil {
+		t.Fatal(err)
+	}
+	if s.token, err = utils.GenerateJWT(s.user.Email, s.user.Username, s.user.Role, "family", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if s.adminToken, err = utils.GenerateJWT("admin@example.com", "admin", "admin", "admin-family", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	ctrl := s.controller(s.mailer)
+	api := router.Group("/api")
+	api.POST("/user/register", ctrl.RegisterUser)
+	api.POST("/token", ctrl.GenerateToken)
+	api.POST("/payments/webhook", ctrl.PaymentWebhook, func(c *gin.Context) {
+		s.webhooks <- c.Writer.Status()
+	})
+	secured := api.Group("/secured").Use(middlewares.Auth(s.store.Tokens))
+	secured.POST("/orders", ctrl.CreateOrder)
+	secured.GET("/orders/:id", ctrl.GetOrderById)
+	secured.GET("/events/date/:date", ctrl.GetEventByDate)
+	secured.PUT("/events/:id", ctrl.UpdateEventById)
+	secured.DELETE("/events/:id", ctrl.DeleteEventById)
+	secured.POST("/events/:id/cancel", ctrl.CancelEvent)
+	secured.PUT("/user/:id", ctrl.UpdateUserById)
+	secured.DELETE("/user/:id", ctrl.DelteUserById)
+	secured.POST("/tickets/:id/transfer", ctrl.TransferTicket)
+	return s
+}
+
+// sends body as json with the token and decodes the json response into result unless it is nil
+func (s *testServer) call(t *testing.T, method string, path string, token string, body interface{}, result interface{}) int {
+	t.Helper()
+	encoded, _ := json.Marshal(body)
+	request, _ := http.NewRequest(method, s.URL+path, bytes.NewReader(encoded))
+	request.Header.Set("Authorization", token)
+	request.Header.Set("Content-Type", "application/json")
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	if result != nil {
+		if err := json.NewDecoder(response.Body).Decode(result); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return response.StatusCode
+}
+
+func (s *testServer) controller(mailer mail.Mailer) *controller.Controller {
+	return controller.New(s.store, s.cfg, s.clock, s.payments, entry.NewSigner("secret"), mailer)
+}
+
+func (s *testServer) createEvent(t *testing.T, capacity int) models.Event {
+	t.Helper()
+	venue := models.Venue{Name: "Olympiahalle", Timezone: "Europe/Berlin", DefaultCapacity: capacity}
+	if err := s.store.Venues.Create(&venue); err != nil {
+		t.Fatal(err)
+	}
+	startsAt := s.clock.Now().AddDate(1, 0, 0)
+	event := models.Event{
+		Band_Name: "Band",
+		VenueID: venue.ID,
+		Location: venue.Name,
+		Price: models.Money{Amount: 2500, Currency: "EUR"},
+		Capacity: capacity,
+		Timezone: venue.Timezone,
+		StartsAt: startsAt,
+		EndsAt: startsAt.Add(3 * time.Hour),
+		ReentryPolicy: models.ReentryNone,
+	}
+	if err := event.NormalizeTimes(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.store.Events.Create(&event); err != nil {
+		t.Fatal(err)
+	}
+	return event
+}
@@ -0,0 +1,263 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mgr1054/go-ticket/pkg/models"
+	"github.com/mgr1054/go-ticket/pkg/store"
+	"github.com/mgr1054/go-ticket/pkg/utils"
+)
+
+type NewHold struct {
+	EventID		uint		`json:"event_id" binding:"required" example:"1"`
+	// required for events with ticket types
+	TicketTypeID uint		`json:"ticket_type_id" example:"2"`
+	Quantity	int			`json:"quantity" binding:"required,min=1" example:"4"`
+	// unlocks tickets before the general sale of the event
+	PresaleCode	string		`json:"presale_code" example:"FANCLUB22"`
+}
+
+// @Summary 		Create Hold
+// @Description		Reserves tickets of an event for a limited time until the hold is confirmed
+// @Description		Before the general sale the hold needs a presale code, it uses the code once for the order it turns into
+// @Description		allowed: user
+// @ID				create-hold
+// @Tags 			holds
+// @Accept			json
+// @Produce 		json
+// @Param			hold body NewHold true "Create Hold"
+// @Success 		201 {object} models.Hold
+// @Failure			400 {string} json "{"error": "Could not create Hold"}"
+// @Failure			400 {string} json "{"error": "Choose a ticket type of the event"}"
+// @Failure			404 {string} json "{"error": "Ticket type not found"}"
+// @Failure			403 {string} json "{"error": "Invalid presale code"}"
+// @Failure			409 {string} json "{"error": "Tickets are not on sale"}"
+// @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
+// @Failure			404 {string} json "{"error": "Event not found"}"
+// @Failure			409 {string} json "{"error": "Unfortunately, there are not enough tickets left"}"
+// @Failure			500 {string} json "{"error": "Could not create Hold"}"
+// @Router 			/secured/holds [post]
+func (ctrl *Controller) CreateHold (c *gin.Context) {
+
+	if err := utils.CheckUserType(c, "user"); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
+		return
+	}
+
+	var request NewHold
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Could not create Hold"})
+		return
+	}
+
+	user, err := ctrl.store.Users.GetByUsername(c.GetString("username"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	hold, err := ctrl.store.Holds.Create(user.ID, request.EventID, request.TicketTypeID, request.Quantity, request.PresaleCode, ctrl.clock.Now(), ctrl.cfg.Holds.TTL.Duration)
+
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	case errors.Is(err, store.ErrTicketTypeNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ticket type not found"})
+		return
+	case errors.Is(err, store.ErrTicketTypeRequired):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Choose a ticket type of the event"})
+		return
+	case errors.Is(err, store.ErrNotOnSale):
+		c.JSON(http.StatusConflict, gin.H{"error": "Tickets are not on sale"})
+		return
+	case errors.Is(err, store.ErrPresaleCodeRejected):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Invalid presale code"})
+		return
+	case errors.Is(err, store.ErrSoldOut):
+		c.JSON(http.StatusConflict, gin.H{"error": "Unfortunately, there are not enough tickets left"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not create Hold"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, hold)
+}
+
+// @Summary 		Get Holds
+// @Description		Gives back all holds of the user, holds past their expiry are shown as expired
+// @Description		allowed: user, admin
+// @ID				get-holds
+// @Tags 			holds
+// @Produce 		json
+// @Success 		200 {object} []models.Hold
+// @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
+// @Failure			404 {string} json "{"error": "User not found"}"
+// @Failure			500 {string} json "{"error": "Could not get Holds"}"
+// @Router 			/secured/holds [get]
+func (ctrl *Controller) GetHolds (c *gin.Context) {
+
+	if err := utils.CheckUserType(c, "user"); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
+		return
+	}
+
+	user, err := ctrl.store.Users.GetByUsername(c.GetString("username"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	holds, err := ctrl.store.Holds.ListByUser(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not get Holds"})
+		return
+	}
+
+	if len(holds) < 1 {
+		c.JSON(http.StatusOK, gin.H{"info": "There are no holds for this user"})
+		return
+	}
+
+	// the sweeper marks expired holds only periodically
+	now := ctrl.clock.Now()
+	for i := range holds {
+		if holds[i].Status == models.HoldActive && !holds[i].IsActive(now) {
+			holds[i].Status = models.HoldExpired
+		}
+	}
+
+	c.JSON(http.StatusOK, holds)
+}
+
+// @Summary 		Confirm Hold
+// @Description		Turns an active hold into an order with its tickets and charges it with the payment method, a promo code discounts the tickets
+// @Description		allowed: user
+// @ID				confirm-hold
+// @Tags 			holds
+// @Accept			json
+// @Produce 		json
+// @Param			payment body CheckoutRequest false "Payment"
+// @Success 		201 {object} models.Order
+// @Success 		202 {object} models.Order
+// @Failure			400 {string} json "{"error": "Could not confirm Hold"}"
+// @Failure			400 {string} json "{"error": "Invalid promo code"}"
+// @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
+// @Failure			402 {string} json "{"error": "Payment declined", "order_id": 1}"
+// @Failure			404 {string} json "{"error": "Hold not found"}"
+// @Failure			409 {string} json "{"error": "Hold is expired, confirmed or released"}"
+// @Failure			500 {string} json "{"error": "Could not confirm Hold"}"
+// @Router 			/secured/holds/{id}/confirm [post]
+func (ctrl *Controller) ConfirmHold (c *gin.Context) {
+
+	if err := utils.CheckUserType(c, "user"); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
+		return
+	}
+
+	var request CheckoutRequest
+
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Could not confirm Hold"})
+			return
+		}
+	}
+
+	hold, ok := ctrl.ownHold(c)
+	if !ok {
+		return
+	}
+
+	order, err := ctrl.store.Holds.Confirm(hold.ID, request.PromoCode, ctrl.clock.Now())
+
+	switch {
+	case errors.Is(err, store.ErrHoldNotActive):
+		c.JSON(http.StatusConflict, gin.H{"error": "Hold is expired, confirmed or released"})
+		return
+	case errors.Is(err, store.ErrPromotionRejected):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid promo code"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not confirm Hold"})
+		return
+	}
+
+	// settles the waitlist offer the hold may belong to
+	ctrl.offerWaitlist(hold.EventID)
+
+	order, err = ctrl.checkout(order, request.PaymentMethod)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not confirm Hold"})
+		return
+	}
+
+	ctrl.respondCheckout(c, order)
+}
+
+// @Summary 		Release Hold
+// @Description		Gives the reserved tickets of an active hold back, they are offered to the waitlist of the event
+// @Description		allowed: user
+// @ID				release-hold
+// @Tags 			holds
+// @Produce 		json
+// @Success 		200 {string} json "{"message": "Hold released"}"
+// @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
+// @Failure			404 {string} json "{"error": "Hold not found"}"
+// @Failure			409 {string} json "{"error": "Hold is expired, confirmed or released"}"
+// @Failure			500 {string} json "{"error": "Could not release Hold"}"
+// @Router 			/secured/holds/{id} [delete]
+func (ctrl *Controller) ReleaseHold (c *gin.Context) {
+
+	if err := utils.CheckUserType(c, "user"); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
+		return
+	}
+
+	hold, ok := ctrl.ownHold(c)
+	if !ok {
+		return
+	}
+
+	err := ctrl.store.Holds.Release(hold.ID, ctrl.clock.Now())
+
+	switch {
+	case errors.Is(err, store.ErrHoldNotActive):
+		c.JSON(http.StatusConflict, gin.H{"error": "Hold is expired, confirmed or released"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not release Hold"})
+		return
+	}
+
+	ctrl.offerWaitlist(hold.EventID)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Hold released"})
+}
+
+// loads the hold from the url and checks that it belongs to the requesting user,
+// writes the error response itself when ok is false
+func (ctrl *Controller) ownHold(c *gin.Context) (hold models.Hold, ok bool) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Hold not found"})
+		return hold, false
+	}
+
+	hold, err := ctrl.store.Holds.Get(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Hold not found"})
+		return hold, false
+	}
+
+	if !ctrl.ownsOrIsAdmin(c, hold.UserID) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
+		return hold, false
+	}
+
+	return hold, true
+}
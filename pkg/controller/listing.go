@@ -0,0 +1,320 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mgr1054/go-ticket/pkg/models"
+	"github.com/mgr1054/go-ticket/pkg/store"
+	"github.com/mgr1054/go-ticket/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+type ListingRequest struct {
+	// at most the face value of the ticket, in its currency
+	Price		*models.Money	`json:"price" binding:"required"`
+}
+
+// buys a resale listing for the user, the ticket moves to the user once the payment succeeded
+func (ctrl *Controller) buyListing(c *gin.Context, user models.User, request NewOrder) {
+	if request.EventID != 0 || len(request.Items) > 0 || request.PresaleCode != "" || request.PromoCode != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Resale tickets are bought on their own"})
+		return
+	}
+
+	listing, err := ctrl.store.Listings.Get(request.ListingID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Listing not found"})
+		return
+	}
+	if listing.SellerID == user.ID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot buy your own listing"})
+		return
+	}
+
+	order, err := ctrl.store.Listings.Buy(listing.ID, user.ID, ctrl.clock.Now())
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Listing not found"})
+		return
+	case errors.Is(err, store.ErrListingClosed):
+		c.JSON(http.StatusConflict, gin.H{"error": "Unfortunately, the ticket is not for sale anymore"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not create Order"})
+		return
+	}
+
+	order, err = ctrl.checkout(order, request.PaymentMethod)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not create Order"})
+		return
+	}
+
+	ctrl.respondCheckout(c, order)
+}
+
+// @Summary 		List Ticket For Resale
+// @Description		Puts a paid ticket up for resale at or below its face value, the price of the ticket before any discount
+// @Description		Other users buy it with POST /secured/orders and its listing_id; the buyer gets the ticket with a new entry code
+// @Description		once the payment succeeded and the seller a payout of the price. Listed tickets are not admitted at the door,
+// @Description		withdraw the listing to use the ticket again. Listings are withdrawn when the event starts
+// @Description		allowed: user, admin
+// @ID				create-listing
+// @Tags 			resale
+// @Accept			json
+// @Produce 		json
+// @Param			id path int true "Ticket ID"
+// @Param			listing body ListingRequest true "List Ticket For Resale"
+// @Success 		201 {object} models.Listing
+// @Failure			400 {string} json "{"error": "Could not list the ticket"}"
+// @Failure			400 {string} json "{"error": "The price exceeds the face value of the ticket"}"
+// @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
+// @Failure			404 {string} json "{"error": "Ticket not found"}"
+// @Failure			409 {string} json "{"error": "Ticket is cancelled or not paid"}"
+// @Failure			409 {string} json "{"error": "Ticket was already used at the door"}"
+// @Failure			409 {string} json "{"error": "The event has already started"}"
+// @Failure			409 {string} json "{"error": "The ticket is already listed for resale"}"
+// @Failure			409 {string} json "{"error": "The ticket belongs to someone else now"}"
+// @Failure			500 {string} json "{"error": "Could not list the ticket"}"
+// @Router 			/secured/tickets/{id}/listing [post]
+func (ctrl *Controller) CreateListing (c *gin.Context) {
+
+	if err := utils.CheckUserType(c, "user"); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
+		return
+	}
+
+	id, ok := paramID(c, "id")
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ticket not found"})
+		return
+	}
+
+	var request ListingRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Could not list the ticket"})
+		return
+	}
+
+	ticket, err := ctrl.store.Tickets.Get(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ticket not found"})
+		return
+	}
+
+	if !ctrl.ownsOrIsAdmin(c, ticket.UserID) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
+		return
+	}
+
+	event, err := ctrl.store.Events.Get(ticket.EventID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not list the ticket"})
+		return
+	}
+	now := ctrl.clock.Now()
+	if !event.StartsAt.After(now) {
+		c.JSON(http.StatusConflict, gin.H{"error": "The event has already started"})
+		return
+	}
+
+	listing := models.Listing{TicketID: ticket.ID, SellerID: ticket.UserID, Price: *request.Price}
+	err = ctrl.store.Listings.Create(&listing, now)
+
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ticket not found"})
+		return
+	case errors.Is(err, store.ErrAboveFaceValue):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "The price exceeds the face value of the ticket"})
+		return
+	case errors.Is(err, store.ErrTicketNotIssued):
+		c.JSON(http.StatusConflict, gin.H{"error": "Ticket is cancelled or not paid"})
+		return
+	case errors.Is(err, store.ErrTicketUsed):
+		c.JSON(http.StatusConflict, gin.H{"error": "Ticket was already used at the door"})
+		return
+	case errors.Is(err, store.ErrDuplicate):
+		c.JSON(http.StatusConflict, gin.H{"error": "The ticket is already listed for resale"})
+		return
+	case errors.Is(err, store.ErrTransferClosed):
+		// the ticket changed hands since it was read
+		c.JSON(http.StatusConflict, gin.H{"error": "The ticket belongs to someone else now"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not list the ticket"})
+		return
+	}
+
+	log.Infof("Ticket %d was listed for resale at %s", ticket.ID, listing.Price)
+	c.JSON(http.StatusCreated, listing)
+}
+
+// @Summary 		Get Event Listings
+// @Description		Gives back the tickets of the event that are for resale, cheapest first
+// @Description		allowed: user, admin
+// @ID				get-event-listings
+// @Tags 			resale
+// @Produce 		json
+// @Param			id path int true "Event ID"
+// @Success 		200 {object} []models.Listing
+// @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
+// @Failure			404 {string} json "{"error": "Event not found"}"
+// @Failure			500 {string} json "{"error": "Could not get the listings"}"
+// @Router 			/secured/events/{id}/listings [get]
+func (ctrl *Controller) GetEventListings (c *gin.Context) {
+
+	if err := utils.CheckUserType(c, "user"); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
+		return
+	}
+
+	id, ok := paramID(c, "id")
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+
+	listings, err := ctrl.store.Listings.ListByEvent(id)
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not get the listings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": listings})
+}
+
+// @Summary 		Get Listings
+// @Description		Gives back all tickets the user put up for resale
+// @Description		allowed: user, admin
+// @ID				get-listings
+// @Tags 			resale
+// @Produce 		json
+// @Success 		200 {object} []models.Listing
+// @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
+// @Failure			404 {string} json "{"error": "User not found"}"
+// @Failure			500 {string} json "{"error": "Could not get the listings"}"
+// @Router 			/secured/listings [get]
+func (ctrl *Controller) GetListings (c *gin.Context) {
+
+	if err := utils.CheckUserType(c, "user"); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
+		return
+	}
+
+	user, err := ctrl.store.Users.GetByUsername(c.GetString("username"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	listings, err := ctrl.store.Listings.ListBySeller(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not get the listings"})
+		return
+	}
+
+	if len(listings) < 1 {
+		c.JSON(http.StatusOK, gin.H{"info": "The user has no listings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, listings)
+}
+
+// @Summary 		Withdraw Listing
+// @Description		Takes a ticket off the resale marketplace, listings a buyer is paying for cannot be withdrawn
+// @Description		allowed: user, admin
+// @ID				withdraw-listing
+// @Tags 			resale
+// @Produce 		json
+// @Param			id path int true "Listing ID"
+// @Success 		200 {object} models.Listing
+// @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
+// @Failure			404 {string} json "{"error": "Listing not found"}"
+// @Failure			409 {string} json "{"error": "The listing is not active anymore"}"
+// @Failure			500 {string} json "{"error": "Could not withdraw the listing"}"
+// @Router 			/secured/listings/{id} [delete]
+func (ctrl *Controller) WithdrawListing (c *gin.Context) {
+
+	if err := utils.CheckUserType(c, "user"); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
+		return
+	}
+
+	id, ok := paramID(c, "id")
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Listing not found"})
+		return
+	}
+
+	listing, err := ctrl.store.Listings.Get(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Listing not found"})
+		return
+	}
+
+	if !ctrl.ownsOrIsAdmin(c, listing.SellerID) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
+		return
+	}
+
+	listing, err = ctrl.store.Listings.Withdraw(id, ctrl.clock.Now())
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Listing not found"})
+		return
+	case errors.Is(err, store.ErrListingClosed):
+		c.JSON(http.StatusConflict, gin.H{"error": "The listing is not active anymore"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not withdraw the listing"})
+		return
+	}
+
+	c.JSON(http.StatusOK, listing)
+}
+
+// @Summary 		Get Payouts
+// @Description		Gives back the money owed to the user for resold tickets
+// @Description		allowed: user, admin
+// @ID				get-payouts
+// @Tags 			resale
+// @Produce 		json
+// @Success 		200 {object} []models.Payout
+// @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
+// @Failure			404 {string} json "{"error": "User not found"}"
+// @Failure			500 {string} json "{"error": "Could not get the payouts"}"
+// @Router 			/secured/payouts [get]
+func (ctrl *Controller) GetPayouts (c *gin.Context) {
+
+	if err := utils.CheckUserType(c, "user"); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
+		return
+	}
+
+	user, err := ctrl.store.Users.GetByUsername(c.GetString("username"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	payouts, err := ctrl.store.Listings.ListPayouts(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not get the payouts"})
+		return
+	}
+
+	if len(payouts) < 1 {
+		c.JSON(http.StatusOK, gin.H{"info": "The user has no payouts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, payouts)
+}
@@ -0,0 +1,254 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mgr1054/go-ticket/pkg/models"
+	"github.com/mgr1054/go-ticket/pkg/store"
+	"github.com/mgr1054/go-ticket/pkg/utils"
+)
+
+// quantity tickets without a seat and one ticket for each of the seats of the event
+type OrderItem struct {
+	EventID		uint		`json:"event_id" binding:"required" example:"1"`
+	// required for events with ticket types
+	TicketTypeID uint		`json:"ticket_type_id" example:"2"`
+	Quantity	int			`json:"quantity" binding:"omitempty,min=1" example:"2"`
+	SeatIDs		[]uint		`json:"seat_ids" example:"14,15"`
+}
+
+// either event_id with quantity or seat_ids for a single event or items for several events
+type NewOrder struct {
+	EventID		uint		`json:"event_id" example:"1"`
+	TicketTypeID uint		`json:"ticket_type_id" example:"2"`
+	Quantity	int			`json:"quantity" binding:"omitempty,min=1" example:"4"`
+	SeatIDs		[]uint		`json:"seat_ids" example:"14,15"`
+	Items		[]OrderItem	`json:"items" binding:"omitempty,dive"`
+	// unlocks tickets before the general sale of their event
+	PresaleCode	string		`json:"presale_code" example:"FANCLUB22"`
+	// discounts the tickets of the order
+	PromoCode	string		`json:"promo_code" example:"SUMMER10"`
+	// buys a ticket for resale, alone without events, items or codes
+	ListingID	uint		`json:"listing_id" example:"3"`
+	PaymentMethod	string	`json:"payment_method" example:"pm_card_ok"`
+}
+
+// @Summary 		Create Order
+// @Description		Buys tickets for one or several events at once, either all tickets are created or none
+// @Description		Tickets with a seat are bought by seat_ids, tickets without one only get the capacity the seats of the event leave
+// @Description		Events with ticket types sell every ticket in the ticket type of the item at its price, within its quota and sale window
+// @Description		Before the general sale of an event its tickets need a presale code with uses left, an order uses the code once
+// @Description		A promo code discounts every ticket it applies to, the total is charged net of the discount
+// @Description		The order is charged with the payment method, it stays pending while the provider processes the payment
+// @Description		With listing_id the order buys a ticket for resale instead, it joins the order once the payment succeeded
+// @Description		allowed: user
+// @ID				create-order
+// @Tags 			orders
+// @Accept			json
+// @Produce 		json
+// @Param			order body NewOrder true "Create Order"
+// @Success 		201 {object} models.Order
+// @Success 		202 {object} models.Order
+// @Failure			400 {string} json "{"error": "Could not create Order"}"
+// @Failure			400 {string} json "{"error": "All events of an order must be priced in the same currency"}"
+// @Failure			400 {string} json "{"error": "Choose a ticket type of the event"}"
+// @Failure			400 {string} json "{"error": "Invalid promo code"}"
+// @Failure			404 {string} json "{"error": "Ticket type not found"}"
+// @Failure			403 {string} json "{"error": "Invalid presale code"}"
+// @Failure			409 {string} json "{"error": "Tickets are not on sale"}"
+// @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
+// @Failure			402 {string} json "{"error": "Payment declined", "order_id": 1}"
+// @Failure			404 {string} json "{"error": "Event not found"}"
+// @Failure			404 {string} json "{"error": "Seat not found"}"
+// @Failure			404 {string} json "{"error": "Listing not found"}"
+// @Failure			400 {string} json "{"error": "Resale tickets are bought on their own"}"
+// @Failure			400 {string} json "{"error": "You cannot buy your own listing"}"
+// @Failure			409 {string} json "{"error": "Unfortunately, the ticket is not for sale anymore"}"
+// @Failure			409 {string} json "{"error": "Unfortunately, there are not enough tickets left"}"
+// @Failure			409 {string} json "{"error": "Unfortunately, one of the seats is already taken"}"
+// @Failure			500 {string} json "{"error": "Could not create Order"}"
+// @Router 			/secured/orders [post]
+func (ctrl *Controller) CreateOrder (c *gin.Context) {
+
+	if err := utils.CheckUserType(c, "user"); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
+		return
+	}
+
+	var request NewOrder
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Could not create Order"})
+		return
+	}
+
+	if request.ListingID != 0 {
+		user, err := ctrl.store.Users.GetByUsername(c.GetString("username"))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		ctrl.buyListing(c, user, request)
+		return
+	}
+
+	var items []store.OrderItem
+	for _, item := range request.Items {
+		items = append(items, store.OrderItem{EventID: item.EventID, TicketTypeID: item.TicketTypeID, Quantity: item.Quantity, SeatIDs: item.SeatIDs})
+	}
+	if request.EventID != 0 {
+		items = append(items, store.OrderItem{EventID: request.EventID, TicketTypeID: request.TicketTypeID, Quantity: request.Quantity, SeatIDs: request.SeatIDs})
+	}
+
+	if len(items) < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Could not create Order"})
+		return
+	}
+	for _, item := range items {
+		if item.Quantity+len(item.SeatIDs) < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Could not create Order"})
+			return
+		}
+	}
+
+	user, err := ctrl.store.Users.GetByUsername(c.GetString("username"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	order, err := ctrl.store.Orders.Create(user.ID, items, store.OrderCodes{Presale: request.PresaleCode, Promotion: request.PromoCode}, ctrl.clock.Now())
+
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	case errors.Is(err, store.ErrTicketTypeNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ticket type not found"})
+		return
+	case errors.Is(err, store.ErrTicketTypeRequired):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Choose a ticket type of the event"})
+		return
+	case errors.Is(err, store.ErrNotOnSale):
+		c.JSON(http.StatusConflict, gin.H{"error": "Tickets are not on sale"})
+		return
+	case errors.Is(err, store.ErrPresaleCodeRejected):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Invalid presale code"})
+		return
+	case errors.Is(err, store.ErrPromotionRejected):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid promo code"})
+		return
+	case errors.Is(err, store.ErrSeatNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Seat not found"})
+		return
+	case errors.Is(err, store.ErrSoldOut):
+		c.JSON(http.StatusConflict, gin.H{"error": "Unfortunately, there are not enough tickets left"})
+		return
+	case errors.Is(err, store.ErrSeatTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": "Unfortunately, one of the seats is already taken"})
+		return
+	case errors.Is(err, models.ErrCurrencyMismatch):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "All events of an order must be priced in the same currency"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not create Order"})
+		return
+	}
+
+	order, err = ctrl.checkout(order, request.PaymentMethod)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not create Order"})
+		return
+	}
+
+	ctrl.respondCheckout(c, order)
+}
+
+// @Summary 		Get Orders
+// @Description		Gives back all orders of the user including their tickets
+// @Description		allowed: user, admin
+// @ID				get-orders
+// @Tags 			orders
+// @Produce 		json
+// @Success 		200 {object} []models.Order
+// @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
+// @Failure			404 {string} json "{"error": "User not found"}"
+// @Failure			500 {string} json "{"error": "Could not get Orders"}"
+// @Router 			/secured/orders [get]
+func (ctrl *Controller) GetOrders (c *gin.Context) {
+
+	if err := utils.CheckUserType(c, "user"); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
+		return
+	}
+
+	user, err := ctrl.store.Users.GetByUsername(c.GetString("username"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	orders, err := ctrl.store.Orders.ListByUser(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not get Orders"})
+		return
+	}
+
+	if len(orders) < 1 {
+		c.JSON(http.StatusOK, gin.H{"info": "There are no orders for this user"})
+		return
+	}
+
+	c.JSON(http.StatusOK, orders)
+}
+
+// @Summary 		Get Order By ID
+// @Description		Sends an Order with its tickets, users only see their own orders
+// @Description		allowed: user, admin
+// @ID				get-order-by-id
+// @Tags 			orders
+// @Produce 		json
+// @Success 		200 {object} models.Order
+// @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
+// @Failure			404 {string} json "{"error": "Order not found"}"
+// @Router 			/secured/orders/{id} [get]
+func (ctrl *Controller) GetOrderById (c *gin.Context) {
+
+	if err := utils.CheckUserType(c, "user"); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
+		return
+	}
+
+	id, ok := paramID(c, "id")
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		return
+	}
+
+	order, err := ctrl.store.Orders.Get(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		return
+	}
+
+	if !ctrl.ownsOrIsAdmin(c, order.UserID) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+// reports whether the requesting user is an admin or the user with userID
+func (ctrl *Controller) ownsOrIsAdmin(c *gin.Context, userID uint) bool {
+	if c.GetString("role") == "admin" {
+		return true
+	}
+	owner, err := ctrl.store.Users.Get(userID)
+	if err != nil {
+		return false
+	}
+	return utils.CheckUser(c, owner.Username) == nil
+}
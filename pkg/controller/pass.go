@@ -0,0 +1,230 @@
+package controller
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mgr1054/go-ticket/pkg/entry"
+	"github.com/mgr1054/go-ticket/pkg/models"
+	"github.com/mgr1054/go-ticket/pkg/utils"
+)
+
+// bounds of the size of QR code PNGs in pixels
+const (
+	minQRCodeSize = 64
+	maxQRCodeSize = 1024
+)
+
+// loads the ticket from the url for its owner or an admin and returns its signed entry pass,
+// only issued tickets of paid orders admit; writes the error response itself when ok is false
+func (ctrl *Controller) ticketPass(c *gin.Context) (ticket models.Ticket, pass string, ok bool) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ticket not found"})
+		return ticket, "", false
+	}
+
+	ticket, err := ctrl.store.Tickets.Get(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ticket not found"})
+		return ticket, "", false
+	}
+
+	if !ctrl.ownsOrIsAdmin(c, ticket.UserID) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
+		return ticket, "", false
+	}
+
+	admits := ticket.Status == models.TicketIssued
+	if admits && ticket.OrderID != nil {
+		order, err := ctrl.store.Orders.Get(*ticket.OrderID)
+		admits = err == nil && order.Status == models.OrderCompleted
+	}
+	if !admits {
+		c.JSON(http.StatusConflict, gin.H{"error": "Ticket is cancelled or not paid"})
+		return ticket, "", false
+	}
+
+	return ticket, ctrl.passes.Sign(entry.Pass{TicketID: ticket.ID, EventID: ticket.EventID, Code: ticket.Code}), true
+}
+
+// @Summary 		Get Entry Public Key
+// @Description		Sends the Ed25519 public key that verifies the entry passes in the QR codes of tickets, so scanners can check them offline
+// @Description		A pass reads GT1.<ticket id>.<event id>.<entry code>.<signature>, the signature covers everything before its dot and is unpadded base64url
+// @Description		allowed: everyone
+// @ID				get-entry-public-key
+// @Tags 			tickets
+// @Produce 		json
+// @Success 		200 {string} json "{"algorithm": "Ed25519", "public_key": "base64", "version": "GT1"}"
+// @Router 			/entry/public-key [get]
+func (ctrl *Controller) GetEntryPublicKey (c *gin.Context) {
+
+	c.JSON(http.StatusOK, gin.H{
+		"algorithm": entry.Algorithm,
+		"public_key": base64.StdEncoding.EncodeToString(ctrl.passes.PublicKey()),
+		"version": entry.Version,
+	})
+}
+
+// @Summary 		Get Ticket Pass
+// @Description		Sends the signed entry pass of a paid ticket, the text its QR code encodes
+// @Description		allowed: user, admin
+// @ID				get-ticket-pass
+// @Tags 			tickets
+// @Produce 		json
+// @Success 		200 {string} json "{"ticket_id": 1, "pass": "GT1.1.1.code.signature"}"
+// @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
+// @Failure			404 {string} json "{"error": "Ticket not found"}"
+// @Failure			409 {string} json "{"error": "Ticket is cancelled or not paid"}"
+// @Router 			/secured/tickets/{id}/pass [get]
+func (ctrl *Controller) GetTicketPass (c *gin.Context) {
+
+	if err := utils.CheckUserType(c, "user"); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
+		return
+	}
+
+	ticket, pass, ok := ctrl.ticketPass(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"ticket_id": ticket.ID, "pass": pass})
+}
+
+// @Summary 		Get Ticket QR Code
+// @Description		Renders the signed entry pass of a paid ticket as QR code to show at the door
+// @Description		allowed: user, admin
+// @ID				get-ticket-qr-code
+// @Tags 			tickets
+// @Produce 		png
+// @Produce 		image/svg+xml
+// @Param			format query string false "png (default) or svg"
+// @Param			size query int false "Width and height of PNGs in pixels, 64 to 1024, default 256"
+// @Success 		200 {file} binary
+// @Failure			400 {string} json "{"error": "Invalid QR code format or size"}"
+// @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
+// @Failure			404 {string} json "{"error": "Ticket not found"}"
+// @Failure			409 {string} json "{"error": "Ticket is cancelled or not paid"}"
+// @Failure			500 {string} json "{"error": "Could not render QR code"}"
+// @Router 			/secured/tickets/{id}/qr [get]
+func (ctrl *Controller) GetTicketQRCode (c *gin.Context) {
+
+	if err := utils.CheckUserType(c, "user"); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
+		return
+	}
+
+	format := c.DefaultQuery("format", "png")
+	size, err := strconv.Atoi(c.DefaultQuery("size", "256"))
+	if (format != "png" && format != "svg") || err != nil || size < minQRCodeSize || size > maxQRCodeSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid QR code format or size"})
+		return
+	}
+
+	_, pass, ok := ctrl.ticketPass(c)
+	if !ok {
+		return
+	}
+
+	var image []byte
+	contentType := "image/png"
+	if format == "svg" {
+		image, err = entry.SVG(pass)
+		contentType = "image/svg+xml"
+	} else {
+		image, err = entry.PNG(pass, size)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not render QR code"})
+		return
+	}
+
+	// passes stop working once the ticket gets a new code
+	c.Header("Cache-Control", "private, no-store")
+	c.Data(http.StatusOK, contentType, image)
+}
+
+// @Summary 		Get Ticket PDF
+// @Description		Renders a paid ticket as printable A4 PDF with band, venue, date, seat or tier, holder and the QR code of its entry pass
+// @Description		allowed: user, admin
+// @ID				get-ticket-pdf
+// @Tags 			tickets
+// @Produce 		application/pdf
+// @Success 		200 {file} binary
+// @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
+// @Failure			404 {string} json "{"error": "Ticket not found"}"
+// @Failure			409 {string} json "{"error": "Ticket is cancelled or not paid"}"
+// @Failure			500 {string} json "{"error": "Could not render the ticket"}"
+// @Router 			/secured/tickets/{id}/pdf [get]
+func (ctrl *Controller) GetTicketPDF (c *gin.Context) {
+
+	if err := utils.CheckUserType(c, "user"); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
+		return
+	}
+
+	ticket, pass, ok := ctrl.ticketPass(c)
+	if !ok {
+		return
+	}
+
+	event, err := ctrl.store.Events.Get(ticket.EventID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ticket not found"})
+		return
+	}
+	holder, err := ctrl.store.Users.Get(ticket.UserID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ticket not found"})
+		return
+	}
+	zone, err := event.Zone()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not render the ticket"})
+		return
+	}
+
+	printout := entry.Printout{
+		TicketID: ticket.ID,
+		Band: event.Band_Name,
+		Venue: event.Location,
+		StartsAt: event.StartsAt.In(zone),
+		DoorsOpenAt: event.DoorsOpenAt.In(zone),
+		Holder: holder.Name,
+		Price: ticket.Price.String(),
+		Pass: pass,
+		PrintedAt: ctrl.clock.Now(),
+	}
+	if ticket.TicketTypeID != nil {
+		if ticketType, err := ctrl.store.TicketTypes.Get(*ticket.TicketTypeID); err == nil {
+			printout.Tier = ticketType.Name
+		}
+	}
+	if ticket.SeatID != nil {
+		seats, err := ctrl.store.Seats.ListByEvent(event.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not render the ticket"})
+			return
+		}
+		for _, seat := range seats {
+			if seat.ID == *ticket.SeatID {
+				printout.Seat = fmt.Sprintf("%s, row %s, seat %s", seat.Section, seat.Row, seat.Number)
+			}
+		}
+	}
+
+	document, err := entry.PDF(printout)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not render the ticket"})
+		return
+	}
+
+	// the pass inside stops working once the ticket gets a new code
+	c.Header("Cache-Control", "private, no-store")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="ticket-%d.pdf"`, ticket.ID))
+	c.Data(http.StatusOK, "application/pdf", document)
+}
@@ -0,0 +1,149 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mgr1054/go-ticket/pkg/models"
+	"github.com/mgr1054/go-ticket/pkg/payment"
+	"github.com/mgr1054/go-ticket/pkg/store"
+	log "github.com/sirupsen/logrus"
+)
+
+// optional body of requests that pay for tickets
+type CheckoutRequest struct {
+	PaymentMethod	string		`json:"payment_method" example:"pm_card_ok"`
+	// discounts the tickets, only used while they are not ordered yet
+	PromoCode	string		`json:"promo_code" example:"SUMMER10"`
+}
+
+// charges a pending order at the payment provider, the returned order is
+// completed, failed or still pending while the provider processes the payment
+func (ctrl *Controller) checkout(order models.Order, paymentMethod string) (models.Order, error) {
+	amount := order.Total.Amount
+
+	record := models.Payment{
+		OrderID: order.ID,
+		Provider: ctrl.payments.Name(),
+		Amount: amount,
+		Currency: order.Total.Currency,
+		Status: models.PaymentPending,
+		CreatedAt: ctrl.clock.Now().UTC(),
+	}
+
+	status := models.PaymentPending
+	if amount == 0 {
+		// nothing to charge
+		record.Provider = "none"
+		status = models.PaymentSucceeded
+	} else {
+		intent, err := ctrl.payments.CreateIntent(amount, record.Currency, fmt.Sprintf("order-%d", order.ID))
+		if err != nil {
+			log.Error("Payment intent could not be created: ", err)
+			status = models.PaymentFailed
+		}
+		record.IntentID = intent.ID
+	}
+
+	if err := ctrl.store.Payments.Create(&record); err != nil {
+		return order, err
+	}
+
+	if status == models.PaymentPending {
+		intent, err := ctrl.payments.Confirm(record.IntentID, paymentMethod)
+		switch {
+		case err != nil:
+			// the outcome is unknown, the payment stays pending until
+			// the webhook arrives or the pending timeout fails it
+			log.Error("Payment could not be confirmed: ", err)
+		case intent.Status == payment.IntentSucceeded:
+			status = models.PaymentSucceeded
+		case intent.Status == payment.IntentFailed:
+			status = models.PaymentFailed
+		}
+	}
+
+	if status != models.PaymentPending {
+		if _, err := ctrl.store.Payments.Settle(record.ID, status, ctrl.clock.Now()); err != nil {
+			return order, err
+		}
+	}
+
+	return ctrl.store.Orders.Get(order.ID)
+}
+
+// writes the response for an order after checkout
+func (ctrl *Controller) respondCheckout(c *gin.Context, order models.Order) {
+	switch order.Status {
+	case models.OrderCompleted:
+		c.JSON(http.StatusCreated, order)
+	case models.OrderPending:
+		c.JSON(http.StatusAccepted, order)
+	default:
+		c.JSON(http.StatusPaymentRequired, gin.H{"error": "Payment declined", "order_id": order.ID})
+	}
+}
+
+// @Summary 		Payment Webhook
+// @Description		Receives payment results from the payment provider, requests must be signed by the provider
+// @Description		allowed: payment provider
+// @ID				payment-webhook
+// @Tags 			payments
+// @Accept			json
+// @Produce 		json
+// @Success 		200 {string} json "{"message": "Webhook processed"}"
+// @Failure			400 {string} json "{"error": "Invalid webhook"}"
+// @Failure			404 {string} json "{"error": "Payment not found"}"
+// @Failure			500 {string} json "{"error": "Could not process webhook"}"
+// @Router 			/payments/webhook [post]
+func (ctrl *Controller) PaymentWebhook (c *gin.Context) {
+
+	payload, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook"})
+		return
+	}
+
+	event, err := ctrl.payments.VerifyWebhook(payload, c.Request.Header)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook"})
+		return
+	}
+
+	var status string
+	switch event.Type {
+	case payment.EventPaymentSucceeded:
+		status = models.PaymentSucceeded
+	case payment.EventPaymentFailed:
+		status = models.PaymentFailed
+	default:
+		c.JSON(http.StatusOK, gin.H{"message": "Webhook ignored"})
+		return
+	}
+
+	record, err := ctrl.store.Payments.GetByIntent(ctrl.payments.Name(), event.IntentID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Payment not found"})
+		return
+	}
+
+	record, err = ctrl.store.Payments.Settle(record.ID, status, ctrl.clock.Now())
+	switch {
+	case errors.Is(err, store.ErrPaymentSettled):
+		// the order was already failed by the pending timeout, so the
+		// money of a late success has to go back to the customer
+		if record.Status == models.PaymentFailed && status == models.PaymentSucceeded {
+			if _, err := ctrl.payments.Refund(record.IntentID, record.Amount); err != nil {
+				log.Error("Late payment ", record.IntentID, " could not be refunded: ", err)
+			}
+		}
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not process webhook"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook processed"})
+}
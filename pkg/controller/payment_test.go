@@ -0,0 +1,158 @@
+package controller_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/mgr1054/go-ticket/pkg/controller"
+	"github.com/mgr1054/go-ticket/pkg/models"
+	"github.com/mgr1054/go-ticket/pkg/payment"
+)
+
+// orders quantity tickets of the event paid with paymentMethod, returns the status
+// code and the id of the order
+func (s *testServer) order(t *testing.T, eventID uint, quantity int, paymentMethod string) (int, uint) {
+	t.Helper()
+	body, _ := json.Marshal(controller.NewOrder{EventID: eventID, Quantity: quantity, PaymentMethod: paymentMethod})
+	request, _ := http.NewRequest(http.MethodPost, s.URL+"/api/secured/orders", bytes.NewReader(body))
+	request.Header.Set("Authorization", s.token)
+	request.Header.Set("Content-Type", "application/json")
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	var result struct {
+		ID		uint	`json:"id"`
+		OrderID	uint	`json:"order_id"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	if result.ID == 0 {
+		result.ID = result.OrderID
+	}
+	return response.StatusCode, result.ID
+}
+
+// the order as returned by the api
+func (s *testServer) getOrder(t *testing.T, id uint) models.Order {
+	t.Helper()
+	request, _ := http.NewRequest(http.MethodGet, s.URL+"/api/secured/orders/"+strconv.Itoa(int(id)), nil)
+	request.Header.Set("Authorization", s.token)
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	var order models.Order
+	if err := json.NewDecoder(response.Body).Decode(&order); err != nil {
+		t.Fatal(err)
+	}
+	return order
+}
+
+func (s *testServer) waitForWebhook(t *testing.T) int {
+	t.Helper()
+	select {
+	case status := <-s.webhooks:
+		return status
+	case <-time.After(5 * time.Second):
+		t.Fatal("the payment webhook did not arrive")
+		return 0
+	}
+}
+
+func (s *testServer) sold(t *testing.T, eventID uint) int64 {
+	t.Helper()
+	sold, err := s.store.Tickets.CountByEvent(eventID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sold
+}
+
+func TestCheckout(t *testing.T) {
+	s := newTestServer(t)
+	event := s.createEvent(t, 10)
+
+	t.Run("card ok", func(t *testing.T) {
+		status, id := s.order(t, event.ID, 2, payment.MockCardOK)
+		if status != http.StatusCreated {
+			t.Fatalf("got status %d, want %d", status, http.StatusCreated)
+		}
+		order := s.getOrder(t, id)
+		if order.Status != models.OrderCompleted || len(order.Tickets) != 2 {
+			t.Errorf("order is %q with %d tickets, want %q with 2", order.Status, len(order.Tickets), models.OrderCompleted)
+		}
+	})
+
+	t.Run("card declined", func(t *testing.T) {
+		before := s.sold(t, event.ID)
+		status, id := s.order(t, event.ID, 2, payment.MockCardDeclined)
+		if status != http.StatusPaymentRequired {
+			t.Fatalf("got status %d, want %d", status, http.StatusPaymentRequired)
+		}
+		order := s.getOrder(t, id)
+		if order.Status != models.OrderFailed || len(order.Tickets) != 0 {
+			t.Errorf("order is %q with %d tickets, want %q without", order.Status, len(order.Tickets), models.OrderFailed)
+		}
+		if sold := s.sold(t, event.ID); sold != before {
+			t.Errorf("%d tickets sold, want %d", sold, before)
+		}
+	})
+
+	t.Run("card delayed", func(t *testing.T) {
+		status, id := s.order(t, event.ID, 1, payment.MockCardDelayed)
+		if status != http.StatusAccepted {
+			t.Fatalf("got status %d, want %d", status, http.StatusAccepted)
+		}
+		if order := s.getOrder(t, id); order.Status != models.OrderPending || len(order.Tickets) != 1 {
+			t.Fatalf("order is %q with %d tickets before the webhook, want %q with 1", order.Status, len(order.Tickets), models.OrderPending)
+		}
+		if status := s.waitForWebhook(t); status != http.StatusOK {
+			t.Fatalf("webhook got status %d, want %d", status, http.StatusOK)
+		}
+		if order := s.getOrder(t, id); order.Status != models.OrderCompleted || len(order.Tickets) != 1 {
+			t.Errorf("order is %q with %d tickets, want %q with 1", order.Status, len(order.Tickets), models.OrderCompleted)
+		}
+	})
+
+	// the pending timeout fails the order before the provider reports the success,
+	// the late payment is refunded
+	t.Run("late success", func(t *testing.T) {
+		before := s.sold(t, event.ID)
+		status, id := s.order(t, event.ID, 1, payment.MockCardDelayed)
+		if status != http.StatusAccepted {
+			t.Fatalf("got status %d, want %d", status, http.StatusAccepted)
+		}
+
+		s.clock.Advance(s.cfg.Payments.PendingTimeout.Duration + time.Second)
+		expired, err := s.store.Payments.ExpirePending(s.clock.Now(), s.cfg.Payments.PendingTimeout.Duration)
+		if err != nil || expired != 1 {
+			t.Fatalf("ExpirePending = %d, %v, want 1", expired, err)
+		}
+		if order := s.getOrder(t, id); order.Status != models.OrderFailed {
+			t.Fatalf("order is %q after the timeout, want %q", order.Status, models.OrderFailed)
+		}
+
+		if status := s.waitForWebhook(t); status != http.StatusOK {
+			t.Fatalf("webhook got status %d, want %d", status, http.StatusOK)
+		}
+		if order := s.getOrder(t, id); order.Status != models.OrderFailed || len(order.Tickets) != 0 {
+			t.Errorf("order is %q with %d tickets after the webhook, want %q without", order.Status, len(order.Tickets), models.OrderFailed)
+		}
+		if sold := s.sold(t, event.ID); sold != before {
+			t.Errorf("%d tickets sold, want %d", sold, before)
+		}
+		refunds := s.payments.Refunds()
+		if len(refunds) != 1 || refunds[0].Amount != event.Price.Amount {
+			t.Errorf("refunds %+v, want one of %d", refunds, event.Price.Amount)
+		}
+	})
+}
@@ -0,0 +1,276 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mgr1054/go-ticket/pkg/models"
+	"github.com/mgr1054/go-ticket/pkg/store"
+	"github.com/mgr1054/go-ticket/pkg/utils"
+)
+
+// times without offset are wall clock times in the timezone of the event
+type PresaleCodeRequest struct {
+	// letters, digits, - and _, generated when empty
+	Code		string		`json:"code" example:"FANCLUB22"`
+	// limits the code to one ticket type of the event
+	TicketTypeID *uint		`json:"ticket_type_id" example:"2"`
+	// 1 for a single-use code
+	MaxUses		int			`json:"max_uses" binding:"required,min=1" example:"100"`
+	ValidFrom	string		`json:"valid_from" example:"2022-05-25T10:00"`
+	ValidUntil	string		`json:"valid_until" example:"2022-06-01T10:00"`
+}
+
+// replaces the fields of the presale code of the event with the request and validates it
+func applyPresaleCode(code *models.PresaleCode, event models.Event, request PresaleCodeRequest) error {
+	zone, err := event.Zone()
+	if err != nil {
+		return err
+	}
+
+	code.Code = request.Code
+	if code.Code == "" {
+		if code.Code, err = models.RandomCode(); err != nil {
+			return err
+		}
+	}
+	code.TicketTypeID = request.TicketTypeID
+	code.MaxUses = request.MaxUses
+	code.ValidFrom, code.ValidUntil = nil, nil
+	for _, field := range []struct {
+		value	string
+		target	**time.Time
+	}{
+		{request.ValidFrom, &code.ValidFrom},
+		{request.ValidUntil, &code.ValidUntil},
+	} {
+		if field.value == "" {
+			continue
+		}
+		parsed, err := models.ParseEventTime(field.value, zone)
+		if err != nil {
+			return err
+		}
+		parsed = parsed.UTC()
+		*field.target = &parsed
+	}
+	return code.Normalize()
+}
+
+// @Summary 		Get Presale Codes
+// @Description		Sends the presale codes of the event with the number of times each was used
+// @Description		allowed: admin
+// @ID				get-presale-codes
+// @Tags 			presale codes
+// @Produce 		json
+// @Success 		200 {object} []models.PresaleCode
+// @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
+// @Failure			404 {string} json "{"error": "Event not found"}"
+// @Failure			500 {string} json "{"error": "Could not get presale codes"}"
+// @Router 			/secured/events/{id}/presale-codes [get]
+func (ctrl *Controller) GetPresaleCodes (c *gin.Context) {
+
+	if err := utils.CheckUserType(c, "admin"); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
+		return
+	}
+
+	id, ok := paramID(c, "id")
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+
+	codes, err := ctrl.store.PresaleCodes.ListByEvent(id, ctrl.clock.Now())
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not get presale codes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": codes})
+}
+
+// @Summary 		Create Presale Code
+// @Description		Adds a presale code that unlocks tickets of the event before its general sale, every order or hold
+// @Description		bought with it uses it once; a code with max_uses 1 is single-use
+// @Description		allowed: admin
+// @ID				create-presale-code
+// @Tags 			presale codes
+// @Accept			json
+// @Produce 		json
+// @Param			presale_code body PresaleCodeRequest true "Create Presale Code"
+// @Success 		201 {object} models.PresaleCode
+// @Failure			400 {string} json "{"error": "Invalid presale code"}"
+// @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
+// @Failure			404 {string} json "{"error": "Event not found"}"
+// @Failure			404 {string} json "{"error": "Ticket type not found"}"
+// @Failure			409 {string} json "{"error": "The event already has this presale code"}"
+// @Failure			500 {string} json "{"error": "Could not create presale code"}"
+// @Router 			/secured/events/{id}/presale-codes [post]
+func (ctrl *Controller) CreatePresaleCode (c *gin.Context) {
+
+	if err := utils.CheckUserType(c, "admin"); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
+		return
+	}
+
+	id, ok := paramID(c, "id")
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+
+	var request PresaleCodeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid presale code"})
+		return
+	}
+
+	event, err := ctrl.store.Events.Get(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+
+	code := models.PresaleCode{EventID: event.ID, CreatedAt: ctrl.clock.Now().UTC()}
+	if err := applyPresaleCode(&code, event, request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid presale code"})
+		return
+	}
+
+	err = ctrl.store.PresaleCodes.Create(&code)
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	case errors.Is(err, store.ErrTicketTypeNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ticket type not found"})
+		return
+	case errors.Is(err, store.ErrDuplicate):
+		c.JSON(http.StatusConflict, gin.H{"error": "The event already has this presale code"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not create presale code"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, code)
+}
+
+// @Summary 		Update Presale Code
+// @Description		Replaces ticket type, uses limit and validity of the presale code and its code unless empty, orders bought with it stay
+// @Description		allowed: admin
+// @ID				update-presale-code
+// @Tags 			presale codes
+// @Accept			json
+// @Produce 		json
+// @Param			presale_code body PresaleCodeRequest true "Update Presale Code"
+// @Success 		200 {object} models.PresaleCode
+// @Failure			400 {string} json "{"error": "Invalid presale code"}"
+// @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
+// @Failure			404 {string} json "{"error": "Presale code not found"}"
+// @Failure			404 {string} json "{"error": "Ticket type not found"}"
+// @Failure			409 {string} json "{"error": "The event already has this presale code"}"
+// @Failure			500 {string} json "{"error": "Could not update presale code"}"
+// @Router 			/secured/presale-codes/{id} [put]
+func (ctrl *Controller) UpdatePresaleCode (c *gin.Context) {
+
+	if err := utils.CheckUserType(c, "admin"); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
+		return
+	}
+
+	id, ok := paramID(c, "id")
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Presale code not found"})
+		return
+	}
+
+	var request PresaleCodeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid presale code"})
+		return
+	}
+
+	now := ctrl.clock.Now()
+	code, err := ctrl.store.PresaleCodes.Get(id, now)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Presale code not found"})
+		return
+	}
+	event, err := ctrl.store.Events.Get(code.EventID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Presale code not found"})
+		return
+	}
+
+	if request.Code == "" {
+		request.Code = code.Code
+	}
+	if err := applyPresaleCode(&code, event, request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid presale code"})
+		return
+	}
+
+	_, err = ctrl.store.PresaleCodes.Update(id, code)
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Presale code not found"})
+		return
+	case errors.Is(err, store.ErrTicketTypeNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ticket type not found"})
+		return
+	case errors.Is(err, store.ErrDuplicate):
+		c.JSON(http.StatusConflict, gin.H{"error": "The event already has this presale code"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not update presale code"})
+		return
+	}
+
+	// the uses stay the same, the response shows them like the list does
+	c.JSON(http.StatusOK, code)
+}
+
+// @Summary 		Delete Presale Code
+// @Description		Deletes a presale code, tickets bought with it stay valid
+// @Description		allowed: admin
+// @ID				delete-presale-code
+// @Tags 			presale codes
+// @Produce 		json
+// @Success 		200 {string} json "{"message": "Presale code deleted"}"
+// @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
+// @Failure			404 {string} json "{"error": "Presale code not found"}"
+// @Failure			500 {string} json "{"error": "Could not delete presale code"}"
+// @Router 			/secured/presale-codes/{id} [delete]
+func (ctrl *Controller) DeletePresaleCode (c *gin.Context) {
+
+	if err := utils.CheckUserType(c, "admin"); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
+		return
+	}
+
+	id, ok := paramID(c, "id")
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Presale code not found"})
+		return
+	}
+
+	err := ctrl.store.PresaleCodes.Delete(id)
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Presale code not found"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not delete presale code"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Presale code deleted"})
+}
@@ -0,0 +1,304 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mgr1054/go-ticket/pkg/models"
+	"github.com/mgr1054/go-ticket/pkg/store"
+	"github.com/mgr1054/go-ticket/pkg/utils"
+)
+
+// times without offset are wall clock times in the timezone of the event, in UTC for global promotions
+type PromotionRequest struct {
+	// letters, digits, - and _, generated when empty
+	Code		string		`json:"code" example:"SUMMER10"`
+	// percent or fixed
+	Kind		string		`json:"kind" binding:"required" example:"percent"`
+	Percent		int			`json:"percent" example:"10"`
+	// taken off every ticket of fixed promotions, the currency defaults to PAYMENT_CURRENCY
+	AmountOff	*models.Money	`json:"amount_off"`
+	// limits the promotion to one event, all events when empty
+	EventID		*uint		`json:"event_id" example:"1"`
+	// 0 for no limit
+	MaxUses		int			`json:"max_uses" binding:"min=0" example:"500"`
+	MaxUsesPerUser int		`json:"max_uses_per_user" binding:"min=0" example:"1"`
+	ValidFrom	string		`json:"valid_from" example:"2022-07-01T00:00"`
+	ValidUntil	string		`json:"valid_until" example:"2022-09-01T00:00"`
+}
+
+// replaces the fields of the promotion with the request and validates it
+func (ctrl *Controller) applyPromotion(promotion *models.Promotion, request PromotionRequest) error {
+	zone := time.UTC
+	if request.EventID != nil {
+		event, err := ctrl.store.Events.Get(*request.EventID)
+		if err != nil {
+			return err
+		}
+		if zone, err = event.Zone(); err != nil {
+			return err
+		}
+	}
+
+	var err error
+	promotion.Code = request.Code
+	if promotion.Code == "" {
+		if promotion.Code, err = models.RandomCode(); err != nil {
+			return err
+		}
+	}
+	promotion.Kind = request.Kind
+	promotion.Percent = request.Percent
+	promotion.AmountOff = models.Money{}
+	if request.AmountOff != nil {
+		if err := ctrl.normalizePrice(request.AmountOff); err != nil {
+			return err
+		}
+		promotion.AmountOff = *request.AmountOff
+	}
+	promotion.EventID = request.EventID
+	promotion.MaxUses = request.MaxUses
+	promotion.MaxUsesPerUser = request.MaxUsesPerUser
+	promotion.ValidFrom, promotion.ValidUntil = nil, nil
+	for _, field := range []struct {
+		value	string
+		target	**time.Time
+	}{
+		{request.ValidFrom, &promotion.ValidFrom},
+		{request.ValidUntil, &promotion.ValidUntil},
+	} {
+		if field.value == "" {
+			continue
+		}
+		parsed, err := models.ParseEventTime(field.value, zone)
+		if err != nil {
+			return err
+		}
+		parsed = parsed.UTC()
+		*field.target = &parsed
+	}
+	return promotion.Normalize()
+}
+
+// @Summary 		Get Promotions
+// @Description		Sends all promotions with the number of orders that used each
+// @Description		allowed: admin
+// @ID				get-promotions
+// @Tags 			promotions
+// @Produce 		json
+// @Success 		200 {object} []models.Promotion
+// @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
+// @Failure			500 {string} json "{"error": "Could not get promotions"}"
+// @Router 			/secured/promotions [get]
+func (ctrl *Controller) GetPromotions (c *gin.Context) {
+
+	if err := utils.CheckUserType(c, "admin"); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
+		return
+	}
+
+	promotions, err := ctrl.store.Promotions.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not get promotions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": promotions})
+}
+
+// @Summary 		Get Promotion By ID
+// @Description		Sends a promotion with the number of orders that used it
+// @Description		allowed: admin
+// @ID				get-promotion-by-id
+// @Tags 			promotions
+// @Produce 		json
+// @Success 		200 {object} models.Promotion
+// @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
+// @Failure			404 {string} json "{"error": "Promotion not found"}"
+// @Router 			/secured/promotions/{id} [get]
+func (ctrl *Controller) GetPromotionById (c *gin.Context) {
+
+	if err := utils.CheckUserType(c, "admin"); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
+		return
+	}
+
+	id, ok := paramID(c, "id")
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Promotion not found"})
+		return
+	}
+
+	promotion, err := ctrl.store.Promotions.Get(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Promotion not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, promotion)
+}
+
+// @Summary 		Create Promotion
+// @Description		Adds a promo code that takes a percentage or a fixed amount off every ticket of an order it applies to,
+// @Description		either for all events or for one; max_uses limits the orders using it overall and max_uses_per_user those of each user
+// @Description		allowed: admin
+// @ID				create-promotion
+// @Tags 			promotions
+// @Accept			json
+// @Produce 		json
+// @Param			promotion body PromotionRequest true "Create Promotion"
+// @Success 		201 {object} models.Promotion
+// @Failure			400 {string} json "{"error": "Invalid promotion"}"
+// @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
+// @Failure			404 {string} json "{"error": "Event not found"}"
+// @Failure			409 {string} json "{"error": "A promotion with this code already exists"}"
+// @Failure			500 {string} json "{"error": "Could not create promotion"}"
+// @Router 			/secured/promotions [post]
+func (ctrl *Controller) CreatePromotion (c *gin.Context) {
+
+	if err := utils.CheckUserType(c, "admin"); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
+		return
+	}
+
+	var request PromotionRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid promotion"})
+		return
+	}
+
+	promotion := models.Promotion{CreatedAt: ctrl.clock.Now().UTC()}
+	err := ctrl.applyPromotion(&promotion, request)
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	case err != nil:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid promotion"})
+		return
+	}
+
+	err = ctrl.store.Promotions.Create(&promotion)
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	case errors.Is(err, store.ErrDuplicate):
+		c.JSON(http.StatusConflict, gin.H{"error": "A promotion with this code already exists"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not create promotion"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, promotion)
+}
+
+// @Summary 		Update Promotion
+// @Description		Replaces all fields of the promotion, an empty code keeps the current one; orders already discounted keep their discount
+// @Description		allowed: admin
+// @ID				update-promotion
+// @Tags 			promotions
+// @Accept			json
+// @Produce 		json
+// @Param			promotion body PromotionRequest true "Update Promotion"
+// @Success 		200 {object} models.Promotion
+// @Failure			400 {string} json "{"error": "Invalid promotion"}"
+// @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
+// @Failure			404 {string} json "{"error": "Promotion not found"}"
+// @Failure			404 {string} json "{"error": "Event not found"}"
+// @Failure			409 {string} json "{"error": "A promotion with this code already exists"}"
+// @Failure			500 {string} json "{"error": "Could not update promotion"}"
+// @Router 			/secured/promotions/{id} [put]
+func (ctrl *Controller) UpdatePromotionById (c *gin.Context) {
+
+	if err := utils.CheckUserType(c, "admin"); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
+		return
+	}
+
+	id, ok := paramID(c, "id")
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Promotion not found"})
+		return
+	}
+
+	var request PromotionRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid promotion"})
+		return
+	}
+
+	promotion, err := ctrl.store.Promotions.Get(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Promotion not found"})
+		return
+	}
+
+	if request.Code == "" {
+		request.Code = promotion.Code
+	}
+	err = ctrl.applyPromotion(&promotion, request)
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	case err != nil:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid promotion"})
+		return
+	}
+
+	_, err = ctrl.store.Promotions.Update(id, promotion)
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Promotion not found"})
+		return
+	case errors.Is(err, store.ErrDuplicate):
+		c.JSON(http.StatusConflict, gin.H{"error": "A promotion with this code already exists"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not update promotion"})
+		return
+	}
+
+	c.JSON(http.StatusOK, promotion)
+}
+
+// @Summary 		Delete Promotion
+// @Description		Deletes a promotion, orders discounted with it keep their discount
+// @Description		allowed: admin
+// @ID				delete-promotion
+// @Tags 			promotions
+// @Produce 		json
+// @Success 		200 {string} json "{"message": "Promotion deleted"}"
+// @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
+// @Failure			404 {string} json "{"error": "Promotion not found"}"
+// @Failure			500 {string} json "{"error": "Could not delete promotion"}"
+// @Router 			/secured/promotions/{id} [delete]
+func (ctrl *Controller) DeletePromotionById (c *gin.Context) {
+
+	if err := utils.CheckUserType(c, "admin"); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
+		return
+	}
+
+	id, ok := paramID(c, "id")
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Promotion not found"})
+		return
+	}
+
+	err := ctrl.store.Promotions.Delete(id)
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Promotion not found"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not delete promotion"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Promotion deleted"})
+}
@@ -0,0 +1,303 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mgr1054/go-ticket/pkg/models"
+	"github.com/mgr1054/go-ticket/pkg/payment"
+	"github.com/mgr1054/go-ticket/pkg/store"
+	"github.com/mgr1054/go-ticket/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+type ManualRefund struct {
+	TicketID	uint		`json:"ticket_id" binding:"required" example:"1"`
+	// in the currency of the ticket, defaults to everything of the price that was not refunded yet
+	Amount		string		`json:"amount" example:"25.50"`
+	Note		string		`json:"note" example:"Band changed the line-up"`
+}
+
+type RefundPolicyRequest struct {
+	FullRefundDays			int		`json:"full_refund_days" example:"14"`
+	PartialRefundDays		int		`json:"partial_refund_days" example:"7"`
+	PartialRefundPercent	int		`json:"partial_refund_percent" example:"50"`
+}
+
+// returns the refund policy of the event or the configured default
+func (ctrl *Controller) refundPolicy(eventID uint) (models.RefundPolicy, error) {
+	policy, err := ctrl.store.Refunds.GetPolicy(eventID)
+	if errors.Is(err, store.ErrNotFound) {
+		return models.RefundPolicy{
+			EventID: eventID,
+			FullRefundDays: ctrl.cfg.Refunds.FullRefundDays,
+			PartialRefundDays: ctrl.cfg.Refunds.PartialRefundDays,
+			PartialRefundPercent: ctrl.cfg.Refunds.PartialRefundPercent,
+		}, nil
+	}
+	return policy, err
+}
+
+// part of the ticket price that is neither refunded nor being refunded, in minor units
+func (ctrl *Controller) refundableAmount(ticket models.Ticket) (int64, error) {
+	amount := ticket.Price.Amount
+
+	refunds, err := ctrl.store.Refunds.ListByTicket(ticket.ID)
+	if err != nil {
+		return 0, err
+	}
+	for _, refund := range refunds {
+		if refund.Status != models.RefundFailed {
+			amount -= refund.Amount
+		}
+	}
+	return amount, nil
+}
+
+// pays a pending refund back through the payment provider and records the result,
+// refunds without a payment have nothing to send back and succeed right away
+func (ctrl *Controller) issueRefund(refund models.Refund) (models.Refund, error) {
+	status := models.RefundSucceeded
+	providerRefundID := ""
+
+	if refund.PaymentID != nil && refund.Amount > 0 {
+		record, err := ctrl.store.Payments.Get(*refund.PaymentID)
+		if err != nil {
+			return refund, err
+		}
+
+		result, err := ctrl.payments.Refund(record.IntentID, refund.Amount)
+		switch {
+		case err != nil:
+			log.Error("Refund ", refund.ID, " could not be issued: ", err)
+			status = models.RefundFailed
+		case result.Status != payment.RefundSucceeded:
+			status = models.RefundFailed
+		}
+		providerRefundID = result.ID
+	}
+
+	return ctrl.store.Refunds.Complete(refund.ID, status, providerRefundID, ctrl.clock.Now())
+}
+
+// @Summary 		Create Refund
+// @Description		Refunds a ticket outside of the refund policy, issued tickets are cancelled, the amount defaults to what was not refunded yet
+// @Description		allowed: admin
+// @ID				create-refund
+// @Tags 			refunds
+// @Accept			json
+// @Produce 		json
+// @Param			refund body ManualRefund true "Refund"
+// @Success 		201 {object} models.Refund
+// @Failure			400 {string} json "{"error": "Could not create Refund"}"
+// @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
+// @Failure			404 {string} json "{"error": "Ticket not found"}"
+// @Failure			409 {string} json "{"error": "Refunds would exceed the ticket price"}"
+// @Failure			409 {string} json "{"error": "Ticket is listed for resale"}"
+// @Failure			500 {string} json "{"error": "Could not create Refund"}"
+// @Router 			/secured/refunds [post]
+func (ctrl *Controller) CreateRefund (c *gin.Context) {
+
+	if err := utils.CheckUserType(c, "admin"); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
+		return
+	}
+
+	var request ManualRefund
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Could not create Refund"})
+		return
+	}
+
+	ticket, err := ctrl.store.Tickets.Get(request.TicketID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ticket not found"})
+		return
+	}
+
+	var amount int64
+	if request.Amount != "" {
+		var money models.Money
+		money, err = models.ParseMoney(request.Amount, ticket.Price.Currency)
+		amount = money.Amount
+	} else {
+		amount, err = ctrl.refundableAmount(ticket)
+	}
+	if err != nil || amount <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Could not create Refund"})
+		return
+	}
+
+	refund := models.Refund{
+		TicketID: ticket.ID,
+		Amount: amount,
+		Currency: ticket.Price.Currency,
+		Reason: models.RefundReasonManual,
+		Note: request.Note,
+	}
+
+	err = ctrl.store.Refunds.Create(&refund, ctrl.clock.Now())
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ticket not found"})
+		return
+	case errors.Is(err, store.ErrRefundExceeded):
+		c.JSON(http.StatusConflict, gin.H{"error": "Refunds would exceed the ticket price"})
+		return
+	case errors.Is(err, store.ErrTicketListed):
+		c.JSON(http.StatusConflict, gin.H{"error": "Ticket is listed for resale"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not create Refund"})
+		return
+	}
+
+	// an issued ticket was cancelled and its place can go to the waitlist
+	ctrl.offerWaitlist(ticket.EventID)
+
+	refund, err = ctrl.issueRefund(refund)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not create Refund"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, refund)
+}
+
+// @Summary 		Get Refunds
+// @Description		Gives back all refunds, optionally only those of one ticket
+// @Description		allowed: admin
+// @ID				get-refunds
+// @Tags 			refunds
+// @Produce 		json
+// @Param			ticket_id query int false "Ticket ID"
+// @Success 		200 {object} []models.Refund
+// @Failure			400 {string} json "{"error": "Invalid ticket id"}"
+// @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
+// @Failure			500 {string} json "{"error": "Could not get refunds"}"
+// @Router 			/secured/refunds [get]
+func (ctrl *Controller) GetRefunds (c *gin.Context) {
+
+	if err := utils.CheckUserType(c, "admin"); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
+		return
+	}
+
+	var refunds []models.Refund
+	var err error
+
+	if ticketID := c.Query("ticket_id"); ticketID != "" {
+		id, parseErr := strconv.ParseUint(ticketID, 10, 64)
+		if parseErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ticket id"})
+			return
+		}
+		refunds, err = ctrl.store.Refunds.ListByTicket(uint(id))
+	} else {
+		refunds, err = ctrl.store.Refunds.List()
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not get refunds"})
+		return
+	}
+
+	c.JSON(http.StatusOK, refunds)
+}
+
+// @Summary 		Get Refund Policy
+// @Description		Gives back the refund policy of the event, events without a policy of their own use the default policy
+// @Description		allowed: user, admin
+// @ID				get-refund-policy
+// @Tags 			refunds
+// @Produce 		json
+// @Success 		200 {object} models.RefundPolicy
+// @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
+// @Failure			404 {string} json "{"error": "Event not found"}"
+// @Failure			500 {string} json "{"error": "Could not get refund policy"}"
+// @Router 			/secured/events/{id}/refund-policy [get]
+func (ctrl *Controller) GetRefundPolicy (c *gin.Context) {
+
+	if err := utils.CheckUserType(c, "user"); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
+		return
+	}
+
+	id, ok := paramID(c, "id")
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+
+	if _, err := ctrl.store.Events.Get(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+
+	policy, err := ctrl.refundPolicy(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not get refund policy"})
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// @Summary 		Set Refund Policy
+// @Description		Sets the refund policy of the event: full refund until full_refund_days before the event,
+// @Description		partial_refund_percent until partial_refund_days before the event, no refund afterwards
+// @Description		allowed: admin
+// @ID				set-refund-policy
+// @Tags 			refunds
+// @Accept			json
+// @Produce 		json
+// @Param			policy body RefundPolicyRequest true "Refund Policy"
+// @Success 		200 {object} models.RefundPolicy
+// @Failure			400 {string} json "{"error": "Invalid refund policy"}"
+// @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
+// @Failure			404 {string} json "{"error": "Event not found"}"
+// @Failure			500 {string} json "{"error": "Could not save refund policy"}"
+// @Router 			/secured/events/{id}/refund-policy [put]
+func (ctrl *Controller) SetRefundPolicy (c *gin.Context) {
+
+	if err := utils.CheckUserType(c, "admin"); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
+		return
+	}
+
+	id, ok := paramID(c, "id")
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+
+	var request RefundPolicyRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid refund policy"})
+		return
+	}
+
+	policy := models.RefundPolicy{
+		EventID: id,
+		FullRefundDays: request.FullRefundDays,
+		PartialRefundDays: request.PartialRefundDays,
+		PartialRefundPercent: request.PartialRefundPercent,
+	}
+	if !policy.Valid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid refund policy"})
+		return
+	}
+
+	if _, err := ctrl.store.Events.Get(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+
+	if err := ctrl.store.Refunds.SavePolicy(policy); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not save refund policy"})
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
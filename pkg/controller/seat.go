@@ -0,0 +1,213 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mgr1054/go-ticket/pkg/models"
+	"github.com/mgr1054/go-ticket/pkg/store"
+	"github.com/mgr1054/go-ticket/pkg/utils"
+)
+
+type SeatRequest struct {
+	SectionID	uint		`json:"section_id" binding:"required" example:"1"`
+	Row			string		`json:"row" binding:"required" example:"12"`
+	Number		string		`json:"number" binding:"required" example:"7"`
+	Accessible	bool		`json:"accessible"`
+}
+
+type SeatMap struct {
+	Seats		[]SeatRequest	`json:"seats" binding:"dive"`
+}
+
+// @Summary 		Get Venue Seats
+// @Description		Sends the seat map of the venue
+// @Description		allowed: user, admin
+// @ID				get-venue-seats
+// @Tags 			seats
+// @Produce 		json
+// @Success 		200 {object} []models.Seat
+// @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
+// @Failure			404 {string} json "{"error": "Venue not found"}"
+// @Failure			500 {string} json "{"error": "Could not get seats"}"
+// @Router 			/secured/venues/{id}/seats [get]
+func (ctrl *Controller) GetVenueSeats (c *gin.Context) {
+
+	if err := utils.CheckUserType(c, "user"); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
+		return
+	}
+
+	id, ok := paramID(c, "id")
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Venue not found"})
+		return
+	}
+
+	seats, err := ctrl.store.Seats.ListByVenue(id)
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Venue not found"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not get seats"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": seats})
+}
+
+// @Summary 		Set Venue Seats
+// @Description		Replaces the seat map of the venue, every seat belongs to one of its sections and a section
+// @Description		has at most as many seats as its capacity; events copy the seat map when they are created
+// @Description		allowed: admin
+// @ID				set-venue-seats
+// @Tags 			seats
+// @Accept			json
+// @Produce 		json
+// @Param			seats body SeatMap true "Seat Map"
+// @Success 		200 {object} []models.Seat
+// @Failure			400 {string} json "{"error": "Invalid seat map"}"
+// @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
+// @Failure			404 {string} json "{"error": "Venue not found"}"
+// @Failure			500 {string} json "{"error": "Could not save seats"}"
+// @Router 			/secured/venues/{id}/seats [put]
+func (ctrl *Controller) SetVenueSeats (c *gin.Context) {
+
+	if err := utils.CheckUserType(c, "admin"); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
+		return
+	}
+
+	id, ok := paramID(c, "id")
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Venue not found"})
+		return
+	}
+
+	var request SeatMap
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid seat map"})
+		return
+	}
+
+	venue, err := ctrl.store.Venues.Get(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Venue not found"})
+		return
+	}
+
+	seats := []models.Seat{}
+	for _, seat := range request.Seats {
+		seats = append(seats, models.Seat{SectionID: seat.SectionID, Row: seat.Row, Number: seat.Number, Accessible: seat.Accessible})
+	}
+	if err := venue.CheckSeats(seats); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid seat map"})
+		return
+	}
+
+	seats, err = ctrl.store.Seats.ReplaceForVenue(id, seats)
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Venue not found"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not save seats"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": seats})
+}
+
+// @Summary 		Get Event Seats
+// @Description		Sends the seats of the event with their availability, available=true only sends the free ones
+// @Description		allowed: user, admin
+// @ID				get-event-seats
+// @Tags 			seats
+// @Produce 		json
+// @Param			available query bool false "Only free seats"
+// @Success 		200 {object} []models.EventSeat
+// @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
+// @Failure			404 {string} json "{"error": "Event not found"}"
+// @Failure			500 {string} json "{"error": "Could not get seats"}"
+// @Router 			/secured/events/{id}/seats [get]
+func (ctrl *Controller) GetEventSeats (c *gin.Context) {
+
+	if err := utils.CheckUserType(c, "user"); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
+		return
+	}
+
+	id, ok := paramID(c, "id")
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+
+	seats, err := ctrl.store.Seats.ListByEvent(id)
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not get seats"})
+		return
+	}
+
+	available := 0
+	free := []models.EventSeat{}
+	for _, seat := range seats {
+		if seat.Available {
+			available++
+			free = append(free, seat)
+		}
+	}
+	if c.Query("available") == "true" {
+		seats = free
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": seats, "available": available})
+}
+
+// @Summary 		Reset Event Seats
+// @Description		Copies the current seat map of the venue into the seat inventory of the event,
+// @Description		only possible as long as no seat of the event was sold
+// @Description		allowed: admin
+// @ID				reset-event-seats
+// @Tags 			seats
+// @Produce 		json
+// @Success 		200 {object} []models.EventSeat
+// @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
+// @Failure			404 {string} json "{"error": "Event not found"}"
+// @Failure			409 {string} json "{"error": "Seats of the event are already sold"}"
+// @Failure			500 {string} json "{"error": "Could not reset seats"}"
+// @Router 			/secured/events/{id}/seats [post]
+func (ctrl *Controller) ResetEventSeats (c *gin.Context) {
+
+	if err := utils.CheckUserType(c, "admin"); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
+		return
+	}
+
+	id, ok := paramID(c, "id")
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+
+	seats, err := ctrl.store.Seats.ResetEvent(id)
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	case errors.Is(err, store.ErrSeatsSold):
+		c.JSON(http.StatusConflict, gin.H{"error": "Seats of the event are already sold"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not reset seats"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": seats})
+}
@@ -1,82 +1,151 @@
 package controller
 
 import (
+	"errors"
 	"net/http"
-	"time"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
-	"github.com/mgr1054/go-ticket/pkg/db"
 	"github.com/mgr1054/go-ticket/pkg/models"
+	"github.com/mgr1054/go-ticket/pkg/store"
 	"github.com/mgr1054/go-ticket/pkg/utils"
 )
 
 type TicketRequest struct {
 	UserID		uint		`json:"user_id"`
 	EventID		uint		`json:"event_id"`
-	Price		string		`json:"price"`
+	Price		models.Money `json:"price"`
 	Event		models.Event `json:"event"`	
 
 }
 
 
 // @Summary 		Create Ticket by EventID
-// @Description		Creates Ticket for EventID, also checks if enough capacity is available
+// @Description		Buys a Ticket for EventID as an order of one ticket, capacity is checked and consumed inside one transaction, active holds count as used
 // @Description		allowed: user
 // @ID				create-ticket
 // @Tags 			tickets
 // @Produce 		json
+// @Param			payment_method query string false "Payment method for the payment provider"
+// @Param			seat_id query int false "Seat of the event for reserved seating"
+// @Param			ticket_type_id query int false "Ticket type, required for events with ticket types"
+// @Param			presale_code query string false "Presale code, unlocks the ticket before the general sale"
+// @Param			promo_code query string false "Promo code, discounts the ticket"
 // @Success 		200 {object} models.Ticket
+// @Success 		202 {string} json "{"info": "Payment is being processed", "order_id": 1}"
+// @Failure			400 {string} json "{"error": "Choose a ticket type of the event"}"
+// @Failure			400 {string} json "{"error": "Invalid promo code"}"
+// @Failure			404 {string} json "{"error": "Ticket type not found"}"
+// @Failure			403 {string} json "{"error": "Invalid presale code"}"
+// @Failure			409 {string} json "{"error": "Tickets are not on sale"}"
 // @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
-// @Failure			404 {string} json "{"error": "User not found"}"
+// @Failure			402 {string} json "{"error": "Payment declined", "order_id": 1}"
+// @Failure			404 {string} json "{"error": "Event not found"}"
+// @Failure			404 {string} json "{"error": "Seat not found"}"
+// @Failure			409 {string} json "{"error": "Unfortunately, this event is fully booked!", "info": "Join the waitlist to be offered tickets that free up"}"
+// @Failure			409 {string} json "{"error": "Unfortunately, this seat is already taken"}"
 // @Failure			500 {string} json "{"error": "Could not create Ticket"}"
 // @Router 			/secured/tickets/{id} [get]
-func CreateTicket (c *gin.Context) {
+func (ctrl *Controller) CreateTicket (c *gin.Context) {
 
 	if err := utils.CheckUserType(c, "user"); err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
 		return
 	}
 
-	event := models.Event{}
-	db.DB.First(&event, "id = ?", c.Param("id"))
-	eventCapacity := event.Capacity
+	user, err := ctrl.store.Users.GetByUsername(c.GetString("username"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
 
-	usedCapacity := int64(0)
-	
-	if err := db.DB.Model(&models.Ticket{}).Where("event_id = ?", c.Param("id")).Count(&usedCapacity).Error; err != nil {
+	id, ok := paramID(c, "id")
+	if !ok {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
 		return
 	}
 
-	if usedCapacity == int64(eventCapacity) {
-		c.JSON(http.StatusOK, gin.H{"info": "Unfortunately, this event is fully booked!"})
-		return
+	item := store.OrderItem{EventID: id, Quantity: 1}
+	if seat := c.Query("seat_id"); seat != "" {
+		seatID, err := strconv.ParseUint(seat, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Seat not found"})
+			return
+		}
+		item = store.OrderItem{EventID: id, SeatIDs: []uint{uint(seatID)}}
+	}
+	if ticketType := c.Query("ticket_type_id"); ticketType != "" {
+		ticketTypeID, err := strconv.ParseUint(ticketType, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Ticket type not found"})
+			return
+		}
+		item.TicketTypeID = uint(ticketTypeID)
 	}
 
-	var user models.User
-	
-	if err := db.DB.Where("username = ?", c.GetString("username")).First(&user).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	order, err := ctrl.store.Orders.Create(user.ID, []store.OrderItem{item}, store.OrderCodes{Presale: c.Query("presale_code"), Promotion: c.Query("promo_code")}, ctrl.clock.Now())
+
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	case errors.Is(err, store.ErrTicketTypeNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ticket type not found"})
+		return
+	case errors.Is(err, store.ErrTicketTypeRequired):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Choose a ticket type of the event"})
+		return
+	case errors.Is(err, store.ErrNotOnSale):
+		c.JSON(http.StatusConflict, gin.H{"error": "Tickets are not on sale"})
+		return
+	case errors.Is(err, store.ErrPresaleCodeRejected):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Invalid presale code"})
+		return
+	case errors.Is(err, store.ErrPromotionRejected):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid promo code"})
+		return
+	case errors.Is(err, store.ErrSeatNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Seat not found"})
+		return
+	case errors.Is(err, store.ErrSoldOut):
+		c.JSON(http.StatusConflict, gin.H{"error": "Unfortunately, this event is fully booked!", "info": "Join the waitlist to be offered tickets that free up"})
+		return
+	case errors.Is(err, store.ErrSeatTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": "Unfortunately, this seat is already taken"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not create Ticket"})
 		return
 	}
 
-	NewTicket := models.Ticket {
-		UserID: user.ID,
-		EventID: event.ID,
-		Price: event.Price,
+	order, err = ctrl.checkout(order, c.Query("payment_method"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not create Ticket"})
+		return
 	}
 
-	if err := db.DB.Create(&NewTicket).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not create Ticket"})
+	switch {
+	case order.Status == models.OrderPending:
+		c.JSON(http.StatusAccepted, gin.H{"info": "Payment is being processed", "order_id": order.ID})
+		return
+	case order.Status != models.OrderCompleted || len(order.Tickets) != 1:
+		c.JSON(http.StatusPaymentRequired, gin.H{"error": "Payment declined", "order_id": order.ID})
 		return
-	} 
+	}
+
+	NewTicket := order.Tickets[0]
+	event, _ := ctrl.store.Events.Get(NewTicket.EventID)
 
 	c.JSON(http.StatusOK, gin.H{
 		"id":  NewTicket.ID, 
 		"username": user.Username, 
 		"event_id": event.ID, 
 		"band_name": event.Band_Name,
-		"price": event.Price,
+		"price": NewTicket.Price,
+		"discount": NewTicket.Discount,
+		"ticket_type_id": NewTicket.TicketTypeID,
+		"seat_id": NewTicket.SeatID,
+		"order_id": order.ID,
 	})
 }
 
@@ -90,16 +159,21 @@ func CreateTicket (c *gin.Context) {
 // @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
 // @Failure			404 {string} json "{"error": "Tickets not found"}""
 // @Router 			/secured/tickets/events/{id} [get]
-func GetTicketsByEvent (c* gin.Context) {
+func (ctrl *Controller) GetTicketsByEvent (c* gin.Context) {
 
 	if err := utils.CheckUserType(c, "admin"); err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
 		return
 	}
 	
-	usedCapacity := int64(0)
+	id, ok := paramID(c, "id")
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tickets not found"})
+		return
+	}
 
-	if err := db.DB.Model(&models.Ticket{}).Where("event_id = ?", c.Param("id")).Count(&usedCapacity).Error; err != nil {
+	usedCapacity, err := ctrl.store.Tickets.CountByEvent(id)
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Tickets not found"})
 		return
 	}
@@ -118,21 +192,21 @@ func GetTicketsByEvent (c* gin.Context) {
 // @Failure			404 {object} string
 // @Failure			500 {object} string
 // @Router 			/secured/tickets/user [get]
-func GetTickets (c* gin.Context) {
+func (ctrl *Controller) GetTickets (c* gin.Context) {
 
 	if err := utils.CheckUserType(c, "user"); err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
 		return
 	}
 
-	var user models.User
-	if err := db.DB.Where("username = ?", c.GetString("username")).First(&user).Error; err != nil {
+	user, err := ctrl.store.Users.GetByUsername(c.GetString("username"))
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
 	}
 
-	var ticket []models.Ticket
-	if err := db.DB.Where("user_id = ?", user.ID).Find(&ticket).Error; err != nil {
+	ticket, err := ctrl.store.Tickets.ListByUser(user.ID)
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Tickets not found"})
 		return
 	}
@@ -145,34 +219,42 @@ func GetTickets (c* gin.Context) {
 	c.JSON(http.StatusOK, ticket)
 }
 
-// @Summary 		Delete Ticket By ID
-// @Description		Deletes Ticket by Ticket ID, available up until one week before the event
+// @Summary 		Cancel Ticket By ID
+// @Description		Cancels the Ticket by Ticket ID and refunds it following the refund policy of the event,
+// @Description		the ticket stays on record as cancelled and becomes refunded once the money went back; its place is offered to the waitlist
 // @Description		allowed: admin, user
 // @ID				delete-tickets-by-user-id
 // @Tags 			tickets
 // @Produce 		json
-// @Success 		200 {string} json "{"message": "Ticket deleted"}"
+// @Success 		200 {string} json "{"message": "Ticket cancelled", "refund": {}}"
 // @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
 // @Failure			404 {string} json "{"error": "Tickets not found"}"
-// @Failure			500 {string} json "{"error": "Could not parse time"}"
+// @Failure			409 {string} json "{"error": "Ticket is already cancelled or not paid"}"
+// @Failure			409 {string} json "{"error": "Ticket was already used at the door"}"
+// @Failure			409 {string} json "{"error": "Ticket is listed for resale"}"
+// @Failure			500 {string} json "{"error": "Could not cancel Ticket"}"
 // @Router 			/secured/tickets/{id} [delete]
-func DeleteTicketById (c *gin.Context) {
+func (ctrl *Controller) DeleteTicketById (c *gin.Context) {
 
 	if err := utils.CheckUserType(c, "user"); err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
 		return
 	}
 
-	var ticket models.Ticket
-	
+	id, ok := paramID(c, "id")
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ticket not found!"})
+		return
+	}
 
-	if err := db.DB.Where("id = ?", c.Param("id")).First(&ticket).Error; err != nil {
+	ticket, err := ctrl.store.Tickets.Get(id)
+	if err != nil {
         c.JSON(http.StatusNotFound, gin.H{"error": "Ticket not found!"})
         return
     }
 
-	var user models.User
-	if err := db.DB.Where("id = ?", ticket.UserID).First(&user).Error; err != nil {
+	user, err := ctrl.store.Users.Get(ticket.UserID)
+	if err != nil {
         c.JSON(http.StatusNotFound, gin.H{"error": "User not found!"})
         return
     }
@@ -182,26 +264,58 @@ func DeleteTicketById (c *gin.Context) {
 		return
 	}
 
-	now := time.Now()
-	currentDate := now.AddDate(0, 0, 7)
-	
-	event := models.Event{}
-	db.DB.First(&event, "id = ?", ticket.EventID)
-	eventDate, err := time.Parse("2006-01-02", event.Date)
+	event, err := ctrl.store.Events.Get(ticket.EventID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found!"})
+		return
+	}
+
+	policy, err := ctrl.refundPolicy(event.ID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not parse time"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not cancel Ticket"})
+		return
 	}
 
-	if(currentDate.After(eventDate)) {
+	now := ctrl.clock.Now()
+	percent := policy.Percent(event.StartsAt, now)
+	if percent == 0 {
 		c.JSON(http.StatusOK, gin.H{"info": "Unfortunately, you are too late to cancle your ticket!"})
 		return
 	}
-	
 
-	if err := db.DB.Delete(&ticket).Error; err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not delete Ticket"})
-        return
-    }
+	refund := models.Refund{
+		Amount: ticket.Price.Percent(percent).Amount,
+		Currency: ticket.Price.Currency,
+		Reason: models.RefundReasonPolicy,
+	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Ticket deleted"})
-}
\ No newline at end of file
+	err = ctrl.store.Tickets.Cancel(ticket.ID, &refund, now)
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ticket not found!"})
+		return
+	case errors.Is(err, store.ErrTicketNotIssued):
+		c.JSON(http.StatusConflict, gin.H{"error": "Ticket is already cancelled or not paid"})
+		return
+	case errors.Is(err, store.ErrTicketUsed):
+		c.JSON(http.StatusConflict, gin.H{"error": "Ticket was already used at the door"})
+		return
+	case errors.Is(err, store.ErrTicketListed):
+		c.JSON(http.StatusConflict, gin.H{"error": "Ticket is listed for resale"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not cancel Ticket"})
+		return
+	}
+
+	ctrl.offerWaitlist(ticket.EventID)
+
+	// the ticket stays cancelled when the provider fails, admins can retry with a manual refund
+	refund, err = ctrl.issueRefund(refund)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not refund Ticket"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Ticket cancelled", "refund": refund})
+}
@@ -0,0 +1,293 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mgr1054/go-ticket/pkg/models"
+	"github.com/mgr1054/go-ticket/pkg/store"
+	"github.com/mgr1054/go-ticket/pkg/utils"
+)
+
+// times without offset are wall clock times in the timezone of the event
+type TicketTypeRequest struct {
+	Name		string		`json:"name" example:"VIP"`
+	// in the currency of the event, defaults to PAYMENT_CURRENCY
+	Price		*models.Money	`json:"price"`
+	Quota		int			`json:"quota" example:"500"`
+	SalesStart	string		`json:"sales_start" example:"2022-09-01T10:00"`
+	SalesEnd	string		`json:"sales_end" example:"2022-10-01T00:00"`
+}
+
+// applies the non-zero fields of the request to the ticket type of the event and validates it
+func (ctrl *Controller) applyTicketType(ticketType *models.TicketType, event models.Event, request TicketTypeRequest) error {
+	zone, err := event.Zone()
+	if err != nil {
+		return err
+	}
+
+	if request.Name != "" {
+		ticketType.Name = request.Name
+	}
+	if request.Price != nil {
+		ticketType.Price = *request.Price
+		if err := ctrl.normalizePrice(&ticketType.Price); err != nil {
+			return err
+		}
+		if ticketType.Price.Currency != event.Price.Currency {
+			return models.ErrCurrencyMismatch
+		}
+	}
+	if request.Quota != 0 {
+		ticketType.Quota = request.Quota
+	}
+	for _, field := range []struct {
+		value	string
+		target	**time.Time
+	}{
+		{request.SalesStart, &ticketType.SalesStart},
+		{request.SalesEnd, &ticketType.SalesEnd},
+	} {
+		if field.value == "" {
+			continue
+		}
+		parsed, err := models.ParseEventTime(field.value, zone)
+		if err != nil {
+			return err
+		}
+		parsed = parsed.UTC()
+		*field.target = &parsed
+	}
+	return ticketType.Normalize()
+}
+
+// fills in the tickets still available in the ticket type
+func (ctrl *Controller) withAvailability(ticketType models.TicketType) models.TicketType {
+	ticketTypes, err := ctrl.store.TicketTypes.ListByEvent(ticketType.EventID, ctrl.clock.Now())
+	if err != nil {
+		return ticketType
+	}
+	for _, listed := range ticketTypes {
+		if listed.ID == ticketType.ID {
+			return listed
+		}
+	}
+	return ticketType
+}
+
+// @Summary 		Get Ticket Types
+// @Description		Sends the ticket types of the event with the number of tickets still available in each
+// @Description		allowed: user, admin
+// @ID				get-ticket-types
+// @Tags 			ticket types
+// @Produce 		json
+// @Success 		200 {object} []models.TicketType
+// @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
+// @Failure			404 {string} json "{"error": "Event not found"}"
+// @Failure			500 {string} json "{"error": "Could not get ticket types"}"
+// @Router 			/secured/events/{id}/ticket-types [get]
+func (ctrl *Controller) GetTicketTypes (c *gin.Context) {
+
+	if err := utils.CheckUserType(c, "user"); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
+		return
+	}
+
+	id, ok := paramID(c, "id")
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+
+	ticketTypes, err := ctrl.store.TicketTypes.ListByEvent(id, ctrl.clock.Now())
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not get ticket types"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": ticketTypes})
+}
+
+// @Summary 		Create Ticket Type
+// @Description		Adds a ticket type to the event, the quotas of all ticket types of an event add up to at most its capacity;
+// @Description		once an event has ticket types every ticket is bought in one of them
+// @Description		allowed: admin
+// @ID				create-ticket-type
+// @Tags 			ticket types
+// @Accept			json
+// @Produce 		json
+// @Param			ticket_type body TicketTypeRequest true "Create Ticket Type"
+// @Success 		201 {object} models.TicketType
+// @Failure			400 {string} json "{"error": "Invalid ticket type"}"
+// @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
+// @Failure			404 {string} json "{"error": "Event not found"}"
+// @Failure			409 {string} json "{"error": "The event already has a ticket type with this name"}"
+// @Failure			409 {string} json "{"error": "Quotas would exceed the capacity of the event"}"
+// @Failure			500 {string} json "{"error": "Could not create ticket type"}"
+// @Router 			/secured/events/{id}/ticket-types [post]
+func (ctrl *Controller) CreateTicketType (c *gin.Context) {
+
+	if err := utils.CheckUserType(c, "admin"); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
+		return
+	}
+
+	id, ok := paramID(c, "id")
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+
+	var request TicketTypeRequest
+	if err := c.ShouldBindJSON(&request); err != nil || request.Price == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ticket type"})
+		return
+	}
+
+	event, err := ctrl.store.Events.Get(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+
+	ticketType := models.TicketType{EventID: event.ID}
+	if err := ctrl.applyTicketType(&ticketType, event, request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ticket type"})
+		return
+	}
+
+	err = ctrl.store.TicketTypes.Create(&ticketType)
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	case errors.Is(err, store.ErrDuplicate):
+		c.JSON(http.StatusConflict, gin.H{"error": "The event already has a ticket type with this name"})
+		return
+	case errors.Is(err, store.ErrQuotaConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": "Quotas would exceed the capacity of the event"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not create ticket type"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, ctrl.withAvailability(ticketType))
+}
+
+// @Summary 		Update Ticket Type
+// @Description		Updates the non-zero fields of the ticket type, the quota cannot fall below the tickets sold in it
+// @Description		allowed: admin
+// @ID				update-ticket-type
+// @Tags 			ticket types
+// @Accept			json
+// @Produce 		json
+// @Param			ticket_type body TicketTypeRequest true "Update Ticket Type"
+// @Success 		200 {object} models.TicketType
+// @Failure			400 {string} json "{"error": "Invalid ticket type"}"
+// @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
+// @Failure			404 {string} json "{"error": "Ticket type not found"}"
+// @Failure			409 {string} json "{"error": "The event already has a ticket type with this name"}"
+// @Failure			409 {string} json "{"error": "Quotas would exceed the capacity of the event or fall below the sold tickets"}"
+// @Failure			500 {string} json "{"error": "Could not update ticket type"}"
+// @Router 			/secured/ticket-types/{id} [put]
+func (ctrl *Controller) UpdateTicketType (c *gin.Context) {
+
+	if err := utils.CheckUserType(c, "admin"); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
+		return
+	}
+
+	id, ok := paramID(c, "id")
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ticket type not found"})
+		return
+	}
+
+	var request TicketTypeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ticket type"})
+		return
+	}
+
+	ticketType, err := ctrl.store.TicketTypes.Get(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ticket type not found"})
+		return
+	}
+	event, err := ctrl.store.Events.Get(ticketType.EventID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ticket type not found"})
+		return
+	}
+
+	if err := ctrl.applyTicketType(&ticketType, event, request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ticket type"})
+		return
+	}
+
+	ticketType, err = ctrl.store.TicketTypes.Update(id, ticketType)
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ticket type not found"})
+		return
+	case errors.Is(err, store.ErrDuplicate):
+		c.JSON(http.StatusConflict, gin.H{"error": "The event already has a ticket type with this name"})
+		return
+	case errors.Is(err, store.ErrQuotaConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": "Quotas would exceed the capacity of the event or fall below the sold tickets"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not update ticket type"})
+		return
+	}
+
+	c.JSON(http.StatusOK, ctrl.withAvailability(ticketType))
+}
+
+// @Summary 		Delete Ticket Type
+// @Description		Deletes a ticket type no ticket or hold was created in
+// @Description		allowed: admin
+// @ID				delete-ticket-type
+// @Tags 			ticket types
+// @Produce 		json
+// @Success 		200 {string} json "{"message": "Ticket type deleted"}"
+// @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
+// @Failure			404 {string} json "{"error": "Ticket type not found"}"
+// @Failure			409 {string} json "{"error": "Tickets of this type were already sold"}"
+// @Failure			500 {string} json "{"error": "Could not delete ticket type"}"
+// @Router 			/secured/ticket-types/{id} [delete]
+func (ctrl *Controller) DeleteTicketType (c *gin.Context) {
+
+	if err := utils.CheckUserType(c, "admin"); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
+		return
+	}
+
+	id, ok := paramID(c, "id")
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ticket type not found"})
+		return
+	}
+
+	err := ctrl.store.TicketTypes.Delete(id)
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ticket type not found"})
+		return
+	case errors.Is(err, store.ErrTicketTypeInUse):
+		c.JSON(http.StatusConflict, gin.H{"error": "Tickets of this type were already sold"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not delete ticket type"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Ticket type deleted"})
+}
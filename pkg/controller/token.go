@@ -1,11 +1,15 @@
 package controller
 
 import (
-	"github.com/mgr1054/go-ticket/pkg/utils"
-	"github.com/mgr1054/go-ticket/pkg/db"
-	"github.com/mgr1054/go-ticket/pkg/models"
+	"errors"
 	"net/http"
+	"time"
+
+	"github.com/mgr1054/go-ticket/pkg/models"
+	"github.com/mgr1054/go-ticket/pkg/store"
+	"github.com/mgr1054/go-ticket/pkg/utils"
 	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
 )
 
 // struct for the incoming request
@@ -14,32 +18,73 @@ type TokenRequest struct {
 	Password string `json:"password" example:"1234"`
 }
 
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// access token and the refresh token that renews it
+type TokenResponse struct {
+	Token			string	`json:"token"`
+	RefreshToken	string	`json:"refresh_token"`
+	// seconds until the access token expires
+	ExpiresIn		int64	`json:"expires_in"`
+}
+
+// time until which revoked access tokens have to stay on the revocation list
+func (ctrl *Controller) revokeUntil(now time.Time) time.Time {
+	return now.Add(ctrl.cfg.Auth.AccessTokenTTL.Duration)
+}
+
+// signs an access token of the family for the user and sends it with the refresh token
+func (ctrl *Controller) respondTokens(c *gin.Context, user models.User, family string, refreshToken string) {
+	tokenString, err := utils.GenerateJWT(user.Email, user.Username, user.Role, family, ctrl.cfg.Auth.AccessTokenTTL.Duration)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error":"Could not create Token"})
+		return
+	}
+	c.JSON(http.StatusCreated, TokenResponse{
+		Token: tokenString,
+		RefreshToken: refreshToken,
+		ExpiresIn: int64(ctrl.cfg.Auth.AccessTokenTTL.Seconds()),
+	})
+}
+
 // @Summary 		Generate Token
 // @Description		Generates JWT Token based on given context, checks if username and password match
-// @Description		Encode JWT with username, email and role
+// @Description		Encode JWT with username, email and role, it expires after ACCESS_TOKEN_TTL
+// @Description		Starts a new token family with a refresh token that renews the access token
 // @Description		allowed: unsecured
 // @ID				generate-token
 // @Tags 			auth
 // @Produce 		json
 // @Param			credentials body TokenRequest true "Create Token"
-// @Success 		201 {string} json
+// @Success 		201 {object} TokenResponse
 // @Failure			400 {string} json "{"error": "Could not create Token"}"
 // @Failure			401 {string} json "{"error": "Password incorrect""
-// @Failure			404 {string} json "{"error":"User not found"}"
+// @Failure			401 {string} json "{"error":"User not found"}"
+// @Failure			403 {string} json "{"error": "Email address is not verified"}"
 // @Failure			500 {string} json "{"error":"Could not create Token"}"
 // @Router 			/token [post]
-func GenerateToken(c *gin.Context) {
+func (ctrl *Controller) GenerateToken(c *gin.Context) {
 	var request TokenRequest
-	var user models.User
 	if err := c.ShouldBindJSON(&request); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Could not create Token"})
 		c.Abort()
 		return
 	}
-	// check if email exists and password is correct
-	record := db.DB.Where("email = ?", request.Email).First(&user)
-	if record.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error":"User not found"})
+	// check if email exists and password is correct, addresses are stored lower-cased
+	email, err := normalizeEmail(request.Email)
+	if err != nil {
+		email = request.Email
+	}
+	user, err := ctrl.store.Users.GetByEmail(email)
+	if errors.Is(err, store.ErrNotFound) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error":"User not found"})
+		c.Abort()
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error":"Could not create Token"})
 		c.Abort()
 		return
 	}
@@ -49,11 +94,127 @@ func GenerateToken(c *gin.Context) {
 		c.Abort()
 		return
 	}
-	tokenString, err:= utils.GenerateJWT(user.Email, user.Username, user.Role)
+	if user.VerifiedAt == nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Email address is not verified, open the link in the verification mail or request a new one"})
+		c.Abort()
+		return
+	}
+	family, err := utils.NewTokenID()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error":"Could not create Token"})
 		c.Abort()
 		return
 	}
-	c.JSON(http.StatusCreated, gin.H{"token": tokenString})
-}
\ No newline at end of file
+	refreshToken, hash, err := utils.NewRefreshToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error":"Could not create Token"})
+		c.Abort()
+		return
+	}
+	now := ctrl.clock.Now()
+	err = ctrl.store.Tokens.Create(&models.RefreshToken{
+		UserID: user.ID,
+		FamilyID: family,
+		TokenHash: hash,
+		ExpiresAt: now.Add(ctrl.cfg.Auth.RefreshTokenTTL.Duration),
+	}, now)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error":"Could not create Token"})
+		c.Abort()
+		return
+	}
+	ctrl.respondTokens(c, user, family, refreshToken)
+}
+
+// @Summary 		Refresh Token
+// @Description		Exchanges a refresh token for a new access token and the next refresh token of its family
+// @Description		Every refresh token works once, using one again revokes the whole family, the login has to be repeated then
+// @Description		The access token carries the current role of the user
+// @Description		allowed: unsecured
+// @ID				refresh-token
+// @Tags 			auth
+// @Produce 		json
+// @Param			refresh_token body RefreshRequest true "Refresh token"
+// @Success 		201 {object} TokenResponse
+// @Failure			400 {string} json "{"error": "Refresh token is required"}"
+// @Failure			401 {string} json "{"error": "Refresh token was already used, all tokens of the login are revoked"}"
+// @Failure			500 {string} json "{"error":"Could not create Token"}"
+// @Router 			/token/refresh [post]
+func (ctrl *Controller) RefreshToken (c *gin.Context) {
+
+	var request RefreshRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Refresh token is required"})
+		return
+	}
+
+	refreshToken, hash, err := utils.NewRefreshToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error":"Could not create Token"})
+		return
+	}
+	now := ctrl.clock.Now()
+	next := models.RefreshToken{TokenHash: hash, ExpiresAt: now.Add(ctrl.cfg.Auth.RefreshTokenTTL.Duration)}
+	err = ctrl.store.Tokens.Rotate(utils.HashToken(request.RefreshToken), &next, now, ctrl.revokeUntil(now))
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token is unknown"})
+		return
+	case errors.Is(err, store.ErrTokenExpired):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token is expired"})
+		return
+	case errors.Is(err, store.ErrTokenRevoked):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token was revoked"})
+		return
+	case errors.Is(err, store.ErrTokenReused):
+		log.Warn("Refresh token of user ", next.UserID, " was used twice, revoked token family ", next.FamilyID)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token was already used, all tokens of the login are revoked"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error":"Could not create Token"})
+		return
+	}
+
+	user, err := ctrl.store.Users.Get(next.UserID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+	ctrl.respondTokens(c, user, next.FamilyID, refreshToken)
+}
+
+// @Summary 		Logout
+// @Description		Revokes the token family of the access token, its refresh tokens stop working and
+// @Description		its access tokens are rejected until they expire
+// @Description		allowed: user, scanner, admin
+// @ID				logout
+// @Tags 			auth
+// @Produce 		json
+// @Success 		200 {string} json "{"message": "Logged out"}"
+// @Failure			401 {string} json "{"error": "token was revoked"}"
+// @Failure			500 {string} json "{"error": "Could not log out"}"
+// @Router 			/logout [post]
+func (ctrl *Controller) Logout (c *gin.Context) {
+
+	now := ctrl.clock.Now()
+	if err := ctrl.store.Tokens.RevokeFamily(c.GetString("family"), now, ctrl.revokeUntil(now)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not log out"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out"})
+}
+// @Summary 		Get JWKS
+// @Description		Sends the public keys that verify access tokens as a JSON Web Key Set, tokens name their key in the kid header
+// @Description		Keys are published before they sign, verifiers that meet an unknown kid fetch the set again
+// @Description		Served at the root, outside of /api
+// @Description		allowed: everyone
+// @ID				get-jwks
+// @Tags 			auth
+// @Produce 		json
+// @Success 		200 {string} json "{"keys": [{"kty": "OKP", "kid": "…", "use": "sig", "alg": "EdDSA", "crv": "Ed25519", "x": "…"}]}"
+// @Router 			/.well-known/jwks.json [get]
+func (ctrl *Controller) GetJWKS (c *gin.Context) {
+
+	c.JSON(http.StatusOK, gin.H{"keys": utils.JWKS(ctrl.clock.Now())})
+}
@@ -0,0 +1,366 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mgr1054/go-ticket/pkg/models"
+	"github.com/mgr1054/go-ticket/pkg/store"
+	"github.com/mgr1054/go-ticket/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+type TransferRequest struct {
+	// email or username of the recipient
+	Recipient	string	`json:"recipient" binding:"required" example:"jane@example.com"`
+}
+
+// shows pending transfers past their deadline as expired, the sweeper settles them only periodically
+func (ctrl *Controller) showTransferExpiry(transfers []models.Transfer) {
+	now := ctrl.clock.Now()
+	for i := range transfers {
+		if transfers[i].Status == models.TransferPending && !transfers[i].IsPending(now) {
+			transfers[i].Status = models.TransferExpired
+		}
+	}
+}
+
+// answers the errors of closing or accepting a transfer, reports whether there was one
+func transferError(c *gin.Context, err error, failure string) bool {
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Transfer not found"})
+	case errors.Is(err, store.ErrTransferClosed):
+		c.JSON(http.StatusConflict, gin.H{"error": "The transfer is not pending anymore"})
+	case errors.Is(err, store.ErrTicketNotIssued):
+		c.JSON(http.StatusConflict, gin.H{"error": "Ticket is cancelled or not paid"})
+	case errors.Is(err, store.ErrTicketUsed):
+		c.JSON(http.StatusConflict, gin.H{"error": "Ticket was already used at the door"})
+	case errors.Is(err, store.ErrTicketListed):
+		c.JSON(http.StatusConflict, gin.H{"error": "Ticket is listed for resale"})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": failure})
+	default:
+		return false
+	}
+	return true
+}
+
+// @Summary 		Transfer Ticket
+// @Description		Offers a paid ticket to another user by email or username, the recipient has to accept before TRANSFER_TTL
+// @Description		passes or the event starts; on acceptance the ticket gets a new entry code and the old pass stops working
+// @Description		allowed: user, admin
+// @ID				transfer-ticket
+// @Tags 			transfers
+// @Accept			json
+// @Produce 		json
+// @Param			id path int true "Ticket ID"
+// @Param			transfer body TransferRequest true "Transfer Ticket"
+// @Success 		201 {object} models.Transfer
+// @Failure			400 {string} json "{"error": "Could not transfer the ticket"}"
+// @Failure			400 {string} json "{"error": "The ticket already belongs to the recipient"}"
+// @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
+// @Failure			404 {string} json "{"error": "Ticket not found"}"
+// @Failure			404 {string} json "{"error": "Recipient not found"}"
+// @Failure			409 {string} json "{"error": "Ticket is cancelled or not paid"}"
+// @Failure			409 {string} json "{"error": "Ticket was already used at the door"}"
+// @Failure			409 {string} json "{"error": "Ticket is listed for resale"}"
+// @Failure			409 {string} json "{"error": "The event has already started"}"
+// @Failure			409 {string} json "{"error": "The ticket is already being transferred"}"
+// @Failure			409 {string} json "{"error": "The ticket belongs to someone else now"}"
+// @Failure			500 {string} json "{"error": "Could not transfer the ticket"}"
+// @Router 			/secured/tickets/{id}/transfer [post]
+func (ctrl *Controller) TransferTicket (c *gin.Context) {
+
+	if err := utils.CheckUserType(c, "user"); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
+		return
+	}
+
+	id, ok := paramID(c, "id")
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ticket not found"})
+		return
+	}
+
+	var request TransferRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Could not transfer the ticket"})
+		return
+	}
+
+	ticket, err := ctrl.store.Tickets.Get(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ticket not found"})
+		return
+	}
+
+	if !ctrl.ownsOrIsAdmin(c, ticket.UserID) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
+		return
+	}
+
+	// addresses are stored lower-cased, anything that is not one can still be a username
+	var recipient models.User
+	email, err := normalizeEmail(request.Recipient)
+	if err == nil {
+		recipient, err = ctrl.store.Users.GetByEmail(email)
+	}
+	if err != nil {
+		recipient, err = ctrl.store.Users.GetByUsername(strings.TrimSpace(request.Recipient))
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recipient not found"})
+		return
+	}
+	if recipient.ID == ticket.UserID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "The ticket already belongs to the recipient"})
+		return
+	}
+
+	event, err := ctrl.store.Events.Get(ticket.EventID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not transfer the ticket"})
+		return
+	}
+	now := ctrl.clock.Now()
+	if !event.StartsAt.After(now) {
+		c.JSON(http.StatusConflict, gin.H{"error": "The event has already started"})
+		return
+	}
+
+	// the recipient has to own the ticket before the doors let anyone in with it
+	expiresAt := now.Add(ctrl.cfg.Transfers.TTL.Duration)
+	if event.StartsAt.Before(expiresAt) {
+		expiresAt = event.StartsAt
+	}
+
+	transfer := models.Transfer{TicketID: ticket.ID, FromUserID: ticket.UserID, ToUserID: recipient.ID, ExpiresAt: expiresAt}
+	err = ctrl.store.Transfers.Create(&transfer, now)
+
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ticket not found"})
+		return
+	case errors.Is(err, store.ErrDuplicate):
+		c.JSON(http.StatusConflict, gin.H{"error": "The ticket is already being transferred"})
+		return
+	case errors.Is(err, store.ErrTransferClosed):
+		// the ticket changed hands since it was read
+		c.JSON(http.StatusConflict, gin.H{"error": "The ticket belongs to someone else now"})
+		return
+	case transferError(c, err, "Could not transfer the ticket"):
+		return
+	}
+
+	log.Infof("Ticket %d is being transferred from user %d to user %d", ticket.ID, transfer.FromUserID, transfer.ToUserID)
+	c.JSON(http.StatusCreated, transfer)
+}
+
+// @Summary 		Get Transfers
+// @Description		Gives back all transfers the user sent or received
+// @Description		allowed: user, admin
+// @ID				get-transfers
+// @Tags 			transfers
+// @Produce 		json
+// @Success 		200 {object} []models.Transfer
+// @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
+// @Failure			404 {string} json "{"error": "User not found"}"
+// @Failure			500 {string} json "{"error": "Could not get the transfers"}"
+// @Router 			/secured/transfers [get]
+func (ctrl *Controller) GetTransfers (c *gin.Context) {
+
+	if err := utils.CheckUserType(c, "user"); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
+		return
+	}
+
+	user, err := ctrl.store.Users.GetByUsername(c.GetString("username"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	transfers, err := ctrl.store.Transfers.ListByUser(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not get the transfers"})
+		return
+	}
+
+	if len(transfers) < 1 {
+		c.JSON(http.StatusOK, gin.H{"info": "The user has no transfers"})
+		return
+	}
+
+	ctrl.showTransferExpiry(transfers)
+	c.JSON(http.StatusOK, transfers)
+}
+
+// @Summary 		Get Ticket Transfers
+// @Description		Gives back all transfers of the ticket in the order they were made, the accepted ones are the chain of its owners
+// @Description		allowed: admin
+// @ID				get-ticket-transfers
+// @Tags 			transfers
+// @Produce 		json
+// @Param			id path int true "Ticket ID"
+// @Success 		200 {object} []models.Transfer
+// @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
+// @Failure			404 {string} json "{"error": "Ticket not found"}"
+// @Failure			500 {string} json "{"error": "Could not get the transfers"}"
+// @Router 			/secured/tickets/{id}/transfers [get]
+func (ctrl *Controller) GetTicketTransfers (c *gin.Context) {
+
+	if err := utils.CheckUserType(c, "admin"); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
+		return
+	}
+
+	id, ok := paramID(c, "id")
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ticket not found"})
+		return
+	}
+
+	if _, err := ctrl.store.Tickets.Get(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ticket not found"})
+		return
+	}
+
+	transfers, err := ctrl.store.Transfers.ListByTicket(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not get the transfers"})
+		return
+	}
+
+	ctrl.showTransferExpiry(transfers)
+	c.JSON(http.StatusOK, gin.H{"data": transfers})
+}
+
+// @Summary 		Accept Transfer
+// @Description		Makes the recipient the owner of the ticket and issues it a new entry code, passes of the old code are rejected at the door
+// @Description		allowed: user, admin
+// @ID				accept-transfer
+// @Tags 			transfers
+// @Produce 		json
+// @Param			id path int true "Transfer ID"
+// @Success 		200 {object} models.Transfer
+// @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
+// @Failure			404 {string} json "{"error": "Transfer not found"}"
+// @Failure			409 {string} json "{"error": "The transfer is not pending anymore"}"
+// @Failure			409 {string} json "{"error": "Ticket is cancelled or not paid"}"
+// @Failure			409 {string} json "{"error": "Ticket was already used at the door"}"
+// @Failure			409 {string} json "{"error": "Ticket is listed for resale"}"
+// @Failure			500 {string} json "{"error": "Could not accept the transfer"}"
+// @Router 			/secured/transfers/{id}/accept [post]
+func (ctrl *Controller) AcceptTransfer (c *gin.Context) {
+
+	transfer, ok := ctrl.receivedTransfer(c)
+	if !ok {
+		return
+	}
+
+	transfer, err := ctrl.store.Transfers.Accept(transfer.ID, ctrl.clock.Now())
+	if transferError(c, err, "Could not accept the transfer") {
+		return
+	}
+
+	log.Infof("Ticket %d was transferred from user %d to user %d", transfer.TicketID, transfer.FromUserID, transfer.ToUserID)
+	c.JSON(http.StatusOK, transfer)
+}
+
+// @Summary 		Decline Transfer
+// @Description		Turns down a pending transfer, the ticket stays with the sender
+// @Description		allowed: user, admin
+// @ID				decline-transfer
+// @Tags 			transfers
+// @Produce 		json
+// @Param			id path int true "Transfer ID"
+// @Success 		200 {object} models.Transfer
+// @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
+// @Failure			404 {string} json "{"error": "Transfer not found"}"
+// @Failure			409 {string} json "{"error": "The transfer is not pending anymore"}"
+// @Failure			500 {string} json "{"error": "Could not decline the transfer"}"
+// @Router 			/secured/transfers/{id}/decline [post]
+func (ctrl *Controller) DeclineTransfer (c *gin.Context) {
+
+	transfer, ok := ctrl.receivedTransfer(c)
+	if !ok {
+		return
+	}
+
+	transfer, err := ctrl.store.Transfers.Close(transfer.ID, models.TransferDeclined, ctrl.clock.Now())
+	if transferError(c, err, "Could not decline the transfer") {
+		return
+	}
+
+	c.JSON(http.StatusOK, transfer)
+}
+
+// @Summary 		Cancel Transfer
+// @Description		Takes back a pending transfer before the recipient accepts it
+// @Description		allowed: user, admin
+// @ID				cancel-transfer
+// @Tags 			transfers
+// @Produce 		json
+// @Param			id path int true "Transfer ID"
+// @Success 		200 {object} models.Transfer
+// @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
+// @Failure			404 {string} json "{"error": "Transfer not found"}"
+// @Failure			409 {string} json "{"error": "The transfer is not pending anymore"}"
+// @Failure			500 {string} json "{"error": "Could not cancel the transfer"}"
+// @Router 			/secured/transfers/{id} [delete]
+func (ctrl *Controller) CancelTransfer (c *gin.Context) {
+
+	transfer, ok := ctrl.transfer(c)
+	if !ok {
+		return
+	}
+
+	if !ctrl.ownsOrIsAdmin(c, transfer.FromUserID) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
+		return
+	}
+
+	transfer, err := ctrl.store.Transfers.Close(transfer.ID, models.TransferCancelled, ctrl.clock.Now())
+	if transferError(c, err, "Could not cancel the transfer") {
+		return
+	}
+
+	c.JSON(http.StatusOK, transfer)
+}
+
+// reads the transfer of the id param for a user, answers the request when it fails
+func (ctrl *Controller) transfer(c *gin.Context) (transfer models.Transfer, ok bool) {
+	if err := utils.CheckUserType(c, "user"); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
+		return transfer, false
+	}
+
+	id, ok := paramID(c, "id")
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Transfer not found"})
+		return transfer, false
+	}
+
+	transfer, err := ctrl.store.Transfers.Get(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Transfer not found"})
+		return transfer, false
+	}
+	return transfer, true
+}
+
+// same as transfer, but only the recipient may answer it
+func (ctrl *Controller) receivedTransfer(c *gin.Context) (transfer models.Transfer, ok bool) {
+	transfer, ok = ctrl.transfer(c)
+	if !ok {
+		return transfer, false
+	}
+	if !ctrl.ownsOrIsAdmin(c, transfer.ToUserID) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
+		return transfer, false
+	}
+	return transfer, true
+}
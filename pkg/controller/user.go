@@ -1,11 +1,15 @@
 package controller
 
 import (
-	"github.com/mgr1054/go-ticket/pkg/db"
+	"errors"
+	"net/mail"
+	"strings"
 	"github.com/mgr1054/go-ticket/pkg/models"
+	"github.com/mgr1054/go-ticket/pkg/store"
 	"github.com/mgr1054/go-ticket/pkg/utils"
 	"net/http"
 	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
 )
 
 type UserUpdate struct {
@@ -16,9 +20,24 @@ type UserUpdate struct {
 	Role		string		`json:"role" example:"user"`
 }
 
+// checks that email is a plain address and lower-cases it, so an address is
+// registered and looked up only once
+func normalizeEmail(email string) (string, error) {
+	email = strings.TrimSpace(email)
+	address, err := mail.ParseAddress(email)
+	if err != nil {
+		return "", err
+	}
+	// display names like "Max <mgr@online.de>" are not an address
+	if address.Address != email {
+		return "", errors.New("mail: not a plain address")
+	}
+	return strings.ToLower(address.Address), nil
+}
+
 // @Summary 		Register User
 // @Description		Creates new User, hashes Password for DB
-// @Description		role automatically set to user
+// @Description		role automatically set to user, the account is pending until the link mailed to its address is opened
 // @Description		allowed: unsecured
 // @ID				register-user
 // @Tags 			user
@@ -26,9 +45,11 @@ type UserUpdate struct {
 // @Param			user body UserUpdate true "Create User"
 // @Success 		201 {object} string
 // @Failure			400 {object} string
+// @Failure			400 {string} json "{"error": "Invalid email address"}"
+// @Failure			409 {string} json "{"error": "Username or email is already taken"}"
 // @Failure			500 {object} string
 // @Router 			/user/register [post]
-func RegisterUser(c *gin.Context) {
+func (ctrl *Controller) RegisterUser(c *gin.Context) {
 	var user models.User
 	if err := c.ShouldBindJSON(&user); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -36,20 +57,35 @@ func RegisterUser(c *gin.Context) {
 		return
 	}
 	
+	email, err := normalizeEmail(user.Email)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email address"})
+		c.Abort()
+		return
+	}
+	user.Email = email
 	user.Role = "user"
+	user.VerifiedAt = nil
 	
 	if err := user.HashPassword(user.Password); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		c.Abort()
 		return
 	}
-	record := db.DB.Create(&user)
-	if record.Error != nil {
+	if err := ctrl.store.Users.Create(&user); errors.Is(err, store.ErrDuplicate) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Username or email is already taken"})
+		c.Abort()
+		return
+	} else if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "User could not be created"})
 		c.Abort()
 		return
 	}
-	c.JSON(http.StatusCreated, gin.H{"userId": user.ID, "email": user.Email, "username": user.Username, "role": user.Role})
+	// the account exists anyway, the link can be requested again
+	if err := ctrl.sendVerification(user); err != nil {
+		log.Error("Could not send the verification mail to user ", user.ID, ": ", err)
+	}
+	c.JSON(http.StatusCreated, gin.H{"userId": user.ID, "email": user.Email, "username": user.Username, "role": user.Role, "verified": false})
 }
 
 // @Summary 		Get User By ID
@@ -62,16 +98,21 @@ func RegisterUser(c *gin.Context) {
 // @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
 // @Failure			404 {string} json "{"error": "User not found"}"
 // @Router 			/secured/user/{id} [get]
-func GetUserById (c *gin.Context) {
+func (ctrl *Controller) GetUserById (c *gin.Context) {
 
 	if err := utils.CheckUserType(c, "admin"); err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
 		return
 	}
 
-	var user models.User
+	id, ok := paramID(c, "id")
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
 
-	if err := db.DB.Where("id = ?", c.Param("id")).First(&user).Error; err != nil {
+	user, err := ctrl.store.Users.Get(id)
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
 	}
@@ -80,7 +121,8 @@ func GetUserById (c *gin.Context) {
 }
 
 // @Summary 		Update User By ID
-// @Description		Updates User with Body and corresponding ID 
+// @Description		Updates User with Body and corresponding ID, the role is user, scanner (door staff) or admin
+// @Description		Changing the username, email, password or role revokes all tokens of the user
 // @Description		allowed:  admin
 // @ID				update-user-by-id
 // @Tags 			user
@@ -88,19 +130,26 @@ func GetUserById (c *gin.Context) {
 // @Accept			json
 // @Param			user body UserUpdate true "Update User"
 // @Success 		200 {object} models.User
+// @Failure			400 {string} json "{"error": "Unknown role, use user, scanner or admin"}"
+// @Failure			400 {string} json "{"error": "Invalid email address"}"
 // @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
 // @Failure			404 {string} json "{"error": "User not found"}"
+// @Failure			409 {string} json "{"error": "Username or email is already taken"}"
 // @Router 			/secured/user/{id} [put]
-func UpdateUserById (c *gin.Context) {
+func (ctrl *Controller) UpdateUserById (c *gin.Context) {
 
 	if err := utils.CheckUserType(c, "admin"); err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
 		return
 	}
 	
-	var user models.User
+	id, ok := paramID(c, "id")
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
 
-	if err := db.DB.Where("id = ?", c.Param("id")).First(&user).Error; err != nil {
+	if _, err := ctrl.store.Users.Get(id); err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
 	}
@@ -112,22 +161,54 @@ func UpdateUserById (c *gin.Context) {
         return
     }
 
-	if err:= db.DB.Model(&user).Updates(models.User{
+	switch updateUser.Role {
+	case "", "user", "scanner", "admin":
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown role, use user, scanner or admin"})
+		return
+	}
+
+	if updateUser.Email != "" {
+		email, err := normalizeEmail(updateUser.Email)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email address"})
+			return
+		}
+		updateUser.Email = email
+	}
+
+	user, err := ctrl.store.Users.Update(id, models.User{
 		Name: updateUser.Name,
 		Username: updateUser.Username, 
 		Email: updateUser.Email, 
 		Password: updateUser.Password, 
 		Role: updateUser.Role,
-	}).Error; err != nil {
+	})
+	if errors.Is(err, store.ErrDuplicate) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Username or email is already taken"})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not update User"})
         return
 	}
 
+	// tokens carry the identity and role, sessions start over with the new ones; a
+	// rejected update keeps them
+	if updateUser.Username != "" || updateUser.Email != "" || updateUser.Password != "" || updateUser.Role != "" {
+		now := ctrl.clock.Now()
+		if err := ctrl.store.Tokens.RevokeUser(id, now, ctrl.revokeUntil(now)); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "User updated, but its tokens could not be revoked"})
+			return
+		}
+	}
+
 	c.JSON(http.StatusOK, user)
 }
 
 // @Summary 		Delete User By ID
-// @Description		Deltes User with corresponding ID 
+// @Description		Deltes User with corresponding ID and revokes all of its tokens, users with orders, tickets
+// @Description		or payouts stay on record
 // @Description		allowed:  admin
 // @ID				delete-user-by-id
 // @Tags 			user
@@ -135,25 +216,67 @@ func UpdateUserById (c *gin.Context) {
 // @Success 		200 {string} json "{"message": "User deleted"}"
 // @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
 // @Failure			404 {string} json "{"error": "User not found"}"
+// @Failure			409 {string} json "{"error": "User has orders, tickets or payouts"}"
 // @Router 			/secured/user/{id} [delete]
-func DelteUserById (c *gin.Context) {
+func (ctrl *Controller) DelteUserById (c *gin.Context) {
 
 	if err := utils.CheckUserType(c, "admin"); err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
 		return
 	}
 	
-	var user models.User
+	id, ok := paramID(c, "id")
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
 
-	if err := db.DB.Where("id = ?", c.Param("id")).First(&user).Error; err != nil {
+	if _, err := ctrl.store.Users.Get(id); err != nil {
         c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
         return
     }
 
-	if err := db.DB.Delete(&user).Error; err != nil {
+	// checked up front so a refused delete keeps the user logged in, the store refuses it again
+	inUse, err := ctrl.userInUse(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if inUse {
+		c.JSON(http.StatusConflict, gin.H{"error": "User has orders, tickets or payouts"})
+		return
+	}
+
+	// the refresh tokens go with the user, the access tokens have to be revoked first
+	now := ctrl.clock.Now()
+	if err := ctrl.store.Tokens.RevokeUser(id, now, ctrl.revokeUntil(now)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = ctrl.store.Users.Delete(id)
+	switch {
+	case errors.Is(err, store.ErrUserInUse):
+		c.JSON(http.StatusConflict, gin.H{"error": "User has orders, tickets or payouts"})
+		return
+	case err != nil:
         c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
         return
     }
 
 	c.JSON(http.StatusOK, gin.H{"message": "User deleted"})
+}
+
+// reports whether the user has orders, tickets or payouts, which keep the user on record
+func (ctrl *Controller) userInUse(id uint) (bool, error) {
+	orders, err := ctrl.store.Orders.ListByUser(id)
+	if err != nil || len(orders) > 0 {
+		return len(orders) > 0, err
+	}
+	tickets, err := ctrl.store.Tickets.ListByUser(id)
+	if err != nil || len(tickets) > 0 {
+		return len(tickets) > 0, err
+	}
+	payouts, err := ctrl.store.Listings.ListPayouts(id)
+	return len(payouts) > 0, err
 }
\ No newline at end of file
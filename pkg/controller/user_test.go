@@ -0,0 +1,120 @@
+package controller_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mgr1054/go-ticket/pkg/controller"
+	"github.com/mgr1054/go-ticket/pkg/models"
+	"github.com/mgr1054/go-ticket/pkg/payment"
+)
+
+// registers an account with the email address, returns the status code
+func (s *testServer) register(t *testing.T, username string, email string) int {
+	t.Helper()
+	body, _ := json.Marshal(map[string]string{"name": "Test", "username": username, "email": email, "password": "secret"})
+	response, err := http.Post(s.URL+"/api/user/register", "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	return response.StatusCode
+}
+
+func TestRegisterUserEmail(t *testing.T) {
+	s := newTestServer(t)
+
+	for _, email := range []string{"", "not-an-email", "erika@", "Erika <erika@example.com>", "erika@example.com, max@example.com"} {
+		if status := s.register(t, "erika", email); status != http.StatusBadRequest {
+			t.Errorf("registering %q got status %d, want %d", email, status, http.StatusBadRequest)
+		}
+	}
+
+	if status := s.register(t, "erika", " Erika@Example.COM "); status != http.StatusCreated {
+		t.Fatalf("got status %d, want %d", status, http.StatusCreated)
+	}
+	user, err := s.store.Users.GetByUsername("erika")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Email != "erika@example.com" {
+		t.Errorf("stored email %q, want erika@example.com", user.Email)
+	}
+	if _, ok := s.mailer.Last("erika@example.com"); !ok {
+		t.Error("no verification mail to the lower-cased address")
+	}
+
+	if status := s.register(t, "erika2", "ERIKA@example.com"); status != http.StatusConflict {
+		t.Errorf("registering the address in other case got status %d, want %d", status, http.StatusConflict)
+	}
+}
+
+// logins and transfers find accounts by their address in any case
+func TestEmailLookupIgnoresCase(t *testing.T) {
+	s := newTestServer(t)
+	if status := s.register(t, "erika", "erika@example.com"); status != http.StatusCreated {
+		t.Fatalf("register got status %d, want %d", status, http.StatusCreated)
+	}
+	erika, err := s.store.Users.GetByUsername("erika")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.store.Users.Verify(erika.ID, erika.Email, s.clock.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	login := map[string]string{"email": " Erika@Example.COM ", "password": "secret"}
+	if status := s.call(t, http.MethodPost, "/api/token", "", login, nil); status != http.StatusCreated {
+		t.Errorf("login in other case got status %d, want %d", status, http.StatusCreated)
+	}
+	unknown := map[string]string{"email": "nobody@example.com", "password": "secret"}
+	if status := s.call(t, http.MethodPost, "/api/token", "", unknown, nil); status != http.StatusUnauthorized {
+		t.Errorf("login of an unknown user got status %d, want %d", status, http.StatusUnauthorized)
+	}
+
+	event := s.createEvent(t, 10)
+	status, orderID := s.order(t, event.ID, 1, payment.MockCardOK)
+	if status != http.StatusCreated {
+		t.Fatalf("order got status %d, want %d", status, http.StatusCreated)
+	}
+	ticket := s.getOrder(t, orderID).Tickets[0]
+	transfer := controller.TransferRequest{Recipient: " ERIKA@example.com"}
+	var created models.Transfer
+	if status := s.call(t, http.MethodPost, fmt.Sprintf("/api/secured/tickets/%d/transfer", ticket.ID), s.token, transfer, &created); status != http.StatusCreated {
+		t.Fatalf("transfer got status %d, want %d", status, http.StatusCreated)
+	}
+	if created.ToUserID != erika.ID {
+		t.Errorf("transfer goes to user %d, want %d", created.ToUserID, erika.ID)
+	}
+}
+
+// a rejected update keeps the sessions of the user, a successful one ends them
+func TestUpdateUserRevokesAfterUpdate(t *testing.T) {
+	s := newTestServer(t)
+	refresh := models.RefreshToken{UserID: s.user.ID, FamilyID: "family", TokenHash: "hash", ExpiresAt: s.clock.Now().Add(time.Hour)}
+	if err := s.store.Tokens.Create(&refresh, s.clock.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if status := s.register(t, "erika", "erika@example.com"); status != http.StatusCreated {
+		t.Fatalf("register got status %d, want %d", status, http.StatusCreated)
+	}
+	path := fmt.Sprintf("/api/secured/user/%d", s.user.ID)
+
+	if status := s.call(t, http.MethodPut, path, s.adminToken, map[string]string{"email": "erika@example.com"}, nil); status != http.StatusConflict {
+		t.Fatalf("update to a taken email got status %d, want %d", status, http.StatusConflict)
+	}
+	if revoked, err := s.store.Tokens.IsRevoked("family", s.clock.Now()); err != nil || revoked {
+		t.Errorf("IsRevoked after a rejected update = %v, %v, want false", revoked, err)
+	}
+
+	if status := s.call(t, http.MethodPut, path, s.adminToken, map[string]string{"role": "scanner"}, nil); status != http.StatusOK {
+		t.Fatalf("update of the role got status %d, want %d", status, http.StatusOK)
+	}
+	if revoked, err := s.store.Tokens.IsRevoked("family", s.clock.Now()); err != nil || !revoked {
+		t.Errorf("IsRevoked after the update = %v, %v, want true", revoked, err)
+	}
+}
@@ -0,0 +1,292 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mgr1054/go-ticket/pkg/models"
+	"github.com/mgr1054/go-ticket/pkg/store"
+	"github.com/mgr1054/go-ticket/pkg/utils"
+)
+
+type VenueSectionRequest struct {
+	// id of an existing section to keep, new sections have none
+	ID			uint		`json:"id"`
+	Name		string		`json:"name" example:"Ostkurve"`
+	Capacity	int			`json:"capacity" example:"25000"`
+}
+
+type NewVenue struct {
+	Name			string		`json:"name" binding:"required" example:"Olympiastadion"`
+	Address			string		`json:"address" example:"Olympischer Platz 3, 14053 Berlin"`
+	Latitude		*float64	`json:"latitude" example:"52.5147"`
+	Longitude		*float64	`json:"longitude" example:"13.2395"`
+	Timezone		string		`json:"timezone" binding:"required" example:"Europe/Berlin"`
+	// defaults to the sum of the sections
+	DefaultCapacity	int			`json:"default_capacity" example:"74475"`
+	Sections		[]VenueSectionRequest	`json:"sections"`
+}
+
+type VenueUpdate struct {
+	Name			string		`json:"name"`
+	Address			string		`json:"address"`
+	Latitude		*float64	`json:"latitude"`
+	Longitude		*float64	`json:"longitude"`
+	Timezone		string		`json:"timezone"`
+	DefaultCapacity	int			`json:"default_capacity"`
+	// replaces all sections when present
+	Sections		[]VenueSectionRequest	`json:"sections"`
+}
+
+func venueSections(requests []VenueSectionRequest) []models.VenueSection {
+	sections := []models.VenueSection{}
+	for _, request := range requests {
+		sections = append(sections, models.VenueSection{ID: request.ID, Name: request.Name, Capacity: request.Capacity})
+	}
+	return sections
+}
+
+// finds the venue of an event by id or, as before venues existed, by its name
+func (ctrl *Controller) resolveVenue(venueID uint, location string) (models.Venue, error) {
+	if venueID != 0 {
+		return ctrl.store.Venues.Get(venueID)
+	}
+	return ctrl.store.Venues.FindByName(location)
+}
+
+// @Summary 		Get All Venues
+// @Description		Sends Array Of Venues with their sections
+// @Description		allowed: user, admin
+// @ID				get-venues
+// @Tags 			venues
+// @Produce 		json
+// @Success 		200 {object} []models.Venue
+// @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
+// @Failure			500 {string} json "{"error": "Could not get venues"}"
+// @Router 			/secured/venues [get]
+func (ctrl *Controller) GetVenues (c *gin.Context) {
+
+	if err := utils.CheckUserType(c, "user"); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
+		return
+	}
+
+	venues, err := ctrl.store.Venues.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not get venues"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": venues})
+}
+
+// @Summary 		Get Venue By ID
+// @Description		Sends a Venue with ID including its sections
+// @Description		allowed: user, admin
+// @ID				get-venue-by-id
+// @Tags 			venues
+// @Produce 		json
+// @Success 		200 {object} models.Venue
+// @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
+// @Failure			404 {string} json "{"error": "Venue not found"}"
+// @Router 			/secured/venues/{id} [get]
+func (ctrl *Controller) GetVenueById (c *gin.Context) {
+
+	if err := utils.CheckUserType(c, "user"); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
+		return
+	}
+
+	id, ok := paramID(c, "id")
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Venue not found"})
+		return
+	}
+
+	venue, err := ctrl.store.Venues.Get(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Venue not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, venue)
+}
+
+// @Summary 		Create Venue
+// @Description		Creates a new Venue with its sections
+// @Description		allowed: admin
+// @ID				create-venue
+// @Tags 			venues
+// @Accept			json
+// @Produce 		json
+// @Param			venue body NewVenue true "Create Venue"
+// @Success 		201 {object} models.Venue
+// @Failure			400 {string} json "{"error": "Invalid venue"}"
+// @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
+// @Failure			409 {string} json "{"error": "A venue with this name already exists"}"
+// @Failure			500 {string} json "{"error": "Could not create Venue"}"
+// @Router 			/secured/venues [post]
+func (ctrl *Controller) CreateVenue (c *gin.Context) {
+
+	if err := utils.CheckUserType(c, "admin"); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
+		return
+	}
+
+	var request NewVenue
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid venue"})
+		return
+	}
+
+	venue := models.Venue{
+		Name: request.Name,
+		Address: request.Address,
+		Latitude: request.Latitude,
+		Longitude: request.Longitude,
+		Timezone: request.Timezone,
+		DefaultCapacity: request.DefaultCapacity,
+		Sections: venueSections(request.Sections),
+	}
+	for i := range venue.Sections {
+		venue.Sections[i].ID = 0
+	}
+	if err := venue.Normalize(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid venue"})
+		return
+	}
+
+	err := ctrl.store.Venues.Create(&venue)
+	switch {
+	case errors.Is(err, store.ErrDuplicate):
+		c.JSON(http.StatusConflict, gin.H{"error": "A venue with this name already exists"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not create Venue"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, venue)
+}
+
+// @Summary 		Update Venue By ID
+// @Description		Updates Venue with given ID, sections replace the current ones when present, sections
+// @Description		with the id of an existing section are kept; events at the venue take over a new name
+// @Description		allowed: admin
+// @ID				update-venue-by-id
+// @Tags 			venues
+// @Accept			json
+// @Produce 		json
+// @Param			venue body VenueUpdate true "Update Venue"
+// @Success 		200 {object} models.Venue
+// @Failure			400 {string} json "{"error": "Invalid venue"}"
+// @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
+// @Failure			404 {string} json "{"error": "Venue not found"}"
+// @Failure			409 {string} json "{"error": "A venue with this name already exists"}"
+// @Failure			500 {string} json "{"error": "Could not update Venue"}"
+// @Router 			/secured/venues/{id} [put]
+func (ctrl *Controller) UpdateVenueById (c *gin.Context) {
+
+	if err := utils.CheckUserType(c, "admin"); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
+		return
+	}
+
+	id, ok := paramID(c, "id")
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Venue not found"})
+		return
+	}
+
+	venue, err := ctrl.store.Venues.Get(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Venue not found"})
+		return
+	}
+
+	var request VenueUpdate
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid venue"})
+		return
+	}
+
+	if request.Name != "" {
+		venue.Name = request.Name
+	}
+	if request.Address != "" {
+		venue.Address = request.Address
+	}
+	if request.Latitude != nil || request.Longitude != nil {
+		venue.Latitude, venue.Longitude = request.Latitude, request.Longitude
+	}
+	if request.Timezone != "" {
+		venue.Timezone = request.Timezone
+	}
+	if request.DefaultCapacity != 0 {
+		venue.DefaultCapacity = request.DefaultCapacity
+	}
+	if request.Sections != nil {
+		venue.Sections = venueSections(request.Sections)
+	}
+	if err := venue.Normalize(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid venue"})
+		return
+	}
+
+	venue, err = ctrl.store.Venues.Update(id, venue)
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Venue not found"})
+		return
+	case errors.Is(err, store.ErrDuplicate):
+		c.JSON(http.StatusConflict, gin.H{"error": "A venue with this name already exists"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not update Venue"})
+		return
+	}
+
+	c.JSON(http.StatusOK, venue)
+}
+
+// @Summary 		Delete Venue By ID
+// @Description		Deletes Venue with given ID, venues with events cannot be deleted
+// @Description		allowed: admin
+// @ID				delete-venue-by-id
+// @Tags 			venues
+// @Produce 		json
+// @Success 		200 {string} json "{"message": "Venue deleted"}"
+// @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
+// @Failure			404 {string} json "{"error": "Venue not found"}"
+// @Failure			409 {string} json "{"error": "Venue still has events"}"
+// @Failure			500 {string} json "{"error": "Could not delete Venue"}"
+// @Router 			/secured/venues/{id} [delete]
+func (ctrl *Controller) DeleteVenueById (c *gin.Context) {
+
+	if err := utils.CheckUserType(c, "admin"); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
+		return
+	}
+
+	id, ok := paramID(c, "id")
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Venue not found"})
+		return
+	}
+
+	err := ctrl.store.Venues.Delete(id)
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Venue not found"})
+		return
+	case errors.Is(err, store.ErrVenueInUse):
+		c.JSON(http.StatusConflict, gin.H{"error": "Venue still has events"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not delete Venue"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Venue deleted"})
+}
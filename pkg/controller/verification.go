@@ -0,0 +1,129 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mgr1054/go-ticket/pkg/mail"
+	"github.com/mgr1054/go-ticket/pkg/models"
+	"github.com/mgr1054/go-ticket/pkg/store"
+	"github.com/mgr1054/go-ticket/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+type ResendRequest struct {
+	Email	string	`json:"email" binding:"required" example:"test@online.de"`
+}
+
+// mails the user a link that verifies the email address until the verification ttl passed
+func (ctrl *Controller) sendVerification(user models.User) error {
+	expiresAt := ctrl.clock.Now().Add(ctrl.cfg.Verification.TTL.Duration)
+	link, err := url.Parse(ctrl.cfg.Verification.URL)
+	if err != nil {
+		return err
+	}
+	query := link.Query()
+	query.Set("token", utils.SignVerification(ctrl.cfg.Verification.Secret, user.ID, user.Email, expiresAt))
+	link.RawQuery = query.Encode()
+
+	name := user.Name
+	if name == "" {
+		name = user.Username
+	}
+	return ctrl.mailer.Send(mail.Message{
+		To: user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hello %s,\n\nplease confirm your email address for Go Ticket by opening this link:\n\n%s\n\n"+
+			"The link works until %s. If you did not register, ignore this mail.\n",
+			name, link.String(), expiresAt.UTC().Format(time.RFC1123)),
+	})
+}
+
+// @Summary 		Verify Email
+// @Description		Target of the link in the verification mail, activates the account so it can log in
+// @Description		Links expire after VERIFICATION_TTL and stop working when the email address changes, opening a link twice does no harm
+// @Description		allowed: unsecured
+// @ID				verify-email
+// @Tags 			user
+// @Produce 		json
+// @Param			token query string true "Token of the verification link"
+// @Success 		200 {string} json "{"message": "Email address verified"}"
+// @Failure			400 {string} json "{"error": "Verification link is invalid"}"
+// @Failure			410 {string} json "{"error": "Verification link expired, request a new one"}"
+// @Failure			500 {string} json "{"error": "Could not verify the email address"}"
+// @Router 			/user/verify [get]
+func (ctrl *Controller) VerifyEmail (c *gin.Context) {
+
+	token, err := utils.ParseVerification(c.Query("token"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Verification link is invalid"})
+		return
+	}
+	user, err := ctrl.store.Users.Get(token.UserID)
+	if err != nil || !token.Valid(ctrl.cfg.Verification.Secret, user.Email) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Verification link is invalid"})
+		return
+	}
+	now := ctrl.clock.Now()
+	if !token.ExpiresAt.After(now) {
+		c.JSON(http.StatusGone, gin.H{"error": "Verification link expired, request a new one"})
+		return
+	}
+
+	_, err = ctrl.store.Users.Verify(user.ID, user.Email, now)
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Verification link is invalid"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not verify the email address"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Email address verified"})
+}
+
+// @Summary 		Resend Verification
+// @Description		Mails a new verification link to an account that is not verified yet, earlier links keep working until they expire
+// @Description		The answer is the same whether the account exists or not
+// @Description		allowed: unsecured
+// @ID				resend-verification
+// @Tags 			user
+// @Accept			json
+// @Produce 		json
+// @Param			request body ResendRequest true "Email address of the account"
+// @Success 		202 {string} json "{"message": "A new link is on its way if the account waits for verification"}"
+// @Failure			400 {string} json "{"error": "Email is required"}"
+// @Failure			500 {string} json "{"error": "Could not send the verification mail"}"
+// @Router 			/user/verify/resend [post]
+func (ctrl *Controller) ResendVerification (c *gin.Context) {
+
+	var request ResendRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Email is required"})
+		return
+	}
+
+	// addresses are stored lower-cased, anything else matches no account
+	email, err := normalizeEmail(request.Email)
+	if err != nil {
+		email = request.Email
+	}
+	user, err := ctrl.store.Users.GetByEmail(email)
+	if err == nil && user.VerifiedAt == nil {
+		if err := ctrl.sendVerification(user); err != nil {
+			log.Error("Could not send the verification mail to user ", user.ID, ": ", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not send the verification mail"})
+			return
+		}
+	} else if err != nil && !errors.Is(err, store.ErrNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not send the verification mail"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "A new link is on its way if the account waits for verification"})
+}
@@ -0,0 +1,294 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mgr1054/go-ticket/pkg/mail"
+	"github.com/mgr1054/go-ticket/pkg/models"
+	"github.com/mgr1054/go-ticket/pkg/store"
+	"github.com/mgr1054/go-ticket/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+type WaitlistRequest struct {
+	// required for events with ticket types
+	TicketTypeID uint		`json:"ticket_type_id" example:"2"`
+	Quantity	int			`json:"quantity" binding:"required,min=1" example:"2"`
+}
+
+// offers the tickets the event has left to its waitlist, failures are only logged
+// because the sweeper offers them again
+func (ctrl *Controller) offerWaitlist(eventID uint) {
+	offers, err := ctrl.store.Waitlist.Offer(eventID, ctrl.clock.Now(), ctrl.cfg.Waitlist.OfferTTL.Duration)
+	if err != nil {
+		log.Error("Waitlist of event ", eventID, " could not be offered: ", err)
+	}
+	ctrl.notifyOffers(offers)
+}
+
+// offers the tickets left at now to the waitlists of all events and mails the
+// offered users, returns the number of new offers; runs with the sweeper
+func (ctrl *Controller) OfferWaitlists(now time.Time) (int64, error) {
+	offers, err := ctrl.store.Waitlist.OfferAll(now, ctrl.cfg.Waitlist.OfferTTL.Duration)
+	ctrl.notifyOffers(offers)
+	return int64(len(offers)), err
+}
+
+// mails every offered user how long the tickets are reserved and how to buy them,
+// failures are only logged because the offer is also listed under GET /waitlist
+func (ctrl *Controller) notifyOffers(offers []models.WaitlistEntry) {
+	for _, offer := range offers {
+		log.Infof("Waitlist entry %d of event %d was offered hold %d", offer.ID, offer.EventID, *offer.HoldID)
+		if err := ctrl.sendOffer(offer); err != nil {
+			log.Error("Could not mail the offer of waitlist entry ", offer.ID, ": ", err)
+		}
+	}
+}
+
+func (ctrl *Controller) sendOffer(offer models.WaitlistEntry) error {
+	user, err := ctrl.store.Users.Get(offer.UserID)
+	if err != nil {
+		return err
+	}
+	event, err := ctrl.store.Events.Get(offer.EventID)
+	if err != nil {
+		return err
+	}
+
+	name := user.Name
+	if name == "" {
+		name = user.Username
+	}
+	return ctrl.mailer.Send(mail.Message{
+		To: user.Email,
+		Subject: "Tickets for " + event.Band_Name + " are waiting for you",
+		Body: fmt.Sprintf("Hello %s,\n\ntickets for %s freed up, %d of them are reserved for you until %s.\n\n"+
+			"Buy them by confirming hold %d with POST /api/secured/holds/%d/confirm, afterwards they are offered to the next "+
+			"person on the waitlist.\n",
+			name, event.Band_Name, offer.Quantity, offer.OfferExpiresAt.UTC().Format(time.RFC1123), *offer.HoldID, *offer.HoldID),
+	})
+}
+
+// shows offers past their expiry as expired, the sweeper settles them only periodically
+func (ctrl *Controller) showOfferExpiry(entries []models.WaitlistEntry) {
+	now := ctrl.clock.Now()
+	for i := range entries {
+		if entries[i].Status == models.WaitlistOffered && entries[i].OfferExpiresAt != nil && !entries[i].OfferExpiresAt.After(now) {
+			entries[i].Status = models.WaitlistExpired
+		}
+	}
+}
+
+// @Summary 		Join Waitlist
+// @Description		Queues the user for tickets of a sold-out event; tickets that free up are offered to the waitlist in the order
+// @Description		it was joined as a hold that expires after WAITLIST_OFFER_TTL, confirm the hold to buy them
+// @Description		allowed: user
+// @ID				join-waitlist
+// @Tags 			waitlist
+// @Accept			json
+// @Produce 		json
+// @Param			entry body WaitlistRequest true "Join Waitlist"
+// @Success 		201 {object} models.WaitlistEntry
+// @Failure			400 {string} json "{"error": "Could not join the waitlist"}"
+// @Failure			400 {string} json "{"error": "Choose a ticket type of the event"}"
+// @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
+// @Failure			404 {string} json "{"error": "Event not found"}"
+// @Failure			404 {string} json "{"error": "Ticket type not found"}"
+// @Failure			409 {string} json "{"error": "You are already on the waitlist of this event"}"
+// @Failure			409 {string} json "{"error": "Tickets are not on sale"}"
+// @Failure			500 {string} json "{"error": "Could not join the waitlist"}"
+// @Router 			/secured/events/{id}/waitlist [post]
+func (ctrl *Controller) JoinWaitlist (c *gin.Context) {
+
+	if err := utils.CheckUserType(c, "user"); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
+		return
+	}
+
+	id, ok := paramID(c, "id")
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+
+	var request WaitlistRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Could not join the waitlist"})
+		return
+	}
+
+	user, err := ctrl.store.Users.GetByUsername(c.GetString("username"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	entry := models.WaitlistEntry{EventID: id, UserID: user.ID, Quantity: request.Quantity}
+	if request.TicketTypeID != 0 {
+		entry.TicketTypeID = &request.TicketTypeID
+	}
+	err = ctrl.store.Waitlist.Join(&entry, ctrl.clock.Now())
+
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	case errors.Is(err, store.ErrTicketTypeNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ticket type not found"})
+		return
+	case errors.Is(err, store.ErrTicketTypeRequired):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Choose a ticket type of the event"})
+		return
+	case errors.Is(err, store.ErrNotOnSale):
+		c.JSON(http.StatusConflict, gin.H{"error": "Tickets are not on sale"})
+		return
+	case errors.Is(err, store.ErrDuplicate):
+		c.JSON(http.StatusConflict, gin.H{"error": "You are already on the waitlist of this event"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not join the waitlist"})
+		return
+	}
+
+	// tickets may have freed up since the user was turned away
+	ctrl.offerWaitlist(id)
+	if joined, err := ctrl.store.Waitlist.Get(entry.ID); err == nil {
+		entry = joined
+	}
+
+	c.JSON(http.StatusCreated, entry)
+}
+
+// @Summary 		Get Waitlist
+// @Description		Gives back all waitlist entries of the user with their position or offer
+// @Description		allowed: user, admin
+// @ID				get-waitlist
+// @Tags 			waitlist
+// @Produce 		json
+// @Success 		200 {object} []models.WaitlistEntry
+// @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
+// @Failure			404 {string} json "{"error": "User not found"}"
+// @Failure			500 {string} json "{"error": "Could not get the waitlist"}"
+// @Router 			/secured/waitlist [get]
+func (ctrl *Controller) GetWaitlist (c *gin.Context) {
+
+	if err := utils.CheckUserType(c, "user"); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
+		return
+	}
+
+	user, err := ctrl.store.Users.GetByUsername(c.GetString("username"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	entries, err := ctrl.store.Waitlist.ListByUser(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not get the waitlist"})
+		return
+	}
+
+	if len(entries) < 1 {
+		c.JSON(http.StatusOK, gin.H{"info": "The user is not on any waitlist"})
+		return
+	}
+
+	ctrl.showOfferExpiry(entries)
+	c.JSON(http.StatusOK, entries)
+}
+
+// @Summary 		Get Event Waitlist
+// @Description		Gives back the waitlist of the event in the order it was joined
+// @Description		allowed: admin
+// @ID				get-event-waitlist
+// @Tags 			waitlist
+// @Produce 		json
+// @Success 		200 {object} []models.WaitlistEntry
+// @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
+// @Failure			404 {string} json "{"error": "Event not found"}"
+// @Failure			500 {string} json "{"error": "Could not get the waitlist"}"
+// @Router 			/secured/events/{id}/waitlist [get]
+func (ctrl *Controller) GetEventWaitlist (c *gin.Context) {
+
+	if err := utils.CheckUserType(c, "admin"); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
+		return
+	}
+
+	id, ok := paramID(c, "id")
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+
+	entries, err := ctrl.store.Waitlist.ListByEvent(id)
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not get the waitlist"})
+		return
+	}
+
+	ctrl.showOfferExpiry(entries)
+	c.JSON(http.StatusOK, gin.H{"data": entries})
+}
+
+// @Summary 		Leave Waitlist
+// @Description		Removes a waiting entry from the waitlist, an open offer is released and goes to the next entry
+// @Description		allowed: user
+// @ID				leave-waitlist
+// @Tags 			waitlist
+// @Produce 		json
+// @Success 		200 {string} json "{"message": "Left the waitlist"}"
+// @Failure			401 {string} json "{"error":"Unauthorized for this route"}"
+// @Failure			404 {string} json "{"error": "Waitlist entry not found"}"
+// @Failure			409 {string} json "{"error": "The entry is not on the waitlist anymore"}"
+// @Failure			500 {string} json "{"error": "Could not leave the waitlist"}"
+// @Router 			/secured/waitlist/{id} [delete]
+func (ctrl *Controller) LeaveWaitlist (c *gin.Context) {
+
+	if err := utils.CheckUserType(c, "user"); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
+		return
+	}
+
+	id, ok := paramID(c, "id")
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Waitlist entry not found"})
+		return
+	}
+
+	entry, err := ctrl.store.Waitlist.Get(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Waitlist entry not found"})
+		return
+	}
+
+	if !ctrl.ownsOrIsAdmin(c, entry.UserID) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error":"Unauthorized for this route"})
+		return
+	}
+
+	err = ctrl.store.Waitlist.Leave(id, ctrl.clock.Now())
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Waitlist entry not found"})
+		return
+	case errors.Is(err, store.ErrWaitlistClosed):
+		c.JSON(http.StatusConflict, gin.H{"error": "The entry is not on the waitlist anymore"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not leave the waitlist"})
+		return
+	}
+
+	ctrl.offerWaitlist(entry.EventID)
+	c.JSON(http.StatusOK, gin.H{"message": "Left the waitlist"})
+}
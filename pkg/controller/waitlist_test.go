@@ -0,0 +1,57 @@
+package controller_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mgr1054/go-ticket/pkg/mail"
+	"github.com/mgr1054/go-ticket/pkg/models"
+)
+
+// every user offered tickets by the sweeper is told by mail which hold to confirm
+func TestOfferWaitlistsMailsOfferedUsers(t *testing.T) {
+	s := newTestServer(t)
+	mailer := mail.NewMemory()
+	ctrl := s.controller(mailer)
+	event := s.createEvent(t, 2)
+
+	hold, err := s.store.Holds.Create(s.user.ID, event.ID, 0, 2, "", s.clock.Now(), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	waiting := models.User{Name: "Erika", Username: "erika", Email: "erika@example.com", Password: "hash", Role: "user"}
+	if err := s.store.Users.Create(&waiting); err != nil {
+		t.Fatal(err)
+	}
+	entry := models.WaitlistEntry{EventID: event.ID, UserID: waiting.ID, Quantity: 2}
+	if err := s.store.Waitlist.Join(&entry, s.clock.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	if count, err := ctrl.OfferWaitlists(s.clock.Now()); err != nil || count != 0 {
+		t.Fatalf("OfferWaitlists while sold out = %d, %v, want 0", count, err)
+	}
+	if len(mailer.Messages()) != 0 {
+		t.Fatalf("sent %d mails without an offer", len(mailer.Messages()))
+	}
+
+	if err := s.store.Holds.Release(hold.ID, s.clock.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if count, err := ctrl.OfferWaitlists(s.clock.Now()); err != nil || count != 1 {
+		t.Fatalf("OfferWaitlists = %d, %v, want 1", count, err)
+	}
+	entry, err = s.store.Waitlist.Get(entry.ID)
+	if err != nil || entry.HoldID == nil {
+		t.Fatalf("entry was not offered: %v", err)
+	}
+	message, ok := mailer.Last(waiting.Email)
+	if !ok {
+		t.Fatal("the offered user got no mail")
+	}
+	if confirm := fmt.Sprintf("/api/secured/holds/%d/confirm", *entry.HoldID); !strings.Contains(message.Body, confirm) {
+		t.Errorf("mail does not name %s:\n%s", confirm, message.Body)
+	}
+}
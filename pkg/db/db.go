@@ -2,51 +2,52 @@ package db
 
 import (
 	"fmt"
+	"strings"
 
-	"github.com/mgr1054/go-ticket/pkg/models"
+	"github.com/glebarez/sqlite"
+	"github.com/mgr1054/go-ticket/pkg/config"
 	log "github.com/sirupsen/logrus"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
-const (
-	host     = "database"
-	port     = "5432"
-	user     = "admin"
-	password = "p"
-	dbname   = "postgres"
-	sslmode  = "disable"
-)
-
-var DB *gorm.DB
-
-func Connect() {
+// opens the Postgres database, the schema is managed by the migrations
+func Connect(cfg config.Database) *gorm.DB {
 
-	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s", host, port, user, password, dbname, sslmode)
-	log.Info("Using DSN for DB:", dsn)
+	dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s", cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.Name, cfg.SSLMode)
+	log.Infof("Using Postgres DB %s on %s:%d", cfg.Name, cfg.Host, cfg.Port)
 
 	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
 	if err != nil {
 		log.Fatalln(err)
 	}
 
-	err = db.AutoMigrate(&models.Event{})
-	if err != nil {
-		log.Fatalln(err)
+	return db
+}
+
+// opens a SQLite database file with foreign keys enforced
+func ConnectSQLite(path string) *gorm.DB {
+
+	log.Info("Using SQLite file for DB:", path)
+
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
 	}
-	log.Info("Event migrated to DB")
+	dsn := path + separator + "_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)"
 
-	err = db.AutoMigrate(&models.Ticket{})
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
 	if err != nil {
 		log.Fatalln(err)
 	}
-	log.Info("Ticket migrated to DB")
 
-	err = db.AutoMigrate(&models.User{})
+	// SQLite allows only one writer, a single connection serializes
+	// transactions instead of failing them with SQLITE_BUSY
+	sqlDB, err := db.DB()
 	if err != nil {
 		log.Fatalln(err)
 	}
-	log.Info("User migrated to DB")
+	sqlDB.SetMaxOpenConns(1)
 
-	DB = db
+	return db
 }
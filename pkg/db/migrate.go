@@ -0,0 +1,296 @@
+package db
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// one directory per dialect, files are named <version>_<name>.<up|down>.sql
+//go:embed migrations
+var migrationFiles embed.FS
+
+type Migration struct {
+	Version	int
+	Name	string
+	Up		string
+	Down	string
+}
+
+// state of a migration as shown by migrate status
+type MigrationStatus struct {
+	Migration
+	AppliedAt	*time.Time
+}
+
+// row of the schema_migrations table
+type schemaMigration struct {
+	Version		int			`gorm:"primaryKey;autoIncrement:false"`
+	Name		string
+	AppliedAt	time.Time
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// reads the embedded migrations for the dialect ordered by version
+func loadMigrations(dialect string) ([]Migration, error) {
+	dir := path.Join("migrations", dialect)
+	entries, err := fs.ReadDir(migrationFiles, dir)
+	if err != nil {
+		return nil, fmt.Errorf("no migrations for dialect %s", dialect)
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		name := entry.Name()
+		var direction string
+		switch {
+		case strings.HasSuffix(name, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(name, ".down.sql"):
+			direction = "down"
+		default:
+			continue
+		}
+
+		base := strings.TrimSuffix(name, "."+direction+".sql")
+		parts := strings.SplitN(base, "_", 2)
+		version, err := strconv.Atoi(parts[0])
+		if err != nil || len(parts) != 2 {
+			return nil, fmt.Errorf("invalid migration file name %s", name)
+		}
+
+		content, err := fs.ReadFile(migrationFiles, path.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: parts[1]}
+			byVersion[version] = m
+		}
+		if direction == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %04d_%s needs an up and a down file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+func appliedMigrations(db *gorm.DB) (map[int]schemaMigration, error) {
+	if err := db.AutoMigrate(&schemaMigration{}); err != nil {
+		return nil, err
+	}
+
+	var rows []schemaMigration
+	if err := db.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	applied := map[int]schemaMigration{}
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+	return applied, nil
+}
+
+// runs fn with foreign key enforcement disabled on SQLite, which is required
+// to rebuild tables that are referenced by others; the pragma cannot change
+// inside a transaction and relies on the single SQLite connection
+func withoutForeignKeys(db *gorm.DB, fn func() error) error {
+	if db.Dialector.Name() != "sqlite" {
+		return fn()
+	}
+	if err := db.Exec("PRAGMA foreign_keys = OFF").Error; err != nil {
+		return err
+	}
+	defer db.Exec("PRAGMA foreign_keys = ON")
+	return fn()
+}
+
+// tables migrations move rows to instead of deleting them, by version
+var quarantineTables = map[int]string{2: "tickets_orphaned"}
+
+// warns about the rows a migration moved to its quarantine table
+func reportQuarantine(db *gorm.DB, version int) error {
+	table, ok := quarantineTables[version]
+	if !ok {
+		return nil
+	}
+	var ids []uint
+	if err := db.Table(table).Order("id").Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+	if len(ids) > 0 {
+		log.Warnf("Migration %04d moved %d rows that violate its constraints to %s, ids %v", version, len(ids), table, ids)
+	}
+	return nil
+}
+
+// rows a migration cannot apply to, listed by a query that returns one value per
+// conflict; the migration stops with them before it changes anything
+type conflictCheck struct {
+	query	string
+	problem	string
+}
+
+var conflictChecks = map[int]conflictCheck{
+	24: {
+		query: "SELECT lower(trim(email)) FROM users WHERE email IS NOT NULL GROUP BY lower(trim(email)) HAVING count(*) > 1 ORDER BY 1",
+		problem: "users share email addresses that differ only in case, merge them first",
+	},
+}
+
+// fails with the conflicting rows of the migration
+func checkConflicts(tx *gorm.DB, version int) error {
+	check, ok := conflictChecks[version]
+	if !ok {
+		return nil
+	}
+	var conflicts []string
+	if err := tx.Raw(check.query).Scan(&conflicts).Error; err != nil {
+		return err
+	}
+	if len(conflicts) > 0 {
+		return fmt.Errorf("%s: %v", check.problem, conflicts)
+	}
+	return nil
+}
+
+// applies all pending migrations in order, each inside its own transaction
+func MigrateUp(db *gorm.DB) error {
+	migrations, err := loadMigrations(db.Dialector.Name())
+	if err != nil {
+		return err
+	}
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return err
+	}
+
+	return withoutForeignKeys(db, func() error {
+		for _, m := range migrations {
+			if _, ok := applied[m.Version]; ok {
+				continue
+			}
+
+			err := db.Transaction(func(tx *gorm.DB) error {
+				if err := checkConflicts(tx, m.Version); err != nil {
+					return err
+				}
+				if err := tx.Exec(m.Up).Error; err != nil {
+					return err
+				}
+				if err := checkForeignKeys(tx); err != nil {
+					return err
+				}
+				return tx.Create(&schemaMigration{Version: m.Version, Name: m.Name, AppliedAt: time.Now()}).Error
+			})
+			if err != nil {
+				return fmt.Errorf("migration %04d_%s failed: %w", m.Version, m.Name, err)
+			}
+			log.Infof("Applied migration %04d_%s", m.Version, m.Name)
+			if err := reportQuarantine(db, m.Version); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// rolls back the given number of most recently applied migrations
+func MigrateDown(db *gorm.DB, steps int) error {
+	migrations, err := loadMigrations(db.Dialector.Name())
+	if err != nil {
+		return err
+	}
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return err
+	}
+
+	return withoutForeignKeys(db, func() error {
+		for i := len(migrations) - 1; i >= 0 && steps > 0; i-- {
+			m := migrations[i]
+			if _, ok := applied[m.Version]; !ok {
+				continue
+			}
+
+			err := db.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Exec(m.Down).Error; err != nil {
+					return err
+				}
+				if err := checkForeignKeys(tx); err != nil {
+					return err
+				}
+				return tx.Delete(&schemaMigration{Version: m.Version}).Error
+			})
+			if err != nil {
+				return fmt.Errorf("rollback of %04d_%s failed: %w", m.Version, m.Name, err)
+			}
+			log.Infof("Rolled back migration %04d_%s", m.Version, m.Name)
+			steps--
+		}
+		return nil
+	})
+}
+
+// SQLite does not validate foreign keys while they are disabled,
+// so violations introduced by a migration are checked explicitly
+func checkForeignKeys(tx *gorm.DB) error {
+	if tx.Dialector.Name() != "sqlite" {
+		return nil
+	}
+	var violations []map[string]interface{}
+	if err := tx.Raw("PRAGMA foreign_key_check").Scan(&violations).Error; err != nil {
+		return err
+	}
+	if len(violations) > 0 {
+		return fmt.Errorf("%d foreign key violations", len(violations))
+	}
+	return nil
+}
+
+// lists all known migrations and when they were applied
+func GetMigrationStatus(db *gorm.DB) ([]MigrationStatus, error) {
+	migrations, err := loadMigrations(db.Dialector.Name())
+	if err != nil {
+		return nil, err
+	}
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	status := make([]MigrationStatus, 0, len(migrations))
+	for _, m := range migrations {
+		s := MigrationStatus{Migration: m}
+		if row, ok := applied[m.Version]; ok {
+			appliedAt := row.AppliedAt
+			s.AppliedAt = &appliedAt
+		}
+		status = append(status, s)
+	}
+	return status, nil
+}
@@ -0,0 +1,165 @@
+package db
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// tickets of deleted users or events are kept in tickets_orphaned instead of being
+// deleted when the foreign keys are added, rolling back restores them
+func TestTicketForeignKeysQuarantineOrphans(t *testing.T) {
+	log.SetLevel(log.WarnLevel)
+	gormDB := ConnectSQLite(filepath.Join(t.TempDir(), "go-ticket.db")).Session(&gorm.Session{Logger: logger.Discard})
+	sqlDB, err := gormDB.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlDB.Close()
+
+	migrations, err := loadMigrations(gormDB.Dialector.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := MigrateUp(gormDB); err != nil {
+		t.Fatal(err)
+	}
+	if err := MigrateDown(gormDB, len(migrations)-1); err != nil {
+		t.Fatal(err)
+	}
+
+	statements := []string{
+		"INSERT INTO users (id, username, email) VALUES (1, 'mgr', 'mgr@example.com')",
+		"INSERT INTO events (id, band_name, location, price, capacity, date) VALUES (1, 'Band', 'Hall', '25', 10, '2030-03-01')",
+		"INSERT INTO tickets (id, user_id, event_id, price) VALUES (1, 1, 1, '25')",
+		"INSERT INTO tickets (id, user_id, event_id, price) VALUES (2, 7, 1, '25')",
+		"INSERT INTO tickets (id, user_id, event_id, price) VALUES (3, 1, NULL, '25')",
+	}
+	for _, statement := range statements {
+		if err := gormDB.Exec(statement).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := MigrateUp(gormDB); err != nil {
+		t.Fatal(err)
+	}
+
+	var kept, orphaned []uint
+	if err := gormDB.Table("tickets").Order("id").Pluck("id", &kept).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := gormDB.Table("tickets_orphaned").Order("id").Pluck("id", &orphaned).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(kept) != 1 || kept[0] != 1 || len(orphaned) != 2 || orphaned[0] != 2 || orphaned[1] != 3 {
+		t.Fatalf("tickets %v and orphaned %v, want [1] and [2 3]", kept, orphaned)
+	}
+
+	if err := MigrateDown(gormDB, len(migrations)-1); err != nil {
+		t.Fatal(err)
+	}
+	var restored []uint
+	if err := gormDB.Table("tickets").Order("id").Pluck("id", &restored).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(restored) != 3 {
+		t.Errorf("rolled back tickets %v, want [1 2 3]", restored)
+	}
+	if gormDB.Migrator().HasTable("tickets_orphaned") {
+		t.Error("tickets_orphaned still exists after the rollback")
+	}
+}
+
+// users, events and orders with tickets cannot be deleted, the financial rows stay
+func TestFinancialRowsRestrictDeletes(t *testing.T) {
+	log.SetLevel(log.WarnLevel)
+	gormDB := ConnectSQLite(filepath.Join(t.TempDir(), "go-ticket.db")).Session(&gorm.Session{Logger: logger.Discard})
+	sqlDB, err := gormDB.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlDB.Close()
+
+	if err := MigrateUp(gormDB); err != nil {
+		t.Fatal(err)
+	}
+
+	statements := []string{
+		"INSERT INTO users (id, username, email) VALUES (1, 'mgr', 'mgr@example.com')",
+		"INSERT INTO venues (id, name, timezone, default_capacity) VALUES (1, 'Hall', 'UTC', 10)",
+		"INSERT INTO events (id, band_name, location, price_amount, price_currency, capacity, venue_id) VALUES (1, 'Band', 'Hall', 2500, 'EUR', 10, 1)",
+		"INSERT INTO orders (id, user_id, status) VALUES (1, 1, 'completed')",
+		"INSERT INTO payments (id, order_id, provider, amount, currency, status) VALUES (1, 1, 'mock', 2500, 'EUR', 'succeeded')",
+		"INSERT INTO tickets (id, user_id, event_id, order_id, code) VALUES (1, 1, 1, 1, 'code')",
+		"INSERT INTO refunds (id, ticket_id, payment_id, amount, currency, status, reason) VALUES (1, 1, 1, 2500, 'EUR', 'succeeded', 'manual')",
+	}
+	for _, statement := range statements {
+		if err := gormDB.Exec(statement).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, table := range []string{"users", "events", "orders", "payments", "tickets"} {
+		if err := gormDB.Exec("DELETE FROM " + table + " WHERE id = 1").Error; err == nil {
+			t.Errorf("deleting from %s succeeded, want a foreign key violation", table)
+		}
+	}
+	refunds := int64(0)
+	if err := gormDB.Table("refunds").Count(&refunds).Error; err != nil {
+		t.Fatal(err)
+	}
+	if refunds != 1 {
+		t.Errorf("%d refunds left, want 1", refunds)
+	}
+}
+
+// addresses are lower-cased unless accounts differ only in their case, then the
+// migration stops and names them
+func TestLowercaseEmailsStopsOnCollisions(t *testing.T) {
+	log.SetLevel(log.WarnLevel)
+	gormDB := ConnectSQLite(filepath.Join(t.TempDir(), "go-ticket.db")).Session(&gorm.Session{Logger: logger.Discard})
+	sqlDB, err := gormDB.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlDB.Close()
+
+	if err := MigrateUp(gormDB); err != nil {
+		t.Fatal(err)
+	}
+	if err := MigrateDown(gormDB, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	statements := []string{
+		"INSERT INTO users (id, username, email) VALUES (1, 'max', 'Max@Example.com')",
+		"INSERT INTO users (id, username, email) VALUES (2, 'maxi', 'max@example.com')",
+		"INSERT INTO users (id, username, email) VALUES (3, 'eva', ' Eva@Example.com')",
+	}
+	for _, statement := range statements {
+		if err := gormDB.Exec(statement).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := MigrateUp(gormDB); err == nil || !strings.Contains(err.Error(), "max@example.com") {
+		t.Fatalf("MigrateUp = %v, want the colliding address", err)
+	}
+
+	if err := gormDB.Exec("DELETE FROM users WHERE id = 2").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := MigrateUp(gormDB); err != nil {
+		t.Fatal(err)
+	}
+	var emails []string
+	if err := gormDB.Table("users").Order("id").Pluck("email", &emails).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(emails) != 2 || emails[0] != "max@example.com" || emails[1] != "eva@example.com" {
+		t.Errorf("emails %q, want lower-cased max@example.com and eva@example.com", emails)
+	}
+}
@@ -0,0 +1,26 @@
+-- matches the tables previously created by gorm AutoMigrate,
+-- so existing databases are adopted without changes
+CREATE TABLE IF NOT EXISTS events (
+    id bigserial PRIMARY KEY,
+    band_name text,
+    location text,
+    price text,
+    capacity bigint,
+    date text
+);
+
+CREATE TABLE IF NOT EXISTS tickets (
+    id bigserial PRIMARY KEY,
+    user_id bigint,
+    event_id bigint,
+    price text
+);
+
+CREATE TABLE IF NOT EXISTS users (
+    id bigserial PRIMARY KEY,
+    name text,
+    username text UNIQUE,
+    email text UNIQUE,
+    password text,
+    role text
+);
@@ -0,0 +1,13 @@
+DROP INDEX IF EXISTS idx_tickets_event_id;
+DROP INDEX IF EXISTS idx_tickets_user_id;
+
+ALTER TABLE tickets
+    DROP CONSTRAINT IF EXISTS fk_tickets_event,
+    DROP CONSTRAINT IF EXISTS fk_tickets_user,
+    ALTER COLUMN event_id DROP NOT NULL,
+    ALTER COLUMN user_id DROP NOT NULL;
+
+-- the quarantined tickets return
+INSERT INTO tickets (id, user_id, event_id, price)
+SELECT id, user_id, event_id, price FROM tickets_orphaned;
+DROP TABLE tickets_orphaned;
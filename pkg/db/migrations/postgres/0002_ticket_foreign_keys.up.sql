@@ -0,0 +1,25 @@
+-- tickets of already deleted users or events would violate the new constraints, they are
+-- moved to tickets_orphaned and kept there until an operator resolves them
+CREATE TABLE tickets_orphaned (
+    id bigint PRIMARY KEY,
+    user_id bigint,
+    event_id bigint,
+    price text
+);
+
+INSERT INTO tickets_orphaned (id, user_id, event_id, price)
+SELECT id, user_id, event_id, price FROM tickets
+WHERE user_id IS NULL OR event_id IS NULL
+    OR user_id NOT IN (SELECT id FROM users)
+    OR event_id NOT IN (SELECT id FROM events);
+
+DELETE FROM tickets WHERE id IN (SELECT id FROM tickets_orphaned);
+
+ALTER TABLE tickets
+    ALTER COLUMN user_id SET NOT NULL,
+    ALTER COLUMN event_id SET NOT NULL,
+    ADD CONSTRAINT fk_tickets_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    ADD CONSTRAINT fk_tickets_event FOREIGN KEY (event_id) REFERENCES events (id) ON DELETE CASCADE;
+
+CREATE INDEX idx_tickets_user_id ON tickets (user_id);
+CREATE INDEX idx_tickets_event_id ON tickets (event_id);
@@ -0,0 +1,14 @@
+CREATE TABLE orders (
+    id bigserial PRIMARY KEY,
+    user_id bigint NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    status text NOT NULL,
+    total text NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_orders_user_id ON orders (user_id);
+
+ALTER TABLE tickets
+    ADD COLUMN order_id bigint REFERENCES orders (id) ON DELETE CASCADE;
+
+CREATE INDEX idx_tickets_order_id ON tickets (order_id);
@@ -0,0 +1,13 @@
+CREATE TABLE holds (
+    id bigserial PRIMARY KEY,
+    user_id bigint NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    event_id bigint NOT NULL REFERENCES events (id) ON DELETE CASCADE,
+    quantity bigint NOT NULL CHECK (quantity > 0),
+    status text NOT NULL,
+    expires_at timestamptz NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    order_id bigint REFERENCES orders (id) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_holds_user_id ON holds (user_id);
+CREATE INDEX idx_holds_event_status_expiry ON holds (event_id, status, expires_at);
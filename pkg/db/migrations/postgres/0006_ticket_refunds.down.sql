@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS refund_policies;
+DROP TABLE IF EXISTS refunds;
+DROP INDEX IF EXISTS idx_tickets_event_status;
+ALTER TABLE tickets
+    DROP COLUMN IF EXISTS cancelled_at,
+    DROP COLUMN IF EXISTS status;
@@ -0,0 +1,28 @@
+ALTER TABLE tickets
+    ADD COLUMN status text NOT NULL DEFAULT 'issued',
+    ADD COLUMN cancelled_at timestamptz;
+
+CREATE INDEX idx_tickets_event_status ON tickets (event_id, status);
+
+CREATE TABLE refunds (
+    id bigserial PRIMARY KEY,
+    ticket_id bigint NOT NULL REFERENCES tickets (id) ON DELETE CASCADE,
+    payment_id bigint REFERENCES payments (id) ON DELETE SET NULL,
+    amount bigint NOT NULL CHECK (amount >= 0),
+    currency text NOT NULL,
+    status text NOT NULL,
+    reason text NOT NULL,
+    note text NOT NULL DEFAULT '',
+    provider_refund_id text NOT NULL DEFAULT '',
+    created_at timestamptz NOT NULL DEFAULT now(),
+    updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_refunds_ticket_id ON refunds (ticket_id);
+
+CREATE TABLE refund_policies (
+    event_id bigint PRIMARY KEY REFERENCES events (id) ON DELETE CASCADE,
+    full_refund_days integer NOT NULL,
+    partial_refund_days integer NOT NULL CHECK (partial_refund_days >= 0),
+    partial_refund_percent integer NOT NULL CHECK (partial_refund_percent BETWEEN 0 AND 100)
+);
@@ -0,0 +1,13 @@
+-- amounts are written back with two decimals, the currency is dropped
+ALTER TABLE events ADD COLUMN price text;
+UPDATE events SET price = (price_amount / 100) || '.' || lpad((price_amount % 100)::text, 2, '0');
+ALTER TABLE events DROP COLUMN price_amount, DROP COLUMN price_currency;
+
+ALTER TABLE tickets ADD COLUMN price text;
+UPDATE tickets SET price = (price_amount / 100) || '.' || lpad((price_amount % 100)::text, 2, '0');
+ALTER TABLE tickets DROP COLUMN price_amount, DROP COLUMN price_currency;
+
+ALTER TABLE orders ADD COLUMN total text NOT NULL DEFAULT '0.00';
+UPDATE orders SET total = (total_amount / 100) || '.' || lpad((total_amount % 100)::text, 2, '0');
+ALTER TABLE orders ALTER COLUMN total DROP DEFAULT;
+ALTER TABLE orders DROP COLUMN total_amount, DROP COLUMN total_currency;
@@ -0,0 +1,34 @@
+-- prices become integer minor units with an ISO 4217 currency, existing prices
+-- like "55" or "55.50" are converted as EUR; any other value leaves the amount
+-- NULL and fails the migration, so such rows have to be fixed by hand first
+ALTER TABLE events
+    ADD COLUMN price_amount bigint NOT NULL DEFAULT 0 CHECK (price_amount >= 0),
+    ADD COLUMN price_currency text NOT NULL DEFAULT 'EUR';
+
+UPDATE events SET price_amount = CASE
+    WHEN trim(price) ~ '^[0-9]+(\.[0-9]{0,2})?$' THEN (trim(price)::numeric * 100)::bigint
+END;
+
+ALTER TABLE events DROP COLUMN price;
+
+ALTER TABLE tickets
+    ADD COLUMN price_amount bigint NOT NULL DEFAULT 0 CHECK (price_amount >= 0),
+    ADD COLUMN price_currency text NOT NULL DEFAULT 'EUR';
+
+-- tickets without a readable price cost what their event costs
+UPDATE tickets SET price_amount = CASE
+    WHEN trim(price) ~ '^[0-9]+(\.[0-9]{0,2})?$' THEN (trim(price)::numeric * 100)::bigint
+    ELSE (SELECT events.price_amount FROM events WHERE events.id = tickets.event_id)
+END;
+
+ALTER TABLE tickets DROP COLUMN price;
+
+ALTER TABLE orders
+    ADD COLUMN total_amount bigint NOT NULL DEFAULT 0 CHECK (total_amount >= 0),
+    ADD COLUMN total_currency text NOT NULL DEFAULT 'EUR';
+
+UPDATE orders SET total_amount = CASE
+    WHEN trim(total) ~ '^[0-9]+(\.[0-9]{0,2})?$' THEN (trim(total)::numeric * 100)::bigint
+END;
+
+ALTER TABLE orders DROP COLUMN total;
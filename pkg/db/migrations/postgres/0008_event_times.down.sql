@@ -0,0 +1,13 @@
+-- only the local start date is kept
+DROP INDEX IF EXISTS idx_events_starts_local;
+DROP INDEX IF EXISTS idx_events_starts_at;
+
+ALTER TABLE events ADD COLUMN date text;
+UPDATE events SET date = left(starts_local, 10);
+
+ALTER TABLE events
+    DROP COLUMN timezone,
+    DROP COLUMN starts_at,
+    DROP COLUMN ends_at,
+    DROP COLUMN doors_open_at,
+    DROP COLUMN starts_local;
@@ -0,0 +1,20 @@
+-- events get start, end and door-open times and the timezone of the venue; the
+-- former dates become events starting at midnight UTC, which is how they were
+-- compared before, and lasting that day; invalid dates fail the migration
+ALTER TABLE events
+    ADD COLUMN timezone text NOT NULL DEFAULT 'UTC',
+    ADD COLUMN starts_at timestamptz NOT NULL DEFAULT 'epoch',
+    ADD COLUMN ends_at timestamptz NOT NULL DEFAULT 'epoch',
+    ADD COLUMN doors_open_at timestamptz NOT NULL DEFAULT 'epoch',
+    ADD COLUMN starts_local text NOT NULL DEFAULT '';
+
+UPDATE events SET
+    starts_at = CASE WHEN trim(date) ~ '^\d{4}-\d{2}-\d{2}$' THEN trim(date)::date::timestamp AT TIME ZONE 'UTC' END,
+    ends_at = CASE WHEN trim(date) ~ '^\d{4}-\d{2}-\d{2}$' THEN (trim(date)::date + 1)::timestamp AT TIME ZONE 'UTC' END,
+    doors_open_at = CASE WHEN trim(date) ~ '^\d{4}-\d{2}-\d{2}$' THEN trim(date)::date::timestamp AT TIME ZONE 'UTC' END,
+    starts_local = trim(date) || 'T00:00:00';
+
+ALTER TABLE events DROP COLUMN date;
+
+CREATE INDEX idx_events_starts_at ON events (starts_at);
+CREATE INDEX idx_events_starts_local ON events (starts_local);
@@ -0,0 +1,38 @@
+-- venues become their own table with sections; every distinct location of the
+-- existing events, compared without case and surrounding spaces, becomes a venue
+-- that takes over the timezone and largest capacity of its events
+CREATE TABLE venues (
+    id bigserial PRIMARY KEY,
+    name text NOT NULL,
+    address text NOT NULL DEFAULT '',
+    latitude double precision CHECK (latitude BETWEEN -90 AND 90),
+    longitude double precision CHECK (longitude BETWEEN -180 AND 180),
+    timezone text NOT NULL,
+    default_capacity integer NOT NULL CHECK (default_capacity >= 0)
+);
+
+CREATE UNIQUE INDEX idx_venues_name ON venues (lower(name));
+
+CREATE TABLE venue_sections (
+    id bigserial PRIMARY KEY,
+    venue_id bigint NOT NULL REFERENCES venues (id) ON DELETE CASCADE,
+    name text NOT NULL,
+    capacity integer NOT NULL CHECK (capacity > 0)
+);
+
+CREATE UNIQUE INDEX idx_venue_sections_name ON venue_sections (venue_id, lower(name));
+
+INSERT INTO venues (name, timezone, default_capacity)
+SELECT min(coalesce(nullif(trim(location), ''), 'Unknown')), max(timezone), max(capacity)
+FROM events
+GROUP BY lower(coalesce(nullif(trim(location), ''), 'Unknown'));
+
+ALTER TABLE events ADD COLUMN venue_id bigint REFERENCES venues (id) ON DELETE RESTRICT;
+
+UPDATE events SET venue_id = venues.id, location = venues.name
+FROM venues
+WHERE lower(venues.name) = lower(coalesce(nullif(trim(events.location), ''), 'Unknown'));
+
+ALTER TABLE events ALTER COLUMN venue_id SET NOT NULL;
+
+CREATE INDEX idx_events_venue_id ON events (venue_id);
@@ -0,0 +1,26 @@
+-- seat maps of venue sections and the seat inventory of every event, copied from the
+-- map of its venue; an issued ticket holds its seat, so no seat can be sold twice
+CREATE TABLE seats (
+    id bigserial PRIMARY KEY,
+    section_id bigint NOT NULL REFERENCES venue_sections (id) ON DELETE CASCADE,
+    row_label text NOT NULL,
+    number text NOT NULL,
+    accessible boolean NOT NULL DEFAULT false
+);
+
+CREATE UNIQUE INDEX idx_seats_label ON seats (section_id, lower(row_label), lower(number));
+
+CREATE TABLE event_seats (
+    id bigserial PRIMARY KEY,
+    event_id bigint NOT NULL REFERENCES events (id) ON DELETE CASCADE,
+    section text NOT NULL,
+    row_label text NOT NULL,
+    number text NOT NULL,
+    accessible boolean NOT NULL DEFAULT false
+);
+
+CREATE INDEX idx_event_seats_event_id ON event_seats (event_id);
+
+ALTER TABLE tickets ADD COLUMN seat_id bigint REFERENCES event_seats (id) ON DELETE SET NULL;
+
+CREATE UNIQUE INDEX idx_tickets_issued_seat ON tickets (seat_id) WHERE status = 'issued';
@@ -0,0 +1,21 @@
+-- tiers of an event with their own price, quota and sale window; tickets and holds
+-- remember the tier they were created in
+CREATE TABLE ticket_types (
+    id bigserial PRIMARY KEY,
+    event_id bigint NOT NULL REFERENCES events (id) ON DELETE CASCADE,
+    name text NOT NULL,
+    price_amount bigint NOT NULL CHECK (price_amount >= 0),
+    price_currency text NOT NULL,
+    quota integer NOT NULL CHECK (quota > 0),
+    sales_start timestamptz,
+    sales_end timestamptz,
+    CHECK (sales_start IS NULL OR sales_end IS NULL OR sales_start < sales_end)
+);
+
+CREATE UNIQUE INDEX idx_ticket_types_name ON ticket_types (event_id, lower(name));
+
+ALTER TABLE tickets ADD COLUMN ticket_type_id bigint REFERENCES ticket_types (id);
+ALTER TABLE holds ADD COLUMN ticket_type_id bigint REFERENCES ticket_types (id);
+
+CREATE INDEX idx_tickets_ticket_type_id ON tickets (ticket_type_id);
+CREATE INDEX idx_holds_ticket_type_id ON holds (ticket_type_id);
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_holds_presale_code_id;
+DROP INDEX IF EXISTS idx_orders_presale_code_id;
+ALTER TABLE holds DROP COLUMN presale_code_id;
+ALTER TABLE orders DROP COLUMN presale_code_id;
+DROP TABLE IF EXISTS presale_codes;
+ALTER TABLE events DROP CONSTRAINT IF EXISTS events_sales_window;
+ALTER TABLE events DROP COLUMN sales_end;
+ALTER TABLE events DROP COLUMN sales_start;
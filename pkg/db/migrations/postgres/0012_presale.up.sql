@@ -0,0 +1,26 @@
+-- sale windows of events and presale codes that unlock tickets before the general
+-- sale; orders and holds remember the code they were bought with
+ALTER TABLE events ADD COLUMN sales_start timestamptz;
+ALTER TABLE events ADD COLUMN sales_end timestamptz;
+ALTER TABLE events ADD CONSTRAINT events_sales_window CHECK (sales_start IS NULL OR sales_end IS NULL OR sales_start < sales_end);
+
+CREATE TABLE presale_codes (
+    id bigserial PRIMARY KEY,
+    event_id bigint NOT NULL REFERENCES events (id) ON DELETE CASCADE,
+    ticket_type_id bigint REFERENCES ticket_types (id) ON DELETE CASCADE,
+    code text NOT NULL,
+    max_uses integer NOT NULL CHECK (max_uses > 0),
+    valid_from timestamptz,
+    valid_until timestamptz,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    CHECK (valid_from IS NULL OR valid_until IS NULL OR valid_from < valid_until)
+);
+
+CREATE UNIQUE INDEX idx_presale_codes_code ON presale_codes (event_id, code);
+CREATE INDEX idx_presale_codes_ticket_type_id ON presale_codes (ticket_type_id);
+
+ALTER TABLE orders ADD COLUMN presale_code_id bigint REFERENCES presale_codes (id) ON DELETE SET NULL;
+ALTER TABLE holds ADD COLUMN presale_code_id bigint REFERENCES presale_codes (id) ON DELETE SET NULL;
+
+CREATE INDEX idx_orders_presale_code_id ON orders (presale_code_id);
+CREATE INDEX idx_holds_presale_code_id ON holds (presale_code_id);
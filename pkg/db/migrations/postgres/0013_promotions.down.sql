@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_orders_promotion_id;
+ALTER TABLE orders DROP COLUMN promotion_id;
+ALTER TABLE orders DROP COLUMN discount_currency;
+ALTER TABLE orders DROP COLUMN discount_amount;
+ALTER TABLE tickets DROP COLUMN discount_currency;
+ALTER TABLE tickets DROP COLUMN discount_amount;
+DROP TABLE IF EXISTS promotions;
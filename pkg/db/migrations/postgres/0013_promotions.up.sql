@@ -0,0 +1,31 @@
+-- promo codes taking a percentage or a fixed amount off every ticket; tickets and
+-- orders record the discount next to their net price, existing ones have none
+CREATE TABLE promotions (
+    id bigserial PRIMARY KEY,
+    code text NOT NULL,
+    kind text NOT NULL CHECK (kind IN ('percent', 'fixed')),
+    percent integer NOT NULL DEFAULT 0 CHECK (percent BETWEEN 0 AND 100),
+    amount_off_amount bigint NOT NULL DEFAULT 0 CHECK (amount_off_amount >= 0),
+    amount_off_currency text NOT NULL DEFAULT '',
+    event_id bigint REFERENCES events (id) ON DELETE CASCADE,
+    max_uses integer NOT NULL DEFAULT 0 CHECK (max_uses >= 0),
+    max_uses_per_user integer NOT NULL DEFAULT 0 CHECK (max_uses_per_user >= 0),
+    valid_from timestamptz,
+    valid_until timestamptz,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    CHECK (valid_from IS NULL OR valid_until IS NULL OR valid_from < valid_until)
+);
+
+CREATE UNIQUE INDEX idx_promotions_code ON promotions (code);
+CREATE INDEX idx_promotions_event_id ON promotions (event_id);
+
+ALTER TABLE tickets ADD COLUMN discount_amount bigint NOT NULL DEFAULT 0 CHECK (discount_amount >= 0);
+ALTER TABLE tickets ADD COLUMN discount_currency text NOT NULL DEFAULT '';
+UPDATE tickets SET discount_currency = price_currency;
+
+ALTER TABLE orders ADD COLUMN discount_amount bigint NOT NULL DEFAULT 0 CHECK (discount_amount >= 0);
+ALTER TABLE orders ADD COLUMN discount_currency text NOT NULL DEFAULT '';
+UPDATE orders SET discount_currency = total_currency;
+
+ALTER TABLE orders ADD COLUMN promotion_id bigint REFERENCES promotions (id) ON DELETE SET NULL;
+CREATE INDEX idx_orders_promotion_id ON orders (promotion_id);
@@ -0,0 +1,20 @@
+-- waitlists of sold-out events, the tickets that free up are offered to the
+-- entries in the order they joined as holds that expire like any other hold
+CREATE TABLE waitlist_entries (
+    id bigserial PRIMARY KEY,
+    event_id bigint NOT NULL REFERENCES events (id) ON DELETE CASCADE,
+    user_id bigint NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    ticket_type_id bigint REFERENCES ticket_types (id) ON DELETE CASCADE,
+    quantity integer NOT NULL CHECK (quantity > 0),
+    status text NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    hold_id bigint REFERENCES holds (id) ON DELETE SET NULL,
+    offer_expires_at timestamptz
+);
+
+CREATE INDEX idx_waitlist_entries_event_status ON waitlist_entries (event_id, status, id);
+CREATE INDEX idx_waitlist_entries_user_id ON waitlist_entries (user_id);
+CREATE INDEX idx_waitlist_entries_ticket_type_id ON waitlist_entries (ticket_type_id);
+CREATE INDEX idx_waitlist_entries_hold_id ON waitlist_entries (hold_id);
+-- a user waits at most once per event
+CREATE UNIQUE INDEX idx_waitlist_entries_open ON waitlist_entries (event_id, user_id) WHERE status IN ('waiting', 'offered');
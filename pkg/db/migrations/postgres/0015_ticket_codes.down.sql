@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_tickets_code;
+ALTER TABLE tickets DROP COLUMN code;
@@ -0,0 +1,9 @@
+-- secret entry codes that the signed entry passes of tickets carry,
+-- existing tickets get a random code
+CREATE EXTENSION IF NOT EXISTS pgcrypto;
+
+ALTER TABLE tickets ADD COLUMN code text;
+UPDATE tickets SET code = encode(gen_random_bytes(16), 'hex');
+ALTER TABLE tickets ALTER COLUMN code SET NOT NULL;
+
+CREATE UNIQUE INDEX idx_tickets_code ON tickets (code);
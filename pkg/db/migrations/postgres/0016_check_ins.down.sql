@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS check_ins;
+ALTER TABLE tickets DROP COLUMN inside;
+ALTER TABLE tickets DROP COLUMN admitted_at;
+ALTER TABLE events DROP COLUMN reentry_policy;
@@ -0,0 +1,7 @@
+-- scans uploaded from the offline log of scanners: scans keep their direction so the
+-- scans of a ticket can be replayed in the order they happened
+ALTER TABLE check_ins ADD COLUMN direction text NOT NULL DEFAULT 'in';
+UPDATE check_ins SET direction = 'out' WHERE result = 'exited';
+ALTER TABLE check_ins ADD COLUMN offline boolean NOT NULL DEFAULT false;
+
+CREATE INDEX idx_check_ins_ticket_scanned_at ON check_ins (ticket_id, scanned_at, id);
@@ -0,0 +1,39 @@
+-- fan-to-fan resale of tickets at or below face value, the buyer pays through an
+-- order of the listing and the seller gets a payout once the payment succeeded
+CREATE TABLE listings (
+    id bigserial PRIMARY KEY,
+    ticket_id bigint NOT NULL REFERENCES tickets (id) ON DELETE CASCADE,
+    event_id bigint NOT NULL REFERENCES events (id) ON DELETE CASCADE,
+    seller_id bigint NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    price_amount bigint NOT NULL CHECK (price_amount >= 0),
+    price_currency text NOT NULL,
+    face_value_amount bigint NOT NULL,
+    face_value_currency text NOT NULL,
+    status text NOT NULL,
+    order_id bigint REFERENCES orders (id) ON DELETE SET NULL,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    closed_at timestamptz,
+    CHECK (price_amount <= face_value_amount)
+);
+
+CREATE INDEX idx_listings_event_status ON listings (event_id, status, price_amount);
+CREATE INDEX idx_listings_seller_id ON listings (seller_id);
+CREATE INDEX idx_listings_order_id ON listings (order_id);
+-- a ticket is listed at most once at a time
+CREATE UNIQUE INDEX idx_listings_open ON listings (ticket_id) WHERE status IN ('active', 'reserved');
+
+ALTER TABLE orders ADD COLUMN listing_id bigint REFERENCES listings (id) ON DELETE SET NULL;
+
+CREATE TABLE payouts (
+    id bigserial PRIMARY KEY,
+    listing_id bigint NOT NULL REFERENCES listings (id) ON DELETE CASCADE,
+    user_id bigint NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    order_id bigint NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    amount_amount bigint NOT NULL,
+    amount_currency text NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX idx_payouts_listing_id ON payouts (listing_id);
+CREATE INDEX idx_payouts_user_id ON payouts (user_id);
+CREATE INDEX idx_payouts_order_id ON payouts (order_id);
@@ -0,0 +1,25 @@
+-- rotating refresh tokens of logins, the tokens descending from one login form a
+-- family that is revoked as a whole on logout or when a used token comes back
+CREATE TABLE refresh_tokens (
+    id bigserial PRIMARY KEY,
+    user_id bigint NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    family_id text NOT NULL,
+    token_hash text NOT NULL UNIQUE,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    expires_at timestamptz NOT NULL,
+    used_at timestamptz,
+    revoked_at timestamptz
+);
+
+CREATE INDEX idx_refresh_tokens_user_id ON refresh_tokens (user_id);
+CREATE INDEX idx_refresh_tokens_family_id ON refresh_tokens (family_id);
+CREATE INDEX idx_refresh_tokens_expires_at ON refresh_tokens (expires_at);
+
+-- revocation list checked on every request, entries outlive the access tokens of their family
+CREATE TABLE token_revocations (
+    family_id text PRIMARY KEY,
+    revoked_at timestamptz NOT NULL,
+    expires_at timestamptz NOT NULL
+);
+
+CREATE INDEX idx_token_revocations_expires_at ON token_revocations (expires_at);
@@ -0,0 +1,14 @@
+-- key pairs that sign access tokens, the public keys are published as JWKS and the
+-- private keys are stored encrypted with the jwt secret
+CREATE TABLE signing_keys (
+    id text PRIMARY KEY,
+    algorithm text NOT NULL,
+    private_key bytea NOT NULL,
+    public_key bytea NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    activates_at timestamptz NOT NULL,
+    retires_at timestamptz NOT NULL,
+    expires_at timestamptz NOT NULL
+);
+
+CREATE INDEX idx_signing_keys_expires_at ON signing_keys (expires_at);
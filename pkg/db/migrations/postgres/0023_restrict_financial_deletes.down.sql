@@ -0,0 +1,29 @@
+ALTER TABLE tickets
+    DROP CONSTRAINT fk_tickets_user,
+    DROP CONSTRAINT fk_tickets_event,
+    DROP CONSTRAINT tickets_order_id_fkey,
+    ADD CONSTRAINT fk_tickets_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    ADD CONSTRAINT fk_tickets_event FOREIGN KEY (event_id) REFERENCES events (id) ON DELETE CASCADE,
+    ADD CONSTRAINT tickets_order_id_fkey FOREIGN KEY (order_id) REFERENCES orders (id) ON DELETE CASCADE;
+
+ALTER TABLE orders
+    DROP CONSTRAINT orders_user_id_fkey,
+    ADD CONSTRAINT orders_user_id_fkey FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE;
+
+ALTER TABLE payments
+    DROP CONSTRAINT payments_order_id_fkey,
+    ADD CONSTRAINT payments_order_id_fkey FOREIGN KEY (order_id) REFERENCES orders (id) ON DELETE CASCADE;
+
+ALTER TABLE refunds
+    DROP CONSTRAINT refunds_ticket_id_fkey,
+    DROP CONSTRAINT refunds_payment_id_fkey,
+    ADD CONSTRAINT refunds_ticket_id_fkey FOREIGN KEY (ticket_id) REFERENCES tickets (id) ON DELETE CASCADE,
+    ADD CONSTRAINT refunds_payment_id_fkey FOREIGN KEY (payment_id) REFERENCES payments (id) ON DELETE SET NULL;
+
+ALTER TABLE payouts
+    DROP CONSTRAINT payouts_listing_id_fkey,
+    DROP CONSTRAINT payouts_user_id_fkey,
+    DROP CONSTRAINT payouts_order_id_fkey,
+    ADD CONSTRAINT payouts_listing_id_fkey FOREIGN KEY (listing_id) REFERENCES listings (id) ON DELETE CASCADE,
+    ADD CONSTRAINT payouts_user_id_fkey FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    ADD CONSTRAINT payouts_order_id_fkey FOREIGN KEY (order_id) REFERENCES orders (id) ON DELETE CASCADE;
@@ -0,0 +1,31 @@
+-- tickets, orders, payments, refunds and payouts are the financial record and must not
+-- disappear with a user or an event
+ALTER TABLE tickets
+    DROP CONSTRAINT fk_tickets_user,
+    DROP CONSTRAINT fk_tickets_event,
+    DROP CONSTRAINT tickets_order_id_fkey,
+    ADD CONSTRAINT fk_tickets_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE RESTRICT,
+    ADD CONSTRAINT fk_tickets_event FOREIGN KEY (event_id) REFERENCES events (id) ON DELETE RESTRICT,
+    ADD CONSTRAINT tickets_order_id_fkey FOREIGN KEY (order_id) REFERENCES orders (id) ON DELETE RESTRICT;
+
+ALTER TABLE orders
+    DROP CONSTRAINT orders_user_id_fkey,
+    ADD CONSTRAINT orders_user_id_fkey FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE RESTRICT;
+
+ALTER TABLE payments
+    DROP CONSTRAINT payments_order_id_fkey,
+    ADD CONSTRAINT payments_order_id_fkey FOREIGN KEY (order_id) REFERENCES orders (id) ON DELETE RESTRICT;
+
+ALTER TABLE refunds
+    DROP CONSTRAINT refunds_ticket_id_fkey,
+    DROP CONSTRAINT refunds_payment_id_fkey,
+    ADD CONSTRAINT refunds_ticket_id_fkey FOREIGN KEY (ticket_id) REFERENCES tickets (id) ON DELETE RESTRICT,
+    ADD CONSTRAINT refunds_payment_id_fkey FOREIGN KEY (payment_id) REFERENCES payments (id) ON DELETE RESTRICT;
+
+ALTER TABLE payouts
+    DROP CONSTRAINT payouts_listing_id_fkey,
+    DROP CONSTRAINT payouts_user_id_fkey,
+    DROP CONSTRAINT payouts_order_id_fkey,
+    ADD CONSTRAINT payouts_listing_id_fkey FOREIGN KEY (listing_id) REFERENCES listings (id) ON DELETE RESTRICT,
+    ADD CONSTRAINT payouts_user_id_fkey FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE RESTRICT,
+    ADD CONSTRAINT payouts_order_id_fkey FOREIGN KEY (order_id) REFERENCES orders (id) ON DELETE RESTRICT;
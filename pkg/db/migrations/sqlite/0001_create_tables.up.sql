@@ -0,0 +1,26 @@
+-- matches the tables previously created by gorm AutoMigrate,
+-- so existing databases are adopted without changes
+CREATE TABLE IF NOT EXISTS events (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    band_name text,
+    location text,
+    price text,
+    capacity integer,
+    date text
+);
+
+CREATE TABLE IF NOT EXISTS tickets (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    user_id integer,
+    event_id integer,
+    price text
+);
+
+CREATE TABLE IF NOT EXISTS users (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    name text,
+    username text UNIQUE,
+    email text UNIQUE,
+    password text,
+    role text
+);
@@ -0,0 +1,17 @@
+CREATE TABLE tickets_old (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    user_id integer,
+    event_id integer,
+    price text
+);
+
+INSERT INTO tickets_old (id, user_id, event_id, price)
+SELECT id, user_id, event_id, price FROM tickets;
+
+-- the quarantined tickets return
+INSERT INTO tickets_old (id, user_id, event_id, price)
+SELECT id, user_id, event_id, price FROM tickets_orphaned;
+DROP TABLE tickets_orphaned;
+
+DROP TABLE tickets;
+ALTER TABLE tickets_old RENAME TO tickets;
@@ -0,0 +1,33 @@
+-- SQLite cannot add constraints to an existing table, so tickets is rebuilt; tickets of
+-- already deleted users or events would violate the new constraints, they are moved to
+-- tickets_orphaned and kept there until an operator resolves them
+CREATE TABLE tickets_orphaned (
+    id integer PRIMARY KEY,
+    user_id integer,
+    event_id integer,
+    price text
+);
+
+INSERT INTO tickets_orphaned (id, user_id, event_id, price)
+SELECT id, user_id, event_id, price FROM tickets
+WHERE user_id IS NULL OR event_id IS NULL
+    OR user_id NOT IN (SELECT id FROM users)
+    OR event_id NOT IN (SELECT id FROM events);
+
+DELETE FROM tickets WHERE id IN (SELECT id FROM tickets_orphaned);
+
+CREATE TABLE tickets_new (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    user_id integer NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    event_id integer NOT NULL REFERENCES events (id) ON DELETE CASCADE,
+    price text
+);
+
+INSERT INTO tickets_new (id, user_id, event_id, price)
+SELECT id, user_id, event_id, price FROM tickets;
+
+DROP TABLE tickets;
+ALTER TABLE tickets_new RENAME TO tickets;
+
+CREATE INDEX idx_tickets_user_id ON tickets (user_id);
+CREATE INDEX idx_tickets_event_id ON tickets (event_id);
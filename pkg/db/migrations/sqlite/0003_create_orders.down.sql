@@ -0,0 +1,18 @@
+-- SQLite cannot drop a column with a foreign key, so tickets is rebuilt
+CREATE TABLE tickets_old (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    user_id integer NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    event_id integer NOT NULL REFERENCES events (id) ON DELETE CASCADE,
+    price text
+);
+
+INSERT INTO tickets_old (id, user_id, event_id, price)
+SELECT id, user_id, event_id, price FROM tickets;
+
+DROP TABLE tickets;
+ALTER TABLE tickets_old RENAME TO tickets;
+
+CREATE INDEX idx_tickets_user_id ON tickets (user_id);
+CREATE INDEX idx_tickets_event_id ON tickets (event_id);
+
+DROP TABLE IF EXISTS orders;
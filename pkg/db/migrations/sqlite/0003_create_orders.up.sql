@@ -0,0 +1,14 @@
+CREATE TABLE orders (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    user_id integer NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    status text NOT NULL,
+    total text NOT NULL,
+    created_at datetime NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_orders_user_id ON orders (user_id);
+
+ALTER TABLE tickets
+    ADD COLUMN order_id integer REFERENCES orders (id) ON DELETE CASCADE;
+
+CREATE INDEX idx_tickets_order_id ON tickets (order_id);
@@ -0,0 +1,13 @@
+CREATE TABLE holds (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    user_id integer NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    event_id integer NOT NULL REFERENCES events (id) ON DELETE CASCADE,
+    quantity integer NOT NULL CHECK (quantity > 0),
+    status text NOT NULL,
+    expires_at datetime NOT NULL,
+    created_at datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    order_id integer REFERENCES orders (id) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_holds_user_id ON holds (user_id);
+CREATE INDEX idx_holds_event_status_expiry ON holds (event_id, status, expires_at);
@@ -0,0 +1,15 @@
+CREATE TABLE payments (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    order_id integer NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    provider text NOT NULL,
+    intent_id text NOT NULL DEFAULT '',
+    amount integer NOT NULL,
+    currency text NOT NULL,
+    status text NOT NULL,
+    created_at datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at datetime NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_payments_order_id ON payments (order_id);
+CREATE INDEX idx_payments_intent ON payments (provider, intent_id);
+CREATE INDEX idx_payments_status_created_at ON payments (status, created_at);
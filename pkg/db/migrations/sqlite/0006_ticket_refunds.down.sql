@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS refund_policies;
+DROP TABLE IF EXISTS refunds;
+DROP INDEX IF EXISTS idx_tickets_event_status;
+ALTER TABLE tickets DROP COLUMN cancelled_at;
+ALTER TABLE tickets DROP COLUMN status;
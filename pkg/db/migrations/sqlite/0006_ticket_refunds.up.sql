@@ -0,0 +1,27 @@
+ALTER TABLE tickets ADD COLUMN status text NOT NULL DEFAULT 'issued';
+ALTER TABLE tickets ADD COLUMN cancelled_at datetime;
+
+CREATE INDEX idx_tickets_event_status ON tickets (event_id, status);
+
+CREATE TABLE refunds (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    ticket_id integer NOT NULL REFERENCES tickets (id) ON DELETE CASCADE,
+    payment_id integer REFERENCES payments (id) ON DELETE SET NULL,
+    amount integer NOT NULL CHECK (amount >= 0),
+    currency text NOT NULL,
+    status text NOT NULL,
+    reason text NOT NULL,
+    note text NOT NULL DEFAULT '',
+    provider_refund_id text NOT NULL DEFAULT '',
+    created_at datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at datetime NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_refunds_ticket_id ON refunds (ticket_id);
+
+CREATE TABLE refund_policies (
+    event_id integer PRIMARY KEY REFERENCES events (id) ON DELETE CASCADE,
+    full_refund_days integer NOT NULL,
+    partial_refund_days integer NOT NULL CHECK (partial_refund_days >= 0),
+    partial_refund_percent integer NOT NULL CHECK (partial_refund_percent BETWEEN 0 AND 100)
+);
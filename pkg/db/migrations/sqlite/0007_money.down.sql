@@ -0,0 +1,15 @@
+-- amounts are written back with two decimals, the currency is dropped
+ALTER TABLE events ADD COLUMN price text;
+UPDATE events SET price = (price_amount / 100) || '.' || substr('0' || (price_amount % 100), -2);
+ALTER TABLE events DROP COLUMN price_amount;
+ALTER TABLE events DROP COLUMN price_currency;
+
+ALTER TABLE tickets ADD COLUMN price text;
+UPDATE tickets SET price = (price_amount / 100) || '.' || substr('0' || (price_amount % 100), -2);
+ALTER TABLE tickets DROP COLUMN price_amount;
+ALTER TABLE tickets DROP COLUMN price_currency;
+
+ALTER TABLE orders ADD COLUMN total text NOT NULL DEFAULT '0.00';
+UPDATE orders SET total = (total_amount / 100) || '.' || substr('0' || (total_amount % 100), -2);
+ALTER TABLE orders DROP COLUMN total_amount;
+ALTER TABLE orders DROP COLUMN total_currency;
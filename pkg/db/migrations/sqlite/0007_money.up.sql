@@ -0,0 +1,40 @@
+-- prices become integer minor units with an ISO 4217 currency, existing prices
+-- like "55" or "55.50" are converted as EUR; any other value leaves the amount
+-- NULL and fails the migration, so such rows have to be fixed by hand first
+ALTER TABLE events ADD COLUMN price_amount integer NOT NULL DEFAULT 0 CHECK (price_amount >= 0);
+ALTER TABLE events ADD COLUMN price_currency text NOT NULL DEFAULT 'EUR';
+
+UPDATE events SET price_amount = CASE
+    WHEN trim(price) <> '' AND trim(price) NOT GLOB '*[^0-9.]*' AND trim(price) NOT GLOB '.*' AND trim(price) NOT GLOB '*.*.*'
+        AND (instr(trim(price), '.') = 0 OR length(trim(price)) - instr(trim(price), '.') <= 2)
+    THEN CAST(substr(trim(price), 1, instr(trim(price) || '.', '.') - 1) AS INTEGER) * 100
+        + CAST(substr(substr(trim(price), instr(trim(price) || '.', '.') + 1) || '00', 1, 2) AS INTEGER)
+END;
+
+ALTER TABLE events DROP COLUMN price;
+
+ALTER TABLE tickets ADD COLUMN price_amount integer NOT NULL DEFAULT 0 CHECK (price_amount >= 0);
+ALTER TABLE tickets ADD COLUMN price_currency text NOT NULL DEFAULT 'EUR';
+
+-- tickets without a readable price cost what their event costs
+UPDATE tickets SET price_amount = CASE
+    WHEN trim(price) <> '' AND trim(price) NOT GLOB '*[^0-9.]*' AND trim(price) NOT GLOB '.*' AND trim(price) NOT GLOB '*.*.*'
+        AND (instr(trim(price), '.') = 0 OR length(trim(price)) - instr(trim(price), '.') <= 2)
+    THEN CAST(substr(trim(price), 1, instr(trim(price) || '.', '.') - 1) AS INTEGER) * 100
+        + CAST(substr(substr(trim(price), instr(trim(price) || '.', '.') + 1) || '00', 1, 2) AS INTEGER)
+    ELSE (SELECT events.price_amount FROM events WHERE events.id = tickets.event_id)
+END;
+
+ALTER TABLE tickets DROP COLUMN price;
+
+ALTER TABLE orders ADD COLUMN total_amount integer NOT NULL DEFAULT 0 CHECK (total_amount >= 0);
+ALTER TABLE orders ADD COLUMN total_currency text NOT NULL DEFAULT 'EUR';
+
+UPDATE orders SET total_amount = CASE
+    WHEN trim(total) <> '' AND trim(total) NOT GLOB '*[^0-9.]*' AND trim(total) NOT GLOB '.*' AND trim(total) NOT GLOB '*.*.*'
+        AND (instr(trim(total), '.') = 0 OR length(trim(total)) - instr(trim(total), '.') <= 2)
+    THEN CAST(substr(trim(total), 1, instr(trim(total) || '.', '.') - 1) AS INTEGER) * 100
+        + CAST(substr(substr(trim(total), instr(trim(total) || '.', '.') + 1) || '00', 1, 2) AS INTEGER)
+END;
+
+ALTER TABLE orders DROP COLUMN total;
@@ -0,0 +1,12 @@
+-- only the local start date is kept
+DROP INDEX IF EXISTS idx_events_starts_local;
+DROP INDEX IF EXISTS idx_events_starts_at;
+
+ALTER TABLE events ADD COLUMN date text;
+UPDATE events SET date = substr(starts_local, 1, 10);
+
+ALTER TABLE events DROP COLUMN timezone;
+ALTER TABLE events DROP COLUMN starts_at;
+ALTER TABLE events DROP COLUMN ends_at;
+ALTER TABLE events DROP COLUMN doors_open_at;
+ALTER TABLE events DROP COLUMN starts_local;
@@ -0,0 +1,19 @@
+-- events get start, end and door-open times and the timezone of the venue; the
+-- former dates become events starting at midnight UTC, which is how they were
+-- compared before, and lasting that day; invalid dates fail the migration
+ALTER TABLE events ADD COLUMN timezone text NOT NULL DEFAULT 'UTC';
+ALTER TABLE events ADD COLUMN starts_at datetime NOT NULL DEFAULT '1970-01-01 00:00:00+00:00';
+ALTER TABLE events ADD COLUMN ends_at datetime NOT NULL DEFAULT '1970-01-01 00:00:00+00:00';
+ALTER TABLE events ADD COLUMN doors_open_at datetime NOT NULL DEFAULT '1970-01-01 00:00:00+00:00';
+ALTER TABLE events ADD COLUMN starts_local text NOT NULL DEFAULT '';
+
+UPDATE events SET
+    starts_at = CASE WHEN date(trim(date)) = trim(date) THEN trim(date) || ' 00:00:00+00:00' END,
+    ends_at = CASE WHEN date(trim(date)) = trim(date) THEN date(trim(date), '+1 day') || ' 00:00:00+00:00' END,
+    doors_open_at = CASE WHEN date(trim(date)) = trim(date) THEN trim(date) || ' 00:00:00+00:00' END,
+    starts_local = trim(date) || 'T00:00:00';
+
+ALTER TABLE events DROP COLUMN date;
+
+CREATE INDEX idx_events_starts_at ON events (starts_at);
+CREATE INDEX idx_events_starts_local ON events (starts_local);
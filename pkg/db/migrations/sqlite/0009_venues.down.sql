@@ -0,0 +1,5 @@
+-- events keep the venue name as their location
+DROP INDEX IF EXISTS idx_events_venue_id;
+ALTER TABLE events DROP COLUMN venue_id;
+DROP TABLE IF EXISTS venue_sections;
+DROP TABLE IF EXISTS venues;
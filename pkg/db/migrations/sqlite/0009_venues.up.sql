@@ -0,0 +1,38 @@
+-- venues become their own table with sections; every distinct location of the
+-- existing events, compared without case and surrounding spaces, becomes a venue
+-- that takes over the timezone and largest capacity of its events
+CREATE TABLE venues (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    name text NOT NULL,
+    address text NOT NULL DEFAULT '',
+    latitude real CHECK (latitude BETWEEN -90 AND 90),
+    longitude real CHECK (longitude BETWEEN -180 AND 180),
+    timezone text NOT NULL,
+    default_capacity integer NOT NULL CHECK (default_capacity >= 0)
+);
+
+CREATE UNIQUE INDEX idx_venues_name ON venues (lower(name));
+
+CREATE TABLE venue_sections (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    venue_id integer NOT NULL REFERENCES venues (id) ON DELETE CASCADE,
+    name text NOT NULL,
+    capacity integer NOT NULL CHECK (capacity > 0)
+);
+
+CREATE UNIQUE INDEX idx_venue_sections_name ON venue_sections (venue_id, lower(name));
+
+INSERT INTO venues (name, timezone, default_capacity)
+SELECT min(coalesce(nullif(trim(location), ''), 'Unknown')), max(timezone), max(capacity)
+FROM events
+GROUP BY lower(coalesce(nullif(trim(location), ''), 'Unknown'));
+
+-- sqlite cannot add a NOT NULL column referencing another table, the
+-- store always sets it
+ALTER TABLE events ADD COLUMN venue_id integer REFERENCES venues (id) ON DELETE RESTRICT;
+
+UPDATE events SET
+    venue_id = (SELECT id FROM venues WHERE lower(venues.name) = lower(coalesce(nullif(trim(events.location), ''), 'Unknown'))),
+    location = (SELECT name FROM venues WHERE lower(venues.name) = lower(coalesce(nullif(trim(events.location), ''), 'Unknown')));
+
+CREATE INDEX idx_events_venue_id ON events (venue_id);
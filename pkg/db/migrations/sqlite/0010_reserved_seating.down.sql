@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_tickets_issued_seat;
+ALTER TABLE tickets DROP COLUMN seat_id;
+DROP TABLE IF EXISTS event_seats;
+DROP TABLE IF EXISTS seats;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_holds_ticket_type_id;
+DROP INDEX IF EXISTS idx_tickets_ticket_type_id;
+ALTER TABLE holds DROP COLUMN ticket_type_id;
+ALTER TABLE tickets DROP COLUMN ticket_type_id;
+DROP TABLE IF EXISTS ticket_types;
@@ -0,0 +1,25 @@
+-- sale windows of events and presale codes that unlock tickets before the general
+-- sale; orders and holds remember the code they were bought with
+ALTER TABLE events ADD COLUMN sales_start datetime;
+ALTER TABLE events ADD COLUMN sales_end datetime;
+
+CREATE TABLE presale_codes (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    event_id integer NOT NULL REFERENCES events (id) ON DELETE CASCADE,
+    ticket_type_id integer REFERENCES ticket_types (id) ON DELETE CASCADE,
+    code text NOT NULL,
+    max_uses integer NOT NULL CHECK (max_uses > 0),
+    valid_from datetime,
+    valid_until datetime,
+    created_at datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (valid_from IS NULL OR valid_until IS NULL OR valid_from < valid_until)
+);
+
+CREATE UNIQUE INDEX idx_presale_codes_code ON presale_codes (event_id, code);
+CREATE INDEX idx_presale_codes_ticket_type_id ON presale_codes (ticket_type_id);
+
+ALTER TABLE orders ADD COLUMN presale_code_id integer REFERENCES presale_codes (id) ON DELETE SET NULL;
+ALTER TABLE holds ADD COLUMN presale_code_id integer REFERENCES presale_codes (id) ON DELETE SET NULL;
+
+CREATE INDEX idx_orders_presale_code_id ON orders (presale_code_id);
+CREATE INDEX idx_holds_presale_code_id ON holds (presale_code_id);
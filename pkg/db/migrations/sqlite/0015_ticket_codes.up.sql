@@ -0,0 +1,6 @@
+-- secret entry codes that the signed entry passes of tickets carry,
+-- existing tickets get a random code
+ALTER TABLE tickets ADD COLUMN code text NOT NULL DEFAULT '';
+UPDATE tickets SET code = lower(hex(randomblob(16)));
+
+CREATE UNIQUE INDEX idx_tickets_code ON tickets (code);
@@ -0,0 +1,19 @@
+-- door check-in: every scan of a ticket at a gate is recorded, tickets remember
+-- their first admission and whether they are inside right now
+ALTER TABLE events ADD COLUMN reentry_policy text NOT NULL DEFAULT 'none';
+ALTER TABLE tickets ADD COLUMN admitted_at datetime;
+ALTER TABLE tickets ADD COLUMN inside boolean NOT NULL DEFAULT 0;
+
+CREATE TABLE check_ins (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    ticket_id integer NOT NULL REFERENCES tickets (id) ON DELETE CASCADE,
+    event_id integer NOT NULL REFERENCES events (id) ON DELETE CASCADE,
+    gate text NOT NULL,
+    scanner_id integer REFERENCES users (id) ON DELETE SET NULL,
+    result text NOT NULL,
+    scanned_at datetime NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_check_ins_ticket_id ON check_ins (ticket_id, result, id);
+CREATE INDEX idx_check_ins_event_id ON check_ins (event_id, result);
+CREATE INDEX idx_check_ins_scanner_id ON check_ins (scanner_id);
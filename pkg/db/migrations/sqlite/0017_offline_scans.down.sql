@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_check_ins_ticket_scanned_at;
+ALTER TABLE check_ins DROP COLUMN offline;
+ALTER TABLE check_ins DROP COLUMN direction;
@@ -0,0 +1,19 @@
+-- handovers of tickets between users, the recipient accepts before the transfer
+-- expires and the accepted transfers of a ticket are the chain of its owners
+CREATE TABLE transfers (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    ticket_id integer NOT NULL REFERENCES tickets (id) ON DELETE CASCADE,
+    from_user_id integer NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    to_user_id integer NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    status text NOT NULL,
+    created_at datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at datetime NOT NULL,
+    closed_at datetime
+);
+
+CREATE INDEX idx_transfers_ticket_id ON transfers (ticket_id, id);
+CREATE INDEX idx_transfers_from_user_id ON transfers (from_user_id);
+CREATE INDEX idx_transfers_to_user_id ON transfers (to_user_id);
+CREATE INDEX idx_transfers_status_expires_at ON transfers (status, expires_at);
+-- a ticket is on its way to at most one recipient
+CREATE UNIQUE INDEX idx_transfers_pending ON transfers (ticket_id) WHERE status = 'pending';
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS payouts;
+ALTER TABLE orders DROP COLUMN listing_id;
+DROP TABLE IF EXISTS listings;
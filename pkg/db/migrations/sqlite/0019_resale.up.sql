@@ -0,0 +1,39 @@
+-- fan-to-fan resale of tickets at or below face value, the buyer pays through an
+-- order of the listing and the seller gets a payout once the payment succeeded
+CREATE TABLE listings (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    ticket_id integer NOT NULL REFERENCES tickets (id) ON DELETE CASCADE,
+    event_id integer NOT NULL REFERENCES events (id) ON DELETE CASCADE,
+    seller_id integer NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    price_amount integer NOT NULL CHECK (price_amount >= 0),
+    price_currency text NOT NULL,
+    face_value_amount integer NOT NULL,
+    face_value_currency text NOT NULL,
+    status text NOT NULL,
+    order_id integer REFERENCES orders (id) ON DELETE SET NULL,
+    created_at datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    closed_at datetime,
+    CHECK (price_amount <= face_value_amount)
+);
+
+CREATE INDEX idx_listings_event_status ON listings (event_id, status, price_amount);
+CREATE INDEX idx_listings_seller_id ON listings (seller_id);
+CREATE INDEX idx_listings_order_id ON listings (order_id);
+-- a ticket is listed at most once at a time
+CREATE UNIQUE INDEX idx_listings_open ON listings (ticket_id) WHERE status IN ('active', 'reserved');
+
+ALTER TABLE orders ADD COLUMN listing_id integer REFERENCES listings (id) ON DELETE SET NULL;
+
+CREATE TABLE payouts (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    listing_id integer NOT NULL REFERENCES listings (id) ON DELETE CASCADE,
+    user_id integer NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    order_id integer NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    amount_amount integer NOT NULL,
+    amount_currency text NOT NULL,
+    created_at datetime NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_payouts_listing_id ON payouts (listing_id);
+CREATE INDEX idx_payouts_user_id ON payouts (user_id);
+CREATE INDEX idx_payouts_order_id ON payouts (order_id);
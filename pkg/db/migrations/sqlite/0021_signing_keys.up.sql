@@ -0,0 +1,14 @@
+-- key pairs that sign access tokens, the public keys are published as JWKS and the
+-- private keys are stored encrypted with the jwt secret
+CREATE TABLE signing_keys (
+    id text PRIMARY KEY,
+    algorithm text NOT NULL,
+    private_key blob NOT NULL,
+    public_key blob NOT NULL,
+    created_at datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    activates_at datetime NOT NULL,
+    retires_at datetime NOT NULL,
+    expires_at datetime NOT NULL
+);
+
+CREATE INDEX idx_signing_keys_expires_at ON signing_keys (expires_at);
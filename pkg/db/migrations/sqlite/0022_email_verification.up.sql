@@ -0,0 +1,5 @@
+-- accounts stay pending until the owner of the email address follows the verification
+-- link, the existing accounts count as verified
+ALTER TABLE users ADD COLUMN verified_at datetime;
+
+UPDATE users SET verified_at = CURRENT_TIMESTAMP;
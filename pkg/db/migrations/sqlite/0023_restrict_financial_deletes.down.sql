@@ -0,0 +1,109 @@
+CREATE TABLE tickets_new (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    user_id integer NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    event_id integer NOT NULL REFERENCES events (id) ON DELETE CASCADE,
+    order_id integer REFERENCES orders (id) ON DELETE CASCADE,
+    status text NOT NULL DEFAULT 'issued',
+    cancelled_at datetime,
+    price_amount integer NOT NULL DEFAULT 0 CHECK (price_amount >= 0),
+    price_currency text NOT NULL DEFAULT 'EUR',
+    seat_id integer REFERENCES event_seats (id) ON DELETE SET NULL,
+    ticket_type_id integer REFERENCES ticket_types (id),
+    discount_amount integer NOT NULL DEFAULT 0 CHECK (discount_amount >= 0),
+    discount_currency text NOT NULL DEFAULT '',
+    code text NOT NULL DEFAULT '',
+    admitted_at datetime,
+    inside boolean NOT NULL DEFAULT 0
+);
+
+INSERT INTO tickets_new SELECT * FROM tickets;
+DROP TABLE tickets;
+ALTER TABLE tickets_new RENAME TO tickets;
+
+CREATE INDEX idx_tickets_user_id ON tickets (user_id);
+CREATE INDEX idx_tickets_event_id ON tickets (event_id);
+CREATE INDEX idx_tickets_order_id ON tickets (order_id);
+CREATE INDEX idx_tickets_event_status ON tickets (event_id, status);
+CREATE UNIQUE INDEX idx_tickets_issued_seat ON tickets (seat_id) WHERE status = 'issued';
+CREATE INDEX idx_tickets_ticket_type_id ON tickets (ticket_type_id);
+CREATE UNIQUE INDEX idx_tickets_code ON tickets (code);
+
+CREATE TABLE orders_new (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    user_id integer NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    status text NOT NULL,
+    created_at datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    total_amount integer NOT NULL DEFAULT 0 CHECK (total_amount >= 0),
+    total_currency text NOT NULL DEFAULT 'EUR',
+    presale_code_id integer REFERENCES presale_codes (id) ON DELETE SET NULL,
+    discount_amount integer NOT NULL DEFAULT 0 CHECK (discount_amount >= 0),
+    discount_currency text NOT NULL DEFAULT '',
+    promotion_id integer REFERENCES promotions (id) ON DELETE SET NULL,
+    listing_id integer REFERENCES listings (id) ON DELETE SET NULL
+);
+
+INSERT INTO orders_new SELECT * FROM orders;
+DROP TABLE orders;
+ALTER TABLE orders_new RENAME TO orders;
+
+CREATE INDEX idx_orders_user_id ON orders (user_id);
+CREATE INDEX idx_orders_presale_code_id ON orders (presale_code_id);
+CREATE INDEX idx_orders_promotion_id ON orders (promotion_id);
+
+CREATE TABLE payments_new (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    order_id integer NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    provider text NOT NULL,
+    intent_id text NOT NULL DEFAULT '',
+    amount integer NOT NULL,
+    currency text NOT NULL,
+    status text NOT NULL,
+    created_at datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at datetime NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO payments_new SELECT * FROM payments;
+DROP TABLE payments;
+ALTER TABLE payments_new RENAME TO payments;
+
+CREATE INDEX idx_payments_order_id ON payments (order_id);
+CREATE INDEX idx_payments_intent ON payments (provider, intent_id);
+CREATE INDEX idx_payments_status_created_at ON payments (status, created_at);
+
+CREATE TABLE refunds_new (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    ticket_id integer NOT NULL REFERENCES tickets (id) ON DELETE CASCADE,
+    payment_id integer REFERENCES payments (id) ON DELETE SET NULL,
+    amount integer NOT NULL CHECK (amount >= 0),
+    currency text NOT NULL,
+    status text NOT NULL,
+    reason text NOT NULL,
+    note text NOT NULL DEFAULT '',
+    provider_refund_id text NOT NULL DEFAULT '',
+    created_at datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at datetime NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO refunds_new SELECT * FROM refunds;
+DROP TABLE refunds;
+ALTER TABLE refunds_new RENAME TO refunds;
+
+CREATE INDEX idx_refunds_ticket_id ON refunds (ticket_id);
+
+CREATE TABLE payouts_new (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    listing_id integer NOT NULL REFERENCES listings (id) ON DELETE CASCADE,
+    user_id integer NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    order_id integer NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    amount_amount integer NOT NULL,
+    amount_currency text NOT NULL,
+    created_at datetime NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO payouts_new SELECT * FROM payouts;
+DROP TABLE payouts;
+ALTER TABLE payouts_new RENAME TO payouts;
+
+CREATE UNIQUE INDEX idx_payouts_listing_id ON payouts (listing_id);
+CREATE INDEX idx_payouts_user_id ON payouts (user_id);
+CREATE INDEX idx_payouts_order_id ON payouts (order_id);
@@ -0,0 +1,2 @@
+-- the original case of the addresses is not kept, rolling back leaves them lower-cased
+SELECT 1;
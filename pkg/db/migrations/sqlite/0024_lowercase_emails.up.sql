@@ -0,0 +1,3 @@
+-- addresses are compared lower-cased since registration normalizes them; accounts whose
+-- addresses differ only in case stop the migration until they are merged by hand
+UPDATE users SET email = lower(trim(email)) WHERE email IS NOT NULL AND email <> lower(trim(email));
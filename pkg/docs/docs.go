@@ -42,80 +42,41 @@ const docTemplate = `{
                 }
             }
         },
-        "/secured/events": {
+        "/.well-known/jwks.json": {
             "get": {
-                "description": "Sends Array Of Events\nallowed: user, admin",
+                "description": "Sends the public keys that verify access tokens as a JSON Web Key Set, tokens name their key in the kid header\nKeys are published before they sign, verifiers that meet an unknown kid fetch the set again\nServed at the root, outside of /api\nallowed: everyone",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "events"
+                    "auth"
                 ],
-                "summary": "Get All Events",
-                "operationId": "get-events",
+                "summary": "Get JWKS",
+                "operationId": "get-jwks",
                 "responses": {
                     "200": {
-                        "description": "OK",
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.Event"
-                            }
-                        }
-                    },
-                    "404": {
-                        "description": "{\"error\": \"Could not get events\"}",
+                        "description": "{\"keys\": [{\"kty\": \"OKP\", \"kid\": \"…\", \"use\": \"sig\", \"alg\": \"EdDSA\", \"crv\": \"Ed25519\", \"x\": \"…\"}]}",
                         "schema": {
                             "type": "string"
                         }
                     }
                 }
-            },
-            "post": {
-                "description": "Creates a new Event\nallowed: admin",
-                "consumes": [
-                    "application/json"
-                ],
+            }
+        },
+        "/entry/public-key": {
+            "get": {
+                "description": "Sends the Ed25519 public key that verifies the entry passes in the QR codes of tickets, so scanners can check them offline\nA pass reads GT1.\u003cticket id\u003e.\u003cevent id\u003e.\u003centry code\u003e.\u003csignature\u003e, the signature covers everything before its dot and is unpadded base64url\nallowed: everyone",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "events"
-                ],
-                "summary": "Create Event",
-                "operationId": "create-event",
-                "parameters": [
-                    {
-                        "description": "Create Event",
-                        "name": "event",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/controller.NewEvent"
-                        }
-                    }
+                    "tickets"
                 ],
+                "summary": "Get Entry Public Key",
+                "operationId": "get-entry-public-key",
                 "responses": {
-                    "201": {
-                        "description": "Created",
-                        "schema": {
-                            "$ref": "#/definitions/models.Event"
-                        }
-                    },
-                    "400": {
-                        "description": "{\"error\": \"Could not create Event\"}",
-                        "schema": {
-                            "type": "string"
-                        }
-                    },
-                    "401": {
-                        "description": "{\"error\":\"Unauthorized for this route\"}",
-                        "schema": {
-                            "type": "string"
-                        }
-                    },
-                    "500": {
-                        "description": "{\"error\": \"Could not create Event\"}",
+                    "200": {
+                        "description": "{\"algorithm\": \"Ed25519\", \"public_key\": \"base64\", \"version\": \"GT1\"}",
                         "schema": {
                             "type": "string"
                         }
@@ -123,35 +84,32 @@ const docTemplate = `{
                 }
             }
         },
-        "/secured/events/{date}": {
-            "get": {
-                "description": "Sends Events for a Date\nallowed: user, admin",
+        "/logout": {
+            "post": {
+                "description": "Revokes the token family of the access token, its refresh tokens stop working and\nits access tokens are rejected until they expire\nallowed: user, scanner, admin",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "events"
+                    "auth"
                 ],
-                "summary": "Get Event By Date",
-                "operationId": "get-event-by-date",
+                "summary": "Logout",
+                "operationId": "logout",
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "{\"message\": \"Logged out\"}",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.Event"
-                            }
+                            "type": "string"
                         }
                     },
                     "401": {
-                        "description": "{\"error\":\"Unauthorized for this route\"}",
+                        "description": "{\"error\": \"token was revoked\"}",
                         "schema": {
                             "type": "string"
                         }
                     },
-                    "404": {
-                        "description": "{\"error\": \"Event not found\"}",
+                    "500": {
+                        "description": "{\"error\": \"Could not log out\"}",
                         "schema": {
                             "type": "string"
                         }
@@ -159,57 +117,82 @@ const docTemplate = `{
                 }
             }
         },
-        "/secured/events/{id}": {
-            "get": {
-                "description": "Sends a Event with ID\nallowed: user, admin",
+        "/payments/webhook": {
+            "post": {
+                "description": "Receives payment results from the payment provider, requests must be signed by the provider\nallowed: payment provider",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "events"
+                    "payments"
                 ],
-                "summary": "Get Event By ID",
-                "operationId": "get-event-by-id",
+                "summary": "Payment Webhook",
+                "operationId": "payment-webhook",
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "{\"message\": \"Webhook processed\"}",
                         "schema": {
-                            "$ref": "#/definitions/models.Event"
+                            "type": "string"
                         }
                     },
-                    "401": {
-                        "description": "{\"error\":\"Unauthorized for this route\"}",
+                    "400": {
+                        "description": "{\"error\": \"Invalid webhook\"}",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "404": {
-                        "description": "{\"error\": \"Event not found\"}",
+                        "description": "{\"error\": \"Payment not found\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"error\": \"Could not process webhook\"}",
                         "schema": {
                             "type": "string"
                         }
                     }
                 }
-            },
-            "put": {
-                "description": "Updates Event with given ID\nallowed: admin",
+            }
+        },
+        "/secured/checkin": {
+            "post": {
+                "description": "Scans a ticket at a gate of its event; each ticket is admitted exactly once, also when it is scanned at\nseveral gates at the same time; scanning a ticket that is inside is answered with the time and gate it was admitted at.\nAt events with the re-entry policy scan_out tickets scanned out with direction out are admitted again\nallowed: scanner, admin",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "events"
+                    "check-in"
+                ],
+                "summary": "Check In Ticket",
+                "operationId": "check-in-ticket",
+                "parameters": [
+                    {
+                        "description": "Check In Ticket",
+                        "name": "scan",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controller.CheckInRequest"
+                        }
+                    }
                 ],
-                "summary": "Update Event By ID",
-                "operationId": "update-event-by-id",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Event"
+                            "$ref": "#/definitions/models.CheckIn"
                         }
                     },
                     "400": {
-                        "description": "{\"error\": \"Event could not be updated with provided data\"}",
+                        "description": "{\"error\": \"Could not check in the ticket\"}",
                         "schema": {
                             "type": "string"
                         }
@@ -220,33 +203,67 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
+                    "403": {
+                        "description": "{\"error\": \"Invalid entry pass\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "404": {
-                        "description": "{\"error\": \"Event not found\"}",
+                        "description": "{\"error\": \"Unknown ticket code\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "{\"error\": \"Ticket is not inside the event\"}",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "500": {
-                        "description": "{\"error\": \"Could not update Event\"}",
+                        "description": "{\"error\": \"Could not check in the ticket\"}",
                         "schema": {
                             "type": "string"
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Deletes Event with given ID\nallowed: admin",
+            }
+        },
+        "/secured/checkin/sync": {
+            "post": {
+                "description": "Uploads the scans a scanner made without network. The scans of each ticket are replayed with the ones already recorded\nin the order they happened: the first scan wins and every later scan of a ticket that is inside is flagged as duplicate,\nalso recorded ones (listed in reflagged). Uploading a scan again does not record it twice. Each result is admitted,\nexited, duplicate (with the admission before it) or rejected with an error\nallowed: scanner, admin",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
-                    "text/plain"
+                    "application/json"
                 ],
                 "tags": [
-                    "events"
+                    "check-in"
+                ],
+                "summary": "Sync Offline Scans",
+                "operationId": "sync-offline-scans",
+                "parameters": [
+                    {
+                        "description": "Sync Offline Scans",
+                        "name": "scans",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controller.SyncRequest"
+                        }
+                    }
                 ],
-                "summary": "Delete Event By ID",
-                "operationId": "delete-event-by-id",
                 "responses": {
                     "200": {
-                        "description": "{\"message\": \"Event deleted\"}",
+                        "description": "{\"results\": [{\"result\": \"admitted\", \"check_in\": {}}], \"reflagged\": []}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "{\"error\": \"Could not sync the scans\"}",
                         "schema": {
                             "type": "string"
                         }
@@ -258,13 +275,13 @@ const docTemplate = `{
                         }
                     },
                     "404": {
-                        "description": "{\"error\": \"Event not found!\"}",
+                        "description": "{\"error\": \"Event not found\"}",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "500": {
-                        "description": "{\"error\": \"Could not create Event\"}",
+                        "description": "{\"error\": \"Could not sync the scans\"}",
                         "schema": {
                             "type": "string"
                         }
@@ -272,17 +289,31 @@ const docTemplate = `{
                 }
             }
         },
-        "/secured/events/{location}": {
+        "/secured/events": {
             "get": {
-                "description": "Sends Events for a Location\nallowed: user, admin",
+                "description": "Sends Array Of Events, from and to limit them to events starting in that range ordered by their start.\nDates (2022-10-11) and times without offset (2022-10-11T18:00) are compared with the local time at each venue,\na date as upper bound includes that day; RFC 3339 times with offset are compared as instants\nallowed: user, admin",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "events"
                 ],
-                "summary": "Get Event By Location",
-                "operationId": "get-event-by-location",
+                "summary": "Get All Events",
+                "operationId": "get-events",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Earliest start",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Latest start, exclusive",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -293,47 +324,65 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "401": {
-                        "description": "{\"error\":\"Unauthorized for this route\"}",
+                    "400": {
+                        "description": "{\"error\": \"Invalid range\"}",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "404": {
-                        "description": "{\"error\": \"Event not found\"}",
+                        "description": "{\"error\": \"Could not get events\"}",
                         "schema": {
                             "type": "string"
                         }
                     }
                 }
-            }
-        },
-        "/secured/tickets/events/{id}": {
-            "get": {
-                "description": "Gives back a number of all sold tickets for this event\nallowed: admin",
+            },
+            "post": {
+                "description": "Creates a new Event\nallowed: admin",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "tickets"
+                    "events"
+                ],
+                "summary": "Create Event",
+                "operationId": "create-event",
+                "parameters": [
+                    {
+                        "description": "Create Event",
+                        "name": "event",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controller.NewEvent"
+                        }
+                    }
                 ],
-                "summary": "Get Tickets By EventID",
-                "operationId": "get-tickets-by-event-id",
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
                             "$ref": "#/definitions/models.Event"
                         }
                     },
+                    "400": {
+                        "description": "{\"error\": \"Invalid re-entry policy, use none or scan_out\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "401": {
                         "description": "{\"error\":\"Unauthorized for this route\"}",
                         "schema": {
                             "type": "string"
                         }
                     },
-                    "404": {
-                        "description": "{\"error\": \"Tickets not found\"}",
+                    "500": {
+                        "description": "{\"error\": \"Could not create Event\"}",
                         "schema": {
                             "type": "string"
                         }
@@ -341,38 +390,52 @@ const docTemplate = `{
                 }
             }
         },
-        "/secured/tickets/user/{id}": {
+        "/secured/events/date/{date}": {
             "get": {
-                "description": "Gives back all tickets for user\nallowed: admin",
+                "description": "Sends Events taking place on a local date at their venue, same as /secured/events?from={date}\u0026to={date}\nDeprecated: kept for existing clients, use the from and to query of /secured/events instead\nallowed: user, admin",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "tickets"
+                    "events"
+                ],
+                "summary": "Get Event By Date",
+                "operationId": "get-event-by-date",
+                "deprecated": true,
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "2022-10-11",
+                        "description": "Local date",
+                        "name": "date",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Get Tickets By UserID",
-                "operationId": "get-tickets-by-user-id",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Ticket"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Event"
+                            }
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "400": {
+                        "description": "{\"error\": \"Invalid date\"}",
                         "schema": {
                             "type": "string"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "401": {
+                        "description": "{\"error\":\"Unauthorized for this route\"}",
                         "schema": {
                             "type": "string"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "404": {
+                        "description": "{\"error\": \"Event not found\"}",
                         "schema": {
                             "type": "string"
                         }
@@ -380,22 +443,34 @@ const docTemplate = `{
                 }
             }
         },
-        "/secured/tickets/{id}": {
+        "/secured/events/location/{location}": {
             "get": {
-                "description": "Creates Ticket for EventID, also checks if enough capacity is available\nallowed: user",
+                "description": "Sends Events at the venue with the name location, ignoring case\nallowed: user, admin",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "tickets"
+                    "events"
+                ],
+                "summary": "Get Event By Location",
+                "operationId": "get-event-by-location",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Venue name",
+                        "name": "location",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Create Ticket by EventID",
-                "operationId": "create-ticket",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Ticket"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Event"
+                            }
                         }
                     },
                     "401": {
@@ -405,13 +480,95 @@ const docTemplate = `{
                         }
                     },
                     "404": {
-                        "description": "{\"error\": \"User not found\"}",
+                        "description": "{\"error\": \"Event not found\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/secured/events/{id}": {
+            "get": {
+                "description": "Sends a Event with ID including its ticket types and the tickets available in each\nallowed: user, admin",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "events"
+                ],
+                "summary": "Get Event By ID",
+                "operationId": "get-event-by-id",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Event"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"error\":\"Unauthorized for this route\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "{\"error\": \"Event not found\"}",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "500": {
-                        "description": "{\"error\": \"Could not create Ticket\"}",
+                        "description": "{\"error\": \"Could not get Event\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Updates Event with given ID, times without offset are wall clock times in the (new) timezone of the event\nallowed: admin",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "events"
+                ],
+                "summary": "Update Event By ID",
+                "operationId": "update-event-by-id",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Event"
+                        }
+                    },
+                    "400": {
+                        "description": "{\"error\": \"Capacity must be positive\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"error\":\"Unauthorized for this route\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "{\"error\": \"Event not found\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "{\"error\": \"Capacity is below the tickets already sold or held\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"error\": \"Could not update Event\"}",
                         "schema": {
                             "type": "string"
                         }
@@ -419,18 +576,63 @@ const docTemplate = `{
                 }
             },
             "delete": {
-                "description": "Deletes Ticket by Ticket ID, available up until one week before the event\nallowed: admin, user",
+                "description": "Deletes Event with given ID, events with tickets stay on record and have to be cancelled instead\nallowed: admin",
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "events"
+                ],
+                "summary": "Delete Event By ID",
+                "operationId": "delete-event-by-id",
+                "responses": {
+                    "200": {
+                        "description": "{\"message\": \"Event deleted\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"error\":\"Unauthorized for this route\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "{\"error\": \"Event not found!\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "{\"error\": \"Event has tickets, cancel it instead\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"error\": \"Could not create Event\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/secured/events/{id}/cancel": {
+            "post": {
+                "description": "Ends the ticket sale of the event, takes its resale listings off the marketplace and cancels\nevery issued ticket with a full refund; the event and its tickets stay on record. Tickets\nalready used at the door or reserved by a resale buyer are skipped and reported\nallowed: admin",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "tickets"
+                    "events"
                 ],
-                "summary": "Delete Ticket By ID",
-                "operationId": "delete-tickets-by-user-id",
+                "summary": "Cancel Event By ID",
+                "operationId": "cancel-event-by-id",
                 "responses": {
                     "200": {
-                        "description": "{\"message\": \"Ticket deleted\"}",
+                        "description": "{\"message\": \"Event cancelled\", \"refunds\": [], \"skipped\": []}",
                         "schema": {
                             "type": "string"
                         }
@@ -442,13 +644,13 @@ const docTemplate = `{
                         }
                     },
                     "404": {
-                        "description": "{\"error\": \"Tickets not found\"}",
+                        "description": "{\"error\": \"Event not found!\"}",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "500": {
-                        "description": "{\"error\": \"Could not parse time\"}",
+                        "description": "{\"error\": \"Could not cancel Event\"}",
                         "schema": {
                             "type": "string"
                         }
@@ -456,22 +658,22 @@ const docTemplate = `{
                 }
             }
         },
-        "/secured/user/{id}": {
+        "/secured/events/{id}/checkins": {
             "get": {
-                "description": "Sends a User with ID\nallowed:  admin",
+                "description": "Sends the live number of issued, admitted and inside tickets of the event and the admissions per gate\nallowed: scanner, admin",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "check-in"
                 ],
-                "summary": "Get User By ID",
-                "operationId": "get-user-by-id",
+                "summary": "Get Event Check-In Stats",
+                "operationId": "get-event-check-in-stats",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.User"
+                            "$ref": "#/definitions/models.CheckInStats"
                         }
                     },
                     "401": {
@@ -481,42 +683,48 @@ const docTemplate = `{
                         }
                     },
                     "404": {
-                        "description": "{\"error\": \"User not found\"}",
+                        "description": "{\"error\": \"Event not found\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"error\": \"Could not get the check-ins\"}",
                         "schema": {
                             "type": "string"
                         }
                     }
                 }
-            },
-            "put": {
-                "description": "Updates User with Body and corresponding ID\nallowed:  admin",
-                "consumes": [
-                    "application/json"
-                ],
+            }
+        },
+        "/secured/events/{id}/listings": {
+            "get": {
+                "description": "Gives back the tickets of the event that are for resale, cheapest first\nallowed: user, admin",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "resale"
                 ],
-                "summary": "Update User By ID",
-                "operationId": "update-user-by-id",
+                "summary": "Get Event Listings",
+                "operationId": "get-event-listings",
                 "parameters": [
                     {
-                        "description": "Update User",
-                        "name": "user",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/controller.UserUpdate"
-                        }
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.User"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Listing"
+                            }
                         }
                     },
                     "401": {
@@ -526,28 +734,39 @@ const docTemplate = `{
                         }
                     },
                     "404": {
-                        "description": "{\"error\": \"User not found\"}",
+                        "description": "{\"error\": \"Event not found\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"error\": \"Could not get the listings\"}",
                         "schema": {
                             "type": "string"
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Deltes User with corresponding ID\nallowed:  admin",
+            }
+        },
+        "/secured/events/{id}/presale-codes": {
+            "get": {
+                "description": "Sends the presale codes of the event with the number of times each was used\nallowed: admin",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "presale codes"
                 ],
-                "summary": "Delete User By ID",
-                "operationId": "delete-user-by-id",
+                "summary": "Get Presale Codes",
+                "operationId": "get-presale-codes",
                 "responses": {
                     "200": {
-                        "description": "{\"message\": \"User deleted\"}",
+                        "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.PresaleCode"
+                            }
                         }
                     },
                     "401": {
@@ -557,33 +776,40 @@ const docTemplate = `{
                         }
                     },
                     "404": {
-                        "description": "{\"error\": \"User not found\"}",
+                        "description": "{\"error\": \"Event not found\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"error\": \"Could not get presale codes\"}",
                         "schema": {
                             "type": "string"
                         }
                     }
                 }
-            }
-        },
-        "/token": {
+            },
             "post": {
-                "description": "Generates JWT Token based on given context, checks if username and password match\nEncode JWT with username, email and role\nallowed: unsecured",
+                "description": "Adds a presale code that unlocks tickets of the event before its general sale, every order or hold\nbought with it uses it once; a code with max_uses 1 is single-use\nallowed: admin",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
+                    "presale codes"
                 ],
-                "summary": "Generate Token",
-                "operationId": "generate-token",
+                "summary": "Create Presale Code",
+                "operationId": "create-presale-code",
                 "parameters": [
                     {
-                        "description": "Create Token",
-                        "name": "credentials",
+                        "description": "Create Presale Code",
+                        "name": "presale_code",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/controller.TokenRequest"
+                            "$ref": "#/definitions/controller.PresaleCodeRequest"
                         }
                     }
                 ],
@@ -591,29 +817,35 @@ const docTemplate = `{
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/models.PresaleCode"
                         }
                     },
                     "400": {
-                        "description": "{\"error\": \"Could not create Token\"}",
+                        "description": "{\"error\": \"Invalid presale code\"}",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "401": {
-                        "description": "{\"error\": \"Password incorrect",
+                        "description": "{\"error\":\"Unauthorized for this route\"}",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "404": {
-                        "description": "{\"error\":\"User not found\"}",
+                        "description": "{\"error\": \"Ticket type not found\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "{\"error\": \"The event already has this presale code\"}",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "500": {
-                        "description": "{\"error\":\"Could not create Token\"}",
+                        "description": "{\"error\": \"Could not create presale code\"}",
                         "schema": {
                             "type": "string"
                         }
@@ -621,164 +853,4436 @@ const docTemplate = `{
                 }
             }
         },
-        "/user/register": {
-            "post": {
-                "description": "Creates new User, hashes Password for DB\nrole automatically set to user\nallowed: unsecured",
+        "/secured/events/{id}/refund-policy": {
+            "get": {
+                "description": "Gives back the refund policy of the event, events without a policy of their own use the default policy\nallowed: user, admin",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "refunds"
                 ],
-                "summary": "Register User",
-                "operationId": "register-user",
-                "parameters": [
-                    {
-                        "description": "Create User",
-                        "name": "user",
-                        "in": "body",
-                        "required": true,
+                "summary": "Get Refund Policy",
+                "operationId": "get-refund-policy",
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/controller.UserUpdate"
+                            "$ref": "#/definitions/models.RefundPolicy"
                         }
-                    }
-                ],
-                "responses": {
-                    "201": {
-                        "description": "Created",
+                    },
+                    "401": {
+                        "description": "{\"error\":\"Unauthorized for this route\"}",
                         "schema": {
                             "type": "string"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "404": {
+                        "description": "{\"error\": \"Event not found\"}",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "500": {
-                        "description": "Internal Server Error",
+                        "description": "{\"error\": \"Could not get refund policy\"}",
                         "schema": {
                             "type": "string"
                         }
                     }
                 }
-            }
-        }
-    },
-    "definitions": {
-        "controller.NewEvent": {
-            "type": "object",
-            "required": [
-                "band_name",
-                "capacity",
-                "date",
-                "location",
-                "price"
-            ],
-            "properties": {
-                "band_name": {
-                    "type": "string",
-                    "example": "Deichkind"
-                },
-                "capacity": {
-                    "type": "integer",
-                    "example": 35000
-                },
-                "date": {
+            },
+            "put": {
+                "description": "Sets the refund policy of the event: full refund until full_refund_days before the event,\npartial_refund_percent until partial_refund_days before the event, no refund afterwards\nallowed: admin",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "refunds"
+                ],
+                "summary": "Set Refund Policy",
+                "operationId": "set-refund-policy",
+                "parameters": [
+                    {
+                        "description": "Refund Policy",
+                        "name": "policy",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controller.RefundPolicyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.RefundPolicy"
+                        }
+                    },
+                    "400": {
+                        "description": "{\"error\": \"Invalid refund policy\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"error\":\"Unauthorized for this route\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "{\"error\": \"Event not found\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"error\": \"Could not save refund policy\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/secured/events/{id}/seats": {
+            "get": {
+                "description": "Sends the seats of the event with their availability, available=true only sends the free ones\nallowed: user, admin",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "seats"
+                ],
+                "summary": "Get Event Seats",
+                "operationId": "get-event-seats",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Only free seats",
+                        "name": "available",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.EventSeat"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "{\"error\":\"Unauthorized for this route\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "{\"error\": \"Event not found\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"error\": \"Could not get seats\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Copies the current seat map of the venue into the seat inventory of the event,\nonly possible as long as no seat of the event was sold\nallowed: admin",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "seats"
+                ],
+                "summary": "Reset Event Seats",
+                "operationId": "reset-event-seats",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.EventSeat"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "{\"error\":\"Unauthorized for this route\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "{\"error\": \"Event not found\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "{\"error\": \"Seats of the event are already sold\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"error\": \"Could not reset seats\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/secured/events/{id}/snapshot": {
+            "get": {
+                "description": "Sends a signed snapshot of the entry codes of all tickets that admit at the event, so scanners keep admitting\ntickets without network. The snapshot reads GTS1.\u003cpayload\u003e.\u003csignature\u003e, the payload is the gzip compressed JSON\n{\"event_id\", \"taken_at\", \"reentry\", \"codes\", \"used\"} in unpadded base64url and is signed with the entry pass key\nallowed: scanner, admin",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "check-in"
+                ],
+                "summary": "Get Scanner Snapshot",
+                "operationId": "get-scanner-snapshot",
+                "responses": {
+                    "200": {
+                        "description": "{\"event_id\": 1, \"taken_at\": \"2022-10-11T17:00:00Z\", \"tickets\": 35000, \"snapshot\": \"GTS1.payload.signature\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"error\":\"Unauthorized for this route\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "{\"error\": \"Event not found\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"error\": \"Could not create the snapshot\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/secured/events/{id}/ticket-types": {
+            "get": {
+                "description": "Sends the ticket types of the event with the number of tickets still available in each\nallowed: user, admin",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ticket types"
+                ],
+                "summary": "Get Ticket Types",
+                "operationId": "get-ticket-types",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.TicketType"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "{\"error\":\"Unauthorized for this route\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "{\"error\": \"Event not found\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"error\": \"Could not get ticket types\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Adds a ticket type to the event, the quotas of all ticket types of an event add up to at most its capacity;\nonce an event has ticket types every ticket is bought in one of them\nallowed: admin",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ticket types"
+                ],
+                "summary": "Create Ticket Type",
+                "operationId": "create-ticket-type",
+                "parameters": [
+                    {
+                        "description": "Create Ticket Type",
+                        "name": "ticket_type",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controller.TicketTypeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.TicketType"
+                        }
+                    },
+                    "400": {
+                        "description": "{\"error\": \"Invalid ticket type\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"error\":\"Unauthorized for this route\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "{\"error\": \"Event not found\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "{\"error\": \"Quotas would exceed the capacity of the event\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"error\": \"Could not create ticket type\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/secured/events/{id}/waitlist": {
+            "get": {
+                "description": "Gives back the waitlist of the event in the order it was joined\nallowed: admin",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "waitlist"
+                ],
+                "summary": "Get Event Waitlist",
+                "operationId": "get-event-waitlist",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.WaitlistEntry"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "{\"error\":\"Unauthorized for this route\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "{\"error\": \"Event not found\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"error\": \"Could not get the waitlist\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Queues the user for tickets of a sold-out event; tickets that free up are offered to the waitlist in the order\nit was joined as a hold that expires after WAITLIST_OFFER_TTL, confirm the hold to buy them\nallowed: user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "waitlist"
+                ],
+                "summary": "Join Waitlist",
+                "operationId": "join-waitlist",
+                "parameters": [
+                    {
+                        "description": "Join Waitlist",
+                        "name": "entry",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controller.WaitlistRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.WaitlistEntry"
+                        }
+                    },
+                    "400": {
+                        "description": "{\"error\": \"Choose a ticket type of the event\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"error\":\"Unauthorized for this route\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "{\"error\": \"Ticket type not found\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "{\"error\": \"Tickets are not on sale\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"error\": \"Could not join the waitlist\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/secured/holds": {
+            "get": {
+                "description": "Gives back all holds of the user, holds past their expiry are shown as expired\nallowed: user, admin",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "holds"
+                ],
+                "summary": "Get Holds",
+                "operationId": "get-holds",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Hold"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "{\"error\":\"Unauthorized for this route\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "{\"error\": \"User not found\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"error\": \"Could not get Holds\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Reserves tickets of an event for a limited time until the hold is confirmed\nBefore the general sale the hold needs a presale code, it uses the code once for the order it turns into\nallowed: user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "holds"
+                ],
+                "summary": "Create Hold",
+                "operationId": "create-hold",
+                "parameters": [
+                    {
+                        "description": "Create Hold",
+                        "name": "hold",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controller.NewHold"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Hold"
+                        }
+                    },
+                    "400": {
+                        "description": "{\"error\": \"Choose a ticket type of the event\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"error\":\"Unauthorized for this route\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "{\"error\": \"Invalid presale code\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "{\"error\": \"Event not found\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "{\"error\": \"Unfortunately, there are not enough tickets left\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"error\": \"Could not create Hold\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/secured/holds/{id}": {
+            "delete": {
+                "description": "Gives the reserved tickets of an active hold back, they are offered to the waitlist of the event\nallowed: user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "holds"
+                ],
+                "summary": "Release Hold",
+                "operationId": "release-hold",
+                "responses": {
+                    "200": {
+                        "description": "{\"message\": \"Hold released\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"error\":\"Unauthorized for this route\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "{\"error\": \"Hold not found\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "{\"error\": \"Hold is expired, confirmed or released\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"error\": \"Could not release Hold\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/secured/holds/{id}/confirm": {
+            "post": {
+                "description": "Turns an active hold into an order with its tickets and charges it with the payment method, a promo code discounts the tickets\nallowed: user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "holds"
+                ],
+                "summary": "Confirm Hold",
+                "operationId": "confirm-hold",
+                "parameters": [
+                    {
+                        "description": "Payment",
+                        "name": "payment",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/controller.CheckoutRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Order"
+                        }
+                    },
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/models.Order"
+                        }
+                    },
+                    "400": {
+                        "description": "{\"error\": \"Invalid promo code\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"error\":\"Unauthorized for this route\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "402": {
+                        "description": "{\"error\": \"Payment declined\", \"order_id\": 1}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "{\"error\": \"Hold not found\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "{\"error\": \"Hold is expired, confirmed or released\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"error\": \"Could not confirm Hold\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/secured/listings": {
+            "get": {
+                "description": "Gives back all tickets the user put up for resale\nallowed: user, admin",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "resale"
+                ],
+                "summary": "Get Listings",
+                "operationId": "get-listings",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Listing"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "{\"error\":\"Unauthorized for this route\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "{\"error\": \"User not found\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"error\": \"Could not get the listings\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/secured/listings/{id}": {
+            "delete": {
+                "description": "Takes a ticket off the resale marketplace, listings a buyer is paying for cannot be withdrawn\nallowed: user, admin",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "resale"
+                ],
+                "summary": "Withdraw Listing",
+                "operationId": "withdraw-listing",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Listing ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Listing"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"error\":\"Unauthorized for this route\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "{\"error\": \"Listing not found\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "{\"error\": \"The listing is not active anymore\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"error\": \"Could not withdraw the listing\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/secured/orders": {
+            "get": {
+                "description": "Gives back all orders of the user including their tickets\nallowed: user, admin",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Get Orders",
+                "operationId": "get-orders",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Order"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "{\"error\":\"Unauthorized for this route\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "{\"error\": \"User not found\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"error\": \"Could not get Orders\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Buys tickets for one or several events at once, either all tickets are created or none\nTickets with a seat are bought by seat_ids, tickets without one only get the capacity the seats of the event leave\nEvents with ticket types sell every ticket in the ticket type of the item at its price, within its quota and sale window\nBefore the general sale of an event its tickets need a presale code with uses left, an order uses the code once\nA promo code discounts every ticket it applies to, the total is charged net of the discount\nThe order is charged with the payment method, it stays pending while the provider processes the payment\nWith listing_id the order buys a ticket for resale instead, it joins the order once the payment succeeded\nallowed: user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Create Order",
+                "operationId": "create-order",
+                "parameters": [
+                    {
+                        "description": "Create Order",
+                        "name": "order",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controller.NewOrder"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Order"
+                        }
+                    },
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/models.Order"
+                        }
+                    },
+                    "400": {
+                        "description": "{\"error\": \"You cannot buy your own listing\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"error\":\"Unauthorized for this route\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "402": {
+                        "description": "{\"error\": \"Payment declined\", \"order_id\": 1}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "{\"error\": \"Invalid presale code\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "{\"error\": \"Listing not found\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "{\"error\": \"Unfortunately, one of the seats is already taken\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"error\": \"Could not create Order\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/secured/orders/{id}": {
+            "get": {
+                "description": "Sends an Order with its tickets, users only see their own orders\nallowed: user, admin",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Get Order By ID",
+                "operationId": "get-order-by-id",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Order"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"error\":\"Unauthorized for this route\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "{\"error\": \"Order not found\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/secured/payouts": {
+            "get": {
+                "description": "Gives back the money owed to the user for resold tickets\nallowed: user, admin",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "resale"
+                ],
+                "summary": "Get Payouts",
+                "operationId": "get-payouts",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Payout"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "{\"error\":\"Unauthorized for this route\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "{\"error\": \"User not found\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"error\": \"Could not get the payouts\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/secured/presale-codes/{id}": {
+            "put": {
+                "description": "Replaces ticket type, uses limit and validity of the presale code and its code unless empty, orders bought with it stay\nallowed: admin",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "presale codes"
+                ],
+                "summary": "Update Presale Code",
+                "operationId": "update-presale-code",
+                "parameters": [
+                    {
+                        "description": "Update Presale Code",
+                        "name": "presale_code",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controller.PresaleCodeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.PresaleCode"
+                        }
+                    },
+                    "400": {
+                        "description": "{\"error\": \"Invalid presale code\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"error\":\"Unauthorized for this route\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "{\"error\": \"Ticket type not found\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "{\"error\": \"The event already has this presale code\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"error\": \"Could not update presale code\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Deletes a presale code, tickets bought with it stay valid\nallowed: admin",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "presale codes"
+                ],
+                "summary": "Delete Presale Code",
+                "operationId": "delete-presale-code",
+                "responses": {
+                    "200": {
+                        "description": "{\"message\": \"Presale code deleted\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"error\":\"Unauthorized for this route\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "{\"error\": \"Presale code not found\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"error\": \"Could not delete presale code\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/secured/promotions": {
+            "get": {
+                "description": "Sends all promotions with the number of orders that used each\nallowed: admin",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "promotions"
+                ],
+                "summary": "Get Promotions",
+                "operationId": "get-promotions",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Promotion"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "{\"error\":\"Unauthorized for this route\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"error\": \"Could not get promotions\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Adds a promo code that takes a percentage or a fixed amount off every ticket of an order it applies to,\neither for all events or for one; max_uses limits the orders using it overall and max_uses_per_user those of each user\nallowed: admin",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "promotions"
+                ],
+                "summary": "Create Promotion",
+                "operationId": "create-promotion",
+                "parameters": [
+                    {
+                        "description": "Create Promotion",
+                        "name": "promotion",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controller.PromotionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Promotion"
+                        }
+                    },
+                    "400": {
+                        "description": "{\"error\": \"Invalid promotion\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"error\":\"Unauthorized for this route\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "{\"error\": \"Event not found\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "{\"error\": \"A promotion with this code already exists\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"error\": \"Could not create promotion\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/secured/promotions/{id}": {
+            "get": {
+                "description": "Sends a promotion with the number of orders that used it\nallowed: admin",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "promotions"
+                ],
+                "summary": "Get Promotion By ID",
+                "operationId": "get-promotion-by-id",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Promotion"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"error\":\"Unauthorized for this route\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "{\"error\": \"Promotion not found\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Replaces all fields of the promotion, an empty code keeps the current one; orders already discounted keep their discount\nallowed: admin",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "promotions"
+                ],
+                "summary": "Update Promotion",
+                "operationId": "update-promotion",
+                "parameters": [
+                    {
+                        "description": "Update Promotion",
+                        "name": "promotion",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controller.PromotionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Promotion"
+                        }
+                    },
+                    "400": {
+                        "description": "{\"error\": \"Invalid promotion\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"error\":\"Unauthorized for this route\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "{\"error\": \"Event not found\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "{\"error\": \"A promotion with this code already exists\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"error\": \"Could not update promotion\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Deletes a promotion, orders discounted with it keep their discount\nallowed: admin",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "promotions"
+                ],
+                "summary": "Delete Promotion",
+                "operationId": "delete-promotion",
+                "responses": {
+                    "200": {
+                        "description": "{\"message\": \"Promotion deleted\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"error\":\"Unauthorized for this route\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "{\"error\": \"Promotion not found\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"error\": \"Could not delete promotion\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/secured/refunds": {
+            "get": {
+                "description": "Gives back all refunds, optionally only those of one ticket\nallowed: admin",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "refunds"
+                ],
+                "summary": "Get Refunds",
+                "operationId": "get-refunds",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Ticket ID",
+                        "name": "ticket_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Refund"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "{\"error\": \"Invalid ticket id\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"error\":\"Unauthorized for this route\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"error\": \"Could not get refunds\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Refunds a ticket outside of the refund policy, issued tickets are cancelled, the amount defaults to what was not refunded yet\nallowed: admin",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "refunds"
+                ],
+                "summary": "Create Refund",
+                "operationId": "create-refund",
+                "parameters": [
+                    {
+                        "description": "Refund",
+                        "name": "refund",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controller.ManualRefund"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Refund"
+                        }
+                    },
+                    "400": {
+                        "description": "{\"error\": \"Could not create Refund\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"error\":\"Unauthorized for this route\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "{\"error\": \"Ticket not found\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "{\"error\": \"Ticket is listed for resale\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"error\": \"Could not create Refund\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/secured/ticket-types/{id}": {
+            "put": {
+                "description": "Updates the non-zero fields of the ticket type, the quota cannot fall below the tickets sold in it\nallowed: admin",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ticket types"
+                ],
+                "summary": "Update Ticket Type",
+                "operationId": "update-ticket-type",
+                "parameters": [
+                    {
+                        "description": "Update Ticket Type",
+                        "name": "ticket_type",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controller.TicketTypeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.TicketType"
+                        }
+                    },
+                    "400": {
+                        "description": "{\"error\": \"Invalid ticket type\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"error\":\"Unauthorized for this route\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "{\"error\": \"Ticket type not found\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "{\"error\": \"Quotas would exceed the capacity of the event or fall below the sold tickets\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"error\": \"Could not update ticket type\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Deletes a ticket type no ticket or hold was created in\nallowed: admin",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ticket types"
+                ],
+                "summary": "Delete Ticket Type",
+                "operationId": "delete-ticket-type",
+                "responses": {
+                    "200": {
+                        "description": "{\"message\": \"Ticket type deleted\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"error\":\"Unauthorized for this route\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "{\"error\": \"Ticket type not found\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "{\"error\": \"Tickets of this type were already sold\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"error\": \"Could not delete ticket type\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/secured/tickets/events/{id}": {
+            "get": {
+                "description": "Gives back a number of all sold tickets for this event\nallowed: admin",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tickets"
+                ],
+                "summary": "Get Tickets By EventID",
+                "operationId": "get-tickets-by-event-id",
+                "responses": {
+                    "200": {
+                        "description": "{\"data\": usedCapacity}",
+                        "schema": {
+                            "type": "int"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"error\":\"Unauthorized for this route\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "{\"error\": \"Tickets not found\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/secured/tickets/user": {
+            "get": {
+                "description": "Gives back all tickets for user\nallowed: user, admin",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tickets"
+                ],
+                "summary": "Get Tickets",
+                "operationId": "get-tickets",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Ticket"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/secured/tickets/{id}": {
+            "get": {
+                "description": "Buys a Ticket for EventID as an order of one ticket, capacity is checked and consumed inside one transaction, active holds count as used\nallowed: user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tickets"
+                ],
+                "summary": "Create Ticket by EventID",
+                "operationId": "create-ticket",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Payment method for the payment provider",
+                        "name": "payment_method",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Seat of the event for reserved seating",
+                        "name": "seat_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Ticket type, required for events with ticket types",
+                        "name": "ticket_type_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Presale code, unlocks the ticket before the general sale",
+                        "name": "presale_code",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Promo code, discounts the ticket",
+                        "name": "promo_code",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Ticket"
+                        }
+                    },
+                    "202": {
+                        "description": "{\"info\": \"Payment is being processed\", \"order_id\": 1}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "{\"error\": \"Invalid promo code\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"error\":\"Unauthorized for this route\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "402": {
+                        "description": "{\"error\": \"Payment declined\", \"order_id\": 1}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "{\"error\": \"Invalid presale code\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "{\"error\": \"Seat not found\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "{\"error\": \"Unfortunately, this seat is already taken\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"error\": \"Could not create Ticket\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Cancels the Ticket by Ticket ID and refunds it following the refund policy of the event,\nthe ticket stays on record as cancelled and becomes refunded once the money went back; its place is offered to the waitlist\nallowed: admin, user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tickets"
+                ],
+                "summary": "Cancel Ticket By ID",
+                "operationId": "delete-tickets-by-user-id",
+                "responses": {
+                    "200": {
+                        "description": "{\"message\": \"Ticket cancelled\", \"refund\": {}}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"error\":\"Unauthorized for this route\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "{\"error\": \"Tickets not found\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "{\"error\": \"Ticket is listed for resale\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"error\": \"Could not cancel Ticket\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/secured/tickets/{id}/checkins": {
+            "get": {
+                "description": "Gives back all scans of the ticket at the door in the order they happened, rejected duplicates included\nallowed: user, admin",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "check-in"
+                ],
+                "summary": "Get Ticket Check-Ins",
+                "operationId": "get-ticket-check-ins",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.CheckIn"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "{\"error\":\"Unauthorized for this route\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "{\"error\": \"Ticket not found\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"error\": \"Could not get the check-ins\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/secured/tickets/{id}/listing": {
+            "post": {
+                "description": "Puts a paid ticket up for resale at or below its face value, the price of the ticket before any discount\nOther users buy it with POST /secured/orders and its listing_id; the buyer gets the ticket with a new entry code\nonce the payment succeeded and the seller a payout of the price. Listed tickets are not admitted at the door,\nwithdraw the listing to use the ticket again. Listings are withdrawn when the event starts\nallowed: user, admin",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "resale"
+                ],
+                "summary": "List Ticket For Resale",
+                "operationId": "create-listing",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Ticket ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "List Ticket For Resale",
+                        "name": "listing",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controller.ListingRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Listing"
+                        }
+                    },
+                    "400": {
+                        "description": "{\"error\": \"The price exceeds the face value of the ticket\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"error\":\"Unauthorized for this route\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "{\"error\": \"Ticket not found\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "{\"error\": \"The ticket belongs to someone else now\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"error\": \"Could not list the ticket\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/secured/tickets/{id}/pass": {
+            "get": {
+                "description": "Sends the signed entry pass of a paid ticket, the text its QR code encodes\nallowed: user, admin",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tickets"
+                ],
+                "summary": "Get Ticket Pass",
+                "operationId": "get-ticket-pass",
+                "responses": {
+                    "200": {
+                        "description": "{\"ticket_id\": 1, \"pass\": \"GT1.1.1.code.signature\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"error\":\"Unauthorized for this route\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "{\"error\": \"Ticket not found\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "{\"error\": \"Ticket is cancelled or not paid\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/secured/tickets/{id}/pdf": {
+            "get": {
+                "description": "Renders a paid ticket as printable A4 PDF with band, venue, date, seat or tier, holder and the QR code of its entry pass\nallowed: user, admin",
+                "produces": [
+                    "application/pdf"
+                ],
+                "tags": [
+                    "tickets"
+                ],
+                "summary": "Get Ticket PDF",
+                "operationId": "get-ticket-pdf",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"error\":\"Unauthorized for this route\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "{\"error\": \"Ticket not found\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "{\"error\": \"Ticket is cancelled or not paid\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"error\": \"Could not render the ticket\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/secured/tickets/{id}/qr": {
+            "get": {
+                "description": "Renders the signed entry pass of a paid ticket as QR code to show at the door\nallowed: user, admin",
+                "produces": [
+                    "image/png",
+                    "image/svg+xml"
+                ],
+                "tags": [
+                    "tickets"
+                ],
+                "summary": "Get Ticket QR Code",
+                "operationId": "get-ticket-qr-code",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "png (default) or svg",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Width and height of PNGs in pixels, 64 to 1024, default 256",
+                        "name": "size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "{\"error\": \"Invalid QR code format or size\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"error\":\"Unauthorized for this route\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "{\"error\": \"Ticket not found\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "{\"error\": \"Ticket is cancelled or not paid\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"error\": \"Could not render QR code\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/secured/tickets/{id}/transfer": {
+            "post": {
+                "description": "Offers a paid ticket to another user by email or username, the recipient has to accept before TRANSFER_TTL\npasses or the event starts; on acceptance the ticket gets a new entry code and the old pass stops working\nallowed: user, admin",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "transfers"
+                ],
+                "summary": "Transfer Ticket",
+                "operationId": "transfer-ticket",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Ticket ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Transfer Ticket",
+                        "name": "transfer",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controller.TransferRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Transfer"
+                        }
+                    },
+                    "400": {
+                        "description": "{\"error\": \"The ticket already belongs to the recipient\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"error\":\"Unauthorized for this route\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "{\"error\": \"Recipient not found\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "{\"error\": \"The ticket belongs to someone else now\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"error\": \"Could not transfer the ticket\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/secured/tickets/{id}/transfers": {
+            "get": {
+                "description": "Gives back all transfers of the ticket in the order they were made, the accepted ones are the chain of its owners\nallowed: admin",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "transfers"
+                ],
+                "summary": "Get Ticket Transfers",
+                "operationId": "get-ticket-transfers",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Ticket ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Transfer"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "{\"error\":\"Unauthorized for this route\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "{\"error\": \"Ticket not found\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"error\": \"Could not get the transfers\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/secured/transfers": {
+            "get": {
+                "description": "Gives back all transfers the user sent or received\nallowed: user, admin",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "transfers"
+                ],
+                "summary": "Get Transfers",
+                "operationId": "get-transfers",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Transfer"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "{\"error\":\"Unauthorized for this route\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "{\"error\": \"User not found\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"error\": \"Could not get the transfers\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/secured/transfers/{id}": {
+            "delete": {
+                "description": "Takes back a pending transfer before the recipient accepts it\nallowed: user, admin",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "transfers"
+                ],
+                "summary": "Cancel Transfer",
+                "operationId": "cancel-transfer",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Transfer ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Transfer"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"error\":\"Unauthorized for this route\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "{\"error\": \"Transfer not found\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "{\"error\": \"The transfer is not pending anymore\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"error\": \"Could not cancel the transfer\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/secured/transfers/{id}/accept": {
+            "post": {
+                "description": "Makes the recipient the owner of the ticket and issues it a new entry code, passes of the old code are rejected at the door\nallowed: user, admin",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "transfers"
+                ],
+                "summary": "Accept Transfer",
+                "operationId": "accept-transfer",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Transfer ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Transfer"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"error\":\"Unauthorized for this route\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "{\"error\": \"Transfer not found\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "{\"error\": \"Ticket is listed for resale\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"error\": \"Could not accept the transfer\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/secured/transfers/{id}/decline": {
+            "post": {
+                "description": "Turns down a pending transfer, the ticket stays with the sender\nallowed: user, admin",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "transfers"
+                ],
+                "summary": "Decline Transfer",
+                "operationId": "decline-transfer",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Transfer ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Transfer"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"error\":\"Unauthorized for this route\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "{\"error\": \"Transfer not found\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "{\"error\": \"The transfer is not pending anymore\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"error\": \"Could not decline the transfer\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/secured/user/{id}": {
+            "get": {
+                "description": "Sends a User with ID\nallowed:  admin",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Get User By ID",
+                "operationId": "get-user-by-id",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"error\":\"Unauthorized for this route\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "{\"error\": \"User not found\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Updates User with Body and corresponding ID, the role is user, scanner (door staff) or admin\nChanging the username, email, password or role revokes all tokens of the user\nallowed:  admin",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Update User By ID",
+                "operationId": "update-user-by-id",
+                "parameters": [
+                    {
+                        "description": "Update User",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controller.UserUpdate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "400": {
+                        "description": "{\"error\": \"Invalid email address\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"error\":\"Unauthorized for this route\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "{\"error\": \"User not found\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "{\"error\": \"Username or email is already taken\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Deltes User with corresponding ID and revokes all of its tokens, users with orders, tickets\nor payouts stay on record\nallowed:  admin",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Delete User By ID",
+                "operationId": "delete-user-by-id",
+                "responses": {
+                    "200": {
+                        "description": "{\"message\": \"User deleted\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"error\":\"Unauthorized for this route\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "{\"error\": \"User not found\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "{\"error\": \"User has orders, tickets or payouts\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/secured/venues": {
+            "get": {
+                "description": "Sends Array Of Venues with their sections\nallowed: user, admin",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "venues"
+                ],
+                "summary": "Get All Venues",
+                "operationId": "get-venues",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Venue"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "{\"error\":\"Unauthorized for this route\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"error\": \"Could not get venues\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Creates a new Venue with its sections\nallowed: admin",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "venues"
+                ],
+                "summary": "Create Venue",
+                "operationId": "create-venue",
+                "parameters": [
+                    {
+                        "description": "Create Venue",
+                        "name": "venue",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controller.NewVenue"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Venue"
+                        }
+                    },
+                    "400": {
+                        "description": "{\"error\": \"Invalid venue\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"error\":\"Unauthorized for this route\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "{\"error\": \"A venue with this name already exists\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"error\": \"Could not create Venue\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/secured/venues/{id}": {
+            "get": {
+                "description": "Sends a Venue with ID including its sections\nallowed: user, admin",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "venues"
+                ],
+                "summary": "Get Venue By ID",
+                "operationId": "get-venue-by-id",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Venue"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"error\":\"Unauthorized for this route\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "{\"error\": \"Venue not found\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Updates Venue with given ID, sections replace the current ones when present, sections\nwith the id of an existing section are kept; events at the venue take over a new name\nallowed: admin",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "venues"
+                ],
+                "summary": "Update Venue By ID",
+                "operationId": "update-venue-by-id",
+                "parameters": [
+                    {
+                        "description": "Update Venue",
+                        "name": "venue",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controller.VenueUpdate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Venue"
+                        }
+                    },
+                    "400": {
+                        "description": "{\"error\": \"Invalid venue\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"error\":\"Unauthorized for this route\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "{\"error\": \"Venue not found\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "{\"error\": \"A venue with this name already exists\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"error\": \"Could not update Venue\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Deletes Venue with given ID, venues with events cannot be deleted\nallowed: admin",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "venues"
+                ],
+                "summary": "Delete Venue By ID",
+                "operationId": "delete-venue-by-id",
+                "responses": {
+                    "200": {
+                        "description": "{\"message\": \"Venue deleted\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"error\":\"Unauthorized for this route\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "{\"error\": \"Venue not found\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "{\"error\": \"Venue still has events\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"error\": \"Could not delete Venue\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/secured/venues/{id}/seats": {
+            "get": {
+                "description": "Sends the seat map of the venue\nallowed: user, admin",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "seats"
+                ],
+                "summary": "Get Venue Seats",
+                "operationId": "get-venue-seats",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Seat"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "{\"error\":\"Unauthorized for this route\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "{\"error\": \"Venue not found\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"error\": \"Could not get seats\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Replaces the seat map of the venue, every seat belongs to one of its sections and a section\nhas at most as many seats as its capacity; events copy the seat map when they are created\nallowed: admin",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "seats"
+                ],
+                "summary": "Set Venue Seats",
+                "operationId": "set-venue-seats",
+                "parameters": [
+                    {
+                        "description": "Seat Map",
+                        "name": "seats",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controller.SeatMap"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Seat"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "{\"error\": \"Invalid seat map\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"error\":\"Unauthorized for this route\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "{\"error\": \"Venue not found\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"error\": \"Could not save seats\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/secured/waitlist": {
+            "get": {
+                "description": "Gives back all waitlist entries of the user with their position or offer\nallowed: user, admin",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "waitlist"
+                ],
+                "summary": "Get Waitlist",
+                "operationId": "get-waitlist",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.WaitlistEntry"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "{\"error\":\"Unauthorized for this route\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "{\"error\": \"User not found\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"error\": \"Could not get the waitlist\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/secured/waitlist/{id}": {
+            "delete": {
+                "description": "Removes a waiting entry from the waitlist, an open offer is released and goes to the next entry\nallowed: user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "waitlist"
+                ],
+                "summary": "Leave Waitlist",
+                "operationId": "leave-waitlist",
+                "responses": {
+                    "200": {
+                        "description": "{\"message\": \"Left the waitlist\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"error\":\"Unauthorized for this route\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "{\"error\": \"Waitlist entry not found\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "{\"error\": \"The entry is not on the waitlist anymore\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"error\": \"Could not leave the waitlist\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/token": {
+            "post": {
+                "description": "Generates JWT Token based on given context, checks if username and password match\nEncode JWT with username, email and role, it expires after ACCESS_TOKEN_TTL\nStarts a new token family with a refresh token that renews the access token\nallowed: unsecured",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Generate Token",
+                "operationId": "generate-token",
+                "parameters": [
+                    {
+                        "description": "Create Token",
+                        "name": "credentials",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controller.TokenRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/controller.TokenResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "{\"error\": \"Could not create Token\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"error\":\"User not found\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "{\"error\": \"Email address is not verified\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"error\":\"Could not create Token\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/token/refresh": {
+            "post": {
+                "description": "Exchanges a refresh token for a new access token and the next refresh token of its family\nEvery refresh token works once, using one again revokes the whole family, the login has to be repeated then\nThe access token carries the current role of the user\nallowed: unsecured",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Refresh Token",
+                "operationId": "refresh-token",
+                "parameters": [
+                    {
+                        "description": "Refresh token",
+                        "name": "refresh_token",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controller.RefreshRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/controller.TokenResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "{\"error\": \"Refresh token is required\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "{\"error\": \"Refresh token was already used, all tokens of the login are revoked\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"error\":\"Could not create Token\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/register": {
+            "post": {
+                "description": "Creates new User, hashes Password for DB\nrole automatically set to user, the account is pending until the link mailed to its address is opened\nallowed: unsecured",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Register User",
+                "operationId": "register-user",
+                "parameters": [
+                    {
+                        "description": "Create User",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controller.UserUpdate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "{\"error\": \"Invalid email address\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "{\"error\": \"Username or email is already taken\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/verify": {
+            "get": {
+                "description": "Target of the link in the verification mail, activates the account so it can log in\nLinks expire after VERIFICATION_TTL and stop working when the email address changes, opening a link twice does no harm\nallowed: unsecured",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Verify Email",
+                "operationId": "verify-email",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Token of the verification link",
+                        "name": "token",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{\"message\": \"Email address verified\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "{\"error\": \"Verification link is invalid\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "410": {
+                        "description": "{\"error\": \"Verification link expired, request a new one\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"error\": \"Could not verify the email address\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/verify/resend": {
+            "post": {
+                "description": "Mails a new verification link to an account that is not verified yet, earlier links keep working until they expire\nThe answer is the same whether the account exists or not\nallowed: unsecured",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Resend Verification",
+                "operationId": "resend-verification",
+                "parameters": [
+                    {
+                        "description": "Email address of the account",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controller.ResendRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "{\"message\": \"A new link is on its way if the account waits for verification\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "{\"error\": \"Email is required\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "{\"error\": \"Could not send the verification mail\"}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "controller.CheckInRequest": {
+            "type": "object",
+            "required": [
+                "code",
+                "event_id",
+                "gate"
+            ],
+            "properties": {
+                "code": {
+                    "description": "entry code of the ticket or the signed entry pass its QR code encodes",
+                    "type": "string",
+                    "example": "GT1.1.1.code.signature"
+                },
+                "direction": {
+                    "description": "in (default) admits the ticket, out scans it out at events that allow re-entry",
+                    "type": "string",
+                    "example": "in"
+                },
+                "event_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "gate": {
+                    "type": "string",
+                    "example": "North 2"
+                }
+            }
+        },
+        "controller.CheckoutRequest": {
+            "type": "object",
+            "properties": {
+                "payment_method": {
+                    "type": "string",
+                    "example": "pm_card_ok"
+                },
+                "promo_code": {
+                    "description": "discounts the tickets, only used while they are not ordered yet",
+                    "type": "string",
+                    "example": "SUMMER10"
+                }
+            }
+        },
+        "controller.ListingRequest": {
+            "type": "object",
+            "required": [
+                "price"
+            ],
+            "properties": {
+                "price": {
+                    "description": "at most the face value of the ticket, in its currency",
+                    "$ref": "#/definitions/models.Money"
+                }
+            }
+        },
+        "controller.ManualRefund": {
+            "type": "object",
+            "required": [
+                "ticket_id"
+            ],
+            "properties": {
+                "amount": {
+                    "description": "in the currency of the ticket, defaults to everything of the price that was not refunded yet",
+                    "type": "string",
+                    "example": "25.50"
+                },
+                "note": {
+                    "type": "string",
+                    "example": "Band changed the line-up"
+                },
+                "ticket_id": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "controller.NewEvent": {
+            "type": "object",
+            "required": [
+                "band_name",
+                "ends_at",
+                "price",
+                "starts_at"
+            ],
+            "properties": {
+                "band_name": {
+                    "type": "string",
+                    "example": "Deichkind"
+                },
+                "capacity": {
+                    "description": "defaults to the default capacity of the venue",
+                    "type": "integer",
+                    "example": 35000
+                },
+                "doors_open_at": {
+                    "description": "defaults to starts_at",
+                    "type": "string",
+                    "example": "2022-10-11T18:30"
+                },
+                "ends_at": {
+                    "type": "string",
+                    "example": "2022-10-11T23:00"
+                },
+                "location": {
+                    "description": "name of an existing venue, used when venue_id is not set",
+                    "type": "string",
+                    "example": "Olympiastadion"
+                },
+                "price": {
+                    "description": "{\"amount\": 5500, \"currency\": \"EUR\"} or \"55.00 EUR\", the currency defaults to the payment currency",
+                    "$ref": "#/definitions/models.Money"
+                },
+                "reentry_policy": {
+                    "description": "none (default) admits every ticket once, scan_out lets tickets scanned out at a gate enter again",
+                    "type": "string",
+                    "example": "none"
+                },
+                "sales_end": {
+                    "type": "string",
+                    "example": "2022-10-11T20:00"
+                },
+                "sales_start": {
+                    "description": "tickets are sold right away and until the event ends unless set",
+                    "type": "string",
+                    "example": "2022-06-01T10:00"
+                },
+                "starts_at": {
+                    "type": "string",
+                    "example": "2022-10-11T20:00"
+                },
+                "timezone": {
+                    "description": "IANA timezone, defaults to the one of the venue; times without offset are wall clock times in it",
+                    "type": "string",
+                    "example": "Europe/Berlin"
+                },
+                "venue_id": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "controller.NewHold": {
+            "type": "object",
+            "required": [
+                "event_id",
+                "quantity"
+            ],
+            "properties": {
+                "event_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "presale_code": {
+                    "description": "unlocks tickets before the general sale of the event",
+                    "type": "string",
+                    "example": "FANCLUB22"
+                },
+                "quantity": {
+                    "type": "integer",
+                    "minimum": 1,
+                    "example": 4
+                },
+                "ticket_type_id": {
+                    "description": "required for events with ticket types",
+                    "type": "integer",
+                    "example": 2
+                }
+            }
+        },
+        "controller.NewOrder": {
+            "type": "object",
+            "properties": {
+                "event_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/controller.OrderItem"
+                    }
+                },
+                "listing_id": {
+                    "description": "buys a ticket for resale, alone without events, items or codes",
+                    "type": "integer",
+                    "example": 3
+                },
+                "payment_method": {
+                    "type": "string",
+                    "example": "pm_card_ok"
+                },
+                "presale_code": {
+                    "description": "unlocks tickets before the general sale of their event",
+                    "type": "string",
+                    "example": "FANCLUB22"
+                },
+                "promo_code": {
+                    "description": "discounts the tickets of the order",
+                    "type": "string",
+                    "example": "SUMMER10"
+                },
+                "quantity": {
+                    "type": "integer",
+                    "minimum": 1,
+                    "example": 4
+                },
+                "seat_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    },
+                    "example": [
+                        14,
+                        15
+                    ]
+                },
+                "ticket_type_id": {
+                    "type": "integer",
+                    "example": 2
+                }
+            }
+        },
+        "controller.NewVenue": {
+            "type": "object",
+            "required": [
+                "name",
+                "timezone"
+            ],
+            "properties": {
+                "address": {
+                    "type": "string",
+                    "example": "Olympischer Platz 3, 14053 Berlin"
+                },
+                "default_capacity": {
+                    "description": "defaults to the sum of the sections",
+                    "type": "integer",
+                    "example": 74475
+                },
+                "latitude": {
+                    "type": "number",
+                    "example": 52.5147
+                },
+                "longitude": {
+                    "type": "number",
+                    "example": 13.2395
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Olympiastadion"
+                },
+                "sections": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/controller.VenueSectionRequest"
+                    }
+                },
+                "timezone": {
+                    "type": "string",
+                    "example": "Europe/Berlin"
+                }
+            }
+        },
+        "controller.OfflineScanRequest": {
+            "type": "object",
+            "required": [
+                "code",
+                "gate",
+                "scanned_at"
+            ],
+            "properties": {
+                "code": {
+                    "description": "entry code of the ticket or the signed entry pass its QR code encodes",
+                    "type": "string",
+                    "example": "GT1.1.1.code.signature"
+                },
+                "direction": {
+                    "description": "in (default) or out",
+                    "type": "string",
+                    "example": "in"
+                },
+                "gate": {
+                    "type": "string",
+                    "example": "North 2"
+                },
+                "scanned_at": {
+                    "description": "time of the scan on the scanner",
+                    "type": "string",
+                    "example": "2022-10-11T18:31:02Z"
+                }
+            }
+        },
+        "controller.OrderItem": {
+            "type": "object",
+            "required": [
+                "event_id"
+            ],
+            "properties": {
+                "event_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "quantity": {
+                    "type": "integer",
+                    "minimum": 1,
+                    "example": 2
+                },
+                "seat_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    },
+                    "example": [
+                        14,
+                        15
+                    ]
+                },
+                "ticket_type_id": {
+                    "description": "required for events with ticket types",
+                    "type": "integer",
+                    "example": 2
+                }
+            }
+        },
+        "controller.PresaleCodeRequest": {
+            "type": "object",
+            "required": [
+                "max_uses"
+            ],
+            "properties": {
+                "code": {
+                    "description": "letters, digits, - and _, generated when empty",
+                    "type": "string",
+                    "example": "FANCLUB22"
+                },
+                "max_uses": {
+                    "description": "1 for a single-use code",
+                    "type": "integer",
+                    "minimum": 1,
+                    "example": 100
+                },
+                "ticket_type_id": {
+                    "description": "limits the code to one ticket type of the event",
+                    "type": "integer",
+                    "example": 2
+                },
+                "valid_from": {
+                    "type": "string",
+                    "example": "2022-05-25T10:00"
+                },
+                "valid_until": {
+                    "type": "string",
+                    "example": "2022-06-01T10:00"
+                }
+            }
+        },
+        "controller.PromotionRequest": {
+            "type": "object",
+            "required": [
+                "kind"
+            ],
+            "properties": {
+                "amount_off": {
+                    "description": "taken off every ticket of fixed promotions, the currency defaults to PAYMENT_CURRENCY",
+                    "$ref": "#/definitions/models.Money"
+                },
+                "code": {
+                    "description": "letters, digits, - and _, generated when empty",
+                    "type": "string",
+                    "example": "SUMMER10"
+                },
+                "event_id": {
+                    "description": "limits the promotion to one event, all events when empty",
+                    "type": "integer",
+                    "example": 1
+                },
+                "kind": {
+                    "description": "percent or fixed",
+                    "type": "string",
+                    "example": "percent"
+                },
+                "max_uses": {
+                    "description": "0 for no limit",
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 500
+                },
+                "max_uses_per_user": {
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 1
+                },
+                "percent": {
+                    "type": "integer",
+                    "example": 10
+                },
+                "valid_from": {
+                    "type": "string",
+                    "example": "2022-07-01T00:00"
+                },
+                "valid_until": {
+                    "type": "string",
+                    "example": "2022-09-01T00:00"
+                }
+            }
+        },
+        "controller.RefreshRequest": {
+            "type": "object",
+            "required": [
+                "refresh_token"
+            ],
+            "properties": {
+                "refresh_token": {
+                    "type": "string"
+                }
+            }
+        },
+        "controller.RefundPolicyRequest": {
+            "type": "object",
+            "properties": {
+                "full_refund_days": {
+                    "type": "integer",
+                    "example": 14
+                },
+                "partial_refund_days": {
+                    "type": "integer",
+                    "example": 7
+                },
+                "partial_refund_percent": {
+                    "type": "integer",
+                    "example": 50
+                }
+            }
+        },
+        "controller.ResendRequest": {
+            "type": "object",
+            "required": [
+                "email"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "test@online.de"
+                }
+            }
+        },
+        "controller.SeatMap": {
+            "type": "object",
+            "properties": {
+                "seats": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/controller.SeatRequest"
+                    }
+                }
+            }
+        },
+        "controller.SeatRequest": {
+            "type": "object",
+            "required": [
+                "number",
+                "row",
+                "section_id"
+            ],
+            "properties": {
+                "accessible": {
+                    "type": "boolean"
+                },
+                "number": {
+                    "type": "string",
+                    "example": "7"
+                },
+                "row": {
+                    "type": "string",
+                    "example": "12"
+                },
+                "section_id": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "controller.SyncRequest": {
+            "type": "object",
+            "required": [
+                "event_id",
+                "scans"
+            ],
+            "properties": {
+                "event_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "scans": {
+                    "type": "array",
+                    "maxItems": 10000,
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/controller.OfflineScanRequest"
+                    }
+                }
+            }
+        },
+        "controller.TicketTypeRequest": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "example": "VIP"
+                },
+                "price": {
+                    "description": "in the currency of the event, defaults to PAYMENT_CURRENCY",
+                    "$ref": "#/definitions/models.Money"
+                },
+                "quota": {
+                    "type": "integer",
+                    "example": 500
+                },
+                "sales_end": {
+                    "type": "string",
+                    "example": "2022-10-01T00:00"
+                },
+                "sales_start": {
+                    "type": "string",
+                    "example": "2022-09-01T10:00"
+                }
+            }
+        },
+        "controller.TokenRequest": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "test@online.de"
+                },
+                "password": {
+                    "type": "string",
+                    "example": "1234"
+                }
+            }
+        },
+        "controller.TokenResponse": {
+            "type": "object",
+            "properties": {
+                "expires_in": {
+                    "description": "seconds until the access token expires",
+                    "type": "integer"
+                },
+                "refresh_token": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "controller.TransferRequest": {
+            "type": "object",
+            "required": [
+                "recipient"
+            ],
+            "properties": {
+                "recipient": {
+                    "description": "email or username of the recipient",
                     "type": "string",
-                    "example": "2022-10-11"
+                    "example": "jane@example.com"
+                }
+            }
+        },
+        "controller.UserUpdate": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "mgr@online.de"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Max"
+                },
+                "password": {
+                    "type": "string",
+                    "example": "1234"
+                },
+                "role": {
+                    "type": "string",
+                    "example": "user"
+                },
+                "username": {
+                    "type": "string",
+                    "example": "mgr"
+                }
+            }
+        },
+        "controller.VenueSectionRequest": {
+            "type": "object",
+            "properties": {
+                "capacity": {
+                    "type": "integer",
+                    "example": 25000
+                },
+                "id": {
+                    "description": "id of an existing section to keep, new sections have none",
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Ostkurve"
+                }
+            }
+        },
+        "controller.VenueUpdate": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "default_capacity": {
+                    "type": "integer"
+                },
+                "latitude": {
+                    "type": "number"
+                },
+                "longitude": {
+                    "type": "number"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "sections": {
+                    "description": "replaces all sections when present",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/controller.VenueSectionRequest"
+                    }
+                },
+                "timezone": {
+                    "type": "string"
+                }
+            }
+        },
+        "controller.WaitlistRequest": {
+            "type": "object",
+            "required": [
+                "quantity"
+            ],
+            "properties": {
+                "quantity": {
+                    "type": "integer",
+                    "minimum": 1,
+                    "example": 2
+                },
+                "ticket_type_id": {
+                    "description": "required for events with ticket types",
+                    "type": "integer",
+                    "example": 2
+                }
+            }
+        },
+        "models.CheckIn": {
+            "type": "object",
+            "properties": {
+                "direction": {
+                    "type": "string",
+                    "example": "in"
+                },
+                "event_id": {
+                    "type": "integer"
+                },
+                "gate": {
+                    "type": "string",
+                    "example": "North 2"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "offline": {
+                    "description": "uploaded from the offline log of a scanner",
+                    "type": "boolean"
+                },
+                "result": {
+                    "type": "string"
+                },
+                "scanned_at": {
+                    "description": "time on the scanner, for offline scans the time they happened and not the time of the upload",
+                    "type": "string"
+                },
+                "scanner_id": {
+                    "description": "account that scanned the ticket, nil once it was deleted",
+                    "type": "integer"
+                },
+                "ticket_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.CheckInStats": {
+            "type": "object",
+            "properties": {
+                "admitted": {
+                    "description": "issued tickets that entered at least once",
+                    "type": "integer"
+                },
+                "event_id": {
+                    "type": "integer"
+                },
+                "gates": {
+                    "description": "admissions per gate, re-entries included",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "inside": {
+                    "description": "tickets currently inside, admitted ones that did not scan out",
+                    "type": "integer"
+                },
+                "issued": {
+                    "description": "issued tickets of the event",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Event": {
+            "type": "object",
+            "properties": {
+                "band_name": {
+                    "type": "string"
+                },
+                "capacity": {
+                    "type": "integer"
+                },
+                "doors_open_at": {
+                    "type": "string"
+                },
+                "ends_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "location": {
+                    "description": "name of the venue, kept in sync by the store",
+                    "type": "string"
+                },
+                "price": {
+                    "$ref": "#/definitions/models.Money"
+                },
+                "reentry_policy": {
+                    "description": "whether tickets scanned out at a gate admit again, none or scan_out",
+                    "type": "string",
+                    "example": "none"
+                },
+                "sales_end": {
+                    "type": "string"
+                },
+                "sales_start": {
+                    "description": "tickets are sold from SalesStart until SalesEnd, an open start sells right away\nand an open end sells until the event ends; presale codes unlock the time before",
+                    "type": "string"
+                },
+                "starts_at": {
+                    "type": "string"
+                },
+                "ticket_types": {
+                    "description": "tiers with their availability, only filled for a single event",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.TicketType"
+                    }
+                },
+                "timezone": {
+                    "description": "IANA name of the timezone the event takes place in, times are rendered in it",
+                    "type": "string",
+                    "example": "Europe/Berlin"
+                },
+                "venue_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.EventSeat": {
+            "type": "object",
+            "properties": {
+                "accessible": {
+                    "type": "boolean"
+                },
+                "available": {
+                    "description": "no issued ticket is on the seat",
+                    "type": "boolean"
+                },
+                "event_id": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "number": {
+                    "type": "string",
+                    "example": "7"
+                },
+                "row": {
+                    "type": "string",
+                    "example": "12"
+                },
+                "section": {
+                    "type": "string",
+                    "example": "Ostkurve"
+                }
+            }
+        },
+        "models.Hold": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "event_id": {
+                    "type": "integer"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "order_id": {
+                    "description": "order created when the hold was confirmed",
+                    "type": "integer"
+                },
+                "presale_code_id": {
+                    "description": "presale code that unlocked the tickets before the general sale",
+                    "type": "integer"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "ticket_type_id": {
+                    "description": "tier the tickets are reserved in",
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Listing": {
+            "type": "object",
+            "properties": {
+                "closed_at": {
+                    "description": "when the listing was sold or withdrawn",
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "event_id": {
+                    "type": "integer"
+                },
+                "face_value": {
+                    "description": "price of the ticket before any discount, the price may not exceed it; the markdown\nof a resale is the difference to the price",
+                    "$ref": "#/definitions/models.Money"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "order_id": {
+                    "description": "order of the buyer while the listing is reserved or sold",
+                    "type": "integer"
+                },
+                "price": {
+                    "description": "asked from the buyer and paid out to the seller",
+                    "$ref": "#/definitions/models.Money"
+                },
+                "seller_id": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "ticket_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Money": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer",
+                    "example": 5500
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "EUR"
+                }
+            }
+        },
+        "models.Order": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "discount": {
+                    "description": "sum of the discounts of the tickets",
+                    "$ref": "#/definitions/models.Money"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "listing_id": {
+                    "description": "resale listing the order buys, its ticket joins the order once the payment succeeded",
+                    "type": "integer"
+                },
+                "payments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Payment"
+                    }
+                },
+                "presale_code_id": {
+                    "description": "presale code that unlocked the tickets before the general sale",
+                    "type": "integer"
+                },
+                "promotion_id": {
+                    "description": "promotion the tickets were discounted with",
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "tickets": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Ticket"
+                    }
+                },
+                "total": {
+                    "description": "amount charged, net of the discount",
+                    "$ref": "#/definitions/models.Money"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Payment": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
                 },
-                "location": {
-                    "type": "string",
-                    "example": "Olympiastadion"
+                "intent_id": {
+                    "type": "string"
                 },
-                "price": {
-                    "type": "string",
-                    "example": "55"
+                "order_id": {
+                    "type": "integer"
+                },
+                "provider": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
                 }
             }
         },
-        "controller.TokenRequest": {
+        "models.Payout": {
             "type": "object",
             "properties": {
-                "email": {
-                    "type": "string",
-                    "example": "test@online.de"
+                "amount": {
+                    "$ref": "#/definitions/models.Money"
                 },
-                "password": {
-                    "type": "string",
-                    "example": "1234"
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "listing_id": {
+                    "type": "integer"
+                },
+                "order_id": {
+                    "description": "order of the buyer that paid for the ticket",
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "integer"
                 }
             }
         },
-        "controller.UserUpdate": {
+        "models.PresaleCode": {
             "type": "object",
             "properties": {
-                "email": {
+                "code": {
+                    "description": "unique per event, stored in upper case and matched ignoring case",
                     "type": "string",
-                    "example": "mgr@online.de"
+                    "example": "FANCLUB22"
                 },
-                "name": {
-                    "type": "string",
-                    "example": "Max"
+                "created_at": {
+                    "type": "string"
                 },
-                "password": {
-                    "type": "string",
-                    "example": "1234"
+                "event_id": {
+                    "type": "integer"
                 },
-                "role": {
+                "id": {
+                    "type": "integer"
+                },
+                "max_uses": {
+                    "description": "1 for a single-use code",
+                    "type": "integer",
+                    "example": 100
+                },
+                "ticket_type_id": {
+                    "description": "limits the code to one ticket type of the event",
+                    "type": "integer"
+                },
+                "uses": {
+                    "description": "orders that did not fail and active holds bought with the code, not stored",
+                    "type": "integer"
+                },
+                "valid_from": {
+                    "description": "the code works from ValidFrom until ValidUntil, open ends have no limit",
+                    "type": "string"
+                },
+                "valid_until": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Promotion": {
+            "type": "object",
+            "properties": {
+                "amount_off": {
+                    "description": "discount per ticket of fixed promotions, tickets in other currencies do not get it",
+                    "$ref": "#/definitions/models.Money"
+                },
+                "code": {
+                    "description": "unique, stored in upper case and matched ignoring case",
                     "type": "string",
-                    "example": "user"
+                    "example": "SUMMER10"
                 },
-                "username": {
+                "created_at": {
+                    "type": "string"
+                },
+                "event_id": {
+                    "description": "limits the promotion to the tickets of one event",
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "kind": {
                     "type": "string",
-                    "example": "mgr"
+                    "example": "percent"
+                },
+                "max_uses": {
+                    "description": "0 for no limit",
+                    "type": "integer",
+                    "example": 500
+                },
+                "max_uses_per_user": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "percent": {
+                    "description": "1 to 100 for percent promotions",
+                    "type": "integer",
+                    "example": 10
+                },
+                "uses": {
+                    "description": "orders that did not fail using the promotion, not stored",
+                    "type": "integer"
+                },
+                "valid_from": {
+                    "description": "the code works from ValidFrom until ValidUntil, open ends have no limit",
+                    "type": "string"
+                },
+                "valid_until": {
+                    "type": "string"
                 }
             }
         },
-        "models.Event": {
+        "models.Refund": {
             "type": "object",
             "properties": {
-                "band_name": {
+                "amount": {
+                    "type": "integer"
+                },
+                "created_at": {
                     "type": "string"
                 },
-                "capacity": {
+                "currency": {
+                    "type": "string"
+                },
+                "id": {
                     "type": "integer"
                 },
-                "date": {
+                "note": {
                     "type": "string"
                 },
-                "id": {
+                "payment_id": {
+                    "description": "payment the money goes back to, tickets without one are refunded outside of the provider",
                     "type": "integer"
                 },
-                "location": {
+                "provider_refund_id": {
                     "type": "string"
                 },
-                "price": {
+                "reason": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "ticket_id": {
+                    "type": "integer"
+                },
+                "updated_at": {
                     "type": "string"
                 }
             }
         },
+        "models.RefundPolicy": {
+            "type": "object",
+            "properties": {
+                "event_id": {
+                    "type": "integer"
+                },
+                "full_refund_days": {
+                    "description": "cancellations at least this many days before the event are refunded in full",
+                    "type": "integer",
+                    "example": 14
+                },
+                "partial_refund_days": {
+                    "description": "later cancellations at least this many days before the event get PartialRefundPercent back",
+                    "type": "integer",
+                    "example": 7
+                },
+                "partial_refund_percent": {
+                    "type": "integer",
+                    "example": 50
+                }
+            }
+        },
+        "models.Seat": {
+            "type": "object",
+            "properties": {
+                "accessible": {
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "number": {
+                    "type": "string",
+                    "example": "7"
+                },
+                "row": {
+                    "type": "string",
+                    "example": "12"
+                },
+                "section_id": {
+                    "type": "integer"
+                }
+            }
+        },
         "models.Ticket": {
             "type": "object",
             "properties": {
+                "admitted_at": {
+                    "description": "first admission at the door, admitted tickets cannot be cancelled anymore",
+                    "type": "string"
+                },
+                "cancelled_at": {
+                    "type": "string"
+                },
+                "code": {
+                    "description": "unguessable secret shown at the door inside the signed entry pass",
+                    "type": "string"
+                },
+                "discount": {
+                    "description": "taken off the price of the event or ticket type by a promotion",
+                    "$ref": "#/definitions/models.Money"
+                },
                 "event_id": {
                     "type": "integer"
                 },
                 "id": {
                     "type": "integer"
                 },
+                "inside": {
+                    "description": "admitted and not scanned out",
+                    "type": "boolean"
+                },
+                "order_id": {
+                    "description": "set when the ticket was bought as part of an order, a resale keeps it and records\nthe order of the buyer on the listing",
+                    "type": "integer"
+                },
                 "price": {
+                    "description": "price its holder paid for the ticket, net of the discount; the listing price once resold",
+                    "$ref": "#/definitions/models.Money"
+                },
+                "seat_id": {
+                    "description": "seat of the event for reserved seating, general admission tickets have none",
+                    "type": "integer"
+                },
+                "status": {
                     "type": "string"
                 },
+                "ticket_type_id": {
+                    "description": "tier the ticket was bought in, events without tiers sell tickets without one",
+                    "type": "integer"
+                },
                 "user_id": {
                     "type": "integer"
                 }
             }
         },
+        "models.TicketType": {
+            "type": "object",
+            "properties": {
+                "available": {
+                    "description": "tickets that can still be bought, only filled when listed for an event",
+                    "type": "integer"
+                },
+                "event_id": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "VIP"
+                },
+                "price": {
+                    "$ref": "#/definitions/models.Money"
+                },
+                "quota": {
+                    "type": "integer",
+                    "example": 500
+                },
+                "sales_end": {
+                    "type": "string"
+                },
+                "sales_start": {
+                    "description": "narrows the sale window of the event, open ends keep the one of the event",
+                    "type": "string"
+                }
+            }
+        },
+        "models.Transfer": {
+            "type": "object",
+            "properties": {
+                "closed_at": {
+                    "description": "when the transfer was accepted, declined, cancelled or expired",
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "description": "the recipient has to accept before, at the latest when the event starts",
+                    "type": "string"
+                },
+                "from_user_id": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "ticket_id": {
+                    "type": "integer"
+                },
+                "to_user_id": {
+                    "type": "integer"
+                }
+            }
+        },
         "models.User": {
             "type": "object",
+            "required": [
+                "email",
+                "username"
+            ],
             "properties": {
                 "email": {
                     "type": "string"
@@ -797,6 +5301,107 @@ const docTemplate = `{
                 },
                 "username": {
                     "type": "string"
+                },
+                "verified_at": {
+                    "description": "nil while the email address is not verified, such accounts cannot log in",
+                    "type": "string"
+                }
+            }
+        },
+        "models.Venue": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string",
+                    "example": "Olympischer Platz 3, 14053 Berlin"
+                },
+                "default_capacity": {
+                    "description": "capacity of events at the venue that do not set their own",
+                    "type": "integer",
+                    "example": 74475
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "latitude": {
+                    "type": "number",
+                    "example": 52.5147
+                },
+                "longitude": {
+                    "type": "number",
+                    "example": 13.2395
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Olympiastadion"
+                },
+                "sections": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.VenueSection"
+                    }
+                },
+                "timezone": {
+                    "description": "IANA timezone, used for events at the venue that do not name one",
+                    "type": "string",
+                    "example": "Europe/Berlin"
+                }
+            }
+        },
+        "models.VenueSection": {
+            "type": "object",
+            "properties": {
+                "capacity": {
+                    "type": "integer",
+                    "example": 25000
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Ostkurve"
+                },
+                "venue_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.WaitlistEntry": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "event_id": {
+                    "type": "integer"
+                },
+                "hold_id": {
+                    "description": "hold reserving the offered tickets, confirm it to buy them",
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "offer_expires_at": {
+                    "type": "string"
+                },
+                "position": {
+                    "description": "1 for the next waiting entry of the event, not stored and only set while waiting",
+                    "type": "integer"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "ticket_type_id": {
+                    "description": "tier the user waits for, required for events with ticket types",
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "integer"
                 }
             }
         }
@@ -0,0 +1,88 @@
+package entry
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// prefix of the pass format, a pass is the text encoded in the QR code of a ticket:
+//
+//	GT1.<ticket id>.<event id>.<entry code>.<signature>
+//
+// the signature is the Ed25519 signature of everything before its dot in unpadded
+// base64url, so scanners verify passes offline with the public key alone
+const Version = "GT1"
+
+const Algorithm = "Ed25519"
+
+var ErrInvalidPass = errors.New("entry pass is malformed or its signature does not match")
+
+// content of an entry pass
+type Pass struct {
+	TicketID	uint
+	EventID		uint
+	// entry code of the ticket, a new code invalidates passes signed before
+	Code		string
+}
+
+// the signed part of the pass
+func (p Pass) message() string {
+	return fmt.Sprintf("%s.%d.%d.%s", Version, p.TicketID, p.EventID, p.Code)
+}
+
+// signs entry passes with an Ed25519 key
+type Signer struct {
+	key ed25519.PrivateKey
+}
+
+// derives the key from secret, so every instance configured with the same
+// secret signs with the same key
+func NewSigner(secret string) *Signer {
+	seed := sha256.Sum256([]byte(secret))
+	return &Signer{key: ed25519.NewKeyFromSeed(seed[:])}
+}
+
+// key scanners verify passes with
+func (s *Signer) PublicKey() ed25519.PublicKey {
+	return s.key.Public().(ed25519.PublicKey)
+}
+
+// returns the encoded and signed pass
+func (s *Signer) Sign(pass Pass) string {
+	message := pass.message()
+	signature := ed25519.Sign(s.key, []byte(message))
+	return message + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// checks the signature of an encoded pass with the public key and returns its content,
+// whether the code is still the current one of the ticket is up to the caller
+func Verify(publicKey ed25519.PublicKey, encoded string) (Pass, error) {
+	cut := strings.LastIndex(encoded, ".")
+	if cut < 0 {
+		return Pass{}, ErrInvalidPass
+	}
+	message := encoded[:cut]
+	signature, err := base64.RawURLEncoding.DecodeString(encoded[cut+1:])
+	if err != nil || !ed25519.Verify(publicKey, []byte(message), signature) {
+		return Pass{}, ErrInvalidPass
+	}
+
+	parts := strings.Split(message, ".")
+	if len(parts) != 4 || parts[0] != Version || parts[3] == "" {
+		return Pass{}, ErrInvalidPass
+	}
+	ticketID, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return Pass{}, ErrInvalidPass
+	}
+	eventID, err := strconv.ParseUint(parts[2], 10, 64)
+	if err != nil {
+		return Pass{}, ErrInvalidPass
+	}
+	return Pass{TicketID: uint(ticketID), EventID: uint(eventID), Code: parts[3]}, nil
+}
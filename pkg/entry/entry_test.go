@@ -0,0 +1,72 @@
+package entry
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// signs message with the key of signer like Sign does, for passes Sign cannot produce
+func signRaw(signer *Signer, message string) string {
+	return message + "." + base64.RawURLEncoding.EncodeToString(ed25519.Sign(signer.key, []byte(message)))
+}
+
+func TestSignVerify(t *testing.T) {
+	signer := NewSigner("secret")
+	pass := Pass{TicketID: 42, EventID: 7, Code: "c0de"}
+	encoded := signer.Sign(pass)
+	signature := encoded[strings.LastIndex(encoded, ".")+1:]
+
+	if !strings.HasPrefix(encoded, "GT1.42.7.c0de.") {
+		t.Fatalf("Sign = %q, want it to start with GT1.42.7.c0de.", encoded)
+	}
+	if again := NewSigner("secret").Sign(pass); again != encoded {
+		t.Errorf("signer with the same secret signed %q, want %q", again, encoded)
+	}
+
+	tests := []struct {
+		name	string
+		key		ed25519.PublicKey
+		encoded	string
+		want	error
+	}{
+		{"round trip", signer.PublicKey(), encoded, nil},
+		{"other ticket", signer.PublicKey(), "GT1.43.7.c0de." + signature, ErrInvalidPass},
+		{"other event", signer.PublicKey(), "GT1.42.8.c0de." + signature, ErrInvalidPass},
+		{"other code", signer.PublicKey(), "GT1.42.7.beef." + signature, ErrInvalidPass},
+		{"flipped signature", signer.PublicKey(), "GT1.42.7.c0de." + flip(signature), ErrInvalidPass},
+		{"truncated signature", signer.PublicKey(), encoded[:len(encoded)-4], ErrInvalidPass},
+		{"no signature", signer.PublicKey(), "GT1.42.7.c0de", ErrInvalidPass},
+		{"signature not base64", signer.PublicKey(), "GT1.42.7.c0de.!!!", ErrInvalidPass},
+		{"other version", signer.PublicKey(), signRaw(signer, "GT2.42.7.c0de"), ErrInvalidPass},
+		{"no version", signer.PublicKey(), signRaw(signer, "42.7.c0de"), ErrInvalidPass},
+		{"snapshot version", signer.PublicKey(), signRaw(signer, "GTS1.42.7.c0de"), ErrInvalidPass},
+		{"empty code", signer.PublicKey(), signRaw(signer, "GT1.42.7."), ErrInvalidPass},
+		{"ticket not a number", signer.PublicKey(), signRaw(signer, "GT1.x.7.c0de"), ErrInvalidPass},
+		{"extra part", signer.PublicKey(), signRaw(signer, "GT1.42.7.c0de.extra"), ErrInvalidPass},
+		{"other key", NewSigner("other").PublicKey(), encoded, ErrInvalidPass},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			got, err := Verify(test.key, test.encoded)
+			if !errors.Is(err, test.want) {
+				t.Fatalf("Verify = %v, want %v", err, test.want)
+			}
+			if test.want == nil && got != pass {
+				t.Errorf("Verify = %+v, want %+v", got, pass)
+			}
+		})
+	}
+}
+
+// changes the first character of the base64url text s to another one, the last
+// character may only carry padding bits
+func flip(s string) string {
+	if s[0] == 'A' {
+		return "B" + s[1:]
+	}
+	return "A" + s[1:]
+}
@@ -0,0 +1,123 @@
+package entry
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// what a printed ticket shows besides its QR code
+type Printout struct {
+	TicketID	uint
+	Band		string
+	Venue		string
+	// times in the timezone of the event
+	StartsAt	time.Time
+	DoorsOpenAt	time.Time
+	// name of the ticket type, empty for events without tiers
+	Tier		string
+	// section, row and number, empty for general admission
+	Seat		string
+	Holder		string
+	Price		string
+	// signed entry pass the QR code encodes
+	Pass		string
+	// stamped into the document, so the same printout renders to the same bytes
+	PrintedAt	time.Time
+}
+
+// layout of the A4 page in millimeters, the ticket is a box at the top of the page
+// that can be cut out
+const (
+	pageMargin	= 15.0
+	boxWidth	= 180.0
+	boxHeight	= 95.0
+	qrSize		= 60.0
+	labelWidth	= 28.0
+)
+
+// renders the printout as one page A4 PDF with the QR code of the pass
+func PDF(p Printout) ([]byte, error) {
+	image, err := PNG(p.Pass, 512)
+	if err != nil {
+		return nil, err
+	}
+
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetCreationDate(p.PrintedAt)
+	pdf.SetModificationDate(p.PrintedAt)
+	pdf.SetCatalogSort(true)
+	pdf.SetTitle(fmt.Sprintf("Ticket %d - %s", p.TicketID, p.Band), true)
+	pdf.SetMargins(pageMargin, pageMargin, pageMargin)
+	pdf.SetAutoPageBreak(false, pageMargin)
+	pdf.AddPage()
+	// the core fonts are cp1252, umlauts of band and holder names are translated
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	pdf.SetDrawColor(60, 60, 60)
+	pdf.SetLineWidth(0.4)
+	pdf.Rect(pageMargin, pageMargin, boxWidth, boxHeight, "D")
+	pdf.SetFillColor(30, 30, 30)
+	pdf.Rect(pageMargin, pageMargin, boxWidth, 12, "F")
+
+	pdf.SetTextColor(255, 255, 255)
+	pdf.SetFont("Helvetica", "B", 11)
+	pdf.SetXY(pageMargin+5, pageMargin)
+	pdf.CellFormat(boxWidth-10, 12, "go-ticket", "", 0, "L", false, 0, "")
+	pdf.SetXY(pageMargin+5, pageMargin)
+	pdf.CellFormat(boxWidth-10, 12, fmt.Sprintf("Ticket #%d", p.TicketID), "", 0, "R", false, 0, "")
+
+	textWidth := boxWidth - qrSize - 15
+	pdf.SetTextColor(0, 0, 0)
+	pdf.SetFont("Helvetica", "B", 22)
+	pdf.SetXY(pageMargin+5, pageMargin+16)
+	pdf.MultiCell(textWidth, 9, tr(p.Band), "", "L", false)
+
+	rows := [][2]string{
+		{"Venue", p.Venue},
+		{"Date", p.StartsAt.Format("Monday, 2 January 2006")},
+		{"Start", p.StartsAt.Format("15:04") + " (" + p.StartsAt.Location().String() + ")"},
+		{"Doors", p.DoorsOpenAt.Format("15:04")},
+	}
+	if p.Tier != "" {
+		rows = append(rows, [2]string{"Tier", p.Tier})
+	}
+	if p.Seat != "" {
+		rows = append(rows, [2]string{"Seat", p.Seat})
+	} else {
+		rows = append(rows, [2]string{"Seat", "General admission"})
+	}
+	rows = append(rows, [2]string{"Holder", p.Holder}, [2]string{"Price", p.Price})
+
+	pdf.SetY(pdf.GetY() + 2)
+	for _, row := range rows {
+		pdf.SetX(pageMargin + 5)
+		pdf.SetFont("Helvetica", "", 9)
+		pdf.SetTextColor(110, 110, 110)
+		pdf.CellFormat(labelWidth, 6.5, row[0], "", 0, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "B", 11)
+		pdf.SetTextColor(0, 0, 0)
+		pdf.CellFormat(textWidth-labelWidth, 6.5, tr(row[1]), "", 1, "L", false, 0, "")
+	}
+
+	options := gofpdf.ImageOptions{ImageType: "PNG"}
+	pdf.RegisterImageOptionsReader("qr", options, bytes.NewReader(image))
+	qrX := pageMargin + boxWidth - qrSize - 5
+	pdf.ImageOptions("qr", qrX, pageMargin+18, qrSize, qrSize, false, options, 0, "")
+	pdf.SetFont("Helvetica", "", 7)
+	pdf.SetTextColor(110, 110, 110)
+	pdf.SetXY(qrX, pageMargin+18+qrSize+1)
+	pdf.MultiCell(qrSize, 3.5, "Admits once. Copies of this code are rejected at the door.", "", "C", false)
+
+	pdf.SetXY(pageMargin, pageMargin+boxHeight+4)
+	pdf.SetFont("Helvetica", "", 8)
+	pdf.CellFormat(boxWidth, 4, "Print this page or show the QR code on your phone. Keep it private, whoever scans it first gets in.", "", 0, "C", false, 0, "")
+
+	var b bytes.Buffer
+	if err := pdf.Output(&b); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
@@ -0,0 +1,77 @@
+package entry
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+func TestPDF(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	startsAt := time.Date(2030, 6, 14, 20, 0, 0, 0, berlin)
+	printedAt := time.Date(2030, 3, 1, 12, 0, 0, 0, time.UTC)
+	// real passes, Ed25519 signatures are deterministic so the goldens stay stable
+	signer := NewSigner("secret")
+
+	tests := []struct {
+		name		string
+		printout	Printout
+	}{
+		{"seated", Printout{
+			TicketID: 42,
+			Band: "Die Ärzte",
+			Venue: "Olympiahalle",
+			StartsAt: startsAt,
+			DoorsOpenAt: startsAt.Add(-time.Hour),
+			// the label the ticket controller prints for a seat
+			Seat: fmt.Sprintf("%s, row %s, seat %s", "Block A", "3", "14"),
+			Holder: "Jürgen Müller",
+			Price: "EUR 55.00",
+			Pass: signer.Sign(Pass{TicketID: 42, EventID: 7, Code: "c0de"}),
+			PrintedAt: printedAt,
+		}},
+		{"tiered", Printout{
+			TicketID: 43,
+			Band: "Muse",
+			Venue: "Zenith",
+			StartsAt: startsAt,
+			DoorsOpenAt: startsAt.Add(-90 * time.Minute),
+			Tier: "VIP",
+			Holder: "Max Mustermann",
+			Price: "EUR 120.00",
+			Pass: signer.Sign(Pass{TicketID: 43, EventID: 8, Code: "beef"}),
+			PrintedAt: printedAt,
+		}},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			got, err := PDF(test.printout)
+			if err != nil {
+				t.Fatal(err)
+			}
+			golden := filepath.Join("testdata", "ticket_"+test.name+".pdf.golden")
+			if *update {
+				if err := os.WriteFile(golden, got, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("PDF differs from %s, rerun with -update after checking the change", golden)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package entry
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/skip2/go-qrcode"
+)
+
+// medium error correction keeps passes readable from scratched screens and prints
+const recovery = qrcode.Medium
+
+// renders the encoded pass as a QR code PNG of size by size pixels
+func PNG(encoded string, size int) ([]byte, error) {
+	return qrcode.Encode(encoded, recovery, size)
+}
+
+// renders the encoded pass as a QR code SVG with one unit per module
+func SVG(encoded string) ([]byte, error) {
+	code, err := qrcode.New(encoded, recovery)
+	if err != nil {
+		return nil, err
+	}
+	bitmap := code.Bitmap()
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" shape-rendering="crispEdges">`, len(bitmap), len(bitmap))
+	b.WriteString(`<rect width="100%" height="100%" fill="#fff"/><path fill="#000" d="`)
+	for y, row := range bitmap {
+		for x, dark := range row {
+			if dark {
+				fmt.Fprintf(&b, "M%d %dh1v1h-1z", x, y)
+			}
+		}
+	}
+	b.WriteString(`"/></svg>`)
+	return b.Bytes(), nil
+}
@@ -0,0 +1,86 @@
+package entry
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+// prefix of the snapshot format, a snapshot lists the tickets scanners admit while offline:
+//
+//	GTS1.<payload>.<signature>
+//
+// the payload is the gzip compressed JSON of the snapshot in unpadded base64url, the
+// signature signs everything before its dot like the one of passes, with the same key
+const SnapshotVersion = "GTS1"
+
+var ErrInvalidSnapshot = errors.New("snapshot is malformed or its signature does not match")
+
+// content of a snapshot
+type Snapshot struct {
+	EventID		uint		`json:"event_id"`
+	TakenAt		time.Time	`json:"taken_at"`
+	// whether tickets scanned out enter again
+	Reentry		bool		`json:"reentry"`
+	// entry codes of all tickets that admit
+	Codes		[]string	`json:"codes"`
+	// codes among them that are inside, or used up at events without re-entry
+	Used		[]string	`json:"used"`
+}
+
+// returns the encoded and signed snapshot
+func (s *Signer) SignSnapshot(snapshot Snapshot) (string, error) {
+	content, err := json.Marshal(snapshot)
+	if err != nil {
+		return "", err
+	}
+	var payload bytes.Buffer
+	zw, _ := gzip.NewWriterLevel(&payload, gzip.BestCompression)
+	if _, err := zw.Write(content); err != nil {
+		return "", err
+	}
+	if err := zw.Close(); err != nil {
+		return "", err
+	}
+
+	message := SnapshotVersion + "." + base64.RawURLEncoding.EncodeToString(payload.Bytes())
+	signature := ed25519.Sign(s.key, []byte(message))
+	return message + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// checks the signature of an encoded snapshot with the public key and returns its content
+func VerifySnapshot(publicKey ed25519.PublicKey, encoded string) (Snapshot, error) {
+	parts := strings.Split(encoded, ".")
+	if len(parts) != 3 || parts[0] != SnapshotVersion {
+		return Snapshot{}, ErrInvalidSnapshot
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !ed25519.Verify(publicKey, []byte(parts[0]+"."+parts[1]), signature) {
+		return Snapshot{}, ErrInvalidSnapshot
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return Snapshot{}, ErrInvalidSnapshot
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(payload))
+	if err != nil {
+		return Snapshot{}, ErrInvalidSnapshot
+	}
+	content, err := io.ReadAll(zr)
+	if err != nil {
+		return Snapshot{}, ErrInvalidSnapshot
+	}
+
+	var snapshot Snapshot
+	if err := json.Unmarshal(content, &snapshot); err != nil {
+		return Snapshot{}, ErrInvalidSnapshot
+	}
+	return snapshot, nil
+}
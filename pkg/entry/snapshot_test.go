@@ -0,0 +1,63 @@
+package entry
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSnapshot(t *testing.T) {
+	signer := NewSigner("secret")
+	snapshot := Snapshot{
+		EventID: 7,
+		TakenAt: time.Date(2030, 3, 1, 12, 0, 0, 0, time.UTC),
+		Reentry: true,
+		Codes: []string{"c0de", "beef", "f00d"},
+		Used: []string{"beef"},
+	}
+	encoded, err := signer.SignSnapshot(snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(encoded, ".")
+	if len(parts) != 3 || parts[0] != SnapshotVersion {
+		t.Fatalf("SignSnapshot = %q, want GTS1.<payload>.<signature>", encoded)
+	}
+	other, err := signer.SignSnapshot(Snapshot{EventID: 8, TakenAt: snapshot.TakenAt})
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherParts := strings.Split(other, ".")
+
+	tests := []struct {
+		name	string
+		signer	*Signer
+		encoded	string
+		want	error
+	}{
+		{"round trip", signer, encoded, nil},
+		{"other payload", signer, parts[0] + "." + otherParts[1] + "." + parts[2], ErrInvalidSnapshot},
+		{"other signature", signer, parts[0] + "." + parts[1] + "." + otherParts[2], ErrInvalidSnapshot},
+		{"flipped signature", signer, parts[0] + "." + parts[1] + "." + flip(parts[2]), ErrInvalidSnapshot},
+		{"pass version", signer, Version + "." + parts[1] + "." + parts[2], ErrInvalidSnapshot},
+		{"signed pass version", signer, signRaw(signer, Version+"."+parts[1]), ErrInvalidSnapshot},
+		{"payload not gzip", signer, signRaw(signer, SnapshotVersion+".bm90IGd6aXA"), ErrInvalidSnapshot},
+		{"missing part", signer, parts[0] + "." + parts[1], ErrInvalidSnapshot},
+		{"pass", signer, signer.Sign(Pass{TicketID: 42, EventID: 7, Code: "c0de"}), ErrInvalidSnapshot},
+		{"other key", NewSigner("other"), encoded, ErrInvalidSnapshot},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			got, err := VerifySnapshot(test.signer.PublicKey(), test.encoded)
+			if !errors.Is(err, test.want) {
+				t.Fatalf("VerifySnapshot = %v, want %v", err, test.want)
+			}
+			if test.want == nil && !reflect.DeepEqual(got, snapshot) {
+				t.Errorf("VerifySnapshot = %+v, want %+v", got, snapshot)
+			}
+		})
+	}
+}
@@ -0,0 +1,213 @@
+package keyring
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"errors"
+	"time"
+
+	"github.com/mgr1054/go-ticket/pkg/config"
+	"github.com/mgr1054/go-ticket/pkg/models"
+	"github.com/mgr1054/go-ticket/pkg/store"
+	"github.com/mgr1054/go-ticket/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// size of generated RSA keys
+const rsaBits = 2048
+
+// keeps the signing keys of access tokens in the store and rotates them; every instance
+// rotates on its own, they all read the same keys from the store
+type Keyring struct {
+	keys		store.SigningKeyStore
+	// encrypts the private keys at rest
+	aead		cipher.AEAD
+	algorithm	string
+	rotation	time.Duration
+	prepublish	time.Duration
+	// keys verify for as long as the tokens they signed last
+	accessTTL	time.Duration
+}
+
+func New(keys store.SigningKeyStore, auth config.Auth) (*Keyring, error) {
+	secret := sha256.Sum256([]byte(auth.JWTSecret))
+	block, err := aes.NewCipher(secret[:])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Keyring{
+		keys: keys,
+		aead: aead,
+		algorithm: auth.Algorithm,
+		rotation: auth.KeyRotation.Duration,
+		prepublish: auth.KeyPrepublish.Duration,
+		accessTTL: auth.AccessTokenTTL.Duration,
+	}, nil
+}
+
+// deletes expired keys, starts a signing key when none can sign at now, publishes its successor
+// ahead of time and loads the keys for signing and verification; returns the number of keys
+// created and deleted, runs on startup and with the sweeper
+func (k *Keyring) Rotate(now time.Time) (int64, error) {
+	changed, err := k.keys.DeleteExpired(now)
+	if err != nil {
+		return changed, err
+	}
+	keys, err := k.keys.List(now)
+	if err != nil {
+		return changed, err
+	}
+
+	signing, found := k.signingKey(keys, now)
+	if !found {
+		key, err := k.create(now, now)
+		if err != nil {
+			return changed, err
+		}
+		keys = append(keys, key)
+		changed++
+		if signing, err = k.decode(key); err != nil {
+			return changed, err
+		}
+	}
+
+	if latest, ok := k.latest(keys); ok && !latest.RetiresAt.After(now.Add(k.prepublish)) {
+		key, err := k.create(now, latest.RetiresAt)
+		if err != nil {
+			return changed, err
+		}
+		keys = append(keys, key)
+		changed++
+	}
+
+	verifying := make([]utils.JWTKey, 0, len(keys))
+	for _, key := range keys {
+		public, err := x509.ParsePKIXPublicKey(key.PublicKey)
+		if err != nil {
+			log.Error("Signing key ", key.ID, " has an invalid public key: ", err)
+			continue
+		}
+		verifying = append(verifying, utils.JWTKey{ID: key.ID, Algorithm: key.Algorithm, Public: public, ExpiresAt: key.ExpiresAt})
+	}
+	utils.SetJWTKeys(signing, verifying)
+	return changed, nil
+}
+
+// the most recently activated key of the configured algorithm that signs at now
+// and can be decrypted with the jwt secret
+func (k *Keyring) signingKey(keys []models.SigningKey, now time.Time) (utils.JWTKey, bool) {
+	for i := len(keys) - 1; i >= 0; i-- {
+		key := keys[i]
+		if key.Algorithm != k.algorithm || key.ActivatesAt.After(now) || !key.RetiresAt.After(now) {
+			continue
+		}
+		signing, err := k.decode(key)
+		if err != nil {
+			log.Warn("Signing key ", key.ID, " cannot be decrypted with the jwt secret, starting a new one")
+			continue
+		}
+		return signing, true
+	}
+	return utils.JWTKey{}, false
+}
+
+// the key of the configured algorithm that activates last
+func (k *Keyring) latest(keys []models.SigningKey) (models.SigningKey, bool) {
+	var latest models.SigningKey
+	found := false
+	for _, key := range keys {
+		if key.Algorithm == k.algorithm && (!found || key.ActivatesAt.After(latest.ActivatesAt)) {
+			latest = key
+			found = true
+		}
+	}
+	return latest, found
+}
+
+// generates and stores a key that signs from activatesAt for one rotation
+func (k *Keyring) create(now time.Time, activatesAt time.Time) (models.SigningKey, error) {
+	id, err := utils.NewTokenID()
+	if err != nil {
+		return models.SigningKey{}, err
+	}
+	var private crypto.Signer
+	switch k.algorithm {
+	case utils.AlgorithmEdDSA:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	case utils.AlgorithmRS256:
+		private, err = rsa.GenerateKey(rand.Reader, rsaBits)
+	default:
+		err = errors.New("unknown jwt algorithm " + k.algorithm)
+	}
+	if err != nil {
+		return models.SigningKey{}, err
+	}
+
+	encoded, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return models.SigningKey{}, err
+	}
+	public, err := x509.MarshalPKIXPublicKey(private.Public())
+	if err != nil {
+		return models.SigningKey{}, err
+	}
+	nonce := make([]byte, k.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return models.SigningKey{}, err
+	}
+
+	retiresAt := activatesAt.Add(k.rotation)
+	key := models.SigningKey{
+		ID: id,
+		Algorithm: k.algorithm,
+		// the kid binds the ciphertext to its key
+		PrivateKey: k.aead.Seal(nonce, nonce, encoded, []byte(id)),
+		PublicKey: public,
+		CreatedAt: now.UTC(),
+		ActivatesAt: activatesAt.UTC(),
+		RetiresAt: retiresAt.UTC(),
+		ExpiresAt: retiresAt.Add(k.accessTTL).UTC(),
+	}
+	if err := k.keys.Create(&key); err != nil {
+		return key, err
+	}
+	log.Info("Created signing key ", key.ID, " that signs from ", key.ActivatesAt.Format(time.RFC3339))
+	return key, nil
+}
+
+// decrypts the private key for signing
+func (k *Keyring) decode(key models.SigningKey) (utils.JWTKey, error) {
+	size := k.aead.NonceSize()
+	if len(key.PrivateKey) < size {
+		return utils.JWTKey{}, errors.New("encrypted private key is too short")
+	}
+	encoded, err := k.aead.Open(nil, key.PrivateKey[:size], key.PrivateKey[size:], []byte(key.ID))
+	if err != nil {
+		return utils.JWTKey{}, err
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(encoded)
+	if err != nil {
+		return utils.JWTKey{}, err
+	}
+	private, ok := parsed.(crypto.Signer)
+	if !ok {
+		return utils.JWTKey{}, errors.New("private key cannot sign")
+	}
+	return utils.JWTKey{
+		ID: key.ID,
+		Algorithm: key.Algorithm,
+		Signer: private,
+		Public: private.Public(),
+		ExpiresAt: key.ExpiresAt,
+	}, nil
+}
@@ -0,0 +1,139 @@
+package keyring
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/mgr1054/go-ticket/pkg/config"
+	"github.com/mgr1054/go-ticket/pkg/store"
+	"github.com/mgr1054/go-ticket/pkg/utils"
+)
+
+func newKeyring(t *testing.T, keys store.SigningKeyStore, secret string) (*Keyring, config.Auth) {
+	t.Helper()
+	auth := config.Default().Auth
+	auth.Algorithm = utils.AlgorithmEdDSA
+	auth.JWTSecret = secret
+	k, err := New(keys, auth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k, auth
+}
+
+// signs a token with the loaded signing key and returns it with its kid
+func signToken(t *testing.T) (string, string) {
+	t.Helper()
+	token, err := utils.GenerateJWT("max@example.com", "max", "user", "family", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, _, err := new(jwt.Parser).ParseUnverified(token, &utils.JWTClaim{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	kid, _ := parsed.Header["kid"].(string)
+	return token, kid
+}
+
+func published(kid string, now time.Time) bool {
+	for _, jwk := range utils.JWKS(now) {
+		if jwk.ID == kid {
+			return true
+		}
+	}
+	return false
+}
+
+func rotate(t *testing.T, k *Keyring, now time.Time, want int64) {
+	t.Helper()
+	if changed, err := k.Rotate(now); err != nil || changed != want {
+		t.Fatalf("Rotate at %s = %d, %v, want %d", now, changed, err, want)
+	}
+}
+
+// tokens are verified with the key in their kid header, successors are published before
+// they sign and predecessors verify until their last tokens expired
+func TestRotate(t *testing.T) {
+	keys := store.NewMemory().SigningKeys
+	k, auth := newKeyring(t, keys, "a secret of at least thirty-two bytes")
+	rotation, prepublish, ttl := auth.KeyRotation.Duration, auth.KeyPrepublish.Duration, auth.AccessTokenTTL.Duration
+	// verification runs on the wall clock, the keyring starts now
+	now := time.Now()
+
+	rotate(t, k, now, 1)
+	rotate(t, k, now, 0)
+	first, firstKid := signToken(t)
+	if _, err := utils.ValidateToken(first); err != nil {
+		t.Fatalf("ValidateToken = %v, want nil", err)
+	}
+	if !published(firstKid, now) {
+		t.Error("signing key is not in the JWKS")
+	}
+
+	// the successor is published ahead of its first token
+	rotate(t, k, now.Add(rotation-prepublish), 1)
+	_, kid := signToken(t)
+	if kid != firstKid {
+		t.Errorf("prepublished key %s signs before it activates", kid)
+	}
+	list, err := keys.List(now)
+	if err != nil || len(list) != 2 {
+		t.Fatalf("List = %d keys, %v, want 2", len(list), err)
+	}
+	successor := list[1].ID
+	if !published(successor, now) {
+		t.Error("successor is not in the JWKS before it signs")
+	}
+
+	// after the switch the previous key still verifies the tokens it signed
+	rotate(t, k, now.Add(rotation), 0)
+	second, kid := signToken(t)
+	if kid != successor {
+		t.Errorf("token signed with %s after the rotation, want %s", kid, successor)
+	}
+	for _, token := range []string{first, second} {
+		if _, err := utils.ValidateToken(token); err != nil {
+			t.Errorf("ValidateToken during the overlap = %v, want nil", err)
+		}
+	}
+
+	// once its last tokens expired the previous key is gone, its tokens are rejected
+	rotate(t, k, now.Add(rotation+ttl+time.Second), 1)
+	if _, err := utils.ValidateToken(first); err == nil {
+		t.Error("ValidateToken accepted a token of a retired key")
+	}
+	if published(firstKid, now) {
+		t.Error("retired key is still in the JWKS")
+	}
+	if _, err := utils.ValidateToken(second); err != nil {
+		t.Errorf("ValidateToken of the current key = %v, want nil", err)
+	}
+}
+
+// keys encrypted with another jwt secret cannot be decrypted, the keyring starts a new one
+func TestRotateWrongSecret(t *testing.T) {
+	keys := store.NewMemory().SigningKeys
+	now := time.Now()
+	k, _ := newKeyring(t, keys, "a secret of at least thirty-two bytes")
+	rotate(t, k, now, 1)
+	_, kid := signToken(t)
+
+	other, _ := newKeyring(t, keys, "another secret of thirty-two bytes")
+	list, err := keys.List(now)
+	if err != nil || len(list) != 1 {
+		t.Fatalf("List = %d keys, %v, want 1", len(list), err)
+	}
+	if _, err := other.decode(list[0]); err == nil {
+		t.Error("decode with the wrong secret = nil, want an error")
+	}
+	if _, err := k.decode(list[0]); err != nil {
+		t.Errorf("decode with the right secret = %v, want nil", err)
+	}
+
+	rotate(t, other, now, 1)
+	if _, newKid := signToken(t); newKid == kid {
+		t.Error("keyring with the wrong secret signs with the undecryptable key")
+	}
+}
@@ -0,0 +1,40 @@
+package mail
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync/atomic"
+	"time"
+)
+
+// characters of recipients that are kept in file names
+var unsafeName = regexp.MustCompile(`[^A-Za-z0-9@._-]+`)
+
+// writes every mail as .eml file into a directory instead of sending it, for
+// development and tests; mail clients open the files as they are
+type File struct {
+	dir		string
+	from	string
+	count	uint64
+}
+
+// creates the directory when it does not exist
+func NewFile(dir string, from string) (*File, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &File{dir: dir, from: from}, nil
+}
+
+func (f *File) Send(msg Message) error {
+	now := time.Now()
+	content, err := msg.render(f.from, now)
+	if err != nil {
+		return err
+	}
+	// the counter keeps mails sent within the same nanosecond apart
+	name := fmt.Sprintf("%s-%d-%s.eml", now.UTC().Format("20060102T150405.000000000"), atomic.AddUint64(&f.count, 1), unsafeName.ReplaceAllString(msg.To, "_"))
+	return os.WriteFile(filepath.Join(f.dir, name), content, 0o600)
+}
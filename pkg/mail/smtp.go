@@ -0,0 +1,44 @@
+package mail
+
+import (
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// sends mails through an SMTP server, the connection is upgraded with STARTTLS
+// when the server offers it
+type SMTP struct {
+	addr	string
+	auth	smtp.Auth
+	from	string
+}
+
+// without username the mails are sent unauthenticated, PLAIN authentication is
+// only attempted over TLS or to localhost
+func NewSMTP(host string, port int, username string, password string, from string) *SMTP {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &SMTP{addr: net.JoinHostPort(host, strconv.Itoa(port)), auth: auth, from: from}
+}
+
+func (s *SMTP) Send(msg Message) error {
+	content, err := msg.render(s.from, time.Now())
+	if err != nil {
+		return err
+	}
+	// the envelope takes the bare addresses of the headers
+	sender, err := mail.ParseAddress(s.from)
+	if err != nil {
+		return err
+	}
+	recipient, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return err
+	}
+	return smtp.SendMail(s.addr, s.auth, sender.Address, []string{recipient.Address}, content)
+}
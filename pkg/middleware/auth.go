@@ -1,13 +1,16 @@
 package middlewares
 
 import (
+	"time"
+
+	"github.com/mgr1054/go-ticket/pkg/store"
 	"github.com/mgr1054/go-ticket/pkg/utils"
 	"github.com/gin-gonic/gin"
 )
 
 
-// validate token from gin http request 
-func Auth() gin.HandlerFunc{
+// validate token from gin http request, tokens of revoked families are rejected
+func Auth(tokens store.TokenStore) gin.HandlerFunc{
 	return func(c *gin.Context) {
 		tokenString := c.GetHeader("Authorization")
 		if tokenString == "" {
@@ -21,9 +24,21 @@ func Auth() gin.HandlerFunc{
 			c.Abort()
 			return
 		}
+		revoked, err := tokens.IsRevoked(claims.Family, time.Now())
+		if err != nil {
+			c.JSON(500, gin.H{"error": "could not check the access token"})
+			c.Abort()
+			return
+		}
+		if revoked {
+			c.JSON(401, gin.H{"error": "token was revoked"})
+			c.Abort()
+			return
+		}
 		
 		c.Set("role", claims.Role)
 		c.Set("username", claims.Username)
+		c.Set("family", claims.Family)
 		c.Next()
 	}
 }
\ No newline at end of file
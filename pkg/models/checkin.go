@@ -0,0 +1,72 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// results of a scan at the door
+const (
+	// the ticket entered the event
+	CheckInAdmitted = "admitted"
+	// the ticket left the event and may enter again
+	CheckInExited = "exited"
+	// the ticket was already inside, nobody was admitted
+	CheckInDuplicate = "duplicate"
+	// a scan out of a ticket that was not inside, only uploaded offline scans are recorded with it
+	CheckInRejected = "rejected"
+)
+
+// directions of scans
+const (
+	DirectionIn = "in"
+	DirectionOut = "out"
+)
+
+// re-entry policies of events
+const (
+	// a ticket admits once
+	ReentryNone = "none"
+	// a ticket scanned out at a gate admits again
+	ReentryScanOut = "scan_out"
+)
+
+var ErrInvalidReentryPolicy = errors.New("invalid re-entry policy")
+
+// checks a re-entry policy, empty means none
+func ValidateReentryPolicy(policy string) error {
+	switch policy {
+	case "", ReentryNone, ReentryScanOut:
+		return nil
+	}
+	return ErrInvalidReentryPolicy
+}
+
+// scan of a ticket at a gate of its event
+type CheckIn struct {
+	ID			uint 		`json:"id" gorm:"primary_key; auto_increment; not_null"`
+	TicketID	uint		`json:"ticket_id"`
+	EventID		uint		`json:"event_id"`
+	Gate		string		`json:"gate" example:"North 2"`
+	// account that scanned the ticket, nil once it was deleted
+	ScannerID	*uint		`json:"scanner_id,omitempty"`
+	Direction	string		`json:"direction" example:"in"`
+	Result		string		`json:"result"`
+	// time on the scanner, for offline scans the time they happened and not the time of the upload
+	ScannedAt	time.Time	`json:"scanned_at"`
+	// uploaded from the offline log of a scanner
+	Offline		bool		`json:"offline"`
+}
+
+// live entry numbers of an event
+type CheckInStats struct {
+	EventID		uint		`json:"event_id"`
+	// issued tickets of the event
+	Issued		int64		`json:"issued"`
+	// issued tickets that entered at least once
+	Admitted	int64		`json:"admitted"`
+	// tickets currently inside, admitted ones that did not scan out
+	Inside		int64		`json:"inside"`
+	// admissions per gate, re-entries included
+	Gates		map[string]int64 `json:"gates"`
+}
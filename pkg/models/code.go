@@ -0,0 +1,40 @@
+package models
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/hex"
+	"regexp"
+	"strings"
+)
+
+// codes customers type in at checkout like presale and promo codes
+var codePattern = regexp.MustCompile(`^[A-Z0-9_-]{4,64}$`)
+
+// upper cases a code the way it is stored
+func NormalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// reports whether a normalized code only has letters, digits, - and _
+func ValidCode(code string) bool {
+	return codePattern.MatchString(code)
+}
+
+// generates an unguessable code for admins that leave it empty
+func RandomCode() (string, error) {
+	b := make([]byte, 10)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base32.StdEncoding.EncodeToString(b), nil
+}
+
+// generates the secret entry code of a ticket, 128 random bits in hex
+func NewEntryCode() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
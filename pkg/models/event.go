@@ -1,12 +1,138 @@
 package models
 
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// layout of StartsLocal, sorts like the time it represents
+const LocalTimeLayout = "2006-01-02T15:04:05"
+
+var ErrInvalidEventTimes = errors.New("invalid event times")
+
+// states of the ticket sale of an event
+const (
+	SaleNotStarted = "not_started"
+	SaleOpen = "open"
+	SaleEnded = "ended"
+)
 
 type Event struct {
-	ID			uint 		`json:"id"	gorm:"primary_key; auto_increment; not_null"`
+	ID			uint 		`json:"id" gorm:"primary_key; auto_increment; not_null"`
 	Band_Name	string		`json:"band_name"`
+	VenueID		uint		`json:"venue_id"`
+	// name of the venue, kept in sync by the store
 	Location	string		`json:"location"`
-	Price		string		`json:"price"`
+	Price		Money		`json:"price" gorm:"embedded;embeddedPrefix:price_"`
 	Capacity	int			`json:"capacity"`
-	Date 		string		`json:"date"`
-}	
+	// IANA name of the timezone the event takes place in, times are rendered in it
+	Timezone	string		`json:"timezone" example:"Europe/Berlin"`
+	StartsAt	time.Time	`json:"starts_at"`
+	EndsAt		time.Time	`json:"ends_at"`
+	DoorsOpenAt	time.Time	`json:"doors_open_at"`
+	// tickets are sold from SalesStart until SalesEnd, an open start sells right away
+	// and an open end sells until the event ends; presale codes unlock the time before
+	SalesStart	*time.Time	`json:"sales_start,omitempty"`
+	SalesEnd	*time.Time	`json:"sales_end,omitempty"`
+	// whether tickets scanned out at a gate admit again, none or scan_out
+	ReentryPolicy string	`json:"reentry_policy" example:"none"`
+	// wall clock start time at the venue, lets events be found by their local date
+	StartsLocal	string		`json:"-"`
+	// tiers with their availability, only filled for a single event
+	TicketTypes	[]TicketType `json:"ticket_types,omitempty" gorm:"-"`
+}
+
+// loads the timezone of the event
+func (e Event) Zone() (*time.Location, error) {
+	if e.Timezone == "" {
+		return nil, ErrInvalidEventTimes
+	}
+	return time.LoadLocation(e.Timezone)
+}
+
+// checks the timezone and the order of the times, stores them in UTC
+// and derives StartsLocal; doors open at the start unless set
+func (e *Event) NormalizeTimes() error {
+	zone, err := e.Zone()
+	if err != nil {
+		return ErrInvalidEventTimes
+	}
+	if e.DoorsOpenAt.IsZero() {
+		e.DoorsOpenAt = e.StartsAt
+	}
+	if e.StartsAt.IsZero() || !e.EndsAt.After(e.StartsAt) || e.DoorsOpenAt.After(e.StartsAt) {
+		return ErrInvalidEventTimes
+	}
+	if e.SalesStart != nil && e.SalesEnd != nil && !e.SalesStart.Before(*e.SalesEnd) {
+		return ErrInvalidEventTimes
+	}
+
+	e.StartsAt = e.StartsAt.UTC()
+	e.EndsAt = e.EndsAt.UTC()
+	e.DoorsOpenAt = e.DoorsOpenAt.UTC()
+	for _, sales := range []**time.Time{&e.SalesStart, &e.SalesEnd} {
+		if *sales != nil {
+			utc := (*sales).UTC()
+			*sales = &utc
+		}
+	}
+	e.StartsLocal = e.StartsAt.In(zone).Format(LocalTimeLayout)
+	return nil
+}
+
+// renders the times in the timezone of the event
+func (e Event) MarshalJSON() ([]byte, error) {
+	type plain Event
+	if zone, err := e.Zone(); err == nil {
+		e.StartsAt = e.StartsAt.In(zone)
+		e.EndsAt = e.EndsAt.In(zone)
+		e.DoorsOpenAt = e.DoorsOpenAt.In(zone)
+		for _, sales := range []**time.Time{&e.SalesStart, &e.SalesEnd} {
+			if *sales != nil {
+				local := (*sales).In(zone)
+				*sales = &local
+			}
+		}
+	}
+	return json.Marshal(plain(e))
+}
+
+// state of the ticket sale at now, a ticket type can narrow the sale window
+// of the event but not widen it
+func (e Event) SaleState(ticketType *TicketType, now time.Time) string {
+	start, end := e.SalesStart, e.SalesEnd
+	if end == nil && !e.EndsAt.IsZero() {
+		end = &e.EndsAt
+	}
+	if ticketType != nil {
+		if ticketType.SalesStart != nil && (start == nil || ticketType.SalesStart.After(*start)) {
+			start = ticketType.SalesStart
+		}
+		if ticketType.SalesEnd != nil && (end == nil || ticketType.SalesEnd.Before(*end)) {
+			end = ticketType.SalesEnd
+		}
+	}
+
+	switch {
+	case end != nil && !now.Before(*end):
+		return SaleEnded
+	case start != nil && now.Before(*start):
+		return SaleNotStarted
+	}
+	return SaleOpen
+}
 
+// parses an RFC 3339 time, times without offset like "2022-10-11T20:00"
+// are wall clock times in zone
+func ParseEventTime(value string, zone *time.Location) (time.Time, error) {
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsed, nil
+	}
+	for _, layout := range []string{LocalTimeLayout, "2006-01-02T15:04"} {
+		if parsed, err := time.ParseInLocation(layout, value, zone); err == nil {
+			return parsed, nil
+		}
+	}
+	return time.Time{}, ErrInvalidEventTimes
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+const (
+	HoldActive = "active"
+	HoldConfirmed = "confirmed"
+	HoldReleased = "released"
+	HoldExpired = "expired"
+)
+
+// reserves tickets of an event for a user until ExpiresAt,
+// active holds count against the event capacity like sold tickets
+type Hold struct {
+	ID			uint 		`json:"id" gorm:"primary_key; auto_increment; not_null"`
+	UserID		uint		`json:"user_id"`
+	EventID		uint		`json:"event_id"`
+	Quantity	int			`json:"quantity"`
+	// tier the tickets are reserved in
+	TicketTypeID *uint		`json:"ticket_type_id,omitempty"`
+	// presale code that unlocked the tickets before the general sale
+	PresaleCodeID *uint		`json:"presale_code_id,omitempty"`
+	Status		string		`json:"status"`
+	ExpiresAt	time.Time	`json:"expires_at"`
+	CreatedAt	time.Time	`json:"created_at"`
+	// order created when the hold was confirmed
+	OrderID		*uint		`json:"order_id,omitempty"`
+}
+
+// reports whether the hold still reserves tickets at now
+func (hold Hold) IsActive(now time.Time) bool {
+	return hold.Status == HoldActive && hold.ExpiresAt.After(now)
+}
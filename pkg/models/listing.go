@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// states of a resale listing
+const (
+	// can be bought
+	ListingActive = "active"
+	// a buyer's order is waiting for its payment
+	ListingReserved = "reserved"
+	// the buyer owns the ticket now
+	ListingSold = "sold"
+	// taken off the marketplace by the seller, an admin or the start of the event
+	ListingWithdrawn = "withdrawn"
+)
+
+// ticket offered for resale by its owner, at most at the face value of the ticket
+type Listing struct {
+	ID			uint 		`json:"id" gorm:"primary_key; auto_increment; not_null"`
+	TicketID	uint		`json:"ticket_id"`
+	EventID		uint		`json:"event_id"`
+	SellerID	uint		`json:"seller_id"`
+	// asked from the buyer and paid out to the seller
+	Price		Money		`json:"price" gorm:"embedded;embeddedPrefix:price_"`
+	// price of the ticket before any discount, the price may not exceed it; the markdown
+	// of a resale is the difference to the price
+	FaceValue	Money		`json:"face_value" gorm:"embedded;embeddedPrefix:face_value_"`
+	Status		string		`json:"status"`
+	// order of the buyer while the listing is reserved or sold
+	OrderID		*uint		`json:"order_id,omitempty"`
+	CreatedAt	time.Time	`json:"created_at"`
+	// when the listing was sold or withdrawn
+	ClosedAt	*time.Time	`json:"closed_at,omitempty"`
+}
+
+// reports whether the listing still holds on to its ticket
+func (l Listing) IsOpen() bool {
+	return l.Status == ListingActive || l.Status == ListingReserved
+}
+
+// money owed to the seller of a resold ticket, recorded when the buyer's payment succeeds
+type Payout struct {
+	ID			uint 		`json:"id" gorm:"primary_key; auto_increment; not_null"`
+	ListingID	uint		`json:"listing_id"`
+	UserID		uint		`json:"user_id"`
+	// order of the buyer that paid for the ticket
+	OrderID		uint		`json:"order_id"`
+	Amount		Money		`json:"amount" gorm:"embedded;embeddedPrefix:amount_"`
+	CreatedAt	time.Time	`json:"created_at"`
+}
@@ -0,0 +1,139 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidPrice = errors.New("invalid price")
+	ErrInvalidCurrency = errors.New("unknown currency")
+	ErrCurrencyMismatch = errors.New("currencies do not match")
+)
+
+// number of minor unit digits of the supported ISO 4217 currencies
+var currencyDigits = map[string]int{
+	"AED": 2, "ARS": 2, "AUD": 2, "BGN": 2, "BHD": 3, "BRL": 2, "CAD": 2, "CHF": 2,
+	"CLP": 0, "CNY": 2, "COP": 2, "CZK": 2, "DKK": 2, "EGP": 2, "EUR": 2, "GBP": 2,
+	"HKD": 2, "HUF": 2, "IDR": 2, "ILS": 2, "INR": 2, "ISK": 0, "JOD": 3, "JPY": 0,
+	"KRW": 0, "KWD": 3, "MXN": 2, "MYR": 2, "NOK": 2, "NZD": 2, "OMR": 3, "PHP": 2,
+	"PLN": 2, "RON": 2, "RSD": 2, "SAR": 2, "SEK": 2, "SGD": 2, "THB": 2, "TND": 3,
+	"TRY": 2, "TWD": 2, "UAH": 2, "USD": 2, "VND": 0, "ZAR": 2,
+}
+
+// reports whether code is a supported ISO 4217 currency code
+func ValidCurrency(code string) bool {
+	_, ok := currencyDigits[code]
+	return ok
+}
+
+// amount of money in minor units of an ISO 4217 currency, e.g. cents for EUR
+type Money struct {
+	Amount		int64		`json:"amount" example:"5500"`
+	Currency	string		`json:"currency" example:"EUR"`
+}
+
+// parses a decimal amount like "55" or "55.50" in the major unit of currency
+func ParseMoney(amount string, currency string) (Money, error) {
+	digits, ok := currencyDigits[currency]
+	if !ok {
+		return Money{}, ErrInvalidCurrency
+	}
+
+	units, fraction, hasFraction := strings.Cut(strings.TrimSpace(amount), ".")
+	if len(fraction) > digits || (hasFraction && fraction == "") {
+		return Money{}, ErrInvalidPrice
+	}
+	fraction += strings.Repeat("0", digits-len(fraction))
+
+	whole, err := strconv.ParseUint(units, 10, 63)
+	if err != nil {
+		return Money{}, ErrInvalidPrice
+	}
+	minor := uint64(0)
+	if fraction != "" {
+		if minor, err = strconv.ParseUint(fraction, 10, 63); err != nil {
+			return Money{}, ErrInvalidPrice
+		}
+	}
+	// amounts beyond int64 minor units would wrap around
+	scale := pow10(digits)
+	if whole > uint64((math.MaxInt64-int64(minor))/scale) {
+		return Money{}, ErrInvalidPrice
+	}
+	return Money{Amount: int64(whole)*scale + int64(minor), Currency: currency}, nil
+}
+
+func pow10(n int) int64 {
+	result := int64(1)
+	for i := 0; i < n; i++ {
+		result *= 10
+	}
+	return result
+}
+
+// checks for a known currency and a non-negative amount
+func (m Money) Validate() error {
+	if !ValidCurrency(m.Currency) {
+		return ErrInvalidCurrency
+	}
+	if m.Amount < 0 {
+		return ErrInvalidPrice
+	}
+	return nil
+}
+
+// formats the amount in the major unit, e.g. "55.50"
+func (m Money) Decimal() string {
+	digits := currencyDigits[m.Currency]
+	if digits == 0 {
+		return strconv.FormatInt(m.Amount, 10)
+	}
+	sign, amount := "", m.Amount
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	return fmt.Sprintf("%s%d.%0*d", sign, amount/pow10(digits), digits, amount%pow10(digits))
+}
+
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency
+}
+
+// sums up two amounts of the same currency
+func (m Money) Add(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return m, ErrCurrencyMismatch
+	}
+	return Money{Amount: m.Amount + other.Amount, Currency: m.Currency}, nil
+}
+
+func (m Money) Times(n int64) Money {
+	return Money{Amount: m.Amount * n, Currency: m.Currency}
+}
+
+// share of the amount in percent, rounded down to the minor unit
+func (m Money) Percent(percent int) Money {
+	return Money{Amount: m.Amount * int64(percent) / 100, Currency: m.Currency}
+}
+
+// accepts {"amount": 5500, "currency": "EUR"} as well as decimal strings like "55.00 EUR"
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		type plain Money
+		return json.Unmarshal(data, (*plain)(m))
+	}
+
+	amount, currency, _ := strings.Cut(strings.TrimSpace(text), " ")
+	parsed, err := ParseMoney(amount, strings.ToUpper(strings.TrimSpace(currency)))
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
@@ -0,0 +1,123 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		amount		string
+		currency	string
+		want		int64
+		err			error
+	}{
+		{"55", "EUR", 5500, nil},
+		{"55.5", "EUR", 5550, nil},
+		{" 55.50 ", "EUR", 5550, nil},
+		{"0", "EUR", 0, nil},
+		{"0.01", "EUR", 1, nil},
+		{"55.505", "EUR", 0, ErrInvalidPrice},
+		{"55.", "EUR", 0, ErrInvalidPrice},
+		{".50", "EUR", 0, ErrInvalidPrice},
+		{"-5", "EUR", 0, ErrInvalidPrice},
+		{"5,50", "EUR", 0, ErrInvalidPrice},
+		{"", "EUR", 0, ErrInvalidPrice},
+		{"55", "XYZ", 0, ErrInvalidCurrency},
+		{"55", "eur", 0, ErrInvalidCurrency},
+		// currencies without minor units
+		{"5500", "JPY", 5500, nil},
+		{"5500.5", "JPY", 0, ErrInvalidPrice},
+		{"5500.", "JPY", 0, ErrInvalidPrice},
+		// currencies with three minor digits
+		{"12.345", "KWD", 12345, nil},
+		{"12.3", "KWD", 12300, nil},
+		{"12", "BHD", 12000, nil},
+		{"12.3456", "KWD", 0, ErrInvalidPrice},
+		// the largest amounts int64 minor units can hold
+		{"92233720368547758.07", "EUR", 9223372036854775807, nil},
+		{"92233720368547758.08", "EUR", 0, ErrInvalidPrice},
+		{"92233720368547759", "EUR", 0, ErrInvalidPrice},
+		{"9223372036854775807", "JPY", 9223372036854775807, nil},
+		{"9223372036854775808", "JPY", 0, ErrInvalidPrice},
+		{"9223372036854775.807", "KWD", 9223372036854775807, nil},
+		{"9223372036854776", "KWD", 0, ErrInvalidPrice},
+		{"100000000000000000000", "EUR", 0, ErrInvalidPrice},
+	}
+	for _, test := range tests {
+		money, err := ParseMoney(test.amount, test.currency)
+		if !errors.Is(err, test.err) {
+			t.Errorf("ParseMoney(%q, %q) = %v, want %v", test.amount, test.currency, err, test.err)
+			continue
+		}
+		if err == nil && (money.Amount != test.want || money.Currency != test.currency) {
+			t.Errorf("ParseMoney(%q, %q) = %v, want %d %s", test.amount, test.currency, money, test.want, test.currency)
+		}
+	}
+}
+
+func TestMoneyDecimal(t *testing.T) {
+	tests := []struct {
+		money	Money
+		want	string
+	}{
+		{Money{5550, "EUR"}, "55.50 EUR"},
+		{Money{5, "EUR"}, "0.05 EUR"},
+		{Money{-5, "EUR"}, "-0.05 EUR"},
+		{Money{5500, "JPY"}, "5500 JPY"},
+		{Money{12345, "KWD"}, "12.345 KWD"},
+		{Money{5, "KWD"}, "0.005 KWD"},
+	}
+	for _, test := range tests {
+		if got := test.money.String(); got != test.want {
+			t.Errorf("String of %d %s = %q, want %q", test.money.Amount, test.money.Currency, got, test.want)
+		}
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	tests := []struct {
+		json	string
+		want	Money
+		err		bool
+	}{
+		{`{"amount": 5550, "currency": "EUR"}`, Money{5550, "EUR"}, false},
+		{`"55.50 EUR"`, Money{5550, "EUR"}, false},
+		{`" 55.50 eur "`, Money{5550, "EUR"}, false},
+		{`"5500 JPY"`, Money{5500, "JPY"}, false},
+		{`"12.345 KWD"`, Money{12345, "KWD"}, false},
+		// decimal strings have to name their currency
+		{`"55.50"`, Money{}, true},
+		{`"55.505 EUR"`, Money{}, true},
+		{`"92233720368547758.08 EUR"`, Money{}, true},
+		{`"55.50 XYZ"`, Money{}, true},
+		{`true`, Money{}, true},
+	}
+	for _, test := range tests {
+		var money Money
+		err := json.Unmarshal([]byte(test.json), &money)
+		if (err != nil) != test.err {
+			t.Errorf("Unmarshal(%s) = %v, want error %v", test.json, err, test.err)
+			continue
+		}
+		if err == nil && money != test.want {
+			t.Errorf("Unmarshal(%s) = %+v, want %+v", test.json, money, test.want)
+		}
+	}
+
+	for _, money := range []Money{{5550, "EUR"}, {5500, "JPY"}, {12345, "KWD"}} {
+		data, err := json.Marshal(money)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded Money
+		if err := json.Unmarshal(data, &decoded); err != nil || decoded != money {
+			t.Errorf("Marshal of %+v gives %s that decodes to %+v, %v", money, data, decoded, err)
+		}
+	}
+	data, _ := json.Marshal(Money{5550, "EUR"})
+	if string(data) != `{"amount":5550,"currency":"EUR"}` {
+		t.Errorf("Marshal = %s, want the amount in minor units and the currency", data)
+	}
+}
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+const (
+	// tickets are reserved while the payment is outstanding
+	OrderPending = "pending"
+	OrderCompleted = "completed"
+	// payment failed, the tickets were given back
+	OrderFailed = "failed"
+)
+
+// groups the tickets bought in one checkout
+type Order struct {
+	ID			uint 		`json:"id" gorm:"primary_key; auto_increment; not_null"`
+	UserID		uint		`json:"user_id"`
+	Status		string		`json:"status"`
+	// amount charged, net of the discount
+	Total		Money		`json:"total" gorm:"embedded;embeddedPrefix:total_"`
+	// sum of the discounts of the tickets
+	Discount	Money		`json:"discount" gorm:"embedded;embeddedPrefix:discount_"`
+	// promotion the tickets were discounted with
+	PromotionID	*uint		`json:"promotion_id,omitempty"`
+	// presale code that unlocked the tickets before the general sale
+	PresaleCodeID *uint		`json:"presale_code_id,omitempty"`
+	// resale listing the order buys, its ticket joins the order once the payment succeeded
+	ListingID	*uint		`json:"listing_id,omitempty"`
+	CreatedAt	time.Time	`json:"created_at"`
+	Tickets		[]Ticket	`json:"tickets"`
+	Payments	[]Payment	`json:"payments,omitempty"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+const (
+	PaymentPending = "pending"
+	PaymentSucceeded = "succeeded"
+	PaymentFailed = "failed"
+)
+
+// charge of an order at the payment provider, amounts are in minor units of the currency
+type Payment struct {
+	ID			uint 		`json:"id" gorm:"primary_key; auto_increment; not_null"`
+	OrderID		uint		`json:"order_id"`
+	Provider	string		`json:"provider"`
+	IntentID	string		`json:"intent_id"`
+	Amount		int64		`json:"amount"`
+	Currency	string		`json:"currency"`
+	Status		string		`json:"status"`
+	CreatedAt	time.Time	`json:"created_at"`
+	UpdatedAt	time.Time	`json:"updated_at"`
+}
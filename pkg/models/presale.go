@@ -0,0 +1,47 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrInvalidPresaleCode = errors.New("invalid presale code")
+
+// unlocks buying tickets of an event before its general sale starts, every order
+// or hold bought with the code counts as one use until MaxUses is reached
+type PresaleCode struct {
+	ID			uint 		`json:"id" gorm:"primary_key; auto_increment; not_null"`
+	EventID		uint		`json:"event_id"`
+	// limits the code to one ticket type of the event
+	TicketTypeID *uint		`json:"ticket_type_id,omitempty"`
+	// unique per event, stored in upper case and matched ignoring case
+	Code		string		`json:"code" example:"FANCLUB22"`
+	// 1 for a single-use code
+	MaxUses		int			`json:"max_uses" example:"100"`
+	// the code works from ValidFrom until ValidUntil, open ends have no limit
+	ValidFrom	*time.Time	`json:"valid_from,omitempty"`
+	ValidUntil	*time.Time	`json:"valid_until,omitempty"`
+	CreatedAt	time.Time	`json:"created_at"`
+	// orders that did not fail and active holds bought with the code, not stored
+	Uses		int			`json:"uses" gorm:"-"`
+}
+
+// normalizes the code and checks it together with the uses limit and validity
+func (p *PresaleCode) Normalize() error {
+	p.Code = NormalizeCode(p.Code)
+	if !ValidCode(p.Code) || p.MaxUses < 1 {
+		return ErrInvalidPresaleCode
+	}
+	if p.ValidFrom != nil && p.ValidUntil != nil && !p.ValidFrom.Before(*p.ValidUntil) {
+		return ErrInvalidPresaleCode
+	}
+	return nil
+}
+
+// reports whether the code unlocks tickets of the ticket type at now, uses are not checked
+func (p PresaleCode) Unlocks(ticketType *TicketType, now time.Time) bool {
+	if p.TicketTypeID != nil && (ticketType == nil || ticketType.ID != *p.TicketTypeID) {
+		return false
+	}
+	return (p.ValidFrom == nil || !now.Before(*p.ValidFrom)) && (p.ValidUntil == nil || now.Before(*p.ValidUntil))
+}
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// kinds of promotions
+const (
+	// takes Percent percent off every ticket
+	PromotionPercent = "percent"
+	// takes AmountOff off every ticket, at most its price
+	PromotionFixed = "fixed"
+)
+
+var ErrInvalidPromotion = errors.New("invalid promotion")
+
+// promo code that discounts the tickets of an order, either for all events or
+// for a single one; every order that did not fail counts as one use
+type Promotion struct {
+	ID			uint 		`json:"id" gorm:"primary_key; auto_increment; not_null"`
+	// unique, stored in upper case and matched ignoring case
+	Code		string		`json:"code" example:"SUMMER10"`
+	Kind		string		`json:"kind" example:"percent"`
+	// 1 to 100 for percent promotions
+	Percent		int			`json:"percent,omitempty" example:"10"`
+	// discount per ticket of fixed promotions, tickets in other currencies do not get it
+	AmountOff	Money		`json:"amount_off" gorm:"embedded;embeddedPrefix:amount_off_"`
+	// limits the promotion to the tickets of one event
+	EventID		*uint		`json:"event_id,omitempty"`
+	// 0 for no limit
+	MaxUses		int			`json:"max_uses" example:"500"`
+	MaxUsesPerUser int		`json:"max_uses_per_user" example:"1"`
+	// the code works from ValidFrom until ValidUntil, open ends have no limit
+	ValidFrom	*time.Time	`json:"valid_from,omitempty"`
+	ValidUntil	*time.Time	`json:"valid_until,omitempty"`
+	CreatedAt	time.Time	`json:"created_at"`
+	// orders that did not fail using the promotion, not stored
+	Uses		int			`json:"uses" gorm:"-"`
+}
+
+// normalizes the code and checks discount, limits and validity
+func (p *Promotion) Normalize() error {
+	p.Code = NormalizeCode(p.Code)
+	if !ValidCode(p.Code) || p.MaxUses < 0 || p.MaxUsesPerUser < 0 {
+		return ErrInvalidPromotion
+	}
+
+	switch p.Kind {
+	case PromotionPercent:
+		if p.Percent < 1 || p.Percent > 100 {
+			return ErrInvalidPromotion
+		}
+		p.AmountOff = Money{}
+	case PromotionFixed:
+		if err := p.AmountOff.Validate(); err != nil || p.AmountOff.Amount == 0 {
+			return ErrInvalidPromotion
+		}
+		p.Percent = 0
+	default:
+		return ErrInvalidPromotion
+	}
+
+	if p.ValidFrom != nil && p.ValidUntil != nil && !p.ValidFrom.Before(*p.ValidUntil) {
+		return ErrInvalidPromotion
+	}
+	return nil
+}
+
+// reports whether the promotion can be used at now, limits are not checked
+func (p Promotion) Active(now time.Time) bool {
+	return (p.ValidFrom == nil || !now.Before(*p.ValidFrom)) && (p.ValidUntil == nil || now.Before(*p.ValidUntil))
+}
+
+// discount the promotion gives on a ticket of the event at price, zero when
+// it does not apply to the event or currency
+func (p Promotion) Discount(eventID uint, price Money) Money {
+	discount := Money{Currency: price.Currency}
+	if p.EventID != nil && *p.EventID != eventID {
+		return discount
+	}
+
+	switch p.Kind {
+	case PromotionPercent:
+		discount = price.Percent(p.Percent)
+	case PromotionFixed:
+		if p.AmountOff.Currency == price.Currency {
+			discount.Amount = p.AmountOff.Amount
+		}
+	}
+	if discount.Amount > price.Amount {
+		discount.Amount = price.Amount
+	}
+	return discount
+}
@@ -0,0 +1,60 @@
+package models
+
+import "time"
+
+const (
+	RefundPending = "pending"
+	RefundSucceeded = "succeeded"
+	RefundFailed = "failed"
+
+	// refund of a cancellation following the refund policy of the event
+	RefundReasonPolicy = "policy"
+	// refund issued by an admin outside of the policy
+	RefundReasonManual = "manual"
+	// refund in full because the event was cancelled
+	RefundReasonEventCancelled = "event_cancelled"
+)
+
+// money paid back for a ticket, amounts are in minor units of the currency
+type Refund struct {
+	ID					uint 		`json:"id" gorm:"primary_key; auto_increment; not_null"`
+	TicketID			uint		`json:"ticket_id"`
+	// payment the money goes back to, tickets without one are refunded outside of the provider
+	PaymentID			*uint		`json:"payment_id,omitempty"`
+	Amount				int64		`json:"amount"`
+	Currency			string		`json:"currency"`
+	Status				string		`json:"status"`
+	Reason				string		`json:"reason"`
+	Note				string		`json:"note,omitempty"`
+	ProviderRefundID	string		`json:"provider_refund_id,omitempty"`
+	CreatedAt			time.Time	`json:"created_at"`
+	UpdatedAt			time.Time	`json:"updated_at"`
+}
+
+// how much of the price is refunded depending on how early a ticket is cancelled
+type RefundPolicy struct {
+	EventID					uint	`json:"event_id" gorm:"primaryKey"`
+	// cancellations at least this many days before the event are refunded in full
+	FullRefundDays			int		`json:"full_refund_days" example:"14"`
+	// later cancellations at least this many days before the event get PartialRefundPercent back
+	PartialRefundDays		int		`json:"partial_refund_days" example:"7"`
+	PartialRefundPercent	int		`json:"partial_refund_percent" example:"50"`
+}
+
+// share of the price in percent refunded for a cancellation at now, 0 once
+// the cancellation is too late for any refund
+func (p RefundPolicy) Percent(eventStart time.Time, now time.Time) int {
+	switch {
+	case !now.AddDate(0, 0, p.FullRefundDays).After(eventStart):
+		return 100
+	case !now.AddDate(0, 0, p.PartialRefundDays).After(eventStart):
+		return p.PartialRefundPercent
+	}
+	return 0
+}
+
+// checks that the windows are ordered and the percentage is in range
+func (p RefundPolicy) Valid() bool {
+	return p.PartialRefundDays >= 0 && p.FullRefundDays >= p.PartialRefundDays &&
+		p.PartialRefundPercent >= 0 && p.PartialRefundPercent <= 100
+}
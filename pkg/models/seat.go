@@ -0,0 +1,61 @@
+package models
+
+import (
+	"errors"
+	"strings"
+)
+
+var ErrInvalidSeatMap = errors.New("invalid seat map")
+
+// seat in a section of a venue
+type Seat struct {
+	ID			uint 		`json:"id" gorm:"primary_key; auto_increment; not_null"`
+	SectionID	uint		`json:"section_id"`
+	Row			string		`json:"row" gorm:"column:row_label" example:"12"`
+	Number		string		`json:"number" example:"7"`
+	Accessible	bool		`json:"accessible"`
+}
+
+// seat of one event, copied from the seat map of the venue so later changes
+// of the map do not move seats that were already sold
+type EventSeat struct {
+	ID			uint 		`json:"id" gorm:"primary_key; auto_increment; not_null"`
+	EventID		uint		`json:"event_id"`
+	Section		string		`json:"section" example:"Ostkurve"`
+	Row			string		`json:"row" gorm:"column:row_label" example:"12"`
+	Number		string		`json:"number" example:"7"`
+	Accessible	bool		`json:"accessible"`
+	// no issued ticket is on the seat
+	Available	bool		`json:"available" gorm:"-"`
+}
+
+// trims rows and numbers and checks that every seat is in a section of the venue,
+// exists once and that no section has more seats than its capacity
+func (v Venue) CheckSeats(seats []Seat) error {
+	capacities := map[uint]int{}
+	for _, section := range v.Sections {
+		capacities[section.ID] = section.Capacity
+	}
+
+	type label struct {
+		section		uint
+		row, number	string
+	}
+	labels := map[label]bool{}
+	for i := range seats {
+		seat := &seats[i]
+		seat.Row = strings.TrimSpace(seat.Row)
+		seat.Number = strings.TrimSpace(seat.Number)
+		key := label{seat.SectionID, strings.ToLower(seat.Row), strings.ToLower(seat.Number)}
+		if seat.Row == "" || seat.Number == "" || labels[key] {
+			return ErrInvalidSeatMap
+		}
+		labels[key] = true
+
+		capacities[seat.SectionID]--
+		if capacities[seat.SectionID] < 0 {
+			return ErrInvalidSeatMap
+		}
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// key pair that signs access tokens, a key signs between ActivatesAt and RetiresAt and is
+// published for verification from its creation until ExpiresAt
+type SigningKey struct {
+	// kid header of the tokens signed with the key
+	ID			string		`json:"id" gorm:"primary_key"`
+	// RS256 or EdDSA
+	Algorithm	string		`json:"algorithm"`
+	// PKCS #8, encrypted with the jwt secret
+	PrivateKey	[]byte		`json:"-"`
+	// PKIX
+	PublicKey	[]byte		`json:"public_key"`
+	CreatedAt	time.Time	`json:"created_at"`
+	ActivatesAt	time.Time	`json:"activates_at"`
+	RetiresAt	time.Time	`json:"retires_at"`
+	// the last tokens signed with the key have expired by then
+	ExpiresAt	time.Time	`json:"expires_at"`
+}
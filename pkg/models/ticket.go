@@ -1,8 +1,36 @@
 package models
 
+import "time"
+
+// a ticket is issued when bought, cancelled tickets give their seat back
+// and become refunded once their refund went through
+const (
+	TicketIssued = "issued"
+	TicketCancelled = "cancelled"
+	TicketRefunded = "refunded"
+)
+
 type Ticket struct {
-	ID			uint 		`json:"id"	gorm:"primary_key; auto_increment; not_null"`
+	ID			uint 		`json:"id" gorm:"primary_key; auto_increment; not_null"`
 	UserID		uint		`json:"user_id" gorm:"foreignKey:UserID"`
 	EventID		uint		`json:"event_id" gorm:"foreignKey:EventID"`
-	Price		string		`json:"price"`
-}	
\ No newline at end of file
+	// price its holder paid for the ticket, net of the discount; the listing price once resold
+	Price		Money		`json:"price" gorm:"embedded;embeddedPrefix:price_"`
+	// taken off the price of the event or ticket type by a promotion
+	Discount	Money		`json:"discount" gorm:"embedded;embeddedPrefix:discount_"`
+	// set when the ticket was bought as part of an order, a resale keeps it and records
+	// the order of the buyer on the listing
+	OrderID		*uint		`json:"order_id,omitempty"`
+	// tier the ticket was bought in, events without tiers sell tickets without one
+	TicketTypeID *uint		`json:"ticket_type_id,omitempty"`
+	// seat of the event for reserved seating, general admission tickets have none
+	SeatID		*uint		`json:"seat_id,omitempty"`
+	Status		string		`json:"status"`
+	// unguessable secret shown at the door inside the signed entry pass
+	Code		string		`json:"code"`
+	// first admission at the door, admitted tickets cannot be cancelled anymore
+	AdmittedAt	*time.Time	`json:"admitted_at,omitempty"`
+	// admitted and not scanned out
+	Inside		bool		`json:"inside,omitempty"`
+	CancelledAt	*time.Time	`json:"cancelled_at,omitempty"`
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+var ErrInvalidTicketType = errors.New("invalid ticket type")
+
+// tier of an event like standing, VIP or early bird with its own price, quota
+// and sale window; the quotas of an event add up to at most its capacity
+type TicketType struct {
+	ID			uint 		`json:"id" gorm:"primary_key; auto_increment; not_null"`
+	EventID		uint		`json:"event_id"`
+	Name		string		`json:"name" example:"VIP"`
+	Price		Money		`json:"price" gorm:"embedded;embeddedPrefix:price_"`
+	Quota		int			`json:"quota" example:"500"`
+	// narrows the sale window of the event, open ends keep the one of the event
+	SalesStart	*time.Time	`json:"sales_start,omitempty"`
+	SalesEnd	*time.Time	`json:"sales_end,omitempty"`
+	// tickets that can still be bought, only filled when listed for an event
+	Available	int			`json:"available" gorm:"-"`
+}
+
+// trims the name and checks price, quota and sale window
+func (t *TicketType) Normalize() error {
+	t.Name = strings.TrimSpace(t.Name)
+	if t.Name == "" || t.Quota <= 0 {
+		return ErrInvalidTicketType
+	}
+	if err := t.Price.Validate(); err != nil {
+		return err
+	}
+	if t.SalesStart != nil && t.SalesEnd != nil && !t.SalesStart.Before(*t.SalesEnd) {
+		return ErrInvalidTicketType
+	}
+	return nil
+}
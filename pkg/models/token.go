@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// refresh token handed out with an access token, every refresh replaces it with the
+// next token of the same family; only the hash of the token is stored
+type RefreshToken struct {
+	ID			uint 		`json:"id" gorm:"primary_key; auto_increment; not_null"`
+	UserID		uint		`json:"user_id"`
+	// the login the token descends from, all tokens of a family are revoked together
+	FamilyID	string		`json:"family_id"`
+	TokenHash	string		`json:"-"`
+	CreatedAt	time.Time	`json:"created_at"`
+	ExpiresAt	time.Time	`json:"expires_at"`
+	// when the token was exchanged for its successor, a second use is a replay
+	UsedAt		*time.Time	`json:"used_at,omitempty"`
+	RevokedAt	*time.Time	`json:"revoked_at,omitempty"`
+}
+
+// entry of the revocation list, access tokens of the family are rejected until
+// ExpiresAt, by then all of them have expired on their own
+type TokenRevocation struct {
+	FamilyID	string		`json:"family_id" gorm:"primary_key"`
+	RevokedAt	time.Time	`json:"revoked_at"`
+	ExpiresAt	time.Time	`json:"expires_at"`
+}
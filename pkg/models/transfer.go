@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// states of a ticket transfer
+const (
+	// waits for the recipient until ExpiresAt
+	TransferPending = "pending"
+	// the recipient owns the ticket now
+	TransferAccepted = "accepted"
+	// the recipient turned the ticket down
+	TransferDeclined = "declined"
+	// the sender took the transfer back
+	TransferCancelled = "cancelled"
+	// the recipient did not answer in time
+	TransferExpired = "expired"
+)
+
+// handover of a ticket from its owner to another user, the accepted transfers of
+// a ticket are the chain of its owners
+type Transfer struct {
+	ID			uint 		`json:"id" gorm:"primary_key; auto_increment; not_null"`
+	TicketID	uint		`json:"ticket_id"`
+	FromUserID	uint		`json:"from_user_id"`
+	ToUserID	uint		`json:"to_user_id"`
+	Status		string		`json:"status"`
+	CreatedAt	time.Time	`json:"created_at"`
+	// the recipient has to accept before, at the latest when the event starts
+	ExpiresAt	time.Time	`json:"expires_at"`
+	// when the transfer was accepted, declined, cancelled or expired
+	ClosedAt	*time.Time	`json:"closed_at,omitempty"`
+}
+
+// reports whether the recipient can still accept at now
+func (t Transfer) IsPending(now time.Time) bool {
+	return t.Status == TransferPending && t.ExpiresAt.After(now)
+}
@@ -1,6 +1,8 @@
 package models
 
 import (
+	"time"
+
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -11,6 +13,8 @@ type User struct {
 	Email    	string 		`json:"email" binding:"required" gorm:"unique"`
 	Password 	string 		`json:"password"`
 	Role		string		`json:"role"`
+	// nil while the email address is not verified, such accounts cannot log in
+	VerifiedAt	*time.Time	`json:"verified_at,omitempty"`
 }
 
 
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+var ErrInvalidVenue = errors.New("invalid venue")
+
+// place events take place at, its sections are the layout reused by every event there
+type Venue struct {
+	ID				uint 			`json:"id" gorm:"primary_key; auto_increment; not_null"`
+	Name			string			`json:"name" example:"Olympiastadion"`
+	Address			string			`json:"address" example:"Olympischer Platz 3, 14053 Berlin"`
+	Latitude		*float64		`json:"latitude,omitempty" example:"52.5147"`
+	Longitude		*float64		`json:"longitude,omitempty" example:"13.2395"`
+	// IANA timezone, used for events at the venue that do not name one
+	Timezone		string			`json:"timezone" example:"Europe/Berlin"`
+	// capacity of events at the venue that do not set their own
+	DefaultCapacity	int				`json:"default_capacity" example:"74475"`
+	Sections		[]VenueSection	`json:"sections"`
+}
+
+type VenueSection struct {
+	ID			uint 		`json:"id" gorm:"primary_key; auto_increment; not_null"`
+	VenueID		uint		`json:"venue_id"`
+	Name		string		`json:"name" example:"Ostkurve"`
+	Capacity	int			`json:"capacity" example:"25000"`
+}
+
+// trims the name, checks timezone, coordinates and capacities; a missing
+// default capacity is the sum of the sections
+func (v *Venue) Normalize() error {
+	v.Name = strings.TrimSpace(v.Name)
+	if v.Name == "" || v.DefaultCapacity < 0 {
+		return ErrInvalidVenue
+	}
+	if _, err := time.LoadLocation(v.Timezone); err != nil || v.Timezone == "" {
+		return ErrInvalidVenue
+	}
+	if (v.Latitude == nil) != (v.Longitude == nil) {
+		return ErrInvalidVenue
+	}
+	if v.Latitude != nil && (*v.Latitude < -90 || *v.Latitude > 90 || *v.Longitude < -180 || *v.Longitude > 180) {
+		return ErrInvalidVenue
+	}
+
+	seated := 0
+	names := map[string]bool{}
+	for i := range v.Sections {
+		section := &v.Sections[i]
+		section.Name = strings.TrimSpace(section.Name)
+		if section.Name == "" || section.Capacity <= 0 || names[strings.ToLower(section.Name)] {
+			return ErrInvalidVenue
+		}
+		names[strings.ToLower(section.Name)] = true
+		seated += section.Capacity
+	}
+	if v.DefaultCapacity == 0 {
+		v.DefaultCapacity = seated
+	}
+	if seated > v.DefaultCapacity {
+		return ErrInvalidVenue
+	}
+	return nil
+}
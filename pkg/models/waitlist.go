@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+// states of a waitlist entry
+const (
+	// queued until tickets of the event free up
+	WaitlistWaiting = "waiting"
+	// the tickets are reserved by a hold until OfferExpiresAt
+	WaitlistOffered = "offered"
+	// the hold of the offer was confirmed
+	WaitlistAccepted = "accepted"
+	// the hold of the offer was released
+	WaitlistDeclined = "declined"
+	// the hold of the offer expired unused
+	WaitlistExpired = "expired"
+	// the user left the waitlist
+	WaitlistLeft = "left"
+)
+
+// place of a user in the waitlist of a sold-out event, entries are offered
+// the tickets that free up in the order they joined
+type WaitlistEntry struct {
+	ID			uint 		`json:"id" gorm:"primary_key; auto_increment; not_null"`
+	EventID		uint		`json:"event_id"`
+	UserID		uint		`json:"user_id"`
+	// tier the user waits for, required for events with ticket types
+	TicketTypeID *uint		`json:"ticket_type_id,omitempty"`
+	Quantity	int			`json:"quantity"`
+	Status		string		`json:"status"`
+	CreatedAt	time.Time	`json:"created_at"`
+	// hold reserving the offered tickets, confirm it to buy them
+	HoldID		*uint		`json:"hold_id,omitempty"`
+	OfferExpiresAt *time.Time `json:"offer_expires_at,omitempty"`
+	// 1 for the next waiting entry of the event, not stored and only set while waiting
+	Position	int			`json:"position,omitempty" gorm:"-"`
+}
+
+// reports whether the entry still waits or holds an offer
+func (e WaitlistEntry) IsOpen() bool {
+	return e.Status == WaitlistWaiting || e.Status == WaitlistOffered
+}
+
+// status an offer moves to at now depending on its hold, stays offered while
+// the hold reserves the tickets; a missing hold counts as expired
+func OfferStatus(hold *Hold, now time.Time) string {
+	switch {
+	case hold == nil:
+		return WaitlistExpired
+	case hold.Status == HoldConfirmed:
+		return WaitlistAccepted
+	case hold.Status == HoldReleased:
+		return WaitlistDeclined
+	case hold.IsActive(now):
+		return WaitlistOffered
+	}
+	return WaitlistExpired
+}
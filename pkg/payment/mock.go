@@ -0,0 +1,184 @@
+package payment
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// payment methods understood by the mock provider
+const (
+	MockCardOK = "pm_card_ok"
+	MockCardDeclined = "pm_card_declined"
+	// the result is only sent by webhook after the configured delay
+	MockCardDelayed = "pm_card_delayed"
+	MockCardDelayedDeclined = "pm_card_delayed_declined"
+
+	MockSignatureHeader = "X-Mock-Signature"
+)
+
+// in-process stand-in for a payment provider, delayed results are delivered
+// as signed HTTP webhooks to webhookURL like a real provider would do
+type Mock struct {
+	mu			sync.Mutex
+	intents		map[string]Intent
+	refunded	map[string]int64
+	secret		[]byte
+	webhookURL	string
+	delay		time.Duration
+	client		*http.Client
+}
+
+func NewMock(secret string, webhookURL string, delay time.Duration) *Mock {
+	return &Mock{
+		intents: map[string]Intent{},
+		refunded: map[string]int64{},
+		secret: []byte(secret),
+		webhookURL: webhookURL,
+		delay: delay,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (m *Mock) Name() string {
+	return "mock"
+}
+
+func randomID(prefix string) string {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return prefix + hex.EncodeToString(b)
+}
+
+func (m *Mock) CreateIntent(amount int64, currency string, reference string) (Intent, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	intent := Intent{
+		ID: randomID("pi_mock_"),
+		Amount: amount,
+		Currency: currency,
+		Reference: reference,
+		Status: IntentRequiresConfirmation,
+	}
+	m.intents[intent.ID] = intent
+	return intent, nil
+}
+
+func (m *Mock) Confirm(intentID string, paymentMethod string) (Intent, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	intent, ok := m.intents[intentID]
+	if !ok {
+		return intent, ErrUnknownIntent
+	}
+	if intent.Status != IntentRequiresConfirmation {
+		return intent, nil
+	}
+
+	switch paymentMethod {
+	case MockCardDeclined:
+		intent.Status = IntentFailed
+		intent.FailureReason = "card_declined"
+	case MockCardDelayed:
+		intent.Status = IntentProcessing
+		m.sendWebhookLater(intent.ID, EventPaymentSucceeded, IntentSucceeded)
+	case MockCardDelayedDeclined:
+		intent.Status = IntentProcessing
+		m.sendWebhookLater(intent.ID, EventPaymentFailed, IntentFailed)
+	default:
+		intent.Status = IntentSucceeded
+	}
+
+	m.intents[intent.ID] = intent
+	return intent, nil
+}
+
+func (m *Mock) Refund(intentID string, amount int64) (Refund, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	intent, ok := m.intents[intentID]
+	if !ok {
+		return Refund{}, ErrUnknownIntent
+	}
+	if intent.Status != IntentSucceeded || amount <= 0 || m.refunded[intentID]+amount > intent.Amount {
+		return Refund{}, ErrRefundNotPossible
+	}
+
+	m.refunded[intentID] += amount
+	return Refund{ID: randomID("re_mock_"), IntentID: intentID, Amount: amount, Status: RefundSucceeded}, nil
+}
+
+type mockWebhook struct {
+	ID			string		`json:"id"`
+	Type		string		`json:"type"`
+	IntentID	string		`json:"intent_id"`
+}
+
+// signs payload like a provider signs its webhooks
+func (m *Mock) Sign(payload []byte) string {
+	mac := hmac.New(sha256.New, m.secret)
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (m *Mock) VerifyWebhook(payload []byte, header http.Header) (WebhookEvent, error) {
+	signature, err := hex.DecodeString(header.Get(MockSignatureHeader))
+	if err != nil {
+		return WebhookEvent{}, ErrInvalidSignature
+	}
+	expected, _ := hex.DecodeString(m.Sign(payload))
+	if !hmac.Equal(signature, expected) {
+		return WebhookEvent{}, ErrInvalidSignature
+	}
+
+	var webhook mockWebhook
+	if err := json.Unmarshal(payload, &webhook); err != nil {
+		return WebhookEvent{}, err
+	}
+	return WebhookEvent{ID: webhook.ID, Type: webhook.Type, IntentID: webhook.IntentID}, nil
+}
+
+// settles the intent after the delay and reports the result by webhook,
+// caller must hold the lock
+func (m *Mock) sendWebhookLater(intentID string, eventType string, status string) {
+	time.AfterFunc(m.delay, func() {
+		m.mu.Lock()
+		intent := m.intents[intentID]
+		intent.Status = status
+		m.intents[intentID] = intent
+		m.mu.Unlock()
+
+		if m.webhookURL == "" {
+			return
+		}
+
+		payload, _ := json.Marshal(mockWebhook{ID: randomID("evt_mock_"), Type: eventType, IntentID: intentID})
+		request, err := http.NewRequest(http.MethodPost, m.webhookURL, bytes.NewReader(payload))
+		if err != nil {
+			log.Error("Mock payment webhook could not be created: ", err)
+			return
+		}
+		request.Header.Set("Content-Type", "application/json")
+		request.Header.Set(MockSignatureHeader, m.Sign(payload))
+
+		response, err := m.client.Do(request)
+		if err != nil {
+			log.Error("Mock payment webhook could not be delivered: ", err)
+			return
+		}
+		response.Body.Close()
+	})
+}
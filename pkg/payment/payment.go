@@ -0,0 +1,63 @@
+package payment
+
+import (
+	"errors"
+	"net/http"
+)
+
+const (
+	IntentRequiresConfirmation = "requires_confirmation"
+	IntentProcessing = "processing"
+	IntentSucceeded = "succeeded"
+	IntentFailed = "failed"
+
+	RefundSucceeded = "succeeded"
+	RefundFailed = "failed"
+
+	EventPaymentSucceeded = "payment.succeeded"
+	EventPaymentFailed = "payment.failed"
+)
+
+var (
+	ErrUnknownIntent = errors.New("unknown payment intent")
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrRefundNotPossible = errors.New("payment cannot be refunded")
+)
+
+// an amount the customer is asked to pay, amounts are in cents
+type Intent struct {
+	ID				string
+	Amount			int64
+	Currency		string
+	Reference		string
+	Status			string
+	FailureReason	string
+}
+
+type Refund struct {
+	ID			string
+	IntentID	string
+	Amount		int64
+	Status		string
+}
+
+// asynchronous notification of the provider about an intent
+type WebhookEvent struct {
+	ID			string
+	Type		string
+	IntentID	string
+}
+
+// payment service provider that charges customers
+type Provider interface {
+	Name() string
+	// registers an amount to be paid, reference identifies the order
+	CreateIntent(amount int64, currency string, reference string) (Intent, error)
+	// charges the intent with the payment method of the customer, the returned
+	// intent is succeeded, failed or processing when the result is sent by webhook
+	Confirm(intentID string, paymentMethod string) (Intent, error)
+	// pays back amount of a succeeded intent
+	Refund(intentID string, amount int64) (Refund, error)
+	// checks the signature of a webhook request and decodes its event
+	VerifyWebhook(payload []byte, header http.Header) (WebhookEvent, error)
+}
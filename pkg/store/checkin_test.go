@@ -0,0 +1,129 @@
+package store_test
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mgr1054/go-ticket/pkg/models"
+	"github.com/mgr1054/go-ticket/pkg/store"
+)
+
+// two scanners upload the same tickets in opposite order at once, both uploads go
+// through and every ticket is admitted by the earlier scan only
+func testConcurrentSync(t *testing.T, s *store.Store) {
+	event := createEvent(t, s, 20)
+	tickets := paidTickets(t, s, event.ID, 10)
+
+	scannedAt := event.StartsAt.Add(-time.Hour)
+	uploads := [2][]store.OfflineScan{}
+	for i := range tickets {
+		forward, backward := tickets[i], tickets[len(tickets)-1-i]
+		uploads[0] = append(uploads[0], store.OfflineScan{Code: forward.Code, Scan: models.CheckIn{EventID: event.ID, Gate: "North", Direction: models.DirectionIn, ScannedAt: scannedAt}})
+		uploads[1] = append(uploads[1], store.OfflineScan{Code: backward.Code, Scan: models.CheckIn{EventID: event.ID, Gate: "South", Direction: models.DirectionIn, ScannedAt: scannedAt.Add(time.Minute)}})
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(uploads))
+	for i := range uploads {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, _, errs[i] = s.CheckIns.Sync(event.ID, uploads[i])
+		}(i)
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("upload %d = %v", i, err)
+		}
+	}
+
+	for _, ticket := range tickets {
+		checkIns, err := s.CheckIns.ListByTicket(ticket.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		admitted := 0
+		for _, checkIn := range checkIns {
+			if checkIn.Result == models.CheckInAdmitted {
+				admitted++
+				if checkIn.Gate != "North" {
+					t.Errorf("ticket %d was admitted by the later scan at %s", ticket.ID, checkIn.Gate)
+				}
+			}
+		}
+		if len(checkIns) != 2 || admitted != 1 {
+			t.Errorf("ticket %d has %d scans with %d admissions, want 2 with 1", ticket.ID, len(checkIns), admitted)
+		}
+	}
+}
+
+// many gates scan the same ticket at once, exactly one admits it and every other scan
+// learns the gate and time of that admission
+func testConcurrentAdmit(t *testing.T, s *store.Store) {
+	const gates = 20
+
+	event := createEvent(t, s, 10)
+	ticket := paidTickets(t, s, event.ID, 1)[0]
+	scannedAt := event.StartsAt.Add(-time.Hour)
+
+	results := make([]models.CheckIn, gates)
+	errs := make([]error, gates)
+	var wg sync.WaitGroup
+	for i := 0; i < gates; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			scan := models.CheckIn{EventID: event.ID, Gate: fmt.Sprintf("Gate %d", i), ScannedAt: scannedAt.Add(time.Duration(i) * time.Second)}
+			results[i], errs[i] = s.CheckIns.Admit(scan, ticket.Code)
+		}(i)
+	}
+	wg.Wait()
+
+	var admission *models.CheckIn
+	for i, err := range errs {
+		switch {
+		case err == nil:
+			if admission != nil {
+				t.Fatalf("gates %s and %s both admitted the ticket", admission.Gate, results[i].Gate)
+			}
+			admission = &results[i]
+		case !errors.Is(err, store.ErrAlreadyAdmitted):
+			t.Fatalf("Admit at gate %d = %v, want nil or ErrAlreadyAdmitted", i, err)
+		}
+	}
+	if admission == nil {
+		t.Fatal("no gate admitted the ticket")
+	}
+	if admission.Result != models.CheckInAdmitted {
+		t.Errorf("admission has result %q, want %q", admission.Result, models.CheckInAdmitted)
+	}
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+		if results[i].ID != admission.ID || results[i].Gate != admission.Gate || !results[i].ScannedAt.Equal(admission.ScannedAt) {
+			t.Errorf("gate %d was told of admission %d at %s %s, want %d at %s %s", i, results[i].ID, results[i].Gate, results[i].ScannedAt, admission.ID, admission.Gate, admission.ScannedAt)
+		}
+	}
+
+	checkIns, err := s.CheckIns.ListByTicket(ticket.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	admitted, duplicates := 0, 0
+	for _, checkIn := range checkIns {
+		switch checkIn.Result {
+		case models.CheckInAdmitted:
+			admitted++
+		case models.CheckInDuplicate:
+			duplicates++
+		}
+	}
+	if admitted != 1 || duplicates != gates-1 {
+		t.Errorf("ticket has %d admissions and %d duplicates, want 1 and %d", admitted, duplicates, gates-1)
+	}
+}
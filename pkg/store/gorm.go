@@ -0,0 +1,353 @@
+package store
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/mgr1054/go-ticket/pkg/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// creates a Store backed by gorm, used for Postgres and SQLite
+func NewGorm(db *gorm.DB) *Store {
+	return &Store{
+		Events: gormEventStore{db},
+		Tickets: gormTicketStore{db},
+		Users: gormUserStore{db},
+		Orders: gormOrderStore{db},
+		Holds: gormHoldStore{db},
+		Payments: gormPaymentStore{db},
+		Refunds: gormRefundStore{db},
+		Venues: gormVenueStore{db},
+		Seats: gormSeatStore{db},
+		TicketTypes: gormTicketTypeStore{db},
+		PresaleCodes: gormPresaleCodeStore{db},
+		Promotions: gormPromotionStore{db},
+		Waitlist: gormWaitlistStore{db},
+		CheckIns: gormCheckInStore{db},
+		Transfers: gormTransferStore{db},
+		Listings: gormListingStore{db},
+		Tokens: gormTokenStore{db},
+		SigningKeys: gormSigningKeyStore{db},
+	}
+}
+
+// locks the event row until the end of the transaction, so concurrent buyers
+// of the same event are serialized between checking and consuming capacity
+func lockEvent(tx *gorm.DB, eventID uint) (models.Event, error) {
+	var event models.Event
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", eventID).First(&event).Error
+	return event, gormError(err)
+}
+
+// number of tickets of the event that are issued or reserved by a hold active at now
+func usedCapacity(tx *gorm.DB, eventID uint, now time.Time) (int64, error) {
+	sold := int64(0)
+	if err := tx.Model(&models.Ticket{}).Where("event_id = ? AND status = ?", eventID, models.TicketIssued).Count(&sold).Error; err != nil {
+		return 0, err
+	}
+
+	held := int64(0)
+	err := tx.Model(&models.Hold{}).
+		Select("COALESCE(SUM(quantity), 0)").
+		Where("event_id = ? AND status = ? AND expires_at > ?", eventID, models.HoldActive, now.UTC()).
+		Scan(&held).Error
+	return sold + held, err
+}
+
+// checks that quantity tickets without a seat and one ticket on each of seatIDs still
+// fit into the event; tickets without a seat only get the capacity its seats leave
+func checkCapacity(tx *gorm.DB, event models.Event, quantity int, seatIDs []uint, now time.Time) error {
+	used, err := usedCapacity(tx, event.ID, now)
+	if err != nil {
+		return err
+	}
+	if used+int64(quantity+len(seatIDs)) > int64(event.Capacity) {
+		return ErrSoldOut
+	}
+
+	seats, seated := int64(0), int64(0)
+	if err := tx.Model(&models.EventSeat{}).Where("event_id = ?", event.ID).Count(&seats).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&models.Ticket{}).Where("event_id = ? AND status = ? AND seat_id IS NOT NULL", event.ID, models.TicketIssued).Count(&seated).Error; err != nil {
+		return err
+	}
+	if quantity > 0 && used-seated+int64(quantity) > int64(event.Capacity)-seats {
+		return ErrSoldOut
+	}
+
+	if len(seatIDs) == 0 {
+		return nil
+	}
+	if hasDuplicates(seatIDs) {
+		return ErrSeatTaken
+	}
+	found, taken := int64(0), int64(0)
+	if err := tx.Model(&models.EventSeat{}).Where("event_id = ? AND id IN ?", event.ID, seatIDs).Count(&found).Error; err != nil {
+		return err
+	}
+	if found != int64(len(seatIDs)) {
+		return ErrSeatNotFound
+	}
+	if err := tx.Model(&models.Ticket{}).Where("seat_id IN ? AND status = ?", seatIDs, models.TicketIssued).Count(&taken).Error; err != nil {
+		return err
+	}
+	if taken > 0 {
+		return ErrSeatTaken
+	}
+	return nil
+}
+
+// maps gorm errors to the store errors
+func gormError(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
+
+type gormEventStore struct {
+	db *gorm.DB
+}
+
+func (s gormEventStore) List() ([]models.Event, error) {
+	var events []models.Event
+	err := s.db.Order("id").Find(&events).Error
+	return events, err
+}
+
+func (s gormEventStore) Get(id uint) (models.Event, error) {
+	var event models.Event
+	err := s.db.Where("id = ?", id).First(&event).Error
+	return event, gormError(err)
+}
+
+func (s gormEventStore) FindByLocation(location string) ([]models.Event, error) {
+	var events []models.Event
+	err := s.db.Where("lower(location) = lower(?)", strings.TrimSpace(location)).Order("id").Find(&events).Error
+	return events, err
+}
+
+func (s gormEventStore) FindByStart(r EventRange) ([]models.Event, error) {
+	column, from, to := "starts_at", interface{}(r.From.UTC()), interface{}(r.To.UTC())
+	if r.Local {
+		column, from, to = "starts_local", r.From.Format(models.LocalTimeLayout), r.To.Format(models.LocalTimeLayout)
+	}
+
+	query := s.db
+	if !r.From.IsZero() {
+		query = query.Where(column+" >= ?", from)
+	}
+	if !r.To.IsZero() {
+		query = query.Where(column+" < ?", to)
+	}
+
+	var events []models.Event
+	err := query.Order("starts_at, id").Find(&events).Error
+	return events, err
+}
+
+func (s gormEventStore) Create(event *models.Event) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(event).Error; err != nil {
+			return err
+		}
+		return copyVenueSeats(tx, *event)
+	})
+}
+
+func (s gormEventStore) Update(id uint, changes models.Event, now time.Time) (models.Event, error) {
+	var event models.Event
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		event, err = lockEvent(tx, id)
+		if err != nil {
+			return err
+		}
+		venueChanged := changes.VenueID != 0 && changes.VenueID != event.VenueID
+
+		if err := tx.Model(&event).Updates(changes).Error; err != nil {
+			return err
+		}
+		if changes.Capacity != 0 {
+			quotas, err := quotaSum(tx, event.ID, 0)
+			if err != nil {
+				return err
+			}
+			if quotas > int64(changes.Capacity) {
+				return ErrQuotaConflict
+			}
+			used, err := usedCapacity(tx, event.ID, now)
+			if err != nil {
+				return err
+			}
+			if used > int64(changes.Capacity) {
+				return ErrCapacityConflict
+			}
+		}
+		if changes.Price.Currency != "" {
+			// Updates skips a zero amount, free events are valid though
+			err = tx.Model(&event).Updates(map[string]interface{}{
+				"price_amount": changes.Price.Amount,
+				"price_currency": changes.Price.Currency,
+			}).Error
+			if err != nil {
+				return err
+			}
+			event.Price = changes.Price
+		}
+
+		if venueChanged {
+			return resetEventSeats(tx, event)
+		}
+		return nil
+	})
+
+	return event, err
+}
+
+func (s gormEventStore) Delete(id uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		event, err := lockEvent(tx, id)
+		if err != nil {
+			return err
+		}
+		tickets := int64(0)
+		if err := tx.Model(&models.Ticket{}).Where("event_id = ?", id).Count(&tickets).Error; err != nil {
+			return err
+		}
+		if tickets > 0 {
+			return ErrEventInUse
+		}
+		return tx.Delete(&event).Error
+	})
+}
+
+type gormTicketStore struct {
+	db *gorm.DB
+}
+
+func (s gormTicketStore) Get(id uint) (models.Ticket, error) {
+	var ticket models.Ticket
+	err := s.db.Where("id = ?", id).First(&ticket).Error
+	return ticket, gormError(err)
+}
+
+func (s gormTicketStore) CountByEvent(eventID uint) (int64, error) {
+	count := int64(0)
+	err := s.db.Model(&models.Ticket{}).Where("event_id = ? AND status = ?", eventID, models.TicketIssued).Count(&count).Error
+	return count, err
+}
+
+func (s gormTicketStore) ListByUser(userID uint) ([]models.Ticket, error) {
+	var tickets []models.Ticket
+	err := s.db.Where("user_id = ?", userID).Order("id").Find(&tickets).Error
+	return tickets, err
+}
+
+func (s gormTicketStore) ListByEvent(eventID uint) ([]models.Ticket, error) {
+	var tickets []models.Ticket
+	err := s.db.Where("event_id = ?", eventID).Order("id").Find(&tickets).Error
+	return tickets, err
+}
+
+
+type gormUserStore struct {
+	db *gorm.DB
+}
+
+func (s gormUserStore) Get(id uint) (models.User, error) {
+	var user models.User
+	err := s.db.Where("id = ?", id).First(&user).Error
+	return user, gormError(err)
+}
+
+func (s gormUserStore) GetByUsername(username string) (models.User, error) {
+	var user models.User
+	err := s.db.Where("username = ?", username).First(&user).Error
+	return user, gormError(err)
+}
+
+func (s gormUserStore) GetByEmail(email string) (models.User, error) {
+	var user models.User
+	err := s.db.Where("email = ?", email).First(&user).Error
+	return user, gormError(err)
+}
+
+// returns ErrDuplicate when a user other than id has the username or email
+func checkUserUnique(tx *gorm.DB, id uint, username string, email string) error {
+	count := int64(0)
+	err := tx.Model(&models.User{}).Where("(username = ? OR email = ?) AND id <> ?", username, email, id).Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrDuplicate
+	}
+	return nil
+}
+
+func (s gormUserStore) Create(user *models.User) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := checkUserUnique(tx, 0, user.Username, user.Email); err != nil {
+			return err
+		}
+		return tx.Create(user).Error
+	})
+}
+
+func (s gormUserStore) Update(id uint, changes models.User) (models.User, error) {
+	var user models.User
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ?", id).First(&user).Error; err != nil {
+			return gormError(err)
+		}
+		// empty fields are not changed and cannot collide
+		if err := checkUserUnique(tx, id, changes.Username, changes.Email); err != nil {
+			return err
+		}
+		return tx.Model(&user).Updates(changes).Error
+	})
+	return user, err
+}
+
+func (s gormUserStore) Verify(id uint, email string, now time.Time) (models.User, error) {
+	var user models.User
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ? AND email = ?", id, email).First(&user).Error
+		if err != nil {
+			return gormError(err)
+		}
+		if user.VerifiedAt != nil {
+			return nil
+		}
+		verifiedAt := now.UTC()
+		user.VerifiedAt = &verifiedAt
+		return tx.Model(&user).Update("verified_at", verifiedAt).Error
+	})
+	return user, err
+}
+
+func (s gormUserStore) Delete(id uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&user).Error
+		if err != nil {
+			return gormError(err)
+		}
+		for _, model := range []interface{}{&models.Order{}, &models.Ticket{}, &models.Payout{}} {
+			count := int64(0)
+			if err := tx.Model(model).Where("user_id = ?", id).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				return ErrUserInUse
+			}
+		}
+		return tx.Delete(&user).Error
+	})
+}
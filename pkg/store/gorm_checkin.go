@@ -0,0 +1,239 @@
+package store
+
+import (
+	"errors"
+
+	"github.com/mgr1054/go-ticket/pkg/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type gormCheckInStore struct {
+	db *gorm.DB
+}
+
+// returns ErrTicketNotIssued unless the ticket is issued and its order, if any, is paid
+func checkAdmits(tx *gorm.DB, ticket models.Ticket) error {
+	if ticket.Status != models.TicketIssued {
+		return ErrTicketNotIssued
+	}
+	if ticket.OrderID == nil {
+		return nil
+	}
+	var order models.Order
+	if err := tx.Where("id = ?", *ticket.OrderID).First(&order).Error; err != nil {
+		return gormError(err)
+	}
+	if order.Status != models.OrderCompleted {
+		return ErrTicketNotIssued
+	}
+	return nil
+}
+
+// locks the ticket with the entry code until the end of the transaction, so the
+// scans of a ticket at different gates are serialized
+func lockTicketByCode(tx *gorm.DB, code string, eventID uint) (models.Ticket, error) {
+	var ticket models.Ticket
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("code = ?", code).First(&ticket).Error
+	if err != nil {
+		return ticket, gormError(err)
+	}
+	if ticket.EventID != eventID {
+		return ticket, ErrWrongEvent
+	}
+	if err := checkAdmits(tx, ticket); err != nil {
+		return ticket, err
+	}
+	// the seller has to withdraw the listing to use the ticket
+	return ticket, checkNotListed(tx, ticket.ID)
+}
+
+// the check-in that let the ticket in last
+func lastAdmission(tx *gorm.DB, ticketID uint) (models.CheckIn, error) {
+	var admission models.CheckIn
+	err := tx.Where("ticket_id = ? AND result = ?", ticketID, models.CheckInAdmitted).Order("scanned_at DESC, id DESC").First(&admission).Error
+	return admission, gormError(err)
+}
+
+func (s gormCheckInStore) Admit(scan models.CheckIn, code string) (models.CheckIn, error) {
+	var first models.CheckIn
+	admitted := false
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		ticket, err := lockTicketByCode(tx, code, scan.EventID)
+		if err != nil {
+			return err
+		}
+		scan.TicketID = ticket.ID
+		scan.Direction = models.DirectionIn
+		scan.ScannedAt = scan.ScannedAt.UTC()
+
+		if ticket.Inside || (ticket.AdmittedAt != nil && !reenters(tx, ticket)) {
+			if first, err = lastAdmission(tx, ticket.ID); err != nil {
+				return err
+			}
+			scan.Result = models.CheckInDuplicate
+			return tx.Create(&scan).Error
+		}
+
+		changes := map[string]interface{}{"inside": true}
+		if ticket.AdmittedAt == nil {
+			changes["admitted_at"] = scan.ScannedAt
+		}
+		if err := tx.Model(&ticket).Updates(changes).Error; err != nil {
+			return err
+		}
+		scan.Result = models.CheckInAdmitted
+		admitted = true
+		return tx.Create(&scan).Error
+	})
+	if err != nil {
+		return models.CheckIn{}, err
+	}
+	if !admitted {
+		// the duplicate is committed, the caller learns when and where the ticket entered
+		return first, ErrAlreadyAdmitted
+	}
+	return scan, nil
+}
+
+// reports whether the event of the ticket lets it enter again after it was scanned out
+func reenters(tx *gorm.DB, ticket models.Ticket) bool {
+	var event models.Event
+	if err := tx.Where("id = ?", ticket.EventID).First(&event).Error; err != nil {
+		return false
+	}
+	return event.ReentryPolicy == models.ReentryScanOut
+}
+
+func (s gormCheckInStore) Exit(scan models.CheckIn, code string) (models.CheckIn, error) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		ticket, err := lockTicketByCode(tx, code, scan.EventID)
+		if err != nil {
+			return err
+		}
+		if !reenters(tx, ticket) {
+			return ErrReentryNotAllowed
+		}
+		if !ticket.Inside {
+			return ErrNotAdmitted
+		}
+
+		if err := tx.Model(&ticket).Update("inside", false).Error; err != nil {
+			return err
+		}
+		scan.TicketID = ticket.ID
+		scan.Direction = models.DirectionOut
+		scan.ScannedAt = scan.ScannedAt.UTC()
+		scan.Result = models.CheckInExited
+		return tx.Create(&scan).Error
+	})
+	return scan, err
+}
+
+func (s gormCheckInStore) Sync(eventID uint, scans []OfflineScan) ([]SyncResult, []models.CheckIn, error) {
+	results := make([]SyncResult, len(scans))
+	var changed []models.CheckIn
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var event models.Event
+		if err := tx.Where("id = ?", eventID).First(&event).Error; err != nil {
+			return gormError(err)
+		}
+		reentry := event.ReentryPolicy == models.ReentryScanOut
+
+		for _, group := range groupByCode(scans) {
+			ticket, err := lockTicketByCode(tx, scans[group[0]].Code, eventID)
+			if errors.Is(err, ErrNotFound) || errors.Is(err, ErrWrongEvent) || errors.Is(err, ErrTicketNotIssued) || errors.Is(err, ErrTicketListed) {
+				for _, i := range group {
+					results[i].Err = err
+				}
+				continue
+			}
+			if err != nil {
+				return err
+			}
+
+			var recorded []models.CheckIn
+			if err := tx.Where("ticket_id = ?", ticket.ID).Order("scanned_at, id").Find(&recorded).Error; err != nil {
+				return err
+			}
+			sync := newTicketSync(ticket, recorded, scans, group, reentry)
+
+			for _, scan := range sync.fresh {
+				if err := tx.Create(scan).Error; err != nil {
+					return err
+				}
+			}
+			for _, scan := range sync.changed {
+				if err := tx.Model(scan).Update("result", scan.Result).Error; err != nil {
+					return err
+				}
+				changed = append(changed, *scan)
+			}
+			err = tx.Model(&ticket).Updates(map[string]interface{}{"admitted_at": sync.admittedAt, "inside": sync.inside}).Error
+			if err != nil {
+				return err
+			}
+			sync.report(group, results)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return results, changed, nil
+}
+
+func (s gormCheckInStore) Admissible(eventID uint) ([]models.Ticket, error) {
+	if _, err := (gormEventStore{s.db}).Get(eventID); err != nil {
+		return nil, err
+	}
+	var tickets []models.Ticket
+	err := s.db.Where("event_id = ? AND status = ?", eventID, models.TicketIssued).
+		Where("order_id IS NULL OR order_id IN (?)", s.db.Model(&models.Order{}).Select("id").Where("status = ?", models.OrderCompleted)).
+		Where("id NOT IN (?)", s.db.Model(&models.Listing{}).Select("ticket_id").Where("status IN ?", []string{models.ListingActive, models.ListingReserved})).
+		Order("id").Find(&tickets).Error
+	return tickets, err
+}
+
+func (s gormCheckInStore) ListByTicket(ticketID uint) ([]models.CheckIn, error) {
+	var checkIns []models.CheckIn
+	err := s.db.Where("ticket_id = ?", ticketID).Order("scanned_at, id").Find(&checkIns).Error
+	return checkIns, err
+}
+
+func (s gormCheckInStore) Stats(eventID uint) (models.CheckInStats, error) {
+	stats := models.CheckInStats{EventID: eventID, Gates: map[string]int64{}}
+	if _, err := (gormEventStore{s.db}).Get(eventID); err != nil {
+		return stats, err
+	}
+
+	counts := []struct {
+		condition	string
+		count		*int64
+	}{
+		{"1 = 1", &stats.Issued},
+		{"admitted_at IS NOT NULL", &stats.Admitted},
+		{"inside", &stats.Inside},
+	}
+	for _, c := range counts {
+		err := s.db.Model(&models.Ticket{}).Where("event_id = ? AND status = ?", eventID, models.TicketIssued).
+			Where(c.condition).Count(c.count).Error
+		if err != nil {
+			return stats, err
+		}
+	}
+
+	var gates []struct {
+		Gate	string
+		Count	int64
+	}
+	err := s.db.Model(&models.CheckIn{}).Select("gate, COUNT(*) AS count").
+		Where("event_id = ? AND result = ?", eventID, models.CheckInAdmitted).
+		Group("gate").Scan(&gates).Error
+	for _, gate := range gates {
+		stats.Gates[gate.Gate] = gate.Count
+	}
+	return stats, err
+}
@@ -0,0 +1,135 @@
+package store
+
+import (
+	"time"
+
+	"github.com/mgr1054/go-ticket/pkg/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type gormHoldStore struct {
+	db *gorm.DB
+}
+
+func (s gormHoldStore) Create(userID uint, eventID uint, ticketTypeID uint, quantity int, presaleCode string, now time.Time, ttl time.Duration) (models.Hold, error) {
+	var hold models.Hold
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		event, err := lockEvent(tx, eventID)
+		if err != nil {
+			return err
+		}
+
+		if err := checkCapacity(tx, event, quantity, nil, now); err != nil {
+			return err
+		}
+		ticketType, err := checkTicketType(tx, event, ticketTypeID, quantity, now)
+		if err != nil {
+			return err
+		}
+		presale, err := checkSale(tx, event, ticketType, presaleCode, now)
+		if err != nil {
+			return err
+		}
+
+		hold = models.Hold{
+			UserID: userID,
+			EventID: event.ID,
+			Quantity: quantity,
+			TicketTypeID: orderLine{ticketType: ticketType}.ticketTypeID(),
+			PresaleCodeID: presaleCodeID(presale),
+			Status: models.HoldActive,
+			ExpiresAt: now.Add(ttl).UTC(),
+			CreatedAt: now.UTC(),
+		}
+		return tx.Create(&hold).Error
+	})
+
+	return hold, err
+}
+
+func (s gormHoldStore) Get(id uint) (models.Hold, error) {
+	var hold models.Hold
+	err := s.db.Where("id = ?", id).First(&hold).Error
+	return hold, gormError(err)
+}
+
+func (s gormHoldStore) ListByUser(userID uint) ([]models.Hold, error) {
+	var holds []models.Hold
+	err := s.db.Where("user_id = ?", userID).Order("id").Find(&holds).Error
+	return holds, err
+}
+
+// locks the hold row until the end of the transaction and checks that it is still active
+func lockActiveHold(tx *gorm.DB, id uint, now time.Time) (models.Hold, error) {
+	var hold models.Hold
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&hold).Error; err != nil {
+		return hold, gormError(err)
+	}
+	if !hold.IsActive(now) {
+		return hold, ErrHoldNotActive
+	}
+	return hold, nil
+}
+
+func (s gormHoldStore) Confirm(id uint, promoCode string, now time.Time) (models.Order, error) {
+	var order models.Order
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		hold, err := lockActiveHold(tx, id, now)
+		if err != nil {
+			return err
+		}
+
+		var event models.Event
+		if err := tx.Where("id = ?", hold.EventID).First(&event).Error; err != nil {
+			return gormError(err)
+		}
+
+		var ticketType *models.TicketType
+		if hold.TicketTypeID != nil {
+			ticketType = &models.TicketType{}
+			if err := tx.Where("id = ?", *hold.TicketTypeID).First(ticketType).Error; err != nil {
+				return gormError(err)
+			}
+		}
+
+		lines := []orderLine{{event: event, ticketType: ticketType, quantity: hold.Quantity}}
+		order = models.Order{UserID: hold.UserID, PresaleCodeID: hold.PresaleCodeID}
+		promotion, err := lockPromotion(tx, promoCode, hold.UserID, now)
+		if err != nil {
+			return err
+		}
+		if err := applyPromotion(&order, lines, promotion); err != nil {
+			return err
+		}
+
+		// the capacity and the use of the presale code were already reserved by the hold
+		order, err = insertOrder(tx, order, lines)
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&hold).Updates(models.Hold{Status: models.HoldConfirmed, OrderID: &order.ID}).Error
+	})
+
+	return order, err
+}
+
+func (s gormHoldStore) Release(id uint, now time.Time) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		hold, err := lockActiveHold(tx, id, now)
+		if err != nil {
+			return err
+		}
+		return tx.Model(&hold).Update("status", models.HoldReleased).Error
+	})
+}
+
+func (s gormHoldStore) ExpireAll(now time.Time) (int64, error) {
+	result := s.db.Model(&models.Hold{}).
+		Where("status = ? AND expires_at <= ?", models.HoldActive, now.UTC()).
+		Update("status", models.HoldExpired)
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,254 @@
+package store
+
+import (
+	"time"
+
+	"github.com/mgr1054/go-ticket/pkg/models"
+	"gorm.io/gorm"
+)
+
+type gormListingStore struct {
+	db *gorm.DB
+}
+
+// returns ErrTicketListed while the ticket is up for resale or reserved for a buyer
+func checkNotListed(tx *gorm.DB, ticketID uint) error {
+	listed := int64(0)
+	err := tx.Model(&models.Listing{}).Where("ticket_id = ? AND status IN ?", ticketID, []string{models.ListingActive, models.ListingReserved}).Count(&listed).Error
+	if err != nil {
+		return err
+	}
+	if listed > 0 {
+		return ErrTicketListed
+	}
+	return nil
+}
+
+// the listing the ticket was sold through last, found is false for tickets that were never resold
+func lastSale(tx *gorm.DB, ticketID uint) (listing models.Listing, found bool, err error) {
+	var listings []models.Listing
+	err = tx.Where("ticket_id = ? AND status = ?", ticketID, models.ListingSold).Order("id DESC").Limit(1).Find(&listings).Error
+	if err != nil || len(listings) == 0 {
+		return listing, false, err
+	}
+	return listings[0], true, nil
+}
+
+// price of the ticket before any discount, resold tickets keep the face value of their first sale
+func faceValue(tx *gorm.DB, ticket models.Ticket) (models.Money, error) {
+	sale, found, err := lastSale(tx, ticket.ID)
+	if found || err != nil {
+		return sale.FaceValue, err
+	}
+	return models.Money{Amount: ticket.Price.Amount + ticket.Discount.Amount, Currency: ticket.Price.Currency}, nil
+}
+
+// order the holder of the ticket paid, the buyer's order for resold tickets
+func holderOrderID(tx *gorm.DB, ticket models.Ticket) (*uint, error) {
+	sale, found, err := lastSale(tx, ticket.ID)
+	if found || err != nil {
+		return sale.OrderID, err
+	}
+	return ticket.OrderID, nil
+}
+
+// checks the price of a listing against the face value of its ticket
+func checkFaceValue(face models.Money, price models.Money) error {
+	if price.Currency != face.Currency || price.Amount < 0 || price.Amount > face.Amount {
+		return ErrAboveFaceValue
+	}
+	return nil
+}
+
+// locks the ticket of the listing and reads the listing again under the lock
+func lockListing(tx *gorm.DB, id uint) (models.Listing, models.Ticket, error) {
+	var listing models.Listing
+	if err := tx.Where("id = ?", id).First(&listing).Error; err != nil {
+		return listing, models.Ticket{}, gormError(err)
+	}
+	ticket, err := lockTicket(tx, listing.TicketID)
+	if err != nil {
+		return listing, ticket, err
+	}
+	err = tx.Where("id = ?", id).First(&listing).Error
+	return listing, ticket, gormError(err)
+}
+
+// stores the final status of the listing
+func closeListing(tx *gorm.DB, listing *models.Listing, status string, now time.Time) error {
+	closedAt := now.UTC()
+	listing.Status = status
+	listing.ClosedAt = &closedAt
+	return tx.Model(listing).Updates(map[string]interface{}{"status": status, "closed_at": closedAt}).Error
+}
+
+func (s gormListingStore) Create(listing *models.Listing, now time.Time) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		ticket, err := lockTicket(tx, listing.TicketID)
+		if err != nil {
+			return err
+		}
+		if err := checkAdmits(tx, ticket); err != nil {
+			return err
+		}
+		if ticket.AdmittedAt != nil {
+			return ErrTicketUsed
+		}
+		if ticket.UserID != listing.SellerID {
+			return ErrTransferClosed
+		}
+		if err := checkNotListed(tx, ticket.ID); err != nil {
+			return ErrDuplicate
+		}
+		face, err := faceValue(tx, ticket)
+		if err != nil {
+			return err
+		}
+		if err := checkFaceValue(face, listing.Price); err != nil {
+			return err
+		}
+
+		listing.EventID = ticket.EventID
+		listing.FaceValue = face
+		listing.Status = models.ListingActive
+		listing.OrderID = nil
+		listing.CreatedAt = now.UTC()
+		return tx.Create(listing).Error
+	})
+}
+
+func (s gormListingStore) Get(id uint) (models.Listing, error) {
+	var listing models.Listing
+	err := s.db.Where("id = ?", id).First(&listing).Error
+	return listing, gormError(err)
+}
+
+func (s gormListingStore) ListByEvent(eventID uint) ([]models.Listing, error) {
+	if _, err := (gormEventStore{s.db}).Get(eventID); err != nil {
+		return nil, err
+	}
+	var listings []models.Listing
+	err := s.db.Where("event_id = ? AND status = ?", eventID, models.ListingActive).Order("price_amount, id").Find(&listings).Error
+	return listings, err
+}
+
+func (s gormListingStore) ListBySeller(sellerID uint) ([]models.Listing, error) {
+	var listings []models.Listing
+	err := s.db.Where("seller_id = ?", sellerID).Order("id").Find(&listings).Error
+	return listings, err
+}
+
+func (s gormListingStore) Withdraw(id uint, now time.Time) (models.Listing, error) {
+	var listing models.Listing
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		listing, _, err = lockListing(tx, id)
+		if err != nil {
+			return err
+		}
+		if listing.Status != models.ListingActive {
+			return ErrListingClosed
+		}
+		return closeListing(tx, &listing, models.ListingWithdrawn, now)
+	})
+	return listing, err
+}
+
+func (s gormListingStore) Buy(id uint, buyerID uint, now time.Time) (models.Order, error) {
+	var order models.Order
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		listing, _, err := lockListing(tx, id)
+		if err != nil {
+			return err
+		}
+		if listing.Status != models.ListingActive {
+			return ErrListingClosed
+		}
+		var event models.Event
+		if err := tx.Where("id = ?", listing.EventID).First(&event).Error; err != nil {
+			return gormError(err)
+		}
+		if !event.StartsAt.After(now) {
+			return ErrListingClosed
+		}
+
+		order = models.Order{
+			UserID: buyerID,
+			Status: models.OrderPending,
+			Total: listing.Price,
+			Discount: models.Money{Currency: listing.Price.Currency},
+			ListingID: &listing.ID,
+			CreatedAt: now.UTC(),
+		}
+		if err := tx.Omit("Tickets").Create(&order).Error; err != nil {
+			return err
+		}
+		return tx.Model(&listing).Updates(map[string]interface{}{"status": models.ListingReserved, "order_id": order.ID}).Error
+	})
+	return order, err
+}
+
+// settles the listing the order of a payment buys: the buyer gets the ticket with a new
+// entry code and the seller a payout when the order completed, otherwise the listing is
+// active again or withdrawn once its event started
+func settleListing(tx *gorm.DB, order models.Order, now time.Time) error {
+	listing, ticket, err := lockListing(tx, *order.ListingID)
+	if err != nil {
+		return err
+	}
+	if listing.Status != models.ListingReserved || listing.OrderID == nil || *listing.OrderID != order.ID {
+		return nil
+	}
+
+	if order.Status != models.OrderCompleted {
+		var event models.Event
+		if err := tx.Where("id = ?", listing.EventID).First(&event).Error; err != nil {
+			return gormError(err)
+		}
+		if !event.StartsAt.After(now) {
+			return closeListing(tx, &listing, models.ListingWithdrawn, now)
+		}
+		return tx.Model(&listing).Updates(map[string]interface{}{"status": models.ListingActive, "order_id": nil}).Error
+	}
+
+	code, err := models.NewEntryCode()
+	if err != nil {
+		return err
+	}
+	// the ticket stays with the order of its first sale, the listing records the order
+	// of the buyer and the markdown from the face value; the buyer paid the listing
+	// price without a promotion
+	err = tx.Model(&ticket).Updates(map[string]interface{}{
+		"user_id": order.UserID,
+		"price_amount": listing.Price.Amount,
+		"discount_amount": 0,
+		"code": code,
+	}).Error
+	if err != nil {
+		return err
+	}
+	// transfers the seller started are void now that the ticket is gone
+	err = tx.Model(&models.Transfer{}).Where("ticket_id = ? AND status = ?", ticket.ID, models.TransferPending).
+		Updates(map[string]interface{}{"status": models.TransferCancelled, "closed_at": now.UTC()}).Error
+	if err != nil {
+		return err
+	}
+	payout := models.Payout{ListingID: listing.ID, UserID: listing.SellerID, OrderID: order.ID, Amount: listing.Price, CreatedAt: now.UTC()}
+	if err := tx.Create(&payout).Error; err != nil {
+		return err
+	}
+	return closeListing(tx, &listing, models.ListingSold, now)
+}
+
+func (s gormListingStore) WithdrawStarted(now time.Time) (int64, error) {
+	result := s.db.Model(&models.Listing{}).
+		Where("status = ? AND event_id IN (?)", models.ListingActive, s.db.Model(&models.Event{}).Select("id").Where("starts_at <= ?", now.UTC())).
+		Updates(map[string]interface{}{"status": models.ListingWithdrawn, "closed_at": now.UTC()})
+	return result.RowsAffected, result.Error
+}
+
+func (s gormListingStore) ListPayouts(userID uint) ([]models.Payout, error) {
+	var payouts []models.Payout
+	err := s.db.Where("user_id = ?", userID).Order("id").Find(&payouts).Error
+	return payouts, err
+}
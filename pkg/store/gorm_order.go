@@ -0,0 +1,237 @@
+package store
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mgr1054/go-ticket/pkg/models"
+	"gorm.io/gorm"
+)
+
+type gormOrderStore struct {
+	db *gorm.DB
+}
+
+// tickets of one event that are part of a new order
+type orderLine struct {
+	event		models.Event
+	// set for events selling ticket types
+	ticketType	*models.TicketType
+	// tickets without a seat
+	quantity	int
+	seatIDs		[]uint
+	// taken off every ticket of the line by a promotion, in minor units of its price
+	discount	int64
+}
+
+// price of each ticket of the line
+func (line orderLine) price() models.Money {
+	if line.ticketType != nil {
+		return line.ticketType.Price
+	}
+	return line.event.Price
+}
+
+// price paid for each ticket of the line
+func (line orderLine) netPrice() models.Money {
+	price := line.price()
+	price.Amount -= line.discount
+	return price
+}
+
+// tier of the tickets of the line
+func (line orderLine) ticketTypeID() *uint {
+	if line.ticketType == nil {
+		return nil
+	}
+	id := line.ticketType.ID
+	return &id
+}
+
+// sums up the net prices and the discounts of all lines, every event of an order
+// has to be priced in the same currency
+func orderTotal(lines []orderLine) (models.Money, models.Money, error) {
+	var total, discount models.Money
+	for i, line := range lines {
+		tickets := int64(line.quantity + len(line.seatIDs))
+		if i == 0 {
+			total.Currency, discount.Currency = line.price().Currency, line.price().Currency
+		}
+		var err error
+		total, err = total.Add(line.netPrice().Times(tickets))
+		if err != nil {
+			return total, discount, fmt.Errorf("event %d: %w", line.event.ID, err)
+		}
+		discount.Amount += line.discount * tickets
+	}
+	return total, discount, nil
+}
+
+// discounts the tickets of the lines of the order with the promotion if there is one,
+// returns ErrPromotionRejected when it does not apply to any of them
+func applyPromotion(order *models.Order, lines []orderLine, promotion *models.Promotion) error {
+	if promotion == nil {
+		return nil
+	}
+	applied := false
+	for i := range lines {
+		lines[i].discount = promotion.Discount(lines[i].event.ID, lines[i].price()).Amount
+		applied = applied || lines[i].discount > 0
+	}
+	if !applied {
+		return ErrPromotionRejected
+	}
+	order.PromotionID = &promotion.ID
+	return nil
+}
+
+// the tickets of an order with new entry codes, seated ones first
+func orderTickets(userID uint, lines []orderLine) ([]models.Ticket, error) {
+	var tickets []models.Ticket
+	for _, line := range lines {
+		ticket := models.Ticket{
+			UserID: userID,
+			EventID: line.event.ID,
+			Price: line.netPrice(),
+			Discount: models.Money{Amount: line.discount, Currency: line.price().Currency},
+			Status: models.TicketIssued,
+			TicketTypeID: line.ticketTypeID(),
+		}
+		for i := range line.seatIDs {
+			seated := ticket
+			seated.SeatID = &line.seatIDs[i]
+			tickets = append(tickets, seated)
+		}
+		for i := 0; i < line.quantity; i++ {
+			tickets = append(tickets, ticket)
+		}
+	}
+	for i := range tickets {
+		code, err := models.NewEntryCode()
+		if err != nil {
+			return nil, err
+		}
+		tickets[i].Code = code
+	}
+	return tickets, nil
+}
+
+// inserts the order of a user with its tickets, the caller has to check the capacity and sale
+func insertOrder(tx *gorm.DB, order models.Order, lines []orderLine) (models.Order, error) {
+	total, discount, err := orderTotal(lines)
+	if err != nil {
+		return order, err
+	}
+
+	order.Status = models.OrderPending
+	order.Total, order.Discount = total, discount
+	tickets, err := orderTickets(order.UserID, lines)
+	if err != nil {
+		return order, err
+	}
+	if err := tx.Omit("Tickets").Create(&order).Error; err != nil {
+		return order, err
+	}
+
+	for i := range tickets {
+		tickets[i].OrderID = &order.ID
+	}
+	if err := tx.Create(&tickets).Error; err != nil {
+		return order, err
+	}
+	order.Tickets = tickets
+	return order, nil
+}
+
+func (s gormOrderStore) Create(userID uint, items []OrderItem, codes OrderCodes, now time.Time) (models.Order, error) {
+	var order models.Order
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var lines []orderLine
+		var event models.Event
+		var err error
+		merged := mergeOrderItems(items)
+		for i, item := range merged {
+			if i == 0 || merged[i-1].EventID != item.EventID {
+				event, err = lockEvent(tx, item.EventID)
+				if err != nil {
+					return fmt.Errorf("event %d: %w", item.EventID, err)
+				}
+				quantity, seatIDs := eventDemand(merged, event.ID)
+				if err := checkCapacity(tx, event, quantity, seatIDs, now); err != nil {
+					return fmt.Errorf("event %d: %w", event.ID, err)
+				}
+			}
+
+			ticketType, err := checkTicketType(tx, event, item.TicketTypeID, item.Quantity+len(item.SeatIDs), now)
+			if err != nil {
+				return fmt.Errorf("event %d: %w", event.ID, err)
+			}
+			presale, err := checkSale(tx, event, ticketType, codes.Presale, now)
+			if err != nil {
+				return fmt.Errorf("event %d: %w", event.ID, err)
+			}
+			if presale != nil {
+				// the order uses the code once however many of its lines need it
+				order.PresaleCodeID = &presale.ID
+			}
+
+			lines = append(lines, orderLine{event: event, ticketType: ticketType, quantity: item.Quantity, seatIDs: item.SeatIDs})
+		}
+
+		promotion, err := lockPromotion(tx, codes.Promotion, userID, now)
+		if err != nil {
+			return err
+		}
+		if err := applyPromotion(&order, lines, promotion); err != nil {
+			return err
+		}
+
+		order.UserID = userID
+		order, err = insertOrder(tx, order, lines)
+		return err
+	})
+
+	return order, err
+}
+// loads tickets and payments together with orders
+func withOrderDetails(db *gorm.DB) *gorm.DB {
+	byID := func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	}
+	return db.Preload("Tickets", byID).Preload("Payments", byID)
+}
+
+// adds the ticket an order bought for resale, it stays linked to the order of its first sale
+func withResoldTicket(db *gorm.DB, order *models.Order) error {
+	if order.ListingID == nil {
+		return nil
+	}
+	var tickets []models.Ticket
+	err := db.Where("id IN (?)", db.Model(&models.Listing{}).Select("ticket_id").
+		Where("id = ? AND status = ? AND order_id = ?", *order.ListingID, models.ListingSold, order.ID)).
+		Find(&tickets).Error
+	order.Tickets = append(order.Tickets, tickets...)
+	return err
+}
+
+func (s gormOrderStore) Get(id uint) (models.Order, error) {
+	var order models.Order
+	if err := withOrderDetails(s.db).Where("id = ?", id).First(&order).Error; err != nil {
+		return order, gormError(err)
+	}
+	return order, withResoldTicket(s.db, &order)
+}
+
+func (s gormOrderStore) ListByUser(userID uint) ([]models.Order, error) {
+	var orders []models.Order
+	if err := withOrderDetails(s.db).Where("user_id = ?", userID).Order("id").Find(&orders).Error; err != nil {
+		return orders, err
+	}
+	for i := range orders {
+		if err := withResoldTicket(s.db, &orders[i]); err != nil {
+			return orders, err
+		}
+	}
+	return orders, nil
+}
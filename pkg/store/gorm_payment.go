@@ -0,0 +1,95 @@
+package store
+
+import (
+	"errors"
+	"time"
+
+	"github.com/mgr1054/go-ticket/pkg/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type gormPaymentStore struct {
+	db *gorm.DB
+}
+
+func (s gormPaymentStore) Create(payment *models.Payment) error {
+	return s.db.Create(payment).Error
+}
+
+func (s gormPaymentStore) Get(id uint) (models.Payment, error) {
+	var payment models.Payment
+	err := s.db.Where("id = ?", id).First(&payment).Error
+	return payment, gormError(err)
+}
+
+func (s gormPaymentStore) GetByIntent(provider string, intentID string) (models.Payment, error) {
+	var payment models.Payment
+	err := s.db.Where("provider = ? AND intent_id = ?", provider, intentID).First(&payment).Error
+	return payment, gormError(err)
+}
+
+// settles a payment inside the transaction tx
+func settlePayment(tx *gorm.DB, id uint, status string, now time.Time) (models.Payment, error) {
+	var payment models.Payment
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&payment).Error; err != nil {
+		return payment, gormError(err)
+	}
+	if payment.Status != models.PaymentPending {
+		return payment, ErrPaymentSettled
+	}
+
+	if err := tx.Model(&payment).Updates(map[string]interface{}{"status": status, "updated_at": now.UTC()}).Error; err != nil {
+		return payment, err
+	}
+
+	orderStatus := models.OrderCompleted
+	if status != models.PaymentSucceeded {
+		orderStatus = models.OrderFailed
+		if err := tx.Where("order_id = ?", payment.OrderID).Delete(&models.Ticket{}).Error; err != nil {
+			return payment, err
+		}
+	}
+	if err := tx.Model(&models.Order{}).Where("id = ?", payment.OrderID).Update("status", orderStatus).Error; err != nil {
+		return payment, err
+	}
+
+	var order models.Order
+	if err := tx.Where("id = ?", payment.OrderID).First(&order).Error; err != nil {
+		return payment, gormError(err)
+	}
+	if order.ListingID != nil {
+		return payment, settleListing(tx, order, now)
+	}
+	return payment, nil
+}
+
+func (s gormPaymentStore) Settle(id uint, status string, now time.Time) (models.Payment, error) {
+	var payment models.Payment
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		payment, err = settlePayment(tx, id, status, now)
+		return err
+	})
+	return payment, err
+}
+
+func (s gormPaymentStore) ExpirePending(now time.Time, timeout time.Duration) (int64, error) {
+	var ids []uint
+	if err := s.db.Model(&models.Payment{}).Where("status = ? AND created_at < ?", models.PaymentPending, now.Add(-timeout).UTC()).Pluck("id", &ids).Error; err != nil {
+		return 0, err
+	}
+
+	count := int64(0)
+	for _, id := range ids {
+		_, err := s.Settle(id, models.PaymentFailed, now)
+		if errors.Is(err, ErrPaymentSettled) {
+			continue
+		}
+		if err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
@@ -0,0 +1,158 @@
+package store
+
+import (
+	"errors"
+	"time"
+
+	"github.com/mgr1054/go-ticket/pkg/models"
+	"gorm.io/gorm"
+)
+
+type gormPresaleCodeStore struct {
+	db *gorm.DB
+}
+
+// number of orders that did not fail and holds active at now bought with the presale code
+func presaleCodeUses(tx *gorm.DB, id uint, now time.Time) (int64, error) {
+	orders, holds := int64(0), int64(0)
+	if err := tx.Model(&models.Order{}).Where("presale_code_id = ? AND status <> ?", id, models.OrderFailed).Count(&orders).Error; err != nil {
+		return 0, err
+	}
+	err := tx.Model(&models.Hold{}).
+		Where("presale_code_id = ? AND status = ? AND expires_at > ?", id, models.HoldActive, now.UTC()).
+		Count(&holds).Error
+	return orders + holds, err
+}
+
+// checks that tickets of the ticket type of the locked event can be bought at now,
+// before the general sale only with a presale code that unlocks them, which is returned
+func checkSale(tx *gorm.DB, event models.Event, ticketType *models.TicketType, code string, now time.Time) (*models.PresaleCode, error) {
+	switch event.SaleState(ticketType, now) {
+	case models.SaleEnded:
+		return nil, ErrNotOnSale
+	case models.SaleOpen:
+		return nil, nil
+	}
+	if code == "" {
+		return nil, ErrNotOnSale
+	}
+
+	var presale models.PresaleCode
+	err := tx.Where("event_id = ? AND code = ?", event.ID, models.NormalizeCode(code)).First(&presale).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrPresaleCodeRejected
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !presale.Unlocks(ticketType, now) {
+		return nil, ErrPresaleCodeRejected
+	}
+
+	// the event lock serializes all uses of its codes
+	uses, err := presaleCodeUses(tx, presale.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	if uses >= int64(presale.MaxUses) {
+		return nil, ErrPresaleCodeRejected
+	}
+	return &presale, nil
+}
+
+// checks that the event has no other presale code of that name and owns the ticket type
+func checkPresaleCodeFits(tx *gorm.DB, code models.PresaleCode) error {
+	count := int64(0)
+	if err := tx.Model(&models.PresaleCode{}).Where("event_id = ? AND code = ? AND id <> ?", code.EventID, code.Code, code.ID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrDuplicate
+	}
+
+	if code.TicketTypeID == nil {
+		return nil
+	}
+	if err := tx.Model(&models.TicketType{}).Where("id = ? AND event_id = ?", *code.TicketTypeID, code.EventID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrTicketTypeNotFound
+	}
+	return nil
+}
+
+func (s gormPresaleCodeStore) ListByEvent(eventID uint, now time.Time) ([]models.PresaleCode, error) {
+	if _, err := (gormEventStore{s.db}).Get(eventID); err != nil {
+		return nil, err
+	}
+
+	var codes []models.PresaleCode
+	if err := s.db.Where("event_id = ?", eventID).Order("id").Find(&codes).Error; err != nil {
+		return nil, err
+	}
+	for i := range codes {
+		uses, err := presaleCodeUses(s.db, codes[i].ID, now)
+		if err != nil {
+			return nil, err
+		}
+		codes[i].Uses = int(uses)
+	}
+	return codes, nil
+}
+
+func (s gormPresaleCodeStore) Get(id uint, now time.Time) (models.PresaleCode, error) {
+	var code models.PresaleCode
+	if err := s.db.Where("id = ?", id).First(&code).Error; err != nil {
+		return code, gormError(err)
+	}
+	uses, err := presaleCodeUses(s.db, id, now)
+	code.Uses = int(uses)
+	return code, err
+}
+
+func (s gormPresaleCodeStore) Create(code *models.PresaleCode) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := lockEvent(tx, code.EventID); err != nil {
+			return err
+		}
+		code.ID = 0
+		if err := checkPresaleCodeFits(tx, *code); err != nil {
+			return err
+		}
+		return tx.Create(code).Error
+	})
+}
+
+func (s gormPresaleCodeStore) Update(id uint, code models.PresaleCode) (models.PresaleCode, error) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var current models.PresaleCode
+		if err := tx.Where("id = ?", id).First(&current).Error; err != nil {
+			return gormError(err)
+		}
+		if _, err := lockEvent(tx, current.EventID); err != nil {
+			return err
+		}
+
+		code.ID, code.EventID, code.CreatedAt = id, current.EventID, current.CreatedAt
+		if err := checkPresaleCodeFits(tx, code); err != nil {
+			return err
+		}
+		return tx.Model(&current).Updates(map[string]interface{}{
+			"ticket_type_id": code.TicketTypeID,
+			"code": code.Code,
+			"max_uses": code.MaxUses,
+			"valid_from": code.ValidFrom,
+			"valid_until": code.ValidUntil,
+		}).Error
+	})
+	return code, err
+}
+
+func (s gormPresaleCodeStore) Delete(id uint) error {
+	var code models.PresaleCode
+	if err := s.db.Where("id = ?", id).First(&code).Error; err != nil {
+		return gormError(err)
+	}
+	return s.db.Delete(&code).Error
+}
@@ -0,0 +1,158 @@
+package store
+
+import (
+	"errors"
+	"time"
+
+	"github.com/mgr1054/go-ticket/pkg/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type gormPromotionStore struct {
+	db *gorm.DB
+}
+
+// number of orders that did not fail using the promotion, only those of the user unless userID is 0
+func promotionUses(tx *gorm.DB, id uint, userID uint) (int64, error) {
+	query := tx.Model(&models.Order{}).Where("promotion_id = ? AND status <> ?", id, models.OrderFailed)
+	if userID != 0 {
+		query = query.Where("user_id = ?", userID)
+	}
+	uses := int64(0)
+	err := query.Count(&uses).Error
+	return uses, err
+}
+
+// returns the promotion with the code if the user can use it at now, nil without a code;
+// the row stays locked until the end of the transaction so concurrent orders of any
+// event are serialized between counting and adding a use
+func lockPromotion(tx *gorm.DB, code string, userID uint, now time.Time) (*models.Promotion, error) {
+	if code == "" {
+		return nil, nil
+	}
+
+	var promotion models.Promotion
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("code = ?", models.NormalizeCode(code)).First(&promotion).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrPromotionRejected
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !promotion.Active(now) {
+		return nil, ErrPromotionRejected
+	}
+
+	for _, limit := range []struct {
+		max		int
+		userID	uint
+	}{
+		{promotion.MaxUses, 0},
+		{promotion.MaxUsesPerUser, userID},
+	} {
+		if limit.max == 0 {
+			continue
+		}
+		uses, err := promotionUses(tx, promotion.ID, limit.userID)
+		if err != nil {
+			return nil, err
+		}
+		if uses >= int64(limit.max) {
+			return nil, ErrPromotionRejected
+		}
+	}
+	return &promotion, nil
+}
+
+// checks that no other promotion has the code and that the event exists
+func checkPromotionFits(tx *gorm.DB, promotion models.Promotion) error {
+	count := int64(0)
+	if err := tx.Model(&models.Promotion{}).Where("code = ? AND id <> ?", promotion.Code, promotion.ID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrDuplicate
+	}
+
+	if promotion.EventID == nil {
+		return nil
+	}
+	if err := tx.Model(&models.Event{}).Where("id = ?", *promotion.EventID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s gormPromotionStore) List() ([]models.Promotion, error) {
+	var promotions []models.Promotion
+	if err := s.db.Order("id").Find(&promotions).Error; err != nil {
+		return nil, err
+	}
+	for i := range promotions {
+		uses, err := promotionUses(s.db, promotions[i].ID, 0)
+		if err != nil {
+			return nil, err
+		}
+		promotions[i].Uses = int(uses)
+	}
+	return promotions, nil
+}
+
+func (s gormPromotionStore) Get(id uint) (models.Promotion, error) {
+	var promotion models.Promotion
+	if err := s.db.Where("id = ?", id).First(&promotion).Error; err != nil {
+		return promotion, gormError(err)
+	}
+	uses, err := promotionUses(s.db, id, 0)
+	promotion.Uses = int(uses)
+	return promotion, err
+}
+
+func (s gormPromotionStore) Create(promotion *models.Promotion) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		promotion.ID = 0
+		if err := checkPromotionFits(tx, *promotion); err != nil {
+			return err
+		}
+		return tx.Create(promotion).Error
+	})
+}
+
+func (s gormPromotionStore) Update(id uint, promotion models.Promotion) (models.Promotion, error) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var current models.Promotion
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&current).Error; err != nil {
+			return gormError(err)
+		}
+
+		promotion.ID, promotion.CreatedAt = id, current.CreatedAt
+		if err := checkPromotionFits(tx, promotion); err != nil {
+			return err
+		}
+		return tx.Model(&current).Updates(map[string]interface{}{
+			"code": promotion.Code,
+			"kind": promotion.Kind,
+			"percent": promotion.Percent,
+			"amount_off_amount": promotion.AmountOff.Amount,
+			"amount_off_currency": promotion.AmountOff.Currency,
+			"event_id": promotion.EventID,
+			"max_uses": promotion.MaxUses,
+			"max_uses_per_user": promotion.MaxUsesPerUser,
+			"valid_from": promotion.ValidFrom,
+			"valid_until": promotion.ValidUntil,
+		}).Error
+	})
+	return promotion, err
+}
+
+func (s gormPromotionStore) Delete(id uint) error {
+	var promotion models.Promotion
+	if err := s.db.Where("id = ?", id).First(&promotion).Error; err != nil {
+		return gormError(err)
+	}
+	return s.db.Delete(&promotion).Error
+}
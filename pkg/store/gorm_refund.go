@@ -0,0 +1,156 @@
+package store
+
+import (
+	"time"
+
+	"github.com/mgr1054/go-ticket/pkg/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type gormRefundStore struct {
+	db *gorm.DB
+}
+
+// locks the ticket row until the end of the transaction
+func lockTicket(tx *gorm.DB, id uint) (models.Ticket, error) {
+	var ticket models.Ticket
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&ticket).Error
+	return ticket, gormError(err)
+}
+
+// stores a pending refund for the locked ticket and cancels the ticket if it is
+// still issued, the money goes back to the payment of the order its holder paid
+func insertRefund(tx *gorm.DB, ticket models.Ticket, refund *models.Refund, now time.Time) error {
+	orderID, err := holderOrderID(tx, ticket)
+	if err != nil {
+		return err
+	}
+	if orderID != nil {
+		var payments []models.Payment
+		err := tx.Where("order_id = ? AND status = ? AND provider <> ?", *orderID, models.PaymentSucceeded, "none").
+			Order("id").Limit(1).Find(&payments).Error
+		if err != nil {
+			return err
+		}
+		if len(payments) > 0 {
+			refund.PaymentID = &payments[0].ID
+			refund.Currency = payments[0].Currency
+		}
+	}
+
+	refund.TicketID = ticket.ID
+	refund.Status = models.RefundPending
+	refund.CreatedAt = now.UTC()
+	refund.UpdatedAt = refund.CreatedAt
+	if err := tx.Create(refund).Error; err != nil {
+		return err
+	}
+
+	if ticket.Status != models.TicketIssued {
+		return nil
+	}
+	return tx.Model(&ticket).Updates(map[string]interface{}{"status": models.TicketCancelled, "cancelled_at": now.UTC()}).Error
+}
+
+func (s gormTicketStore) Cancel(id uint, refund *models.Refund, now time.Time) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		ticket, err := lockTicket(tx, id)
+		if err != nil {
+			return err
+		}
+		if err := checkAdmits(tx, ticket); err != nil {
+			return err
+		}
+		if ticket.AdmittedAt != nil {
+			return ErrTicketUsed
+		}
+		if err := checkNotListed(tx, ticket.ID); err != nil {
+			return err
+		}
+		return insertRefund(tx, ticket, refund, now)
+	})
+}
+
+func (s gormRefundStore) Create(refund *models.Refund, now time.Time) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		ticket, err := lockTicket(tx, refund.TicketID)
+		if err != nil {
+			return err
+		}
+
+		refunded := int64(0)
+		err = tx.Model(&models.Refund{}).
+			Select("COALESCE(SUM(amount), 0)").
+			Where("ticket_id = ? AND status IN ?", ticket.ID, []string{models.RefundPending, models.RefundSucceeded}).
+			Scan(&refunded).Error
+		if err != nil {
+			return err
+		}
+		if refunded+refund.Amount > ticket.Price.Amount {
+			return ErrRefundExceeded
+		}
+		if err := checkNotListed(tx, ticket.ID); err != nil {
+			return err
+		}
+
+		return insertRefund(tx, ticket, refund, now)
+	})
+}
+
+func (s gormRefundStore) Get(id uint) (models.Refund, error) {
+	var refund models.Refund
+	err := s.db.Where("id = ?", id).First(&refund).Error
+	return refund, gormError(err)
+}
+
+func (s gormRefundStore) List() ([]models.Refund, error) {
+	var refunds []models.Refund
+	err := s.db.Order("id").Find(&refunds).Error
+	return refunds, err
+}
+
+func (s gormRefundStore) ListByTicket(ticketID uint) ([]models.Refund, error) {
+	var refunds []models.Refund
+	err := s.db.Where("ticket_id = ?", ticketID).Order("id").Find(&refunds).Error
+	return refunds, err
+}
+
+func (s gormRefundStore) Complete(id uint, status string, providerRefundID string, now time.Time) (models.Refund, error) {
+	var refund models.Refund
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&refund).Error; err != nil {
+			return gormError(err)
+		}
+		if refund.Status != models.RefundPending {
+			return ErrRefundSettled
+		}
+
+		refund.Status = status
+		refund.ProviderRefundID = providerRefundID
+		refund.UpdatedAt = now.UTC()
+		err := tx.Model(&refund).Updates(map[string]interface{}{
+			"status": status,
+			"provider_refund_id": providerRefundID,
+			"updated_at": refund.UpdatedAt,
+		}).Error
+		if err != nil || status != models.RefundSucceeded {
+			return err
+		}
+
+		return tx.Model(&models.Ticket{}).
+			Where("id = ? AND status = ?", refund.TicketID, models.TicketCancelled).
+			Update("status", models.TicketRefunded).Error
+	})
+	return refund, err
+}
+
+func (s gormRefundStore) GetPolicy(eventID uint) (models.RefundPolicy, error) {
+	var policy models.RefundPolicy
+	err := s.db.Where("event_id = ?", eventID).First(&policy).Error
+	return policy, gormError(err)
+}
+
+func (s gormRefundStore) SavePolicy(policy models.RefundPolicy) error {
+	return s.db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&policy).Error
+}
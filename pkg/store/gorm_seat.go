@@ -0,0 +1,112 @@
+package store
+
+import (
+	"github.com/mgr1054/go-ticket/pkg/models"
+	"gorm.io/gorm"
+)
+
+type gormSeatStore struct {
+	db *gorm.DB
+}
+
+// copies the seat map of the venue of the event into its seat inventory
+func copyVenueSeats(tx *gorm.DB, event models.Event) error {
+	return tx.Exec(`INSERT INTO event_seats (event_id, section, row_label, number, accessible)
+		SELECT ?, venue_sections.name, seats.row_label, seats.number, seats.accessible
+		FROM seats JOIN venue_sections ON venue_sections.id = seats.section_id
+		WHERE venue_sections.venue_id = ?
+		ORDER BY seats.id`, event.ID, event.VenueID).Error
+}
+
+// replaces the seat inventory of the locked event by the seat map of its venue
+func resetEventSeats(tx *gorm.DB, event models.Event) error {
+	sold := int64(0)
+	err := tx.Model(&models.Ticket{}).Where("event_id = ? AND status = ? AND seat_id IS NOT NULL", event.ID, models.TicketIssued).Count(&sold).Error
+	if err != nil {
+		return err
+	}
+	if sold > 0 {
+		return ErrSeatsSold
+	}
+	if err := tx.Where("event_id = ?", event.ID).Delete(&models.EventSeat{}).Error; err != nil {
+		return err
+	}
+	return copyVenueSeats(tx, event)
+}
+
+// seats of the venue joined with its sections
+func venueSeats(db *gorm.DB, venueID uint) *gorm.DB {
+	return db.Model(&models.Seat{}).
+		Joins("JOIN venue_sections ON venue_sections.id = seats.section_id").
+		Where("venue_sections.venue_id = ?", venueID)
+}
+
+func (s gormSeatStore) ListByVenue(venueID uint) ([]models.Seat, error) {
+	if err := s.db.Where("id = ?", venueID).First(&models.Venue{}).Error; err != nil {
+		return nil, gormError(err)
+	}
+	var seats []models.Seat
+	err := venueSeats(s.db, venueID).Order("seats.id").Find(&seats).Error
+	return seats, err
+}
+
+func (s gormSeatStore) ReplaceForVenue(venueID uint, seats []models.Seat) ([]models.Seat, error) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ?", venueID).First(&models.Venue{}).Error; err != nil {
+			return gormError(err)
+		}
+		sections := tx.Model(&models.VenueSection{}).Select("id").Where("venue_id = ?", venueID)
+		if err := tx.Where("section_id IN (?)", sections).Delete(&models.Seat{}).Error; err != nil {
+			return err
+		}
+		for i := range seats {
+			seats[i].ID = 0
+		}
+		if len(seats) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(&seats, 500).Error
+	})
+	return seats, err
+}
+
+func (s gormSeatStore) ListByEvent(eventID uint) ([]models.EventSeat, error) {
+	if err := s.db.Where("id = ?", eventID).First(&models.Event{}).Error; err != nil {
+		return nil, gormError(err)
+	}
+
+	var seats []models.EventSeat
+	if err := s.db.Where("event_id = ?", eventID).Order("id").Find(&seats).Error; err != nil {
+		return nil, err
+	}
+	var sold []uint
+	err := s.db.Model(&models.Ticket{}).
+		Where("event_id = ? AND status = ? AND seat_id IS NOT NULL", eventID, models.TicketIssued).
+		Pluck("seat_id", &sold).Error
+	if err != nil {
+		return nil, err
+	}
+
+	taken := map[uint]bool{}
+	for _, id := range sold {
+		taken[id] = true
+	}
+	for i := range seats {
+		seats[i].Available = !taken[seats[i].ID]
+	}
+	return seats, nil
+}
+
+func (s gormSeatStore) ResetEvent(eventID uint) ([]models.EventSeat, error) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		event, err := lockEvent(tx, eventID)
+		if err != nil {
+			return err
+		}
+		return resetEventSeats(tx, event)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.ListByEvent(eventID)
+}
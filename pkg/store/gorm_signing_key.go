@@ -0,0 +1,27 @@
+package store
+
+import (
+	"time"
+
+	"github.com/mgr1054/go-ticket/pkg/models"
+	"gorm.io/gorm"
+)
+
+type gormSigningKeyStore struct {
+	db *gorm.DB
+}
+
+func (s gormSigningKeyStore) List(now time.Time) ([]models.SigningKey, error) {
+	var keys []models.SigningKey
+	err := s.db.Where("expires_at > ?", now.UTC()).Order("activates_at, created_at, id").Find(&keys).Error
+	return keys, err
+}
+
+func (s gormSigningKeyStore) Create(key *models.SigningKey) error {
+	return s.db.Create(key).Error
+}
+
+func (s gormSigningKeyStore) DeleteExpired(now time.Time) (int64, error) {
+	result := s.db.Where("expires_at <= ?", now.UTC()).Delete(&models.SigningKey{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,208 @@
+package store
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/mgr1054/go-ticket/pkg/models"
+	"gorm.io/gorm"
+)
+
+type gormTicketTypeStore struct {
+	db *gorm.DB
+}
+
+// sum of the quotas of the ticket types of the event except the one with id skip
+func quotaSum(tx *gorm.DB, eventID uint, skip uint) (int64, error) {
+	sum := int64(0)
+	err := tx.Model(&models.TicketType{}).
+		Select("COALESCE(SUM(quota), 0)").
+		Where("event_id = ? AND id <> ?", eventID, skip).
+		Scan(&sum).Error
+	return sum, err
+}
+
+// number of tickets of the ticket type that are issued or reserved by a hold active at now
+func ticketTypeUsed(tx *gorm.DB, id uint, now time.Time) (int64, error) {
+	sold := int64(0)
+	if err := tx.Model(&models.Ticket{}).Where("ticket_type_id = ? AND status = ?", id, models.TicketIssued).Count(&sold).Error; err != nil {
+		return 0, err
+	}
+
+	held := int64(0)
+	err := tx.Model(&models.Hold{}).
+		Select("COALESCE(SUM(quantity), 0)").
+		Where("ticket_type_id = ? AND status = ? AND expires_at > ?", id, models.HoldActive, now.UTC()).
+		Scan(&held).Error
+	return sold + held, err
+}
+
+// returns the ticket type of the locked event that count tickets are bought in and checks
+// its quota; events without ticket types sell tickets without one
+func checkTicketType(tx *gorm.DB, event models.Event, id uint, count int, now time.Time) (*models.TicketType, error) {
+	if id == 0 {
+		types := int64(0)
+		if err := tx.Model(&models.TicketType{}).Where("event_id = ?", event.ID).Count(&types).Error; err != nil {
+			return nil, err
+		}
+		if types > 0 {
+			return nil, ErrTicketTypeRequired
+		}
+		return nil, nil
+	}
+
+	var ticketType models.TicketType
+	err := tx.Where("id = ? AND event_id = ?", id, event.ID).First(&ticketType).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrTicketTypeNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	used, err := ticketTypeUsed(tx, id, now)
+	if err != nil {
+		return nil, err
+	}
+	if used+int64(count) > int64(ticketType.Quota) {
+		return nil, ErrSoldOut
+	}
+	return &ticketType, nil
+}
+
+// checks name and quota of a ticket type of the locked event, the quota has to cover
+// the issued tickets and active holds of the type
+func checkTicketTypeFits(tx *gorm.DB, event models.Event, ticketType models.TicketType) error {
+	count := int64(0)
+	err := tx.Model(&models.TicketType{}).
+		Where("event_id = ? AND lower(name) = lower(?) AND id <> ?", event.ID, strings.TrimSpace(ticketType.Name), ticketType.ID).
+		Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrDuplicate
+	}
+
+	quotas, err := quotaSum(tx, event.ID, ticketType.ID)
+	if err != nil {
+		return err
+	}
+	if quotas+int64(ticketType.Quota) > int64(event.Capacity) {
+		return ErrQuotaConflict
+	}
+
+	// holds count until the sweeper expired them
+	sold, held := int64(0), int64(0)
+	if err := tx.Model(&models.Ticket{}).Where("ticket_type_id = ? AND status = ?", ticketType.ID, models.TicketIssued).Count(&sold).Error; err != nil {
+		return err
+	}
+	err = tx.Model(&models.Hold{}).
+		Select("COALESCE(SUM(quantity), 0)").
+		Where("ticket_type_id = ? AND status = ?", ticketType.ID, models.HoldActive).
+		Scan(&held).Error
+	if err != nil {
+		return err
+	}
+	if ticketType.ID != 0 && sold+held > int64(ticketType.Quota) {
+		return ErrQuotaConflict
+	}
+	return nil
+}
+
+func (s gormTicketTypeStore) ListByEvent(eventID uint, now time.Time) ([]models.TicketType, error) {
+	var event models.Event
+	if err := s.db.Where("id = ?", eventID).First(&event).Error; err != nil {
+		return nil, gormError(err)
+	}
+
+	var ticketTypes []models.TicketType
+	if err := s.db.Where("event_id = ?", eventID).Order("id").Find(&ticketTypes).Error; err != nil {
+		return nil, err
+	}
+
+	used, err := usedCapacity(s.db, eventID, now)
+	if err != nil {
+		return nil, err
+	}
+	left := int64(event.Capacity) - used
+	for i := range ticketTypes {
+		sold, err := ticketTypeUsed(s.db, ticketTypes[i].ID, now)
+		if err != nil {
+			return nil, err
+		}
+		ticketTypes[i].Available = availableTickets(ticketTypes[i].Quota, sold, left)
+	}
+	return ticketTypes, nil
+}
+
+func (s gormTicketTypeStore) Get(id uint) (models.TicketType, error) {
+	var ticketType models.TicketType
+	err := s.db.Where("id = ?", id).First(&ticketType).Error
+	return ticketType, gormError(err)
+}
+
+func (s gormTicketTypeStore) Create(ticketType *models.TicketType) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		event, err := lockEvent(tx, ticketType.EventID)
+		if err != nil {
+			return err
+		}
+		ticketType.ID = 0
+		if err := checkTicketTypeFits(tx, event, *ticketType); err != nil {
+			return err
+		}
+		return tx.Create(ticketType).Error
+	})
+}
+
+func (s gormTicketTypeStore) Update(id uint, ticketType models.TicketType) (models.TicketType, error) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var current models.TicketType
+		if err := tx.Where("id = ?", id).First(&current).Error; err != nil {
+			return gormError(err)
+		}
+		event, err := lockEvent(tx, current.EventID)
+		if err != nil {
+			return err
+		}
+
+		ticketType.ID, ticketType.EventID = id, current.EventID
+		if err := checkTicketTypeFits(tx, event, ticketType); err != nil {
+			return err
+		}
+		return tx.Model(&current).Updates(map[string]interface{}{
+			"name": ticketType.Name,
+			"price_amount": ticketType.Price.Amount,
+			"price_currency": ticketType.Price.Currency,
+			"quota": ticketType.Quota,
+			"sales_start": ticketType.SalesStart,
+			"sales_end": ticketType.SalesEnd,
+		}).Error
+	})
+	return ticketType, err
+}
+
+func (s gormTicketTypeStore) Delete(id uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var ticketType models.TicketType
+		if err := tx.Where("id = ?", id).First(&ticketType).Error; err != nil {
+			return gormError(err)
+		}
+		if _, err := lockEvent(tx, ticketType.EventID); err != nil {
+			return err
+		}
+
+		tickets, holds := int64(0), int64(0)
+		if err := tx.Model(&models.Ticket{}).Where("ticket_type_id = ?", id).Count(&tickets).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Hold{}).Where("ticket_type_id = ?", id).Count(&holds).Error; err != nil {
+			return err
+		}
+		if tickets+holds > 0 {
+			return ErrTicketTypeInUse
+		}
+		return tx.Delete(&ticketType).Error
+	})
+}
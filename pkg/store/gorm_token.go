@@ -0,0 +1,124 @@
+package store
+
+import (
+	"time"
+
+	"github.com/mgr1054/go-ticket/pkg/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type gormTokenStore struct {
+	db *gorm.DB
+}
+
+func (s gormTokenStore) Create(token *models.RefreshToken, now time.Time) error {
+	token.CreatedAt = now.UTC()
+	token.ExpiresAt = token.ExpiresAt.UTC()
+	return s.db.Create(token).Error
+}
+
+// revokes the refresh tokens of the families and puts them on the revocation list until the given time
+func revokeFamilies(tx *gorm.DB, familyIDs []string, now time.Time, until time.Time) error {
+	if len(familyIDs) == 0 {
+		return nil
+	}
+	err := tx.Model(&models.RefreshToken{}).
+		Where("family_id IN ? AND revoked_at IS NULL", familyIDs).
+		Update("revoked_at", now.UTC()).Error
+	if err != nil {
+		return err
+	}
+
+	revocations := make([]models.TokenRevocation, len(familyIDs))
+	for i, familyID := range familyIDs {
+		revocations[i] = models.TokenRevocation{FamilyID: familyID, RevokedAt: now.UTC(), ExpiresAt: until.UTC()}
+	}
+	return tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "family_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"revoked_at", "expires_at"}),
+	}).Create(&revocations).Error
+}
+
+func (s gormTokenStore) Rotate(hash string, next *models.RefreshToken, now time.Time, revokeUntil time.Time) error {
+	reused := false
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var token models.RefreshToken
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("token_hash = ?", hash).First(&token).Error
+		if err != nil {
+			return gormError(err)
+		}
+		if token.RevokedAt != nil {
+			return ErrTokenRevoked
+		}
+		// the revocation has to be committed, so the error is only returned afterwards
+		next.UserID = token.UserID
+		next.FamilyID = token.FamilyID
+		if token.UsedAt != nil {
+			reused = true
+			return revokeFamilies(tx, []string{token.FamilyID}, now, revokeUntil)
+		}
+		if !token.ExpiresAt.After(now) {
+			return ErrTokenExpired
+		}
+
+		// only one of two concurrent refreshes with the same token gets past the condition
+		result := tx.Model(&token).Where("used_at IS NULL").Update("used_at", now.UTC())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			reused = true
+			return revokeFamilies(tx, []string{token.FamilyID}, now, revokeUntil)
+		}
+
+		next.CreatedAt = now.UTC()
+		next.ExpiresAt = next.ExpiresAt.UTC()
+		return tx.Create(next).Error
+	})
+	if err == nil && reused {
+		return ErrTokenReused
+	}
+	return err
+}
+
+func (s gormTokenStore) RevokeFamily(familyID string, now time.Time, until time.Time) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		return revokeFamilies(tx, []string{familyID}, now, until)
+	})
+}
+
+func (s gormTokenStore) RevokeUser(userID uint, now time.Time, until time.Time) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var familyIDs []string
+		err := tx.Model(&models.RefreshToken{}).
+			Distinct("family_id").
+			Where("user_id = ? AND expires_at > ?", userID, now.UTC()).
+			Pluck("family_id", &familyIDs).Error
+		if err != nil {
+			return err
+		}
+		return revokeFamilies(tx, familyIDs, now, until)
+	})
+}
+
+func (s gormTokenStore) IsRevoked(familyID string, now time.Time) (bool, error) {
+	count := int64(0)
+	err := s.db.Model(&models.TokenRevocation{}).Where("family_id = ? AND expires_at > ?", familyID, now.UTC()).Count(&count).Error
+	return count > 0, err
+}
+
+func (s gormTokenStore) Purge(now time.Time) (int64, error) {
+	purged := int64(0)
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("expires_at <= ?", now.UTC()).Delete(&models.RefreshToken{})
+		if result.Error != nil {
+			return result.Error
+		}
+		purged += result.RowsAffected
+		result = tx.Where("expires_at <= ?", now.UTC()).Delete(&models.TokenRevocation{})
+		purged += result.RowsAffected
+		return result.Error
+	})
+	return purged, err
+}
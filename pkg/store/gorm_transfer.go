@@ -0,0 +1,156 @@
+package store
+
+import (
+	"time"
+
+	"github.com/mgr1054/go-ticket/pkg/models"
+	"gorm.io/gorm"
+)
+
+type gormTransferStore struct {
+	db *gorm.DB
+}
+
+// checks that the locked ticket can change hands: it admits, was not used at the door yet
+// and is not up for resale
+func checkTransferable(tx *gorm.DB, ticket models.Ticket) error {
+	if err := checkAdmits(tx, ticket); err != nil {
+		return err
+	}
+	if ticket.AdmittedAt != nil {
+		return ErrTicketUsed
+	}
+	return checkNotListed(tx, ticket.ID)
+}
+
+// marks the pending transfers whose recipient did not answer before now as expired
+func expireTransfers(tx *gorm.DB, now time.Time) (int64, error) {
+	result := tx.Model(&models.Transfer{}).
+		Where("status = ? AND expires_at <= ?", models.TransferPending, now.UTC()).
+		Updates(map[string]interface{}{"status": models.TransferExpired, "closed_at": now.UTC()})
+	return result.RowsAffected, result.Error
+}
+
+func (s gormTransferStore) Create(transfer *models.Transfer, now time.Time) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		ticket, err := lockTicket(tx, transfer.TicketID)
+		if err != nil {
+			return err
+		}
+		if err := checkTransferable(tx, ticket); err != nil {
+			return err
+		}
+		if ticket.UserID != transfer.FromUserID {
+			return ErrTransferClosed
+		}
+
+		// a transfer that expired unnoticed does not block the next one
+		if _, err := expireTransfers(tx.Where("ticket_id = ?", ticket.ID), now); err != nil {
+			return err
+		}
+		pending := int64(0)
+		err = tx.Model(&models.Transfer{}).Where("ticket_id = ? AND status = ?", ticket.ID, models.TransferPending).Count(&pending).Error
+		if err != nil {
+			return err
+		}
+		if pending > 0 {
+			return ErrDuplicate
+		}
+
+		transfer.Status = models.TransferPending
+		transfer.CreatedAt = now.UTC()
+		transfer.ExpiresAt = transfer.ExpiresAt.UTC()
+		return tx.Create(transfer).Error
+	})
+}
+
+func (s gormTransferStore) Get(id uint) (models.Transfer, error) {
+	var transfer models.Transfer
+	err := s.db.Where("id = ?", id).First(&transfer).Error
+	return transfer, gormError(err)
+}
+
+func (s gormTransferStore) ListByTicket(ticketID uint) ([]models.Transfer, error) {
+	var transfers []models.Transfer
+	err := s.db.Where("ticket_id = ?", ticketID).Order("id").Find(&transfers).Error
+	return transfers, err
+}
+
+func (s gormTransferStore) ListByUser(userID uint) ([]models.Transfer, error) {
+	var transfers []models.Transfer
+	err := s.db.Where("from_user_id = ? OR to_user_id = ?", userID, userID).Order("id").Find(&transfers).Error
+	return transfers, err
+}
+
+// locks the ticket of the transfer and returns the transfer when it is pending at now
+func lockPendingTransfer(tx *gorm.DB, id uint, now time.Time) (models.Transfer, models.Ticket, error) {
+	var transfer models.Transfer
+	if err := tx.Where("id = ?", id).First(&transfer).Error; err != nil {
+		return transfer, models.Ticket{}, gormError(err)
+	}
+	ticket, err := lockTicket(tx, transfer.TicketID)
+	if err != nil {
+		return transfer, ticket, err
+	}
+	// read again under the lock of the ticket, a concurrent answer may have closed it
+	if err := tx.Where("id = ?", id).First(&transfer).Error; err != nil {
+		return transfer, ticket, gormError(err)
+	}
+	if !transfer.IsPending(now) {
+		return transfer, ticket, ErrTransferClosed
+	}
+	return transfer, ticket, nil
+}
+
+func (s gormTransferStore) Accept(id uint, now time.Time) (models.Transfer, error) {
+	var transfer models.Transfer
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var ticket models.Ticket
+		var err error
+		transfer, ticket, err = lockPendingTransfer(tx, id, now)
+		if err != nil {
+			return err
+		}
+		if err := checkTransferable(tx, ticket); err != nil {
+			return err
+		}
+		if ticket.UserID != transfer.FromUserID {
+			return ErrTransferClosed
+		}
+
+		code, err := models.NewEntryCode()
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&ticket).Updates(map[string]interface{}{"user_id": transfer.ToUserID, "code": code}).Error; err != nil {
+			return err
+		}
+		return closeTransfer(tx, &transfer, models.TransferAccepted, now)
+	})
+	return transfer, err
+}
+
+// stores the final status of the transfer
+func closeTransfer(tx *gorm.DB, transfer *models.Transfer, status string, now time.Time) error {
+	closedAt := now.UTC()
+	transfer.Status = status
+	transfer.ClosedAt = &closedAt
+	return tx.Model(transfer).Updates(map[string]interface{}{"status": status, "closed_at": closedAt}).Error
+}
+
+func (s gormTransferStore) Close(id uint, status string, now time.Time) (models.Transfer, error) {
+	var transfer models.Transfer
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		transfer, _, err = lockPendingTransfer(tx, id, now)
+		if err != nil {
+			return err
+		}
+		return closeTransfer(tx, &transfer, status, now)
+	})
+	return transfer, err
+}
+
+func (s gormTransferStore) ExpireAll(now time.Time) (int64, error) {
+	return expireTransfers(s.db, now)
+}
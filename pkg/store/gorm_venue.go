@@ -0,0 +1,133 @@
+package store
+
+import (
+	"strings"
+
+	"github.com/mgr1054/go-ticket/pkg/models"
+	"gorm.io/gorm"
+)
+
+type gormVenueStore struct {
+	db *gorm.DB
+}
+
+// loads sections together with venues
+func withSections(db *gorm.DB) *gorm.DB {
+	return db.Preload("Sections", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	})
+}
+
+// returns ErrDuplicate when a venue other than id is called name
+func checkVenueName(tx *gorm.DB, id uint, name string) error {
+	count := int64(0)
+	err := tx.Model(&models.Venue{}).Where("lower(name) = lower(?) AND id <> ?", strings.TrimSpace(name), id).Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrDuplicate
+	}
+	return nil
+}
+
+func (s gormVenueStore) List() ([]models.Venue, error) {
+	var venues []models.Venue
+	err := withSections(s.db).Order("id").Find(&venues).Error
+	return venues, err
+}
+
+func (s gormVenueStore) Get(id uint) (models.Venue, error) {
+	var venue models.Venue
+	err := withSections(s.db).Where("id = ?", id).First(&venue).Error
+	return venue, gormError(err)
+}
+
+func (s gormVenueStore) FindByName(name string) (models.Venue, error) {
+	var venue models.Venue
+	err := withSections(s.db).Where("lower(name) = lower(?)", strings.TrimSpace(name)).First(&venue).Error
+	return venue, gormError(err)
+}
+
+func (s gormVenueStore) Create(venue *models.Venue) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := checkVenueName(tx, 0, venue.Name); err != nil {
+			return err
+		}
+		return tx.Create(venue).Error
+	})
+}
+
+func (s gormVenueStore) Update(id uint, venue models.Venue) (models.Venue, error) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var current models.Venue
+		if err := withSections(tx).Where("id = ?", id).First(&current).Error; err != nil {
+			return gormError(err)
+		}
+		if err := checkVenueName(tx, id, venue.Name); err != nil {
+			return err
+		}
+
+		err := tx.Model(&current).Updates(map[string]interface{}{
+			"name": venue.Name,
+			"address": venue.Address,
+			"latitude": venue.Latitude,
+			"longitude": venue.Longitude,
+			"timezone": venue.Timezone,
+			"default_capacity": venue.DefaultCapacity,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		known := map[uint]bool{}
+		for _, section := range current.Sections {
+			known[section.ID] = true
+		}
+		kept := []uint{0}
+		for i := range venue.Sections {
+			section := &venue.Sections[i]
+			section.VenueID = id
+			if !known[section.ID] {
+				section.ID = 0
+				if err := tx.Create(section).Error; err != nil {
+					return err
+				}
+			} else {
+				err := tx.Model(&models.VenueSection{}).Where("id = ?", section.ID).
+					Updates(map[string]interface{}{"name": section.Name, "capacity": section.Capacity}).Error
+				if err != nil {
+					return err
+				}
+			}
+			kept = append(kept, section.ID)
+		}
+		if err := tx.Where("venue_id = ? AND id NOT IN ?", id, kept).Delete(&models.VenueSection{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&models.Event{}).Where("venue_id = ?", id).Update("location", venue.Name).Error
+	})
+	if err != nil {
+		return models.Venue{}, err
+	}
+	return s.Get(id)
+}
+
+func (s gormVenueStore) Delete(id uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var venue models.Venue
+		if err := tx.Where("id = ?", id).First(&venue).Error; err != nil {
+			return gormError(err)
+		}
+
+		events := int64(0)
+		if err := tx.Model(&models.Event{}).Where("venue_id = ?", id).Count(&events).Error; err != nil {
+			return err
+		}
+		if events > 0 {
+			return ErrVenueInUse
+		}
+		return tx.Delete(&venue).Error
+	})
+}
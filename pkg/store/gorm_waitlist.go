@@ -0,0 +1,267 @@
+package store
+
+import (
+	"errors"
+	"time"
+
+	"github.com/mgr1054/go-ticket/pkg/models"
+	"gorm.io/gorm"
+)
+
+type gormWaitlistStore struct {
+	db *gorm.DB
+}
+
+// ticket type of the entry, 0 for events without ticket types
+func waitlistTicketTypeID(entry models.WaitlistEntry) uint {
+	if entry.TicketTypeID == nil {
+		return 0
+	}
+	return *entry.TicketTypeID
+}
+
+// sets the position of a waiting entry among the waiting entries of its event
+func waitlistPosition(tx *gorm.DB, entry *models.WaitlistEntry) error {
+	entry.Position = 0
+	if entry.Status != models.WaitlistWaiting {
+		return nil
+	}
+	ahead := int64(0)
+	err := tx.Model(&models.WaitlistEntry{}).
+		Where("event_id = ? AND status = ? AND id < ?", entry.EventID, models.WaitlistWaiting, entry.ID).
+		Count(&ahead).Error
+	entry.Position = int(ahead) + 1
+	return err
+}
+
+func waitlistPositions(tx *gorm.DB, entries []models.WaitlistEntry) ([]models.WaitlistEntry, error) {
+	for i := range entries {
+		if err := waitlistPosition(tx, &entries[i]); err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
+// moves the offers of the event whose hold is used up at now to their final status
+func settleOffers(tx *gorm.DB, eventID uint, now time.Time) error {
+	var offers []models.WaitlistEntry
+	if err := tx.Where("event_id = ? AND status = ?", eventID, models.WaitlistOffered).Find(&offers).Error; err != nil {
+		return err
+	}
+	for _, offer := range offers {
+		var hold *models.Hold
+		if offer.HoldID != nil {
+			hold = &models.Hold{}
+			err := tx.Where("id = ?", *offer.HoldID).First(hold).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				hold = nil
+			} else if err != nil {
+				return err
+			}
+		}
+		if status := models.OfferStatus(hold, now); status != offer.Status {
+			if err := tx.Model(&offer).Update("status", status).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s gormWaitlistStore) Join(entry *models.WaitlistEntry, now time.Time) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		event, err := lockEvent(tx, entry.EventID)
+		if err != nil {
+			return err
+		}
+		// only checks that the ticket type belongs to the event, it is expected to be sold out
+		ticketType, err := checkTicketType(tx, event, waitlistTicketTypeID(*entry), 0, now)
+		if err != nil {
+			return err
+		}
+		if event.SaleState(ticketType, now) == models.SaleEnded {
+			return ErrNotOnSale
+		}
+
+		open := int64(0)
+		err = tx.Model(&models.WaitlistEntry{}).
+			Where("event_id = ? AND user_id = ? AND status IN ?", entry.EventID, entry.UserID, []string{models.WaitlistWaiting, models.WaitlistOffered}).
+			Count(&open).Error
+		if err != nil {
+			return err
+		}
+		if open > 0 {
+			return ErrDuplicate
+		}
+
+		entry.ID = 0
+		entry.Status = models.WaitlistWaiting
+		entry.HoldID, entry.OfferExpiresAt = nil, nil
+		entry.CreatedAt = now.UTC()
+		if err := tx.Create(entry).Error; err != nil {
+			return err
+		}
+		return waitlistPosition(tx, entry)
+	})
+}
+
+func (s gormWaitlistStore) Get(id uint) (models.WaitlistEntry, error) {
+	var entry models.WaitlistEntry
+	if err := s.db.Where("id = ?", id).First(&entry).Error; err != nil {
+		return entry, gormError(err)
+	}
+	err := waitlistPosition(s.db, &entry)
+	return entry, err
+}
+
+func (s gormWaitlistStore) ListByEvent(eventID uint) ([]models.WaitlistEntry, error) {
+	if _, err := (gormEventStore{s.db}).Get(eventID); err != nil {
+		return nil, err
+	}
+
+	var entries []models.WaitlistEntry
+	if err := s.db.Where("event_id = ?", eventID).Order("id").Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	return waitlistPositions(s.db, entries)
+}
+
+func (s gormWaitlistStore) ListByUser(userID uint) ([]models.WaitlistEntry, error) {
+	var entries []models.WaitlistEntry
+	if err := s.db.Where("user_id = ?", userID).Order("id").Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	return waitlistPositions(s.db, entries)
+}
+
+func (s gormWaitlistStore) Leave(id uint, now time.Time) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var entry models.WaitlistEntry
+		if err := tx.Where("id = ?", id).First(&entry).Error; err != nil {
+			return gormError(err)
+		}
+		if _, err := lockEvent(tx, entry.EventID); err != nil {
+			return err
+		}
+		if err := settleOffers(tx, entry.EventID, now); err != nil {
+			return err
+		}
+		if err := tx.Where("id = ?", id).First(&entry).Error; err != nil {
+			return gormError(err)
+		}
+		if !entry.IsOpen() {
+			return ErrWaitlistClosed
+		}
+
+		if entry.HoldID != nil {
+			err := tx.Model(&models.Hold{}).
+				Where("id = ? AND status = ?", *entry.HoldID, models.HoldActive).
+				Update("status", models.HoldReleased).Error
+			if err != nil {
+				return err
+			}
+		}
+		return tx.Model(&entry).Update("status", models.WaitlistLeft).Error
+	})
+}
+
+func (s gormWaitlistStore) Offer(eventID uint, now time.Time, ttl time.Duration) ([]models.WaitlistEntry, error) {
+	offers := []models.WaitlistEntry{}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		event, err := lockEvent(tx, eventID)
+		if err != nil {
+			return err
+		}
+		if err := settleOffers(tx, eventID, now); err != nil {
+			return err
+		}
+
+		var waiting []models.WaitlistEntry
+		if err := tx.Where("event_id = ? AND status = ?", eventID, models.WaitlistWaiting).Order("id").Find(&waiting).Error; err != nil {
+			return err
+		}
+
+		blocked := map[uint]bool{}
+		for _, entry := range waiting {
+			ticketTypeID := waitlistTicketTypeID(entry)
+			if blocked[ticketTypeID] {
+				continue
+			}
+
+			err := checkCapacity(tx, event, entry.Quantity, nil, now)
+			if errors.Is(err, ErrSoldOut) {
+				break
+			}
+			if err != nil {
+				return err
+			}
+			ticketType, err := checkTicketType(tx, event, ticketTypeID, entry.Quantity, now)
+			switch {
+			case errors.Is(err, ErrSoldOut), errors.Is(err, ErrTicketTypeRequired), errors.Is(err, ErrTicketTypeNotFound):
+				blocked[ticketTypeID] = true
+				continue
+			case err != nil:
+				return err
+			}
+			if event.SaleState(ticketType, now) != models.SaleOpen {
+				blocked[ticketTypeID] = true
+				continue
+			}
+
+			hold := models.Hold{
+				UserID: entry.UserID,
+				EventID: event.ID,
+				Quantity: entry.Quantity,
+				TicketTypeID: entry.TicketTypeID,
+				Status: models.HoldActive,
+				ExpiresAt: now.Add(ttl).UTC(),
+				CreatedAt: now.UTC(),
+			}
+			if err := tx.Create(&hold).Error; err != nil {
+				return err
+			}
+
+			entry.Status, entry.HoldID, entry.OfferExpiresAt = models.WaitlistOffered, &hold.ID, &hold.ExpiresAt
+			err = tx.Model(&entry).Updates(map[string]interface{}{
+				"status": entry.Status,
+				"hold_id": entry.HoldID,
+				"offer_expires_at": entry.OfferExpiresAt,
+			}).Error
+			if err != nil {
+				return err
+			}
+			offers = append(offers, entry)
+		}
+		return nil
+	})
+
+	return offers, err
+}
+
+func (s gormWaitlistStore) OfferAll(now time.Time, ttl time.Duration) ([]models.WaitlistEntry, error) {
+	var eventIDs []uint
+	err := s.db.Model(&models.WaitlistEntry{}).
+		Distinct().
+		Where("status IN ?", []string{models.WaitlistWaiting, models.WaitlistOffered}).
+		Order("event_id").
+		Pluck("event_id", &eventIDs).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var offers []models.WaitlistEntry
+	for _, eventID := range eventIDs {
+		offered, err := s.Offer(eventID, now, ttl)
+		// the event was deleted in the meantime
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return offers, err
+		}
+		offers = append(offers, offered...)
+	}
+	return offers, nil
+}
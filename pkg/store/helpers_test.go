@@ -0,0 +1,189 @@
+package store_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mgr1054/go-ticket/pkg/db"
+	"github.com/mgr1054/go-ticket/pkg/models"
+	"github.com/mgr1054/go-ticket/pkg/store"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// the store implementations the conformance suite runs against
+type backend struct {
+	name	string
+	open	func(t *testing.T) *store.Store
+}
+
+var backends = []backend{
+	{"memory", func(t *testing.T) *store.Store { return store.NewMemory() }},
+	{"sqlite", openSQLite},
+}
+
+// Postgres the suite also runs against when set, e.g.
+// "host=localhost user=postgres password=p dbname=go_ticket_test sslmode=disable";
+// unlike SQLite it runs orders on many connections at once, so only it shows
+// whether the row locks keep concurrent orders within the capacity
+const postgresDSNVariable = "TEST_POSTGRES_DSN"
+
+func TestMain(m *testing.M) {
+	// connecting and migrating logs on info level
+	log.SetLevel(log.WarnLevel)
+	if os.Getenv(postgresDSNVariable) != "" {
+		backends = append(backends, backend{"postgres", openPostgres})
+	}
+	os.Exit(m.Run())
+}
+
+// migrated SQLite database in a temporary file
+func openSQLite(t *testing.T) *store.Store {
+	// gorm reports slow statements of the migrations otherwise
+	gormDB := db.ConnectSQLite(filepath.Join(t.TempDir(), "go-ticket.db")).Session(&gorm.Session{Logger: logger.Discard})
+	if err := db.MigrateUp(gormDB); err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := gormDB.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	return store.NewGorm(gormDB)
+}
+
+// migrated schema of its own in the Postgres database of TEST_POSTGRES_DSN, dropped
+// again after the test
+func openPostgres(t *testing.T) *store.Store {
+	dsn := os.Getenv(postgresDSNVariable)
+	schema := fmt.Sprintf("store_test_%d_%d", os.Getpid(), nextFixture())
+
+	admin, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	adminDB, err := admin.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := admin.Exec("CREATE SCHEMA " + schema).Error; err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		admin.Exec("DROP SCHEMA " + schema + " CASCADE")
+		adminDB.Close()
+	})
+
+	// every connection of the pool creates its tables in the schema, public stays on the
+	// path for extensions like pgcrypto that are installed there already
+	searchPath := schema + ",public"
+	switch {
+	case strings.Contains(dsn, "://") && strings.Contains(dsn, "?"):
+		dsn += "&search_path=" + searchPath
+	case strings.Contains(dsn, "://"):
+		dsn += "?search_path=" + searchPath
+	default:
+		dsn += " search_path=" + searchPath
+	}
+	gormDB, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := gormDB.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// enough connections to race, few enough for the default max_connections
+	sqlDB.SetMaxOpenConns(20)
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := db.MigrateUp(gormDB); err != nil {
+		t.Fatal(err)
+	}
+	return store.NewGorm(gormDB)
+}
+
+// point in time the tests start at, events take place a year later
+var testNow = time.Date(2030, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// keeps the names of fixtures unique
+var fixtures uint64
+
+func nextFixture() uint64 {
+	return atomic.AddUint64(&fixtures, 1)
+}
+
+func createUser(t *testing.T, s *store.Store) models.User {
+	t.Helper()
+	n := nextFixture()
+	user := models.User{
+		Name: "Test",
+		Username: fmt.Sprintf("user%d", n),
+		Email: fmt.Sprintf("user%d@example.com", n),
+		Password: "hash",
+		Role: "user",
+	}
+	if err := s.Users.Create(&user); err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+// event on sale without ticket types or seats at a venue of its own
+func createEvent(t *testing.T, s *store.Store, capacity int) models.Event {
+	t.Helper()
+	n := nextFixture()
+	venue := models.Venue{Name: fmt.Sprintf("Venue %d", n), Timezone: "Europe/Berlin", DefaultCapacity: capacity}
+	if err := s.Venues.Create(&venue); err != nil {
+		t.Fatal(err)
+	}
+	event := models.Event{
+		Band_Name: fmt.Sprintf("Band %d", n),
+		VenueID: venue.ID,
+		Location: venue.Name,
+		Price: models.Money{Amount: 2500, Currency: "EUR"},
+		Capacity: capacity,
+		Timezone: venue.Timezone,
+		StartsAt: testNow.AddDate(1, 0, 0),
+		EndsAt: testNow.AddDate(1, 0, 0).Add(3 * time.Hour),
+		ReentryPolicy: models.ReentryNone,
+	}
+	if err := event.NormalizeTimes(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Events.Create(&event); err != nil {
+		t.Fatal(err)
+	}
+	return event
+}
+
+// pays the pending order in full at testNow
+func payOrder(t *testing.T, s *store.Store, order models.Order) models.Payment {
+	t.Helper()
+	payment := models.Payment{OrderID: order.ID, Provider: "mock", IntentID: "pi_order", Amount: order.Total.Amount, Currency: order.Total.Currency, Status: models.PaymentPending, CreatedAt: testNow}
+	if err := s.Payments.Create(&payment); err != nil {
+		t.Fatal(err)
+	}
+	payment, err := s.Payments.Settle(payment.ID, models.PaymentSucceeded, testNow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return payment
+}
+
+// tickets of a completed order of quantity tickets
+func paidTickets(t *testing.T, s *store.Store, eventID uint, quantity int) []models.Ticket {
+	t.Helper()
+	order, err := s.Orders.Create(createUser(t, s).ID, []store.OrderItem{{EventID: eventID, Quantity: quantity}}, store.OrderCodes{}, testNow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payOrder(t, s, order)
+	return order.Tickets
+}
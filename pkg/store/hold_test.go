@@ -0,0 +1,110 @@
+package store_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mgr1054/go-ticket/pkg/clock"
+	"github.com/mgr1054/go-ticket/pkg/models"
+	"github.com/mgr1054/go-ticket/pkg/store"
+	"github.com/mgr1054/go-ticket/pkg/sweeper"
+)
+
+const holdTTL = 10 * time.Minute
+
+func testHoldCapacity(t *testing.T, s *store.Store) {
+	event := createEvent(t, s, 5)
+	user := createUser(t, s)
+
+	hold, err := s.Holds.Create(user.ID, event.ID, 0, 3, "", testNow, holdTTL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// active holds count against the capacity
+	if _, err := s.Holds.Create(user.ID, event.ID, 0, 3, "", testNow, holdTTL); !errors.Is(err, store.ErrSoldOut) {
+		t.Errorf("hold beyond the capacity = %v, want ErrSoldOut", err)
+	}
+	if _, err := s.Orders.Create(user.ID, []store.OrderItem{{EventID: event.ID, Quantity: 3}}, store.OrderCodes{}, testNow); !errors.Is(err, store.ErrSoldOut) {
+		t.Errorf("order beyond the capacity = %v, want ErrSoldOut", err)
+	}
+	if _, err := s.Orders.Create(user.ID, []store.OrderItem{{EventID: event.ID, Quantity: 2}}, store.OrderCodes{}, testNow); err != nil {
+		t.Fatalf("order of the tickets left = %v", err)
+	}
+
+	if err := s.Holds.Release(hold.ID, testNow); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Holds.Release(hold.ID, testNow); !errors.Is(err, store.ErrHoldNotActive) {
+		t.Errorf("second release = %v, want ErrHoldNotActive", err)
+	}
+	if _, err := s.Orders.Create(user.ID, []store.OrderItem{{EventID: event.ID, Quantity: 3}}, store.OrderCodes{}, testNow); err != nil {
+		t.Errorf("order of the released tickets = %v", err)
+	}
+}
+
+func testHoldConfirm(t *testing.T, s *store.Store) {
+	event := createEvent(t, s, 5)
+	user := createUser(t, s)
+
+	hold, err := s.Holds.Create(user.ID, event.ID, 0, 2, "", testNow, holdTTL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	order, err := s.Holds.Confirm(hold.ID, "", testNow.Add(holdTTL/2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.Status != models.OrderPending || len(order.Tickets) != 2 {
+		t.Errorf("Confirm = status %q with %d tickets, want %q with 2", order.Status, len(order.Tickets), models.OrderPending)
+	}
+	hold, err = s.Holds.Get(hold.ID)
+	if err != nil || hold.Status != models.HoldConfirmed {
+		t.Errorf("confirmed hold = %q, %v, want %q", hold.Status, err, models.HoldConfirmed)
+	}
+	if _, err := s.Holds.Confirm(hold.ID, "", testNow.Add(holdTTL/2)); !errors.Is(err, store.ErrHoldNotActive) {
+		t.Errorf("second confirm = %v, want ErrHoldNotActive", err)
+	}
+	// the tickets of the order replace the hold
+	if _, err := s.Orders.Create(user.ID, []store.OrderItem{{EventID: event.ID, Quantity: 4}}, store.OrderCodes{}, testNow); !errors.Is(err, store.ErrSoldOut) {
+		t.Errorf("order beyond the capacity = %v, want ErrSoldOut", err)
+	}
+}
+
+// a hold past its ttl cannot be confirmed and the sweeper gives its tickets back
+func testHoldExpiry(t *testing.T, s *store.Store) {
+	fake := clock.NewFake(testNow)
+	sweep := sweeper.New(fake, time.Minute)
+	sweep.Add("expire holds", s.Holds.ExpireAll)
+
+	event := createEvent(t, s, 2)
+	user := createUser(t, s)
+	hold, err := s.Holds.Create(user.ID, event.ID, 0, 2, "", fake.Now(), holdTTL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sweep.Sweep()
+	if hold, err = s.Holds.Get(hold.ID); err != nil || hold.Status != models.HoldActive {
+		t.Fatalf("hold within its ttl = %q, %v, want %q", hold.Status, err, models.HoldActive)
+	}
+
+	fake.Advance(holdTTL + time.Second)
+	if _, err := s.Holds.Confirm(hold.ID, "", fake.Now()); !errors.Is(err, store.ErrHoldNotActive) {
+		t.Errorf("confirm of an expired hold = %v, want ErrHoldNotActive", err)
+	}
+	if err := s.Holds.Release(hold.ID, fake.Now()); !errors.Is(err, store.ErrHoldNotActive) {
+		t.Errorf("release of an expired hold = %v, want ErrHoldNotActive", err)
+	}
+
+	sweep.Sweep()
+	if hold, err = s.Holds.Get(hold.ID); err != nil || hold.Status != models.HoldExpired {
+		t.Errorf("swept hold = %q, %v, want %q", hold.Status, err, models.HoldExpired)
+	}
+	if count, err := s.Holds.ExpireAll(fake.Now()); err != nil || count != 0 {
+		t.Errorf("second ExpireAll = %d, %v, want 0", count, err)
+	}
+	if _, err := s.Orders.Create(user.ID, []store.OrderItem{{EventID: event.ID, Quantity: 2}}, store.OrderCodes{}, fake.Now()); err != nil {
+		t.Errorf("order of the expired hold's tickets = %v", err)
+	}
+}
@@ -0,0 +1,70 @@
+package store_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mgr1054/go-ticket/pkg/models"
+	"github.com/mgr1054/go-ticket/pkg/store"
+)
+
+// a resold ticket goes to the buyer but stays part of the order of its first sale,
+// the listing and the payout record the buyer's order
+func testResale(t *testing.T, s *store.Store) {
+	event := createEvent(t, s, 5)
+	ticket := paidTickets(t, s, event.ID, 1)[0]
+	buyer := createUser(t, s)
+
+	listing := models.Listing{TicketID: ticket.ID, SellerID: ticket.UserID, Price: models.Money{Amount: 2000, Currency: "EUR"}}
+	if err := s.Listings.Create(&listing, testNow); err != nil {
+		t.Fatal(err)
+	}
+	order, err := s.Listings.Buy(listing.ID, buyer.ID, testNow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payment := payOrder(t, s, order)
+
+	resold, err := s.Tickets.Get(ticket.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resold.UserID != buyer.ID || resold.Code == ticket.Code {
+		t.Errorf("resold ticket belongs to user %d, want %d with a new code", resold.UserID, buyer.ID)
+	}
+	if resold.OrderID == nil || *resold.OrderID != *ticket.OrderID {
+		t.Errorf("resold ticket has order %v, want the first order %d", resold.OrderID, *ticket.OrderID)
+	}
+	if resold.Price.Amount != 2000 || resold.Discount.Amount != 0 {
+		t.Errorf("resold ticket has price %d and discount %d, want 2000 and 0", resold.Price.Amount, resold.Discount.Amount)
+	}
+	if first, err := s.Orders.Get(*ticket.OrderID); err != nil || len(first.Tickets) != 1 {
+		t.Errorf("first order has %d tickets, %v, want 1", len(first.Tickets), err)
+	}
+	if bought, err := s.Orders.Get(order.ID); err != nil || len(bought.Tickets) != 1 || bought.Tickets[0].ID != ticket.ID {
+		t.Errorf("buyer's order has tickets %v, %v, want ticket %d", bought.Tickets, err, ticket.ID)
+	}
+
+	if listing, err = s.Listings.Get(listing.ID); err != nil || listing.OrderID == nil || *listing.OrderID != order.ID {
+		t.Errorf("sold listing has order %v, %v, want %d", listing.OrderID, err, order.ID)
+	}
+	payouts, err := s.Listings.ListPayouts(ticket.UserID)
+	if err != nil || len(payouts) != 1 || payouts[0].OrderID != order.ID || payouts[0].Amount.Amount != 2000 {
+		t.Errorf("payouts %+v, %v, want one of 2000 for order %d", payouts, err, order.ID)
+	}
+
+	// the face value of the first sale still caps the price
+	relisting := models.Listing{TicketID: ticket.ID, SellerID: buyer.ID, Price: models.Money{Amount: 2600, Currency: "EUR"}}
+	if err := s.Listings.Create(&relisting, testNow); !errors.Is(err, store.ErrAboveFaceValue) {
+		t.Errorf("listing above the face value = %v, want ErrAboveFaceValue", err)
+	}
+
+	// the buyer is refunded from the payment of the resale
+	refund := models.Refund{Amount: resold.Price.Amount, Currency: "EUR", Reason: models.RefundReasonPolicy}
+	if err := s.Tickets.Cancel(ticket.ID, &refund, testNow); err != nil {
+		t.Fatal(err)
+	}
+	if refund.PaymentID == nil || *refund.PaymentID != payment.ID {
+		t.Errorf("refund goes to payment %v, want the buyer's payment %d", refund.PaymentID, payment.ID)
+	}
+}
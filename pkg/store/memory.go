@@ -0,0 +1,504 @@
+package store
+
+import (
+	"sort"
+	"time"
+	"sync"
+
+	"github.com/mgr1054/go-ticket/pkg/models"
+)
+
+// in-memory state shared by all memory repositories, a single lock
+// keeps operations spanning several tables atomic
+type memory struct {
+	mu		sync.Mutex
+	lastID	map[string]uint
+	events	map[uint]models.Event
+	tickets	map[uint]models.Ticket
+	users	map[uint]models.User
+	orders	map[uint]models.Order
+	holds	map[uint]models.Hold
+	payments map[uint]models.Payment
+	refunds	map[uint]models.Refund
+	refundPolicies map[uint]models.RefundPolicy
+	venues	map[uint]models.Venue
+	seats	map[uint]models.Seat
+	eventSeats map[uint]models.EventSeat
+	ticketTypes map[uint]models.TicketType
+	presaleCodes map[uint]models.PresaleCode
+	promotions map[uint]models.Promotion
+	waitlist map[uint]models.WaitlistEntry
+	checkIns map[uint]models.CheckIn
+	transfers map[uint]models.Transfer
+	listings map[uint]models.Listing
+	payouts	map[uint]models.Payout
+	refreshTokens map[uint]models.RefreshToken
+	revocations map[string]models.TokenRevocation
+	signingKeys map[string]models.SigningKey
+}
+
+// creates a Store that keeps all data in process memory, nothing is persisted
+func NewMemory() *Store {
+	m := &memory{
+		lastID: map[string]uint{},
+		events: map[uint]models.Event{},
+		tickets: map[uint]models.Ticket{},
+		users: map[uint]models.User{},
+		orders: map[uint]models.Order{},
+		holds: map[uint]models.Hold{},
+		payments: map[uint]models.Payment{},
+		refunds: map[uint]models.Refund{},
+		refundPolicies: map[uint]models.RefundPolicy{},
+		venues: map[uint]models.Venue{},
+		seats: map[uint]models.Seat{},
+		eventSeats: map[uint]models.EventSeat{},
+		ticketTypes: map[uint]models.TicketType{},
+		presaleCodes: map[uint]models.PresaleCode{},
+		promotions: map[uint]models.Promotion{},
+		waitlist: map[uint]models.WaitlistEntry{},
+		checkIns: map[uint]models.CheckIn{},
+		transfers: map[uint]models.Transfer{},
+		listings: map[uint]models.Listing{},
+		payouts: map[uint]models.Payout{},
+		refreshTokens: map[uint]models.RefreshToken{},
+		revocations: map[string]models.TokenRevocation{},
+		signingKeys: map[string]models.SigningKey{},
+	}
+	return &Store{
+		Events: memoryEventStore{m},
+		Tickets: memoryTicketStore{m},
+		Users: memoryUserStore{m},
+		Orders: memoryOrderStore{m},
+		Holds: memoryHoldStore{m},
+		Payments: memoryPaymentStore{m},
+		Refunds: memoryRefundStore{m},
+		Venues: memoryVenueStore{m},
+		Seats: memorySeatStore{m},
+		TicketTypes: memoryTicketTypeStore{m},
+		PresaleCodes: memoryPresaleCodeStore{m},
+		Promotions: memoryPromotionStore{m},
+		Waitlist: memoryWaitlistStore{m},
+		CheckIns: memoryCheckInStore{m},
+		Transfers: memoryTransferStore{m},
+		Listings: memoryListingStore{m},
+		Tokens: memoryTokenStore{m},
+		SigningKeys: memorySigningKeyStore{m},
+	}
+}
+
+// returns the next auto increment id for table, caller must hold the lock
+func (m *memory) nextID(table string) uint {
+	m.lastID[table]++
+	return m.lastID[table]
+}
+
+// number of tickets of the event that are issued or reserved by a hold
+// active at now, caller must hold the lock
+func (m *memory) usedCapacity(eventID uint, now time.Time) int64 {
+	used := int64(0)
+	for _, ticket := range m.tickets {
+		if ticket.EventID == eventID && ticket.Status == models.TicketIssued {
+			used++
+		}
+	}
+	for _, hold := range m.holds {
+		if hold.EventID == eventID && hold.IsActive(now) {
+			used += int64(hold.Quantity)
+		}
+	}
+	return used
+}
+
+// deletes a ticket together with its refunds, check-ins, transfers and listings like
+// the ON DELETE CASCADE of the SQL schema, caller must hold the lock
+func (m *memory) deleteTicket(id uint) {
+	delete(m.tickets, id)
+	for refundID, refund := range m.refunds {
+		if refund.TicketID == id {
+			delete(m.refunds, refundID)
+		}
+	}
+	for checkInID, checkIn := range m.checkIns {
+		if checkIn.TicketID == id {
+			delete(m.checkIns, checkInID)
+		}
+	}
+	for transferID, transfer := range m.transfers {
+		if transfer.TicketID == id {
+			delete(m.transfers, transferID)
+		}
+	}
+	for listingID, listing := range m.listings {
+		if listing.TicketID == id {
+			m.deleteListing(listingID)
+		}
+	}
+}
+
+// returns the values of a map ordered by id like the SQL stores do
+func sortedValues[T any](rows map[uint]T, keep func(T) bool) []T {
+	ids := make([]uint, 0, len(rows))
+	for id := range rows {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	values := []T{}
+	for _, id := range ids {
+		if keep == nil || keep(rows[id]) {
+			values = append(values, rows[id])
+		}
+	}
+	return values
+}
+
+type memoryEventStore struct {
+	m *memory
+}
+
+func (s memoryEventStore) List() ([]models.Event, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	return sortedValues(s.m.events, nil), nil
+}
+
+func (s memoryEventStore) Get(id uint) (models.Event, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	event, ok := s.m.events[id]
+	if !ok {
+		return event, ErrNotFound
+	}
+	return event, nil
+}
+
+func (s memoryEventStore) FindByLocation(location string) ([]models.Event, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	return sortedValues(s.m.events, func(e models.Event) bool { return sameName(e.Location, location) }), nil
+}
+
+func (s memoryEventStore) FindByStart(r EventRange) ([]models.Event, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	events := sortedValues(s.m.events, r.contains)
+	sort.SliceStable(events, func(i, j int) bool { return events[i].StartsAt.Before(events[j].StartsAt) })
+	return events, nil
+}
+
+func (s memoryEventStore) Create(event *models.Event) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	event.ID = s.m.nextID("events")
+	s.m.events[event.ID] = *event
+	s.m.copyVenueSeats(*event)
+	return nil
+}
+
+func (s memoryEventStore) Update(id uint, changes models.Event, now time.Time) (models.Event, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	event, ok := s.m.events[id]
+	if !ok {
+		return event, ErrNotFound
+	}
+	if changes.Band_Name != "" {
+		event.Band_Name = changes.Band_Name
+	}
+	if changes.VenueID != 0 {
+		event.VenueID = changes.VenueID
+	}
+	if changes.Location != "" {
+		event.Location = changes.Location
+	}
+	if changes.Price.Currency != "" {
+		event.Price = changes.Price
+	}
+	if changes.Capacity != 0 {
+		if s.m.quotaSum(id, 0) > int64(changes.Capacity) {
+			return models.Event{}, ErrQuotaConflict
+		}
+		if s.m.usedCapacity(id, now) > int64(changes.Capacity) {
+			return models.Event{}, ErrCapacityConflict
+		}
+		event.Capacity = changes.Capacity
+	}
+	if changes.Timezone != "" {
+		event.Timezone = changes.Timezone
+	}
+	if !changes.StartsAt.IsZero() {
+		event.StartsAt = changes.StartsAt
+	}
+	if !changes.EndsAt.IsZero() {
+		event.EndsAt = changes.EndsAt
+	}
+	if !changes.DoorsOpenAt.IsZero() {
+		event.DoorsOpenAt = changes.DoorsOpenAt
+	}
+	if changes.StartsLocal != "" {
+		event.StartsLocal = changes.StartsLocal
+	}
+	if changes.SalesStart != nil {
+		event.SalesStart = changes.SalesStart
+	}
+	if changes.SalesEnd != nil {
+		event.SalesEnd = changes.SalesEnd
+	}
+	if changes.ReentryPolicy != "" {
+		event.ReentryPolicy = changes.ReentryPolicy
+	}
+	if event.VenueID != s.m.events[id].VenueID {
+		if err := s.m.resetEventSeats(event); err != nil {
+			return models.Event{}, err
+		}
+	}
+	s.m.events[id] = event
+	return event, nil
+}
+
+func (s memoryEventStore) Delete(id uint) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	if _, ok := s.m.events[id]; !ok {
+		return ErrNotFound
+	}
+	// same as the ON DELETE RESTRICT of the SQL schema
+	for _, ticket := range s.m.tickets {
+		if ticket.EventID == id {
+			return ErrEventInUse
+		}
+	}
+	delete(s.m.events, id)
+	delete(s.m.refundPolicies, id)
+	// same as the ON DELETE CASCADE of the SQL schema
+	for holdID, hold := range s.m.holds {
+		if hold.EventID == id {
+			delete(s.m.holds, holdID)
+		}
+	}
+	s.m.deleteEventSeats(id)
+	for ticketTypeID, ticketType := range s.m.ticketTypes {
+		if ticketType.EventID == id {
+			delete(s.m.ticketTypes, ticketTypeID)
+		}
+	}
+	for codeID, code := range s.m.presaleCodes {
+		if code.EventID == id {
+			delete(s.m.presaleCodes, codeID)
+		}
+	}
+	for promotionID, promotion := range s.m.promotions {
+		if promotion.EventID != nil && *promotion.EventID == id {
+			delete(s.m.promotions, promotionID)
+		}
+	}
+	for entryID, entry := range s.m.waitlist {
+		if entry.EventID == id {
+			delete(s.m.waitlist, entryID)
+		}
+	}
+	return nil
+}
+
+type memoryTicketStore struct {
+	m *memory
+}
+
+func (s memoryTicketStore) Get(id uint) (models.Ticket, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	ticket, ok := s.m.tickets[id]
+	if !ok {
+		return ticket, ErrNotFound
+	}
+	return ticket, nil
+}
+
+func (s memoryTicketStore) CountByEvent(eventID uint) (int64, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	return s.countByEvent(eventID), nil
+}
+
+// caller must hold the lock
+func (s memoryTicketStore) countByEvent(eventID uint) int64 {
+	count := int64(0)
+	for _, ticket := range s.m.tickets {
+		if ticket.EventID == eventID && ticket.Status == models.TicketIssued {
+			count++
+		}
+	}
+	return count
+}
+
+func (s memoryTicketStore) ListByUser(userID uint) ([]models.Ticket, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	return sortedValues(s.m.tickets, func(t models.Ticket) bool { return t.UserID == userID }), nil
+}
+
+func (s memoryTicketStore) ListByEvent(eventID uint) ([]models.Ticket, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	return sortedValues(s.m.tickets, func(t models.Ticket) bool { return t.EventID == eventID }), nil
+}
+
+
+type memoryUserStore struct {
+	m *memory
+}
+
+func (s memoryUserStore) Get(id uint) (models.User, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	user, ok := s.m.users[id]
+	if !ok {
+		return user, ErrNotFound
+	}
+	return user, nil
+}
+
+func (s memoryUserStore) GetByUsername(username string) (models.User, error) {
+	return s.find(func(u models.User) bool { return u.Username == username })
+}
+
+func (s memoryUserStore) GetByEmail(email string) (models.User, error) {
+	return s.find(func(u models.User) bool { return u.Email == email })
+}
+
+func (s memoryUserStore) find(match func(models.User) bool) (models.User, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	users := sortedValues(s.m.users, match)
+	if len(users) < 1 {
+		return models.User{}, ErrNotFound
+	}
+	return users[0], nil
+}
+
+// enforces the unique constraints of the users table, caller must hold the lock
+func (s memoryUserStore) checkUnique(user models.User) error {
+	for _, existing := range s.m.users {
+		if existing.ID == user.ID {
+			continue
+		}
+		if existing.Username == user.Username || existing.Email == user.Email {
+			return ErrDuplicate
+		}
+	}
+	return nil
+}
+
+func (s memoryUserStore) Create(user *models.User) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	if err := s.checkUnique(*user); err != nil {
+		return err
+	}
+	user.ID = s.m.nextID("users")
+	s.m.users[user.ID] = *user
+	return nil
+}
+
+func (s memoryUserStore) Update(id uint, changes models.User) (models.User, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	user, ok := s.m.users[id]
+	if !ok {
+		return user, ErrNotFound
+	}
+	if changes.Name != "" {
+		user.Name = changes.Name
+	}
+	if changes.Username != "" {
+		user.Username = changes.Username
+	}
+	if changes.Email != "" {
+		user.Email = changes.Email
+	}
+	if changes.Password != "" {
+		user.Password = changes.Password
+	}
+	if changes.Role != "" {
+		user.Role = changes.Role
+	}
+	if err := s.checkUnique(user); err != nil {
+		return models.User{}, err
+	}
+	s.m.users[id] = user
+	return user, nil
+}
+
+func (s memoryUserStore) Verify(id uint, email string, now time.Time) (models.User, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	user, ok := s.m.users[id]
+	if !ok || user.Email != email {
+		return models.User{}, ErrNotFound
+	}
+	if user.VerifiedAt == nil {
+		verifiedAt := now.UTC()
+		user.VerifiedAt = &verifiedAt
+		s.m.users[id] = user
+	}
+	return user, nil
+}
+
+func (s memoryUserStore) Delete(id uint) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	if _, ok := s.m.users[id]; !ok {
+		return ErrNotFound
+	}
+	// same as the ON DELETE RESTRICT of the SQL schema
+	for _, order := range s.m.orders {
+		if order.UserID == id {
+			return ErrUserInUse
+		}
+	}
+	for _, ticket := range s.m.tickets {
+		if ticket.UserID == id {
+			return ErrUserInUse
+		}
+	}
+	for _, payout := range s.m.payouts {
+		if payout.UserID == id {
+			return ErrUserInUse
+		}
+	}
+	delete(s.m.users, id)
+	// same as the ON DELETE CASCADE of the SQL schema
+	for holdID, hold := range s.m.holds {
+		if hold.UserID == id {
+			delete(s.m.holds, holdID)
+		}
+	}
+	for entryID, entry := range s.m.waitlist {
+		if entry.UserID == id {
+			delete(s.m.waitlist, entryID)
+		}
+	}
+	for transferID, transfer := range s.m.transfers {
+		if transfer.FromUserID == id || transfer.ToUserID == id {
+			delete(s.m.transfers, transferID)
+		}
+	}
+	for listingID, listing := range s.m.listings {
+		if listing.SellerID == id {
+			s.m.deleteListing(listingID)
+		}
+	}
+	for tokenID, token := range s.m.refreshTokens {
+		if token.UserID == id {
+			delete(s.m.refreshTokens, tokenID)
+		}
+	}
+	// same as the ON DELETE SET NULL of the SQL schema
+	for checkInID, checkIn := range s.m.checkIns {
+		if checkIn.ScannerID != nil && *checkIn.ScannerID == id {
+			checkIn.ScannerID = nil
+			s.m.checkIns[checkInID] = checkIn
+		}
+	}
+	return nil
+}
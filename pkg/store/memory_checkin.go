@@ -0,0 +1,190 @@
+package store
+
+import (
+	"sort"
+
+	"github.com/mgr1054/go-ticket/pkg/models"
+)
+
+type memoryCheckInStore struct {
+	m *memory
+}
+
+// same as lockTicketByCode of the SQL stores, caller must hold the lock
+func (m *memory) ticketByCode(code string, eventID uint) (models.Ticket, error) {
+	for _, ticket := range m.tickets {
+		if ticket.Code != code {
+			continue
+		}
+		if ticket.EventID != eventID {
+			return ticket, ErrWrongEvent
+		}
+		if ticket.Status != models.TicketIssued {
+			return ticket, ErrTicketNotIssued
+		}
+		if ticket.OrderID != nil && m.orders[*ticket.OrderID].Status != models.OrderCompleted {
+			return ticket, ErrTicketNotIssued
+		}
+		return ticket, m.checkNotListed(ticket.ID)
+	}
+	return models.Ticket{}, ErrNotFound
+}
+
+// stores the scan with its result, caller must hold the lock
+func (m *memory) insertCheckIn(scan *models.CheckIn, result string) {
+	scan.ID = m.nextID("check_ins")
+	scan.ScannedAt = scan.ScannedAt.UTC()
+	scan.Result = result
+	m.checkIns[scan.ID] = *scan
+}
+
+func (s memoryCheckInStore) Admit(scan models.CheckIn, code string) (models.CheckIn, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	ticket, err := s.m.ticketByCode(code, scan.EventID)
+	if err != nil {
+		return models.CheckIn{}, err
+	}
+	scan.TicketID = ticket.ID
+	reenters := s.m.events[ticket.EventID].ReentryPolicy == models.ReentryScanOut
+
+	if ticket.Inside || (ticket.AdmittedAt != nil && !reenters) {
+		first := models.CheckIn{}
+		for _, checkIn := range s.m.ticketCheckIns(ticket.ID) {
+			if checkIn.Result == models.CheckInAdmitted {
+				first = checkIn
+			}
+		}
+		scan.Direction = models.DirectionIn
+		s.m.insertCheckIn(&scan, models.CheckInDuplicate)
+		return first, ErrAlreadyAdmitted
+	}
+
+	scan.Direction = models.DirectionIn
+	s.m.insertCheckIn(&scan, models.CheckInAdmitted)
+	if ticket.AdmittedAt == nil {
+		ticket.AdmittedAt = &scan.ScannedAt
+	}
+	ticket.Inside = true
+	s.m.tickets[ticket.ID] = ticket
+	return scan, nil
+}
+
+func (s memoryCheckInStore) Exit(scan models.CheckIn, code string) (models.CheckIn, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	ticket, err := s.m.ticketByCode(code, scan.EventID)
+	if err != nil {
+		return models.CheckIn{}, err
+	}
+	if s.m.events[ticket.EventID].ReentryPolicy != models.ReentryScanOut {
+		return models.CheckIn{}, ErrReentryNotAllowed
+	}
+	if !ticket.Inside {
+		return models.CheckIn{}, ErrNotAdmitted
+	}
+
+	scan.TicketID = ticket.ID
+	scan.Direction = models.DirectionOut
+	s.m.insertCheckIn(&scan, models.CheckInExited)
+	ticket.Inside = false
+	s.m.tickets[ticket.ID] = ticket
+	return scan, nil
+}
+
+// scans of the ticket ordered by (scanned_at, id) like the SQL stores, caller must hold the lock
+func (m *memory) ticketCheckIns(ticketID uint) []models.CheckIn {
+	checkIns := sortedValues(m.checkIns, func(checkIn models.CheckIn) bool { return checkIn.TicketID == ticketID })
+	sort.SliceStable(checkIns, func(i, j int) bool { return checkIns[i].ScannedAt.Before(checkIns[j].ScannedAt) })
+	return checkIns
+}
+
+func (s memoryCheckInStore) Sync(eventID uint, scans []OfflineScan) ([]SyncResult, []models.CheckIn, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	event, ok := s.m.events[eventID]
+	if !ok {
+		return nil, nil, ErrNotFound
+	}
+	reentry := event.ReentryPolicy == models.ReentryScanOut
+
+	results := make([]SyncResult, len(scans))
+	var changed []models.CheckIn
+	for _, group := range groupByCode(scans) {
+		ticket, err := s.m.ticketByCode(scans[group[0]].Code, eventID)
+		if err != nil {
+			for _, i := range group {
+				results[i].Err = err
+			}
+			continue
+		}
+
+		sync := newTicketSync(ticket, s.m.ticketCheckIns(ticket.ID), scans, group, reentry)
+		for _, scan := range sync.fresh {
+			s.m.insertCheckIn(scan, scan.Result)
+		}
+		for _, scan := range sync.changed {
+			s.m.checkIns[scan.ID] = *scan
+			changed = append(changed, *scan)
+		}
+		ticket.AdmittedAt, ticket.Inside = sync.admittedAt, sync.inside
+		s.m.tickets[ticket.ID] = ticket
+		sync.report(group, results)
+	}
+	return results, changed, nil
+}
+
+func (s memoryCheckInStore) Admissible(eventID uint) ([]models.Ticket, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	if _, ok := s.m.events[eventID]; !ok {
+		return nil, ErrNotFound
+	}
+	return sortedValues(s.m.tickets, func(ticket models.Ticket) bool {
+		if ticket.EventID != eventID || ticket.Status != models.TicketIssued {
+			return false
+		}
+		if ticket.OrderID != nil && s.m.orders[*ticket.OrderID].Status != models.OrderCompleted {
+			return false
+		}
+		return s.m.checkNotListed(ticket.ID) == nil
+	}), nil
+}
+
+func (s memoryCheckInStore) ListByTicket(ticketID uint) ([]models.CheckIn, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	return s.m.ticketCheckIns(ticketID), nil
+}
+
+func (s memoryCheckInStore) Stats(eventID uint) (models.CheckInStats, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	stats := models.CheckInStats{EventID: eventID, Gates: map[string]int64{}}
+	if _, ok := s.m.events[eventID]; !ok {
+		return stats, ErrNotFound
+	}
+	for _, ticket := range s.m.tickets {
+		if ticket.EventID != eventID || ticket.Status != models.TicketIssued {
+			continue
+		}
+		stats.Issued++
+		if ticket.AdmittedAt != nil {
+			stats.Admitted++
+		}
+		if ticket.Inside {
+			stats.Inside++
+		}
+	}
+	for _, checkIn := range s.m.checkIns {
+		if checkIn.EventID == eventID && checkIn.Result == models.CheckInAdmitted {
+			stats.Gates[checkIn.Gate]++
+		}
+	}
+	return stats, nil
+}
@@ -0,0 +1,143 @@
+package store
+
+import (
+	"time"
+
+	"github.com/mgr1054/go-ticket/pkg/models"
+)
+
+type memoryHoldStore struct {
+	m *memory
+}
+
+func (s memoryHoldStore) Create(userID uint, eventID uint, ticketTypeID uint, quantity int, presaleCode string, now time.Time, ttl time.Duration) (models.Hold, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	event, ok := s.m.events[eventID]
+	if !ok {
+		return models.Hold{}, ErrNotFound
+	}
+	if err := s.m.checkCapacity(event, quantity, nil, now); err != nil {
+		return models.Hold{}, err
+	}
+	ticketType, err := s.m.checkTicketType(event, ticketTypeID, quantity, now)
+	if err != nil {
+		return models.Hold{}, err
+	}
+	presale, err := s.m.checkSale(event, ticketType, presaleCode, now)
+	if err != nil {
+		return models.Hold{}, err
+	}
+
+	hold := models.Hold{
+		ID: s.m.nextID("holds"),
+		UserID: userID,
+		EventID: event.ID,
+		Quantity: quantity,
+		TicketTypeID: orderLine{ticketType: ticketType}.ticketTypeID(),
+		PresaleCodeID: presaleCodeID(presale),
+		Status: models.HoldActive,
+		ExpiresAt: now.Add(ttl).UTC(),
+		CreatedAt: now.UTC(),
+	}
+	s.m.holds[hold.ID] = hold
+	return hold, nil
+}
+
+func (s memoryHoldStore) Get(id uint) (models.Hold, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	hold, ok := s.m.holds[id]
+	if !ok {
+		return hold, ErrNotFound
+	}
+	return hold, nil
+}
+
+func (s memoryHoldStore) ListByUser(userID uint) ([]models.Hold, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	return sortedValues(s.m.holds, func(h models.Hold) bool { return h.UserID == userID }), nil
+}
+
+// returns the hold if it is still active, caller must hold the lock
+func (s memoryHoldStore) activeHold(id uint, now time.Time) (models.Hold, error) {
+	hold, ok := s.m.holds[id]
+	if !ok {
+		return hold, ErrNotFound
+	}
+	if !hold.IsActive(now) {
+		return hold, ErrHoldNotActive
+	}
+	return hold, nil
+}
+
+func (s memoryHoldStore) Confirm(id uint, promoCode string, now time.Time) (models.Order, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	hold, err := s.activeHold(id, now)
+	if err != nil {
+		return models.Order{}, err
+	}
+	event, ok := s.m.events[hold.EventID]
+	if !ok {
+		return models.Order{}, ErrNotFound
+	}
+
+	var ticketType *models.TicketType
+	if hold.TicketTypeID != nil {
+		found := s.m.ticketTypes[*hold.TicketTypeID]
+		ticketType = &found
+	}
+
+	lines := []orderLine{{event: event, ticketType: ticketType, quantity: hold.Quantity}}
+	order := models.Order{UserID: hold.UserID, PresaleCodeID: hold.PresaleCodeID}
+	promotion, err := s.m.findPromotion(promoCode, hold.UserID, now)
+	if err != nil {
+		return order, err
+	}
+	if err := applyPromotion(&order, lines, promotion); err != nil {
+		return order, err
+	}
+
+	// the capacity and the use of the presale code were already reserved by the hold
+	order, err = s.m.insertOrder(order, lines, now)
+	if err != nil {
+		return order, err
+	}
+
+	hold.Status = models.HoldConfirmed
+	hold.OrderID = &order.ID
+	s.m.holds[hold.ID] = hold
+	return order, nil
+}
+
+func (s memoryHoldStore) Release(id uint, now time.Time) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	hold, err := s.activeHold(id, now)
+	if err != nil {
+		return err
+	}
+	hold.Status = models.HoldReleased
+	s.m.holds[hold.ID] = hold
+	return nil
+}
+
+func (s memoryHoldStore) ExpireAll(now time.Time) (int64, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	count := int64(0)
+	for id, hold := range s.m.holds {
+		if hold.Status == models.HoldActive && !hold.ExpiresAt.After(now) {
+			hold.Status = models.HoldExpired
+			s.m.holds[id] = hold
+			count++
+		}
+	}
+	return count, nil
+}
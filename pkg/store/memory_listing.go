@@ -0,0 +1,253 @@
+package store
+
+import (
+	"sort"
+	"time"
+
+	"github.com/mgr1054/go-ticket/pkg/models"
+)
+
+type memoryListingStore struct {
+	m *memory
+}
+
+// same as checkNotListed of the SQL stores, caller must hold the lock
+func (m *memory) checkNotListed(ticketID uint) error {
+	for _, listing := range m.listings {
+		if listing.TicketID == ticketID && listing.IsOpen() {
+			return ErrTicketListed
+		}
+	}
+	return nil
+}
+
+// same as lastSale of the SQL stores, caller must hold the lock
+func (m *memory) lastSale(ticketID uint) (models.Listing, bool) {
+	sales := sortedValues(m.listings, func(l models.Listing) bool {
+		return l.TicketID == ticketID && l.Status == models.ListingSold
+	})
+	if len(sales) == 0 {
+		return models.Listing{}, false
+	}
+	return sales[len(sales)-1], true
+}
+
+// same as faceValue of the SQL stores, caller must hold the lock
+func (m *memory) faceValue(ticket models.Ticket) models.Money {
+	if sale, found := m.lastSale(ticket.ID); found {
+		return sale.FaceValue
+	}
+	return models.Money{Amount: ticket.Price.Amount + ticket.Discount.Amount, Currency: ticket.Price.Currency}
+}
+
+// same as holderOrderID of the SQL stores, caller must hold the lock
+func (m *memory) holderOrderID(ticket models.Ticket) *uint {
+	if sale, found := m.lastSale(ticket.ID); found {
+		return sale.OrderID
+	}
+	return ticket.OrderID
+}
+
+// same as closeListing of the SQL stores, caller must hold the lock
+func (m *memory) closeListing(listing *models.Listing, status string, now time.Time) {
+	closedAt := now.UTC()
+	listing.Status = status
+	listing.ClosedAt = &closedAt
+	m.listings[listing.ID] = *listing
+}
+
+// deletes a listing together with its payouts like the ON DELETE CASCADE of the SQL
+// schema, orders buying it keep no reference, caller must hold the lock
+func (m *memory) deleteListing(id uint) {
+	delete(m.listings, id)
+	for payoutID, payout := range m.payouts {
+		if payout.ListingID == id {
+			delete(m.payouts, payoutID)
+		}
+	}
+	for orderID, order := range m.orders {
+		if order.ListingID != nil && *order.ListingID == id {
+			order.ListingID = nil
+			m.orders[orderID] = order
+		}
+	}
+}
+
+func (s memoryListingStore) Create(listing *models.Listing, now time.Time) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	ticket, ok := s.m.tickets[listing.TicketID]
+	if !ok {
+		return ErrNotFound
+	}
+	if ticket.Status != models.TicketIssued {
+		return ErrTicketNotIssued
+	}
+	if ticket.OrderID != nil && s.m.orders[*ticket.OrderID].Status != models.OrderCompleted {
+		return ErrTicketNotIssued
+	}
+	if ticket.AdmittedAt != nil {
+		return ErrTicketUsed
+	}
+	if ticket.UserID != listing.SellerID {
+		return ErrTransferClosed
+	}
+	if err := s.m.checkNotListed(ticket.ID); err != nil {
+		return ErrDuplicate
+	}
+	face := s.m.faceValue(ticket)
+	if err := checkFaceValue(face, listing.Price); err != nil {
+		return err
+	}
+
+	listing.ID = s.m.nextID("listings")
+	listing.EventID = ticket.EventID
+	listing.FaceValue = face
+	listing.Status = models.ListingActive
+	listing.OrderID = nil
+	listing.CreatedAt = now.UTC()
+	s.m.listings[listing.ID] = *listing
+	return nil
+}
+
+func (s memoryListingStore) Get(id uint) (models.Listing, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	listing, ok := s.m.listings[id]
+	if !ok {
+		return models.Listing{}, ErrNotFound
+	}
+	return listing, nil
+}
+
+func (s memoryListingStore) ListByEvent(eventID uint) ([]models.Listing, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	if _, ok := s.m.events[eventID]; !ok {
+		return nil, ErrNotFound
+	}
+	listings := sortedValues(s.m.listings, func(listing models.Listing) bool {
+		return listing.EventID == eventID && listing.Status == models.ListingActive
+	})
+	sort.SliceStable(listings, func(i, j int) bool { return listings[i].Price.Amount < listings[j].Price.Amount })
+	return listings, nil
+}
+
+func (s memoryListingStore) ListBySeller(sellerID uint) ([]models.Listing, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	return sortedValues(s.m.listings, func(listing models.Listing) bool { return listing.SellerID == sellerID }), nil
+}
+
+func (s memoryListingStore) Withdraw(id uint, now time.Time) (models.Listing, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	listing, ok := s.m.listings[id]
+	if !ok {
+		return listing, ErrNotFound
+	}
+	if listing.Status != models.ListingActive {
+		return listing, ErrListingClosed
+	}
+	s.m.closeListing(&listing, models.ListingWithdrawn, now)
+	return listing, nil
+}
+
+func (s memoryListingStore) Buy(id uint, buyerID uint, now time.Time) (models.Order, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	listing, ok := s.m.listings[id]
+	if !ok {
+		return models.Order{}, ErrNotFound
+	}
+	if listing.Status != models.ListingActive || !s.m.events[listing.EventID].StartsAt.After(now) {
+		return models.Order{}, ErrListingClosed
+	}
+
+	order := models.Order{
+		ID: s.m.nextID("orders"),
+		UserID: buyerID,
+		Status: models.OrderPending,
+		Total: listing.Price,
+		Discount: models.Money{Currency: listing.Price.Currency},
+		ListingID: &listing.ID,
+		CreatedAt: now.UTC(),
+	}
+	s.m.orders[order.ID] = order
+
+	listing.Status = models.ListingReserved
+	listing.OrderID = &order.ID
+	s.m.listings[listing.ID] = listing
+	return order, nil
+}
+
+// same as settleListing of the SQL stores, caller must hold the lock
+func (m *memory) settleListing(order models.Order, now time.Time) error {
+	listing, ok := m.listings[*order.ListingID]
+	if !ok || listing.Status != models.ListingReserved || listing.OrderID == nil || *listing.OrderID != order.ID {
+		return nil
+	}
+
+	if order.Status != models.OrderCompleted {
+		if !m.events[listing.EventID].StartsAt.After(now) {
+			m.closeListing(&listing, models.ListingWithdrawn, now)
+			return nil
+		}
+		listing.Status = models.ListingActive
+		listing.OrderID = nil
+		m.listings[listing.ID] = listing
+		return nil
+	}
+
+	code, err := models.NewEntryCode()
+	if err != nil {
+		return err
+	}
+	ticket := m.tickets[listing.TicketID]
+	ticket.UserID = order.UserID
+	ticket.Price.Amount = listing.Price.Amount
+	ticket.Discount.Amount = 0
+	ticket.Code = code
+	m.tickets[ticket.ID] = ticket
+
+	for _, transfer := range m.transfers {
+		if transfer.TicketID == ticket.ID && transfer.Status == models.TransferPending {
+			m.closeTransfer(&transfer, models.TransferCancelled, now)
+		}
+	}
+	payout := models.Payout{
+		ID: m.nextID("payouts"),
+		ListingID: listing.ID,
+		UserID: listing.SellerID,
+		OrderID: order.ID,
+		Amount: listing.Price,
+		CreatedAt: now.UTC(),
+	}
+	m.payouts[payout.ID] = payout
+	m.closeListing(&listing, models.ListingSold, now)
+	return nil
+}
+
+func (s memoryListingStore) WithdrawStarted(now time.Time) (int64, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	withdrawn := int64(0)
+	for _, listing := range s.m.listings {
+		if listing.Status == models.ListingActive && !s.m.events[listing.EventID].StartsAt.After(now) {
+			s.m.closeListing(&listing, models.ListingWithdrawn, now)
+			withdrawn++
+		}
+	}
+	return withdrawn, nil
+}
+
+func (s memoryListingStore) ListPayouts(userID uint) ([]models.Payout, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	return sortedValues(s.m.payouts, func(payout models.Payout) bool { return payout.UserID == userID }), nil
+}
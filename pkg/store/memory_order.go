@@ -0,0 +1,121 @@
+package store
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mgr1054/go-ticket/pkg/models"
+)
+
+type memoryOrderStore struct {
+	m *memory
+}
+
+// stores the order of a user with its tickets, the caller has to hold the lock
+// and check the capacity and sale
+func (m *memory) insertOrder(order models.Order, lines []orderLine, now time.Time) (models.Order, error) {
+	total, discount, err := orderTotal(lines)
+	if err != nil {
+		return models.Order{}, err
+	}
+
+	tickets, err := orderTickets(order.UserID, lines)
+	if err != nil {
+		return models.Order{}, err
+	}
+	order.ID = m.nextID("orders")
+	order.Status = models.OrderPending
+	order.Total, order.Discount = total, discount
+	order.CreatedAt = now
+	for i := range tickets {
+		tickets[i].ID = m.nextID("tickets")
+		tickets[i].OrderID = &order.ID
+		m.tickets[tickets[i].ID] = tickets[i]
+	}
+	m.orders[order.ID] = order
+
+	order.Tickets = tickets
+	return order, nil
+}
+
+func (s memoryOrderStore) Create(userID uint, items []OrderItem, codes OrderCodes, now time.Time) (models.Order, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	// everything is validated before the first ticket is stored
+	var lines []orderLine
+	order := models.Order{UserID: userID}
+	merged := mergeOrderItems(items)
+	for i, item := range merged {
+		event, ok := s.m.events[item.EventID]
+		if !ok {
+			return models.Order{}, fmt.Errorf("event %d: %w", item.EventID, ErrNotFound)
+		}
+		if i == 0 || merged[i-1].EventID != item.EventID {
+			quantity, seatIDs := eventDemand(merged, event.ID)
+			if err := s.m.checkCapacity(event, quantity, seatIDs, now); err != nil {
+				return models.Order{}, fmt.Errorf("event %d: %w", event.ID, err)
+			}
+		}
+		ticketType, err := s.m.checkTicketType(event, item.TicketTypeID, item.Quantity+len(item.SeatIDs), now)
+		if err != nil {
+			return models.Order{}, fmt.Errorf("event %d: %w", event.ID, err)
+		}
+		presale, err := s.m.checkSale(event, ticketType, codes.Presale, now)
+		if err != nil {
+			return models.Order{}, fmt.Errorf("event %d: %w", event.ID, err)
+		}
+		if presale != nil {
+			order.PresaleCodeID = &presale.ID
+		}
+		lines = append(lines, orderLine{event: event, ticketType: ticketType, quantity: item.Quantity, seatIDs: item.SeatIDs})
+	}
+
+	promotion, err := s.m.findPromotion(codes.Promotion, userID, now)
+	if err != nil {
+		return models.Order{}, err
+	}
+	if err := applyPromotion(&order, lines, promotion); err != nil {
+		return models.Order{}, err
+	}
+	return s.m.insertOrder(order, lines, now)
+}
+
+func (s memoryOrderStore) Get(id uint) (models.Order, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	order, ok := s.m.orders[id]
+	if !ok {
+		return order, ErrNotFound
+	}
+	return s.withTickets(order), nil
+}
+
+func (s memoryOrderStore) ListByUser(userID uint) ([]models.Order, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	orders := sortedValues(s.m.orders, func(o models.Order) bool { return o.UserID == userID })
+	for i := range orders {
+		orders[i] = s.withTickets(orders[i])
+	}
+	return orders, nil
+}
+
+// attaches the tickets and payments of the order, caller must hold the lock
+func (s memoryOrderStore) withTickets(order models.Order) models.Order {
+	order.Tickets = sortedValues(s.m.tickets, func(t models.Ticket) bool {
+		return t.OrderID != nil && *t.OrderID == order.ID
+	})
+	// same as withResoldTicket of the SQL stores
+	if order.ListingID != nil {
+		listing, ok := s.m.listings[*order.ListingID]
+		if ok && listing.Status == models.ListingSold && listing.OrderID != nil && *listing.OrderID == order.ID {
+			order.Tickets = append(order.Tickets, s.m.tickets[listing.TicketID])
+		}
+	}
+	payments := sortedValues(s.m.payments, func(p models.Payment) bool { return p.OrderID == order.ID })
+	if len(payments) > 0 {
+		order.Payments = payments
+	}
+	return order
+}
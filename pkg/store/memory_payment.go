@@ -0,0 +1,96 @@
+package store
+
+import (
+	"time"
+
+	"github.com/mgr1054/go-ticket/pkg/models"
+)
+
+type memoryPaymentStore struct {
+	m *memory
+}
+
+func (s memoryPaymentStore) Create(payment *models.Payment) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	payment.ID = s.m.nextID("payments")
+	payment.UpdatedAt = payment.CreatedAt
+	s.m.payments[payment.ID] = *payment
+	return nil
+}
+
+func (s memoryPaymentStore) Get(id uint) (models.Payment, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	payment, ok := s.m.payments[id]
+	if !ok {
+		return payment, ErrNotFound
+	}
+	return payment, nil
+}
+
+func (s memoryPaymentStore) GetByIntent(provider string, intentID string) (models.Payment, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	payments := sortedValues(s.m.payments, func(p models.Payment) bool {
+		return p.Provider == provider && p.IntentID == intentID
+	})
+	if len(payments) < 1 {
+		return models.Payment{}, ErrNotFound
+	}
+	return payments[0], nil
+}
+
+// caller must hold the lock
+func (m *memory) settlePayment(id uint, status string, now time.Time) (models.Payment, error) {
+	payment, ok := m.payments[id]
+	if !ok {
+		return payment, ErrNotFound
+	}
+	if payment.Status != models.PaymentPending {
+		return payment, ErrPaymentSettled
+	}
+
+	payment.Status = status
+	payment.UpdatedAt = now
+	m.payments[id] = payment
+
+	order := m.orders[payment.OrderID]
+	order.Status = models.OrderCompleted
+	if status != models.PaymentSucceeded {
+		order.Status = models.OrderFailed
+		for ticketID, ticket := range m.tickets {
+			if ticket.OrderID != nil && *ticket.OrderID == order.ID {
+				m.deleteTicket(ticketID)
+			}
+		}
+	}
+	m.orders[order.ID] = order
+	if order.ListingID != nil {
+		return payment, m.settleListing(order, now)
+	}
+	return payment, nil
+}
+
+func (s memoryPaymentStore) Settle(id uint, status string, now time.Time) (models.Payment, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	return s.m.settlePayment(id, status, now)
+}
+
+func (s memoryPaymentStore) ExpirePending(now time.Time, timeout time.Duration) (int64, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	cutoff := now.Add(-timeout)
+	count := int64(0)
+	for id, payment := range s.m.payments {
+		if payment.Status == models.PaymentPending && payment.CreatedAt.Before(cutoff) {
+			if _, err := s.m.settlePayment(id, models.PaymentFailed, now); err != nil {
+				return count, err
+			}
+			count++
+		}
+	}
+	return count, nil
+}
@@ -0,0 +1,146 @@
+package store
+
+import (
+	"time"
+
+	"github.com/mgr1054/go-ticket/pkg/models"
+)
+
+type memoryPresaleCodeStore struct {
+	m *memory
+}
+
+// same as presaleCodeUses of the SQL stores, caller must hold the lock
+func (m *memory) presaleCodeUses(id uint, now time.Time) int64 {
+	uses := int64(0)
+	for _, order := range m.orders {
+		if order.PresaleCodeID != nil && *order.PresaleCodeID == id && order.Status != models.OrderFailed {
+			uses++
+		}
+	}
+	for _, hold := range m.holds {
+		if hold.PresaleCodeID != nil && *hold.PresaleCodeID == id && hold.IsActive(now) {
+			uses++
+		}
+	}
+	return uses
+}
+
+// same as checkSale of the SQL stores, caller must hold the lock
+func (m *memory) checkSale(event models.Event, ticketType *models.TicketType, code string, now time.Time) (*models.PresaleCode, error) {
+	switch event.SaleState(ticketType, now) {
+	case models.SaleEnded:
+		return nil, ErrNotOnSale
+	case models.SaleOpen:
+		return nil, nil
+	}
+	if code == "" {
+		return nil, ErrNotOnSale
+	}
+
+	code = models.NormalizeCode(code)
+	for _, presale := range m.presaleCodes {
+		if presale.EventID != event.ID || presale.Code != code {
+			continue
+		}
+		if !presale.Unlocks(ticketType, now) || m.presaleCodeUses(presale.ID, now) >= int64(presale.MaxUses) {
+			return nil, ErrPresaleCodeRejected
+		}
+		return &presale, nil
+	}
+	return nil, ErrPresaleCodeRejected
+}
+
+// same as checkPresaleCodeFits of the SQL stores, caller must hold the lock
+func (m *memory) checkPresaleCodeFits(code models.PresaleCode) error {
+	for _, existing := range m.presaleCodes {
+		if existing.EventID == code.EventID && existing.ID != code.ID && existing.Code == code.Code {
+			return ErrDuplicate
+		}
+	}
+	if code.TicketTypeID != nil {
+		if ticketType, ok := m.ticketTypes[*code.TicketTypeID]; !ok || ticketType.EventID != code.EventID {
+			return ErrTicketTypeNotFound
+		}
+	}
+	return nil
+}
+
+func (s memoryPresaleCodeStore) ListByEvent(eventID uint, now time.Time) ([]models.PresaleCode, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	if _, ok := s.m.events[eventID]; !ok {
+		return nil, ErrNotFound
+	}
+
+	codes := sortedValues(s.m.presaleCodes, func(p models.PresaleCode) bool { return p.EventID == eventID })
+	for i := range codes {
+		codes[i].Uses = int(s.m.presaleCodeUses(codes[i].ID, now))
+	}
+	return codes, nil
+}
+
+func (s memoryPresaleCodeStore) Get(id uint, now time.Time) (models.PresaleCode, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	code, ok := s.m.presaleCodes[id]
+	if !ok {
+		return code, ErrNotFound
+	}
+	code.Uses = int(s.m.presaleCodeUses(id, now))
+	return code, nil
+}
+
+func (s memoryPresaleCodeStore) Create(code *models.PresaleCode) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	if _, ok := s.m.events[code.EventID]; !ok {
+		return ErrNotFound
+	}
+	code.ID = 0
+	if err := s.m.checkPresaleCodeFits(*code); err != nil {
+		return err
+	}
+	code.ID = s.m.nextID("presale_codes")
+	s.m.presaleCodes[code.ID] = *code
+	return nil
+}
+
+func (s memoryPresaleCodeStore) Update(id uint, code models.PresaleCode) (models.PresaleCode, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	current, ok := s.m.presaleCodes[id]
+	if !ok {
+		return code, ErrNotFound
+	}
+	code.ID, code.EventID, code.CreatedAt = id, current.EventID, current.CreatedAt
+	if err := s.m.checkPresaleCodeFits(code); err != nil {
+		return code, err
+	}
+	code.Uses = 0
+	s.m.presaleCodes[id] = code
+	return code, nil
+}
+
+func (s memoryPresaleCodeStore) Delete(id uint) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	if _, ok := s.m.presaleCodes[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.m.presaleCodes, id)
+	// same as the ON DELETE SET NULL of the SQL schema
+	for orderID, order := range s.m.orders {
+		if order.PresaleCodeID != nil && *order.PresaleCodeID == id {
+			order.PresaleCodeID = nil
+			s.m.orders[orderID] = order
+		}
+	}
+	for holdID, hold := range s.m.holds {
+		if hold.PresaleCodeID != nil && *hold.PresaleCodeID == id {
+			hold.PresaleCodeID = nil
+			s.m.holds[holdID] = hold
+		}
+	}
+	return nil
+}
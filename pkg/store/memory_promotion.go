@@ -0,0 +1,124 @@
+package store
+
+import (
+	"time"
+
+	"github.com/mgr1054/go-ticket/pkg/models"
+)
+
+type memoryPromotionStore struct {
+	m *memory
+}
+
+// same as promotionUses of the SQL stores, caller must hold the lock
+func (m *memory) promotionUses(id uint, userID uint) int64 {
+	uses := int64(0)
+	for _, order := range m.orders {
+		if order.PromotionID != nil && *order.PromotionID == id && order.Status != models.OrderFailed && (userID == 0 || order.UserID == userID) {
+			uses++
+		}
+	}
+	return uses
+}
+
+// same as lockPromotion of the SQL stores, caller must hold the lock
+func (m *memory) findPromotion(code string, userID uint, now time.Time) (*models.Promotion, error) {
+	if code == "" {
+		return nil, nil
+	}
+
+	code = models.NormalizeCode(code)
+	for _, promotion := range m.promotions {
+		if promotion.Code != code {
+			continue
+		}
+		if !promotion.Active(now) ||
+			(promotion.MaxUses > 0 && m.promotionUses(promotion.ID, 0) >= int64(promotion.MaxUses)) ||
+			(promotion.MaxUsesPerUser > 0 && m.promotionUses(promotion.ID, userID) >= int64(promotion.MaxUsesPerUser)) {
+			return nil, ErrPromotionRejected
+		}
+		return &promotion, nil
+	}
+	return nil, ErrPromotionRejected
+}
+
+// same as checkPromotionFits of the SQL stores, caller must hold the lock
+func (m *memory) checkPromotionFits(promotion models.Promotion) error {
+	for _, existing := range m.promotions {
+		if existing.ID != promotion.ID && existing.Code == promotion.Code {
+			return ErrDuplicate
+		}
+	}
+	if promotion.EventID != nil {
+		if _, ok := m.events[*promotion.EventID]; !ok {
+			return ErrNotFound
+		}
+	}
+	return nil
+}
+
+func (s memoryPromotionStore) List() ([]models.Promotion, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	promotions := sortedValues(s.m.promotions, nil)
+	for i := range promotions {
+		promotions[i].Uses = int(s.m.promotionUses(promotions[i].ID, 0))
+	}
+	return promotions, nil
+}
+
+func (s memoryPromotionStore) Get(id uint) (models.Promotion, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	promotion, ok := s.m.promotions[id]
+	if !ok {
+		return promotion, ErrNotFound
+	}
+	promotion.Uses = int(s.m.promotionUses(id, 0))
+	return promotion, nil
+}
+
+func (s memoryPromotionStore) Create(promotion *models.Promotion) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	promotion.ID = 0
+	if err := s.m.checkPromotionFits(*promotion); err != nil {
+		return err
+	}
+	promotion.ID = s.m.nextID("promotions")
+	s.m.promotions[promotion.ID] = *promotion
+	return nil
+}
+
+func (s memoryPromotionStore) Update(id uint, promotion models.Promotion) (models.Promotion, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	current, ok := s.m.promotions[id]
+	if !ok {
+		return promotion, ErrNotFound
+	}
+	promotion.ID, promotion.CreatedAt = id, current.CreatedAt
+	if err := s.m.checkPromotionFits(promotion); err != nil {
+		return promotion, err
+	}
+	promotion.Uses = 0
+	s.m.promotions[id] = promotion
+	return promotion, nil
+}
+
+func (s memoryPromotionStore) Delete(id uint) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	if _, ok := s.m.promotions[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.m.promotions, id)
+	// same as the ON DELETE SET NULL of the SQL schema
+	for orderID, order := range s.m.orders {
+		if order.PromotionID != nil && *order.PromotionID == id {
+			order.PromotionID = nil
+			s.m.orders[orderID] = order
+		}
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package store
+
+import (
+	"time"
+
+	"github.com/mgr1054/go-ticket/pkg/models"
+)
+
+type memoryRefundStore struct {
+	m *memory
+}
+
+// stores a pending refund for the ticket and cancels the ticket if it is still
+// issued, caller must hold the lock
+func (m *memory) insertRefund(ticket models.Ticket, refund *models.Refund, now time.Time) {
+	if orderID := m.holderOrderID(ticket); orderID != nil {
+		payments := sortedValues(m.payments, func(p models.Payment) bool {
+			return p.OrderID == *orderID && p.Status == models.PaymentSucceeded && p.Provider != "none"
+		})
+		if len(payments) > 0 {
+			refund.PaymentID = &payments[0].ID
+			refund.Currency = payments[0].Currency
+		}
+	}
+
+	refund.ID = m.nextID("refunds")
+	refund.TicketID = ticket.ID
+	refund.Status = models.RefundPending
+	refund.CreatedAt = now
+	refund.UpdatedAt = now
+	m.refunds[refund.ID] = *refund
+
+	if ticket.Status == models.TicketIssued {
+		ticket.Status = models.TicketCancelled
+		ticket.CancelledAt = &now
+		m.tickets[ticket.ID] = ticket
+	}
+}
+
+func (s memoryTicketStore) Cancel(id uint, refund *models.Refund, now time.Time) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	ticket, ok := s.m.tickets[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if ticket.Status != models.TicketIssued {
+		return ErrTicketNotIssued
+	}
+	if ticket.OrderID != nil && s.m.orders[*ticket.OrderID].Status != models.OrderCompleted {
+		return ErrTicketNotIssued
+	}
+	if ticket.AdmittedAt != nil {
+		return ErrTicketUsed
+	}
+	if err := s.m.checkNotListed(ticket.ID); err != nil {
+		return err
+	}
+
+	s.m.insertRefund(ticket, refund, now)
+	return nil
+}
+
+func (s memoryRefundStore) Create(refund *models.Refund, now time.Time) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	ticket, ok := s.m.tickets[refund.TicketID]
+	if !ok {
+		return ErrNotFound
+	}
+
+	refunded := int64(0)
+	for _, existing := range s.m.refunds {
+		if existing.TicketID == ticket.ID && existing.Status != models.RefundFailed {
+			refunded += existing.Amount
+		}
+	}
+	if refunded+refund.Amount > ticket.Price.Amount {
+		return ErrRefundExceeded
+	}
+	if err := s.m.checkNotListed(ticket.ID); err != nil {
+		return err
+	}
+
+	s.m.insertRefund(ticket, refund, now)
+	return nil
+}
+
+func (s memoryRefundStore) Get(id uint) (models.Refund, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	refund, ok := s.m.refunds[id]
+	if !ok {
+		return refund, ErrNotFound
+	}
+	return refund, nil
+}
+
+func (s memoryRefundStore) List() ([]models.Refund, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	return sortedValues(s.m.refunds, nil), nil
+}
+
+func (s memoryRefundStore) ListByTicket(ticketID uint) ([]models.Refund, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	return sortedValues(s.m.refunds, func(r models.Refund) bool { return r.TicketID == ticketID }), nil
+}
+
+func (s memoryRefundStore) Complete(id uint, status string, providerRefundID string, now time.Time) (models.Refund, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	refund, ok := s.m.refunds[id]
+	if !ok {
+		return refund, ErrNotFound
+	}
+	if refund.Status != models.RefundPending {
+		return refund, ErrRefundSettled
+	}
+
+	refund.Status = status
+	refund.ProviderRefundID = providerRefundID
+	refund.UpdatedAt = now
+	s.m.refunds[id] = refund
+
+	ticket, ok := s.m.tickets[refund.TicketID]
+	if ok && status == models.RefundSucceeded && ticket.Status == models.TicketCancelled {
+		ticket.Status = models.TicketRefunded
+		s.m.tickets[ticket.ID] = ticket
+	}
+	return refund, nil
+}
+
+func (s memoryRefundStore) GetPolicy(eventID uint) (models.RefundPolicy, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	policy, ok := s.m.refundPolicies[eventID]
+	if !ok {
+		return policy, ErrNotFound
+	}
+	return policy, nil
+}
+
+func (s memoryRefundStore) SavePolicy(policy models.RefundPolicy) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	if _, ok := s.m.events[policy.EventID]; !ok {
+		return ErrNotFound
+	}
+	s.m.refundPolicies[policy.EventID] = policy
+	return nil
+}
@@ -0,0 +1,186 @@
+package store
+
+import (
+	"time"
+
+	"github.com/mgr1054/go-ticket/pkg/models"
+)
+
+type memorySeatStore struct {
+	m *memory
+}
+
+// same as checkCapacity of the SQL stores, caller must hold the lock
+func (m *memory) checkCapacity(event models.Event, quantity int, seatIDs []uint, now time.Time) error {
+	used := m.usedCapacity(event.ID, now)
+	if used+int64(quantity+len(seatIDs)) > int64(event.Capacity) {
+		return ErrSoldOut
+	}
+
+	seats := int64(len(sortedValues(m.eventSeats, func(s models.EventSeat) bool { return s.EventID == event.ID })))
+	sold := m.soldSeats(event.ID)
+	if quantity > 0 && used-int64(len(sold))+int64(quantity) > int64(event.Capacity)-seats {
+		return ErrSoldOut
+	}
+
+	if hasDuplicates(seatIDs) {
+		return ErrSeatTaken
+	}
+	for _, id := range seatIDs {
+		seat, ok := m.eventSeats[id]
+		if !ok || seat.EventID != event.ID {
+			return ErrSeatNotFound
+		}
+		if sold[id] {
+			return ErrSeatTaken
+		}
+	}
+	return nil
+}
+
+// seats of the event with an issued ticket, caller must hold the lock
+func (m *memory) soldSeats(eventID uint) map[uint]bool {
+	sold := map[uint]bool{}
+	for _, ticket := range m.tickets {
+		if ticket.EventID == eventID && ticket.Status == models.TicketIssued && ticket.SeatID != nil {
+			sold[*ticket.SeatID] = true
+		}
+	}
+	return sold
+}
+
+// copies the seat map of the venue of the event into its seat inventory, caller must hold the lock
+func (m *memory) copyVenueSeats(event models.Event) {
+	sections := map[uint]string{}
+	for _, section := range m.venues[event.VenueID].Sections {
+		sections[section.ID] = section.Name
+	}
+	for _, seat := range sortedValues(m.seats, nil) {
+		name, ok := sections[seat.SectionID]
+		if !ok {
+			continue
+		}
+		id := m.nextID("event_seats")
+		m.eventSeats[id] = models.EventSeat{
+			ID: id,
+			EventID: event.ID,
+			Section: name,
+			Row: seat.Row,
+			Number: seat.Number,
+			Accessible: seat.Accessible,
+		}
+	}
+}
+
+// replaces the seat inventory of the event by the seat map of its venue, caller must hold the lock
+func (m *memory) resetEventSeats(event models.Event) error {
+	if len(m.soldSeats(event.ID)) > 0 {
+		return ErrSeatsSold
+	}
+	m.deleteEventSeats(event.ID)
+	m.copyVenueSeats(event)
+	return nil
+}
+
+// deletes the seat inventory of the event, tickets keep no seat like the
+// ON DELETE SET NULL of the SQL schema; caller must hold the lock
+func (m *memory) deleteEventSeats(eventID uint) {
+	for id, seat := range m.eventSeats {
+		if seat.EventID != eventID {
+			continue
+		}
+		delete(m.eventSeats, id)
+		for ticketID, ticket := range m.tickets {
+			if ticket.SeatID != nil && *ticket.SeatID == id {
+				ticket.SeatID = nil
+				m.tickets[ticketID] = ticket
+			}
+		}
+	}
+}
+
+// deletes the seats of sections that are not part of the venue anymore like the
+// ON DELETE CASCADE of the SQL schema, caller must hold the lock
+func (m *memory) deleteOrphanedSeats() {
+	sections := map[uint]bool{}
+	for _, venue := range m.venues {
+		for _, section := range venue.Sections {
+			sections[section.ID] = true
+		}
+	}
+	for id, seat := range m.seats {
+		if !sections[seat.SectionID] {
+			delete(m.seats, id)
+		}
+	}
+}
+
+func (s memorySeatStore) ListByVenue(venueID uint) ([]models.Seat, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	venue, ok := s.m.venues[venueID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return s.venueSeats(venue), nil
+}
+
+// caller must hold the lock
+func (s memorySeatStore) venueSeats(venue models.Venue) []models.Seat {
+	sections := map[uint]bool{}
+	for _, section := range venue.Sections {
+		sections[section.ID] = true
+	}
+	return sortedValues(s.m.seats, func(seat models.Seat) bool { return sections[seat.SectionID] })
+}
+
+func (s memorySeatStore) ReplaceForVenue(venueID uint, seats []models.Seat) ([]models.Seat, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	venue, ok := s.m.venues[venueID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	for _, seat := range s.venueSeats(venue) {
+		delete(s.m.seats, seat.ID)
+	}
+
+	seats = append([]models.Seat{}, seats...)
+	for i := range seats {
+		seats[i].ID = s.m.nextID("seats")
+		s.m.seats[seats[i].ID] = seats[i]
+	}
+	return seats, nil
+}
+
+func (s memorySeatStore) ListByEvent(eventID uint) ([]models.EventSeat, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	if _, ok := s.m.events[eventID]; !ok {
+		return nil, ErrNotFound
+	}
+	return s.eventSeats(eventID), nil
+}
+
+// caller must hold the lock
+func (s memorySeatStore) eventSeats(eventID uint) []models.EventSeat {
+	sold := s.m.soldSeats(eventID)
+	seats := sortedValues(s.m.eventSeats, func(seat models.EventSeat) bool { return seat.EventID == eventID })
+	for i := range seats {
+		seats[i].Available = !sold[seats[i].ID]
+	}
+	return seats
+}
+
+func (s memorySeatStore) ResetEvent(eventID uint) ([]models.EventSeat, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	event, ok := s.m.events[eventID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if err := s.m.resetEventSeats(event); err != nil {
+		return nil, err
+	}
+	return s.eventSeats(eventID), nil
+}
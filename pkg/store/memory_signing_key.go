@@ -0,0 +1,59 @@
+package store
+
+import (
+	"sort"
+	"time"
+
+	"github.com/mgr1054/go-ticket/pkg/models"
+)
+
+type memorySigningKeyStore struct {
+	m *memory
+}
+
+func (s memorySigningKeyStore) List(now time.Time) ([]models.SigningKey, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	keys := []models.SigningKey{}
+	for _, key := range s.m.signingKeys {
+		if key.ExpiresAt.After(now) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if !keys[i].ActivatesAt.Equal(keys[j].ActivatesAt) {
+			return keys[i].ActivatesAt.Before(keys[j].ActivatesAt)
+		}
+		if !keys[i].CreatedAt.Equal(keys[j].CreatedAt) {
+			return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+		}
+		return keys[i].ID < keys[j].ID
+	})
+	return keys, nil
+}
+
+func (s memorySigningKeyStore) Create(key *models.SigningKey) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	if _, ok := s.m.signingKeys[key.ID]; ok {
+		return ErrDuplicate
+	}
+	s.m.signingKeys[key.ID] = *key
+	return nil
+}
+
+func (s memorySigningKeyStore) DeleteExpired(now time.Time) (int64, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	deleted := int64(0)
+	for id, key := range s.m.signingKeys {
+		if !key.ExpiresAt.After(now) {
+			delete(s.m.signingKeys, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
@@ -0,0 +1,178 @@
+package store
+
+import (
+	"time"
+
+	"github.com/mgr1054/go-ticket/pkg/models"
+)
+
+type memoryTicketTypeStore struct {
+	m *memory
+}
+
+// sum of the quotas of the ticket types of the event except the one with id skip,
+// caller must hold the lock
+func (m *memory) quotaSum(eventID uint, skip uint) int64 {
+	sum := int64(0)
+	for _, ticketType := range m.ticketTypes {
+		if ticketType.EventID == eventID && ticketType.ID != skip {
+			sum += int64(ticketType.Quota)
+		}
+	}
+	return sum
+}
+
+// number of tickets of the ticket type that are issued or reserved by a hold
+// active at now, caller must hold the lock
+func (m *memory) ticketTypeUsed(id uint, now time.Time) int64 {
+	used := int64(0)
+	for _, ticket := range m.tickets {
+		if ticket.TicketTypeID != nil && *ticket.TicketTypeID == id && ticket.Status == models.TicketIssued {
+			used++
+		}
+	}
+	for _, hold := range m.holds {
+		if hold.TicketTypeID != nil && *hold.TicketTypeID == id && hold.IsActive(now) {
+			used += int64(hold.Quantity)
+		}
+	}
+	return used
+}
+
+// same as checkTicketType of the SQL stores, caller must hold the lock
+func (m *memory) checkTicketType(event models.Event, id uint, count int, now time.Time) (*models.TicketType, error) {
+	if id == 0 {
+		for _, ticketType := range m.ticketTypes {
+			if ticketType.EventID == event.ID {
+				return nil, ErrTicketTypeRequired
+			}
+		}
+		return nil, nil
+	}
+
+	ticketType, ok := m.ticketTypes[id]
+	if !ok || ticketType.EventID != event.ID {
+		return nil, ErrTicketTypeNotFound
+	}
+	if m.ticketTypeUsed(id, now)+int64(count) > int64(ticketType.Quota) {
+		return nil, ErrSoldOut
+	}
+	return &ticketType, nil
+}
+
+// same as checkTicketTypeFits of the SQL stores, caller must hold the lock
+func (m *memory) checkTicketTypeFits(event models.Event, ticketType models.TicketType) error {
+	for _, existing := range m.ticketTypes {
+		if existing.EventID == event.ID && existing.ID != ticketType.ID && sameName(existing.Name, ticketType.Name) {
+			return ErrDuplicate
+		}
+	}
+	if m.quotaSum(event.ID, ticketType.ID)+int64(ticketType.Quota) > int64(event.Capacity) {
+		return ErrQuotaConflict
+	}
+
+	// holds count until the sweeper expired them
+	used := int64(0)
+	for _, ticket := range m.tickets {
+		if ticket.TicketTypeID != nil && *ticket.TicketTypeID == ticketType.ID && ticket.Status == models.TicketIssued {
+			used++
+		}
+	}
+	for _, hold := range m.holds {
+		if hold.TicketTypeID != nil && *hold.TicketTypeID == ticketType.ID && hold.Status == models.HoldActive {
+			used += int64(hold.Quantity)
+		}
+	}
+	if ticketType.ID != 0 && used > int64(ticketType.Quota) {
+		return ErrQuotaConflict
+	}
+	return nil
+}
+
+func (s memoryTicketTypeStore) ListByEvent(eventID uint, now time.Time) ([]models.TicketType, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	event, ok := s.m.events[eventID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	left := int64(event.Capacity) - s.m.usedCapacity(eventID, now)
+	ticketTypes := sortedValues(s.m.ticketTypes, func(t models.TicketType) bool { return t.EventID == eventID })
+	for i := range ticketTypes {
+		ticketTypes[i].Available = availableTickets(ticketTypes[i].Quota, s.m.ticketTypeUsed(ticketTypes[i].ID, now), left)
+	}
+	return ticketTypes, nil
+}
+
+func (s memoryTicketTypeStore) Get(id uint) (models.TicketType, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	ticketType, ok := s.m.ticketTypes[id]
+	if !ok {
+		return ticketType, ErrNotFound
+	}
+	return ticketType, nil
+}
+
+func (s memoryTicketTypeStore) Create(ticketType *models.TicketType) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	event, ok := s.m.events[ticketType.EventID]
+	if !ok {
+		return ErrNotFound
+	}
+	ticketType.ID = 0
+	if err := s.m.checkTicketTypeFits(event, *ticketType); err != nil {
+		return err
+	}
+	ticketType.ID = s.m.nextID("ticket_types")
+	s.m.ticketTypes[ticketType.ID] = *ticketType
+	return nil
+}
+
+func (s memoryTicketTypeStore) Update(id uint, ticketType models.TicketType) (models.TicketType, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	current, ok := s.m.ticketTypes[id]
+	if !ok {
+		return ticketType, ErrNotFound
+	}
+	ticketType.ID, ticketType.EventID = id, current.EventID
+	if err := s.m.checkTicketTypeFits(s.m.events[current.EventID], ticketType); err != nil {
+		return ticketType, err
+	}
+	s.m.ticketTypes[id] = ticketType
+	return ticketType, nil
+}
+
+func (s memoryTicketTypeStore) Delete(id uint) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	if _, ok := s.m.ticketTypes[id]; !ok {
+		return ErrNotFound
+	}
+	for _, ticket := range s.m.tickets {
+		if ticket.TicketTypeID != nil && *ticket.TicketTypeID == id {
+			return ErrTicketTypeInUse
+		}
+	}
+	for _, hold := range s.m.holds {
+		if hold.TicketTypeID != nil && *hold.TicketTypeID == id {
+			return ErrTicketTypeInUse
+		}
+	}
+	delete(s.m.ticketTypes, id)
+	// same as the ON DELETE CASCADE of the SQL schema
+	for codeID, code := range s.m.presaleCodes {
+		if code.TicketTypeID != nil && *code.TicketTypeID == id {
+			delete(s.m.presaleCodes, codeID)
+		}
+	}
+	for entryID, entry := range s.m.waitlist {
+		if entry.TicketTypeID != nil && *entry.TicketTypeID == id {
+			delete(s.m.waitlist, entryID)
+		}
+	}
+	return nil
+}
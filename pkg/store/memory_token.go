@@ -0,0 +1,128 @@
+package store
+
+import (
+	"time"
+
+	"github.com/mgr1054/go-ticket/pkg/models"
+)
+
+type memoryTokenStore struct {
+	m *memory
+}
+
+func (s memoryTokenStore) Create(token *models.RefreshToken, now time.Time) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	for _, other := range s.m.refreshTokens {
+		if other.TokenHash == token.TokenHash {
+			return ErrDuplicate
+		}
+	}
+	token.ID = s.m.nextID("refresh_tokens")
+	token.CreatedAt = now.UTC()
+	token.ExpiresAt = token.ExpiresAt.UTC()
+	s.m.refreshTokens[token.ID] = *token
+	return nil
+}
+
+// same as revokeFamilies of the SQL stores, caller must hold the lock
+func (m *memory) revokeFamilies(familyIDs map[string]bool, now time.Time, until time.Time) {
+	revokedAt := now.UTC()
+	for id, token := range m.refreshTokens {
+		if familyIDs[token.FamilyID] && token.RevokedAt == nil {
+			token.RevokedAt = &revokedAt
+			m.refreshTokens[id] = token
+		}
+	}
+	for familyID := range familyIDs {
+		m.revocations[familyID] = models.TokenRevocation{FamilyID: familyID, RevokedAt: revokedAt, ExpiresAt: until.UTC()}
+	}
+}
+
+func (s memoryTokenStore) Rotate(hash string, next *models.RefreshToken, now time.Time, revokeUntil time.Time) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	var token models.RefreshToken
+	found := false
+	for _, token = range s.m.refreshTokens {
+		if token.TokenHash == hash {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return ErrNotFound
+	}
+	if token.RevokedAt != nil {
+		return ErrTokenRevoked
+	}
+	next.UserID = token.UserID
+	next.FamilyID = token.FamilyID
+	if token.UsedAt != nil {
+		s.m.revokeFamilies(map[string]bool{token.FamilyID: true}, now, revokeUntil)
+		return ErrTokenReused
+	}
+	if !token.ExpiresAt.After(now) {
+		return ErrTokenExpired
+	}
+
+	usedAt := now.UTC()
+	token.UsedAt = &usedAt
+	s.m.refreshTokens[token.ID] = token
+
+	next.ID = s.m.nextID("refresh_tokens")
+	next.CreatedAt = now.UTC()
+	next.ExpiresAt = next.ExpiresAt.UTC()
+	s.m.refreshTokens[next.ID] = *next
+	return nil
+}
+
+func (s memoryTokenStore) RevokeFamily(familyID string, now time.Time, until time.Time) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	s.m.revokeFamilies(map[string]bool{familyID: true}, now, until)
+	return nil
+}
+
+func (s memoryTokenStore) RevokeUser(userID uint, now time.Time, until time.Time) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	familyIDs := map[string]bool{}
+	for _, token := range s.m.refreshTokens {
+		if token.UserID == userID && token.ExpiresAt.After(now) {
+			familyIDs[token.FamilyID] = true
+		}
+	}
+	s.m.revokeFamilies(familyIDs, now, until)
+	return nil
+}
+
+func (s memoryTokenStore) IsRevoked(familyID string, now time.Time) (bool, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	revocation, ok := s.m.revocations[familyID]
+	return ok && revocation.ExpiresAt.After(now), nil
+}
+
+func (s memoryTokenStore) Purge(now time.Time) (int64, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	purged := int64(0)
+	for id, token := range s.m.refreshTokens {
+		if !token.ExpiresAt.After(now) {
+			delete(s.m.refreshTokens, id)
+			purged++
+		}
+	}
+	for familyID, revocation := range s.m.revocations {
+		if !revocation.ExpiresAt.After(now) {
+			delete(s.m.revocations, familyID)
+			purged++
+		}
+	}
+	return purged, nil
+}
@@ -0,0 +1,160 @@
+package store
+
+import (
+	"time"
+
+	"github.com/mgr1054/go-ticket/pkg/models"
+)
+
+type memoryTransferStore struct {
+	m *memory
+}
+
+// same as checkTransferable of the SQL stores, caller must hold the lock
+func (m *memory) checkTransferable(ticket models.Ticket) error {
+	if ticket.Status != models.TicketIssued {
+		return ErrTicketNotIssued
+	}
+	if ticket.OrderID != nil && m.orders[*ticket.OrderID].Status != models.OrderCompleted {
+		return ErrTicketNotIssued
+	}
+	if ticket.AdmittedAt != nil {
+		return ErrTicketUsed
+	}
+	return m.checkNotListed(ticket.ID)
+}
+
+// same as expireTransfers of the SQL stores, caller must hold the lock
+func (m *memory) expireTransfers(keep func(models.Transfer) bool, now time.Time) int64 {
+	expired := int64(0)
+	for id, transfer := range m.transfers {
+		if transfer.Status != models.TransferPending || transfer.ExpiresAt.After(now) || !keep(transfer) {
+			continue
+		}
+		closedAt := now.UTC()
+		transfer.Status = models.TransferExpired
+		transfer.ClosedAt = &closedAt
+		m.transfers[id] = transfer
+		expired++
+	}
+	return expired
+}
+
+func (s memoryTransferStore) Create(transfer *models.Transfer, now time.Time) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	ticket, ok := s.m.tickets[transfer.TicketID]
+	if !ok {
+		return ErrNotFound
+	}
+	if err := s.m.checkTransferable(ticket); err != nil {
+		return err
+	}
+	if ticket.UserID != transfer.FromUserID {
+		return ErrTransferClosed
+	}
+
+	s.m.expireTransfers(func(other models.Transfer) bool { return other.TicketID == ticket.ID }, now)
+	for _, other := range s.m.transfers {
+		if other.TicketID == ticket.ID && other.Status == models.TransferPending {
+			return ErrDuplicate
+		}
+	}
+
+	transfer.ID = s.m.nextID("transfers")
+	transfer.Status = models.TransferPending
+	transfer.CreatedAt = now.UTC()
+	transfer.ExpiresAt = transfer.ExpiresAt.UTC()
+	s.m.transfers[transfer.ID] = *transfer
+	return nil
+}
+
+func (s memoryTransferStore) Get(id uint) (models.Transfer, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	transfer, ok := s.m.transfers[id]
+	if !ok {
+		return models.Transfer{}, ErrNotFound
+	}
+	return transfer, nil
+}
+
+func (s memoryTransferStore) ListByTicket(ticketID uint) ([]models.Transfer, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	return sortedValues(s.m.transfers, func(transfer models.Transfer) bool { return transfer.TicketID == ticketID }), nil
+}
+
+func (s memoryTransferStore) ListByUser(userID uint) ([]models.Transfer, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	return sortedValues(s.m.transfers, func(transfer models.Transfer) bool {
+		return transfer.FromUserID == userID || transfer.ToUserID == userID
+	}), nil
+}
+
+// same as lockPendingTransfer of the SQL stores, caller must hold the lock
+func (m *memory) pendingTransfer(id uint, now time.Time) (models.Transfer, error) {
+	transfer, ok := m.transfers[id]
+	if !ok {
+		return transfer, ErrNotFound
+	}
+	if !transfer.IsPending(now) {
+		return transfer, ErrTransferClosed
+	}
+	return transfer, nil
+}
+
+// same as closeTransfer of the SQL stores, caller must hold the lock
+func (m *memory) closeTransfer(transfer *models.Transfer, status string, now time.Time) {
+	closedAt := now.UTC()
+	transfer.Status = status
+	transfer.ClosedAt = &closedAt
+	m.transfers[transfer.ID] = *transfer
+}
+
+func (s memoryTransferStore) Accept(id uint, now time.Time) (models.Transfer, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	transfer, err := s.m.pendingTransfer(id, now)
+	if err != nil {
+		return transfer, err
+	}
+	ticket := s.m.tickets[transfer.TicketID]
+	if err := s.m.checkTransferable(ticket); err != nil {
+		return transfer, err
+	}
+	if ticket.UserID != transfer.FromUserID {
+		return transfer, ErrTransferClosed
+	}
+
+	code, err := models.NewEntryCode()
+	if err != nil {
+		return transfer, err
+	}
+	ticket.UserID = transfer.ToUserID
+	ticket.Code = code
+	s.m.tickets[ticket.ID] = ticket
+	s.m.closeTransfer(&transfer, models.TransferAccepted, now)
+	return transfer, nil
+}
+
+func (s memoryTransferStore) Close(id uint, status string, now time.Time) (models.Transfer, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	transfer, err := s.m.pendingTransfer(id, now)
+	if err != nil {
+		return transfer, err
+	}
+	s.m.closeTransfer(&transfer, status, now)
+	return transfer, nil
+}
+
+func (s memoryTransferStore) ExpireAll(now time.Time) (int64, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	return s.m.expireTransfers(func(models.Transfer) bool { return true }, now), nil
+}
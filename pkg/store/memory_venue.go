@@ -0,0 +1,129 @@
+package store
+
+import (
+	"strings"
+
+	"github.com/mgr1054/go-ticket/pkg/models"
+)
+
+type memoryVenueStore struct {
+	m *memory
+}
+
+// copies the sections so callers cannot change the stored venue
+func cloneVenue(venue models.Venue) models.Venue {
+	venue.Sections = append([]models.VenueSection{}, venue.Sections...)
+	return venue
+}
+
+// same comparison as lower(name) = lower(?) of the SQL stores
+func sameName(a string, b string) bool {
+	return strings.EqualFold(strings.TrimSpace(a), strings.TrimSpace(b))
+}
+
+// returns ErrDuplicate when a venue other than id is called name, caller must hold the lock
+func (s memoryVenueStore) checkName(id uint, name string) error {
+	for _, venue := range s.m.venues {
+		if venue.ID != id && sameName(venue.Name, name) {
+			return ErrDuplicate
+		}
+	}
+	return nil
+}
+
+func (s memoryVenueStore) List() ([]models.Venue, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	venues := sortedValues(s.m.venues, nil)
+	for i := range venues {
+		venues[i] = cloneVenue(venues[i])
+	}
+	return venues, nil
+}
+
+func (s memoryVenueStore) Get(id uint) (models.Venue, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	venue, ok := s.m.venues[id]
+	if !ok {
+		return venue, ErrNotFound
+	}
+	return cloneVenue(venue), nil
+}
+
+func (s memoryVenueStore) FindByName(name string) (models.Venue, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	venues := sortedValues(s.m.venues, func(v models.Venue) bool { return sameName(v.Name, name) })
+	if len(venues) < 1 {
+		return models.Venue{}, ErrNotFound
+	}
+	return cloneVenue(venues[0]), nil
+}
+
+func (s memoryVenueStore) Create(venue *models.Venue) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	if err := s.checkName(0, venue.Name); err != nil {
+		return err
+	}
+
+	venue.ID = s.m.nextID("venues")
+	for i := range venue.Sections {
+		venue.Sections[i].ID = s.m.nextID("venue_sections")
+		venue.Sections[i].VenueID = venue.ID
+	}
+	s.m.venues[venue.ID] = cloneVenue(*venue)
+	return nil
+}
+
+func (s memoryVenueStore) Update(id uint, venue models.Venue) (models.Venue, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	current, ok := s.m.venues[id]
+	if !ok {
+		return venue, ErrNotFound
+	}
+	if err := s.checkName(id, venue.Name); err != nil {
+		return models.Venue{}, err
+	}
+
+	known := map[uint]bool{}
+	for _, section := range current.Sections {
+		known[section.ID] = true
+	}
+	venue.ID = id
+	venue.Sections = append([]models.VenueSection{}, venue.Sections...)
+	for i := range venue.Sections {
+		if !known[venue.Sections[i].ID] {
+			venue.Sections[i].ID = s.m.nextID("venue_sections")
+		}
+		venue.Sections[i].VenueID = id
+	}
+	s.m.venues[id] = venue
+	s.m.deleteOrphanedSeats()
+
+	for eventID, event := range s.m.events {
+		if event.VenueID == id {
+			event.Location = venue.Name
+			s.m.events[eventID] = event
+		}
+	}
+	return cloneVenue(venue), nil
+}
+
+func (s memoryVenueStore) Delete(id uint) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	if _, ok := s.m.venues[id]; !ok {
+		return ErrNotFound
+	}
+	for _, event := range s.m.events {
+		if event.VenueID == id {
+			return ErrVenueInUse
+		}
+	}
+	delete(s.m.venues, id)
+	s.m.deleteOrphanedSeats()
+	return nil
+}
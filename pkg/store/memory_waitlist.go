@@ -0,0 +1,219 @@
+package store
+
+import (
+	"errors"
+	"time"
+
+	"github.com/mgr1054/go-ticket/pkg/models"
+)
+
+type memoryWaitlistStore struct {
+	m *memory
+}
+
+// same as waitlistPosition of the SQL stores, caller must hold the lock
+func (m *memory) waitlistPosition(entry *models.WaitlistEntry) {
+	entry.Position = 0
+	if entry.Status != models.WaitlistWaiting {
+		return
+	}
+	entry.Position = 1
+	for _, other := range m.waitlist {
+		if other.EventID == entry.EventID && other.Status == models.WaitlistWaiting && other.ID < entry.ID {
+			entry.Position++
+		}
+	}
+}
+
+// caller must hold the lock
+func (m *memory) waitlistEntries(keep func(models.WaitlistEntry) bool) []models.WaitlistEntry {
+	entries := sortedValues(m.waitlist, keep)
+	for i := range entries {
+		m.waitlistPosition(&entries[i])
+	}
+	return entries
+}
+
+// same as settleOffers of the SQL stores, caller must hold the lock
+func (m *memory) settleOffers(eventID uint, now time.Time) {
+	for id, offer := range m.waitlist {
+		if offer.EventID != eventID || offer.Status != models.WaitlistOffered {
+			continue
+		}
+		var hold *models.Hold
+		if offer.HoldID != nil {
+			if found, ok := m.holds[*offer.HoldID]; ok {
+				hold = &found
+			}
+		}
+		offer.Status = models.OfferStatus(hold, now)
+		m.waitlist[id] = offer
+	}
+}
+
+func (s memoryWaitlistStore) Join(entry *models.WaitlistEntry, now time.Time) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	event, ok := s.m.events[entry.EventID]
+	if !ok {
+		return ErrNotFound
+	}
+	ticketType, err := s.m.checkTicketType(event, waitlistTicketTypeID(*entry), 0, now)
+	if err != nil {
+		return err
+	}
+	if event.SaleState(ticketType, now) == models.SaleEnded {
+		return ErrNotOnSale
+	}
+	for _, other := range s.m.waitlist {
+		if other.EventID == entry.EventID && other.UserID == entry.UserID && other.IsOpen() {
+			return ErrDuplicate
+		}
+	}
+
+	entry.ID = s.m.nextID("waitlist_entries")
+	entry.Status = models.WaitlistWaiting
+	entry.HoldID, entry.OfferExpiresAt = nil, nil
+	entry.CreatedAt = now.UTC()
+	s.m.waitlist[entry.ID] = *entry
+	s.m.waitlistPosition(entry)
+	return nil
+}
+
+func (s memoryWaitlistStore) Get(id uint) (models.WaitlistEntry, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	entry, ok := s.m.waitlist[id]
+	if !ok {
+		return entry, ErrNotFound
+	}
+	s.m.waitlistPosition(&entry)
+	return entry, nil
+}
+
+func (s memoryWaitlistStore) ListByEvent(eventID uint) ([]models.WaitlistEntry, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	if _, ok := s.m.events[eventID]; !ok {
+		return nil, ErrNotFound
+	}
+	return s.m.waitlistEntries(func(e models.WaitlistEntry) bool { return e.EventID == eventID }), nil
+}
+
+func (s memoryWaitlistStore) ListByUser(userID uint) ([]models.WaitlistEntry, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	return s.m.waitlistEntries(func(e models.WaitlistEntry) bool { return e.UserID == userID }), nil
+}
+
+func (s memoryWaitlistStore) Leave(id uint, now time.Time) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	entry, ok := s.m.waitlist[id]
+	if !ok {
+		return ErrNotFound
+	}
+	s.m.settleOffers(entry.EventID, now)
+	entry = s.m.waitlist[id]
+	if !entry.IsOpen() {
+		return ErrWaitlistClosed
+	}
+
+	if entry.HoldID != nil {
+		if hold, ok := s.m.holds[*entry.HoldID]; ok && hold.Status == models.HoldActive {
+			hold.Status = models.HoldReleased
+			s.m.holds[hold.ID] = hold
+		}
+	}
+	entry.Status = models.WaitlistLeft
+	s.m.waitlist[id] = entry
+	return nil
+}
+
+func (s memoryWaitlistStore) Offer(eventID uint, now time.Time, ttl time.Duration) ([]models.WaitlistEntry, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	return s.m.offer(eventID, now, ttl)
+}
+
+// same as Offer of the SQL stores, caller must hold the lock
+func (m *memory) offer(eventID uint, now time.Time, ttl time.Duration) ([]models.WaitlistEntry, error) {
+	event, ok := m.events[eventID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	m.settleOffers(eventID, now)
+
+	offers := []models.WaitlistEntry{}
+	blocked := map[uint]bool{}
+	waiting := sortedValues(m.waitlist, func(e models.WaitlistEntry) bool {
+		return e.EventID == eventID && e.Status == models.WaitlistWaiting
+	})
+	for _, entry := range waiting {
+		ticketTypeID := waitlistTicketTypeID(entry)
+		if blocked[ticketTypeID] {
+			continue
+		}
+
+		err := m.checkCapacity(event, entry.Quantity, nil, now)
+		if errors.Is(err, ErrSoldOut) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		ticketType, err := m.checkTicketType(event, ticketTypeID, entry.Quantity, now)
+		switch {
+		case errors.Is(err, ErrSoldOut), errors.Is(err, ErrTicketTypeRequired), errors.Is(err, ErrTicketTypeNotFound):
+			blocked[ticketTypeID] = true
+			continue
+		case err != nil:
+			return nil, err
+		}
+		if event.SaleState(ticketType, now) != models.SaleOpen {
+			blocked[ticketTypeID] = true
+			continue
+		}
+
+		hold := models.Hold{
+			ID: m.nextID("holds"),
+			UserID: entry.UserID,
+			EventID: event.ID,
+			Quantity: entry.Quantity,
+			TicketTypeID: entry.TicketTypeID,
+			Status: models.HoldActive,
+			ExpiresAt: now.Add(ttl).UTC(),
+			CreatedAt: now.UTC(),
+		}
+		m.holds[hold.ID] = hold
+
+		entry.Status, entry.HoldID, entry.OfferExpiresAt = models.WaitlistOffered, &hold.ID, &hold.ExpiresAt
+		m.waitlist[entry.ID] = entry
+		offers = append(offers, entry)
+	}
+	return offers, nil
+}
+
+func (s memoryWaitlistStore) OfferAll(now time.Time, ttl time.Duration) ([]models.WaitlistEntry, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	eventIDs := map[uint]bool{}
+	for _, entry := range s.m.waitlist {
+		if entry.IsOpen() {
+			eventIDs[entry.EventID] = true
+		}
+	}
+
+	var offers []models.WaitlistEntry
+	for eventID := range eventIDs {
+		offered, err := s.m.offer(eventID, now, ttl)
+		if err != nil {
+			return offers, err
+		}
+		offers = append(offers, offered...)
+	}
+	return offers, nil
+}
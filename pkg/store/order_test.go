@@ -0,0 +1,100 @@
+package store_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/mgr1054/go-ticket/pkg/models"
+	"github.com/mgr1054/go-ticket/pkg/store"
+)
+
+func testOrders(t *testing.T, s *store.Store) {
+	event := createEvent(t, s, 3)
+	user := createUser(t, s)
+
+	order, err := s.Orders.Create(user.ID, []store.OrderItem{{EventID: event.ID, Quantity: 2}}, store.OrderCodes{}, testNow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.Status != models.OrderPending || len(order.Tickets) != 2 {
+		t.Errorf("Create = status %q with %d tickets, want %q with 2", order.Status, len(order.Tickets), models.OrderPending)
+	}
+	if order.Total != (models.Money{Amount: 5000, Currency: "EUR"}) {
+		t.Errorf("Create = total %+v, want 5000 EUR", order.Total)
+	}
+
+	found, err := s.Orders.Get(order.ID)
+	if err != nil || len(found.Tickets) != 2 {
+		t.Fatalf("Get = %d tickets, %v, want 2", len(found.Tickets), err)
+	}
+	orders, err := s.Orders.ListByUser(user.ID)
+	if err != nil || len(orders) != 1 || orders[0].ID != order.ID {
+		t.Errorf("ListByUser = %d orders, %v, want order %d", len(orders), err, order.ID)
+	}
+
+	// nothing of an order that does not fit is created
+	if _, err := s.Orders.Create(user.ID, []store.OrderItem{{EventID: event.ID, Quantity: 2}}, store.OrderCodes{}, testNow); !errors.Is(err, store.ErrSoldOut) {
+		t.Errorf("Create beyond the capacity = %v, want ErrSoldOut", err)
+	}
+	sold, err := s.Tickets.CountByEvent(event.ID)
+	if err != nil || sold != 2 {
+		t.Errorf("CountByEvent = %d, %v, want 2", sold, err)
+	}
+	if _, err := s.Orders.Create(user.ID, []store.OrderItem{{EventID: event.ID + 1000, Quantity: 1}}, store.OrderCodes{}, testNow); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Create for an unknown event = %v, want ErrNotFound", err)
+	}
+}
+
+// hundreds of buyers race for the last tickets of one event, the locked capacity
+// check lets exactly as many orders through as the event has room for; memory and
+// SQLite serialize the orders anyway, only the postgres backend really races them
+func testConcurrentOrders(t *testing.T, s *store.Store) {
+	const capacity = 25
+	const buyers = 300
+
+	event := createEvent(t, s, capacity)
+	users := []uint{createUser(t, s).ID, createUser(t, s).ID, createUser(t, s).ID}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	succeeded, soldOut := 0, 0
+	var unexpected []error
+	for i := 0; i < buyers; i++ {
+		wg.Add(1)
+		go func(userID uint) {
+			defer wg.Done()
+			items := []store.OrderItem{{EventID: event.ID, Quantity: 1}}
+			_, err := s.Orders.Create(userID, items, store.OrderCodes{}, testNow)
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				succeeded++
+			case errors.Is(err, store.ErrSoldOut):
+				soldOut++
+			default:
+				unexpected = append(unexpected, err)
+			}
+		}(users[i%len(users)])
+	}
+	wg.Wait()
+
+	if len(unexpected) > 0 {
+		t.Fatalf("%d orders failed unexpectedly, first: %v", len(unexpected), unexpected[0])
+	}
+	if succeeded != capacity || soldOut != buyers-capacity {
+		t.Errorf("got %d orders and %d sold out, want %d and %d", succeeded, soldOut, capacity, buyers-capacity)
+	}
+	sold, err := s.Tickets.CountByEvent(event.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sold > capacity {
+		t.Errorf("sold %d tickets, capacity is %d", sold, capacity)
+	}
+	if sold != capacity {
+		t.Errorf("sold %d tickets, want %d", sold, capacity)
+	}
+}
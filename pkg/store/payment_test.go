@@ -0,0 +1,55 @@
+package store_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mgr1054/go-ticket/pkg/clock"
+	"github.com/mgr1054/go-ticket/pkg/models"
+	"github.com/mgr1054/go-ticket/pkg/store"
+)
+
+const pendingTimeout = 30 * time.Minute
+
+// pending payments fail once the timeout passed on the clock of the caller
+// and give the tickets of their order back
+func testPaymentExpiry(t *testing.T, s *store.Store) {
+	fake := clock.NewFake(testNow)
+	event := createEvent(t, s, 5)
+	user := createUser(t, s)
+	order, err := s.Orders.Create(user.ID, []store.OrderItem{{EventID: event.ID, Quantity: 2}}, store.OrderCodes{}, fake.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	payment := models.Payment{OrderID: order.ID, Provider: "mock", IntentID: "pi_1", Amount: order.Total.Amount, Currency: "EUR", Status: models.PaymentPending, CreatedAt: fake.Now()}
+	if err := s.Payments.Create(&payment); err != nil {
+		t.Fatal(err)
+	}
+
+	fake.Advance(pendingTimeout - time.Second)
+	if count, err := s.Payments.ExpirePending(fake.Now(), pendingTimeout); err != nil || count != 0 {
+		t.Fatalf("ExpirePending within the timeout = %d, %v, want 0", count, err)
+	}
+	fake.Advance(2 * time.Second)
+	if count, err := s.Payments.ExpirePending(fake.Now(), pendingTimeout); err != nil || count != 1 {
+		t.Fatalf("ExpirePending after the timeout = %d, %v, want 1", count, err)
+	}
+
+	payment, err = s.Payments.Get(payment.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if payment.Status != models.PaymentFailed || !payment.UpdatedAt.Equal(fake.Now()) {
+		t.Errorf("expired payment is %q updated at %v, want %q at %v", payment.Status, payment.UpdatedAt, models.PaymentFailed, fake.Now())
+	}
+	if order, err = s.Orders.Get(order.ID); err != nil || order.Status != models.OrderFailed {
+		t.Errorf("order of the expired payment = %q, %v, want %q", order.Status, err, models.OrderFailed)
+	}
+	if sold, err := s.Tickets.CountByEvent(event.ID); err != nil || sold != 0 {
+		t.Errorf("CountByEvent = %d, %v, want 0", sold, err)
+	}
+	if _, err := s.Payments.Settle(payment.ID, models.PaymentSucceeded, fake.Now()); !errors.Is(err, store.ErrPaymentSettled) {
+		t.Errorf("late Settle = %v, want ErrPaymentSettled", err)
+	}
+}
@@ -0,0 +1,127 @@
+package store_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mgr1054/go-ticket/pkg/models"
+	"github.com/mgr1054/go-ticket/pkg/store"
+)
+
+// event whose general sale starts a week after testNow
+func createPresaleEvent(t *testing.T, s *store.Store, capacity int) models.Event {
+	t.Helper()
+	event := createEvent(t, s, capacity)
+	start, end := testNow.AddDate(0, 0, 7), testNow.AddDate(0, 1, 0)
+	event, err := s.Events.Update(event.ID, models.Event{SalesStart: &start, SalesEnd: &end}, testNow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return event
+}
+
+func createPresaleCode(t *testing.T, s *store.Store, code models.PresaleCode) models.PresaleCode {
+	t.Helper()
+	if err := code.Normalize(); err != nil {
+		t.Fatal(err)
+	}
+	code.CreatedAt = testNow
+	if err := s.PresaleCodes.Create(&code); err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+func presaleOrder(s *store.Store, userID uint, item store.OrderItem, code string, now time.Time) (models.Order, error) {
+	return s.Orders.Create(userID, []store.OrderItem{item}, store.OrderCodes{Presale: code}, now)
+}
+
+// before the general sale only a valid presale code with uses left unlocks tickets
+func testPresale(t *testing.T, s *store.Store) {
+	event := createPresaleEvent(t, s, 10)
+	other := createPresaleEvent(t, s, 10)
+	user := createUser(t, s)
+	from, until := testNow.Add(time.Hour), testNow.AddDate(0, 0, 3)
+	fanclub := createPresaleCode(t, s, models.PresaleCode{EventID: event.ID, Code: "fanclub", MaxUses: 2})
+	createPresaleCode(t, s, models.PresaleCode{EventID: event.ID, Code: "LATER", MaxUses: 5, ValidFrom: &from})
+	createPresaleCode(t, s, models.PresaleCode{EventID: event.ID, Code: "RADIO", MaxUses: 5, ValidUntil: &until})
+	createPresaleCode(t, s, models.PresaleCode{EventID: other.ID, Code: "OTHER", MaxUses: 5})
+	item := store.OrderItem{EventID: event.ID, Quantity: 1}
+
+	rejected := []struct {
+		name	string
+		code	string
+		now		time.Time
+		want	error
+	}{
+		{"no code", "", testNow, store.ErrNotOnSale},
+		{"unknown code", "NOPE", testNow, store.ErrPresaleCodeRejected},
+		{"code of another event", "OTHER", testNow, store.ErrPresaleCodeRejected},
+		{"code not valid yet", "LATER", testNow, store.ErrPresaleCodeRejected},
+		{"code expired", "RADIO", until, store.ErrPresaleCodeRejected},
+	}
+	for _, test := range rejected {
+		if _, err := presaleOrder(s, user.ID, item, test.code, test.now); !errors.Is(err, test.want) {
+			t.Errorf("%s: Create = %v, want %v", test.name, err, test.want)
+		}
+	}
+	for _, code := range []string{"LATER", "RADIO"} {
+		if _, err := presaleOrder(s, user.ID, item, code, from); err != nil {
+			t.Errorf("Create with %s inside its validity = %v, want nil", code, err)
+		}
+	}
+
+	// codes are matched ignoring case and every order or hold uses them once
+	order, err := presaleOrder(s, user.ID, item, " FanClub ", testNow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.PresaleCodeID == nil || *order.PresaleCodeID != fanclub.ID {
+		t.Errorf("order used presale code %v, want %d", order.PresaleCodeID, fanclub.ID)
+	}
+	hold, err := s.Holds.Create(user.ID, event.ID, 0, 1, "fanclub", testNow, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := presaleOrder(s, user.ID, item, "FANCLUB", testNow); !errors.Is(err, store.ErrPresaleCodeRejected) {
+		t.Errorf("Create with a used up code = %v, want ErrPresaleCodeRejected", err)
+	}
+	if got, err := s.PresaleCodes.Get(fanclub.ID, testNow); err != nil || got.Uses != 2 {
+		t.Errorf("Get = %d uses, %v, want 2", got.Uses, err)
+	}
+
+	// a released hold gives its use back
+	if err := s.Holds.Release(hold.ID, testNow); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := presaleOrder(s, user.ID, item, "FANCLUB", testNow); err != nil {
+		t.Errorf("Create after the hold was released = %v, want nil", err)
+	}
+
+	// the general sale needs no code
+	if _, err := presaleOrder(s, user.ID, item, "", *event.SalesStart); err != nil {
+		t.Errorf("Create in the general sale = %v, want nil", err)
+	}
+}
+
+// a presale code of a ticket type does not unlock the other types of the event
+func testPresaleTicketType(t *testing.T, s *store.Store) {
+	event := createPresaleEvent(t, s, 10)
+	user := createUser(t, s)
+	types := make([]models.TicketType, 2)
+	for i, name := range []string{"Standing", "VIP"} {
+		types[i] = models.TicketType{EventID: event.ID, Name: name, Price: event.Price, Quota: 5}
+		if err := s.TicketTypes.Create(&types[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	createPresaleCode(t, s, models.PresaleCode{EventID: event.ID, TicketTypeID: &types[1].ID, Code: "VIPONLY", MaxUses: 5})
+
+	if _, err := presaleOrder(s, user.ID, store.OrderItem{EventID: event.ID, TicketTypeID: types[0].ID, Quantity: 1}, "VIPONLY", testNow); !errors.Is(err, store.ErrPresaleCodeRejected) {
+		t.Errorf("Create of another ticket type = %v, want ErrPresaleCodeRejected", err)
+	}
+	if _, err := presaleOrder(s, user.ID, store.OrderItem{EventID: event.ID, TicketTypeID: types[1].ID, Quantity: 1}, "VIPONLY", testNow); err != nil {
+		t.Errorf("Create of the unlocked ticket type = %v, want nil", err)
+	}
+}
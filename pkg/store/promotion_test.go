@@ -0,0 +1,110 @@
+package store_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mgr1054/go-ticket/pkg/models"
+	"github.com/mgr1054/go-ticket/pkg/store"
+)
+
+func createPromotion(t *testing.T, s *store.Store, promotion models.Promotion) models.Promotion {
+	t.Helper()
+	if err := promotion.Normalize(); err != nil {
+		t.Fatal(err)
+	}
+	promotion.CreatedAt = testNow
+	if err := s.Promotions.Create(&promotion); err != nil {
+		t.Fatal(err)
+	}
+	return promotion
+}
+
+func promotionOrder(s *store.Store, userID uint, eventID uint, code string, now time.Time) (models.Order, error) {
+	return s.Orders.Create(userID, []store.OrderItem{{EventID: eventID, Quantity: 1}}, store.OrderCodes{Promotion: code}, now)
+}
+
+// promotions discount orders within their total and per user limits, failed orders
+// give their use back
+func testPromotionLimits(t *testing.T, s *store.Store) {
+	event := createEvent(t, s, 10)
+	first, second, third := createUser(t, s), createUser(t, s), createUser(t, s)
+	promotion := createPromotion(t, s, models.Promotion{Code: "summer10", Kind: models.PromotionPercent, Percent: 10, MaxUses: 2, MaxUsesPerUser: 1})
+
+	order, err := promotionOrder(s, first.ID, event.ID, " Summer10 ", testNow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.PromotionID == nil || *order.PromotionID != promotion.ID || order.Discount.Amount != 250 || order.Total.Amount != 2250 {
+		t.Errorf("order with promotion %v, discount %d and total %d, want %d, 250 and 2250", order.PromotionID, order.Discount.Amount, order.Total.Amount, promotion.ID)
+	}
+	if _, err := promotionOrder(s, first.ID, event.ID, "SUMMER10", testNow); !errors.Is(err, store.ErrPromotionRejected) {
+		t.Errorf("second use of the user = %v, want ErrPromotionRejected", err)
+	}
+
+	pending, err := promotionOrder(s, second.ID, event.ID, "SUMMER10", testNow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := promotionOrder(s, third.ID, event.ID, "SUMMER10", testNow); !errors.Is(err, store.ErrPromotionRejected) {
+		t.Errorf("use beyond the total limit = %v, want ErrPromotionRejected", err)
+	}
+	if got, err := s.Promotions.Get(promotion.ID); err != nil || got.Uses != 2 {
+		t.Errorf("Get = %d uses, %v, want 2", got.Uses, err)
+	}
+
+	payment := models.Payment{OrderID: pending.ID, Provider: "mock", IntentID: "pi_promotion", Amount: pending.Total.Amount, Currency: pending.Total.Currency, Status: models.PaymentPending, CreatedAt: testNow}
+	if err := s.Payments.Create(&payment); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Payments.Settle(payment.ID, models.PaymentFailed, testNow); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := promotionOrder(s, third.ID, event.ID, "SUMMER10", testNow); err != nil {
+		t.Errorf("use after an order failed = %v, want nil", err)
+	}
+}
+
+// promotions only work inside their validity and for their event
+func testPromotionExpiry(t *testing.T, s *store.Store) {
+	event := createEvent(t, s, 10)
+	other := createEvent(t, s, 10)
+	user := createUser(t, s)
+	from, until := testNow.Add(time.Hour), testNow.AddDate(0, 0, 7)
+	createPromotion(t, s, models.Promotion{Code: "WEEK", Kind: models.PromotionFixed, AmountOff: models.Money{Amount: 500, Currency: "EUR"}, ValidFrom: &from, ValidUntil: &until})
+	createPromotion(t, s, models.Promotion{Code: "BAND", Kind: models.PromotionPercent, Percent: 50, EventID: &event.ID})
+
+	tests := []struct {
+		name	string
+		eventID	uint
+		code	string
+		now		time.Time
+		want	error
+	}{
+		{"unknown code", event.ID, "NOPE", testNow, store.ErrPromotionRejected},
+		{"before its validity", event.ID, "WEEK", testNow, store.ErrPromotionRejected},
+		{"at its start", event.ID, "WEEK", from, nil},
+		{"just before its end", event.ID, "WEEK", until.Add(-time.Second), nil},
+		{"at its end", event.ID, "WEEK", until, store.ErrPromotionRejected},
+		{"other event", other.ID, "BAND", testNow, store.ErrPromotionRejected},
+		{"its event", event.ID, "BAND", testNow, nil},
+	}
+	for _, test := range tests {
+		if _, err := promotionOrder(s, user.ID, test.eventID, test.code, test.now); !errors.Is(err, test.want) {
+			t.Errorf("%s: Create = %v, want %v", test.name, err, test.want)
+		}
+	}
+
+	// confirming a hold checks the promotion at that time
+	hold, err := s.Holds.Create(user.ID, event.ID, 0, 1, "", until.Add(-time.Minute), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Holds.Confirm(hold.ID, "WEEK", until); !errors.Is(err, store.ErrPromotionRejected) {
+		t.Errorf("Confirm with an expired promotion = %v, want ErrPromotionRejected", err)
+	}
+	if _, err := s.Holds.Confirm(hold.ID, "", until); err != nil {
+		t.Errorf("Confirm without the promotion = %v, want nil", err)
+	}
+}
@@ -0,0 +1,83 @@
+package store_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mgr1054/go-ticket/pkg/models"
+	"github.com/mgr1054/go-ticket/pkg/store"
+)
+
+// policies are stored per event, cancelling within a tier records the refund of that tier
+func testRefundPolicy(t *testing.T, s *store.Store) {
+	event := createEvent(t, s, 10)
+	other := createEvent(t, s, 10)
+	if _, err := s.Refunds.GetPolicy(event.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("GetPolicy without a policy = %v, want ErrNotFound", err)
+	}
+
+	if err := s.Refunds.SavePolicy(models.RefundPolicy{EventID: event.ID, FullRefundDays: 30, PartialRefundDays: 3, PartialRefundPercent: 25}); err != nil {
+		t.Fatal(err)
+	}
+	policy := models.RefundPolicy{EventID: event.ID, FullRefundDays: 14, PartialRefundDays: 7, PartialRefundPercent: 50}
+	if err := s.Refunds.SavePolicy(policy); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := s.Refunds.GetPolicy(event.ID); err != nil || got != policy {
+		t.Errorf("GetPolicy = %+v, %v, want the replaced policy %+v", got, err, policy)
+	}
+	if _, err := s.Refunds.GetPolicy(other.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("GetPolicy of another event = %v, want ErrNotFound", err)
+	}
+
+	start := event.StartsAt
+	tests := []struct {
+		name	string
+		now		time.Time
+		percent	int
+		amount	int64
+	}{
+		{"long before", start.AddDate(0, 0, -60), 100, 2500},
+		{"full refund boundary", start.AddDate(0, 0, -14), 100, 2500},
+		{"partial refund", start.AddDate(0, 0, -14).Add(time.Second), 50, 1250},
+		{"partial refund boundary", start.AddDate(0, 0, -7), 50, 1250},
+		{"too late", start.AddDate(0, 0, -7).Add(time.Second), 0, 0},
+	}
+	tickets := paidTickets(t, s, event.ID, len(tests))
+	for i, test := range tests {
+		ticket := tickets[i]
+		percent := policy.Percent(event.StartsAt, test.now)
+		if percent != test.percent {
+			t.Errorf("%s: Percent = %d, want %d", test.name, percent, test.percent)
+			continue
+		}
+		if percent == 0 {
+			continue
+		}
+
+		refund := models.Refund{Amount: ticket.Price.Percent(percent).Amount, Currency: ticket.Price.Currency, Reason: models.RefundReasonPolicy}
+		if err := s.Tickets.Cancel(ticket.ID, &refund, test.now); err != nil {
+			t.Fatalf("%s: Cancel = %v", test.name, err)
+		}
+		refunds, err := s.Refunds.ListByTicket(ticket.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(refunds) != 1 || refunds[0].Amount != test.amount || refunds[0].Status != models.RefundPending || refunds[0].PaymentID == nil {
+			t.Errorf("%s: refunds %+v, want one pending refund of %d for the payment", test.name, refunds, test.amount)
+		}
+		if cancelled, err := s.Tickets.Get(ticket.ID); err != nil || cancelled.Status != models.TicketCancelled {
+			t.Errorf("%s: ticket is %s, %v, want cancelled", test.name, cancelled.Status, err)
+		}
+	}
+
+	// the rest of a partial refund can still be paid out by hand, not more
+	partial := tickets[2]
+	if err := s.Refunds.Create(&models.Refund{TicketID: partial.ID, Amount: 1251, Currency: "EUR", Reason: models.RefundReasonManual}, testNow); !errors.Is(err, store.ErrRefundExceeded) {
+		t.Errorf("Create beyond the ticket price = %v, want ErrRefundExceeded", err)
+	}
+	if err := s.Refunds.Create(&models.Refund{TicketID: partial.ID, Amount: 1250, Currency: "EUR", Reason: models.RefundReasonManual}, testNow); err != nil {
+		t.Errorf("Create of the rest = %v, want nil", err)
+	}
+}
@@ -0,0 +1,195 @@
+package store_test
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mgr1054/go-ticket/pkg/models"
+	"github.com/mgr1054/go-ticket/pkg/store"
+)
+
+// event whose capacity are the seats of its venue and unseated tickets beyond them,
+// returns the event with its seat inventory
+func createSeatedEvent(t *testing.T, s *store.Store, seats int, unseated int) (models.Event, []models.EventSeat) {
+	t.Helper()
+	n := nextFixture()
+	venue := models.Venue{
+		Name: fmt.Sprintf("Venue %d", n),
+		Timezone: "Europe/Berlin",
+		DefaultCapacity: seats + unseated,
+		Sections: []models.VenueSection{{Name: "Parkett", Capacity: seats}},
+	}
+	if err := s.Venues.Create(&venue); err != nil {
+		t.Fatal(err)
+	}
+	seatMap := make([]models.Seat, seats)
+	for i := range seatMap {
+		seatMap[i] = models.Seat{SectionID: venue.Sections[0].ID, Row: "A", Number: fmt.Sprint(i + 1)}
+	}
+	if _, err := s.Seats.ReplaceForVenue(venue.ID, seatMap); err != nil {
+		t.Fatal(err)
+	}
+
+	event := models.Event{
+		Band_Name: fmt.Sprintf("Band %d", n),
+		VenueID: venue.ID,
+		Location: venue.Name,
+		Price: models.Money{Amount: 2500, Currency: "EUR"},
+		Capacity: seats + unseated,
+		Timezone: venue.Timezone,
+		StartsAt: testNow.AddDate(1, 0, 0),
+		EndsAt: testNow.AddDate(1, 0, 0).Add(3 * time.Hour),
+		ReentryPolicy: models.ReentryNone,
+	}
+	if err := event.NormalizeTimes(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Events.Create(&event); err != nil {
+		t.Fatal(err)
+	}
+	inventory, err := s.Seats.ListByEvent(event.ID)
+	if err != nil || len(inventory) != seats {
+		t.Fatalf("ListByEvent = %d seats, %v, want %d", len(inventory), err, seats)
+	}
+	return event, inventory
+}
+
+func orderSeats(s *store.Store, userID uint, eventID uint, seatIDs ...uint) (models.Order, error) {
+	return s.Orders.Create(userID, []store.OrderItem{{EventID: eventID, SeatIDs: seatIDs}}, store.OrderCodes{}, testNow)
+}
+
+// reports whether the seat of the event is available
+func seatAvailable(t *testing.T, s *store.Store, eventID uint, seatID uint) bool {
+	t.Helper()
+	seats, err := s.Seats.ListByEvent(eventID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, seat := range seats {
+		if seat.ID == seatID {
+			return seat.Available
+		}
+	}
+	t.Fatalf("seat %d is not part of event %d", seatID, eventID)
+	return false
+}
+
+// many buyers race for the same seat, exactly one gets it
+func testConcurrentSeat(t *testing.T, s *store.Store) {
+	const buyers = 50
+
+	event, seats := createSeatedEvent(t, s, 2, 10)
+	users := []uint{createUser(t, s).ID, createUser(t, s).ID, createUser(t, s).ID}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	succeeded, taken := 0, 0
+	var unexpected []error
+	for i := 0; i < buyers; i++ {
+		wg.Add(1)
+		go func(userID uint) {
+			defer wg.Done()
+			_, err := orderSeats(s, userID, event.ID, seats[0].ID)
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				succeeded++
+			case errors.Is(err, store.ErrSeatTaken):
+				taken++
+			default:
+				unexpected = append(unexpected, err)
+			}
+		}(users[i%len(users)])
+	}
+	wg.Wait()
+
+	if len(unexpected) > 0 {
+		t.Fatalf("%d orders failed unexpectedly, first: %v", len(unexpected), unexpected[0])
+	}
+	if succeeded != 1 || taken != buyers-1 {
+		t.Errorf("got %d orders and %d taken, want 1 and %d", succeeded, taken, buyers-1)
+	}
+	if sold, err := s.Tickets.CountByEvent(event.ID); err != nil || sold != 1 {
+		t.Errorf("CountByEvent = %d, %v, want 1", sold, err)
+	}
+	if seatAvailable(t, s, event.ID, seats[0].ID) || !seatAvailable(t, s, event.ID, seats[1].ID) {
+		t.Error("want only the raced seat taken")
+	}
+	if _, err := orderSeats(s, users[0], event.ID, seats[1].ID, seats[1].ID); !errors.Is(err, store.ErrSeatTaken) {
+		t.Errorf("ordering one seat twice = %v, want ErrSeatTaken", err)
+	}
+}
+
+// a pending order holds its seats until its payment fails, holds of unseated tickets
+// cannot take the capacity of the seats
+func testHeldSeat(t *testing.T, s *store.Store) {
+	event, seats := createSeatedEvent(t, s, 2, 0)
+	first, second := createUser(t, s), createUser(t, s)
+
+	pending, err := orderSeats(s, first.ID, event.ID, seats[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seatAvailable(t, s, event.ID, seats[0].ID) {
+		t.Error("seat of the pending order is available")
+	}
+	if _, err := orderSeats(s, second.ID, event.ID, seats[0].ID); !errors.Is(err, store.ErrSeatTaken) {
+		t.Errorf("ordering the held seat = %v, want ErrSeatTaken", err)
+	}
+	if _, err := s.Holds.Create(second.ID, event.ID, 0, 1, "", testNow, time.Minute); !errors.Is(err, store.ErrSoldOut) {
+		t.Errorf("holding an unseated ticket of a fully seated event = %v, want ErrSoldOut", err)
+	}
+
+	payment := models.Payment{OrderID: pending.ID, Provider: "mock", IntentID: "pi_failed", Amount: pending.Total.Amount, Currency: pending.Total.Currency, Status: models.PaymentPending, CreatedAt: testNow}
+	if err := s.Payments.Create(&payment); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Payments.Settle(payment.ID, models.PaymentFailed, testNow); err != nil {
+		t.Fatal(err)
+	}
+	if !seatAvailable(t, s, event.ID, seats[0].ID) {
+		t.Error("seat of the failed order is still taken")
+	}
+	if _, err := orderSeats(s, second.ID, event.ID, seats[0].ID); err != nil {
+		t.Errorf("ordering the seat of the failed order = %v, want nil", err)
+	}
+}
+
+// cancelling a ticket gives its seat back to the sale
+func testSeatCancel(t *testing.T, s *store.Store) {
+	event, seats := createSeatedEvent(t, s, 1, 1)
+	first, second := createUser(t, s), createUser(t, s)
+
+	order, err := orderSeats(s, first.ID, event.ID, seats[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payOrder(t, s, order)
+	if _, err := orderSeats(s, second.ID, event.ID, seats[0].ID); !errors.Is(err, store.ErrSeatTaken) {
+		t.Fatalf("ordering the sold seat = %v, want ErrSeatTaken", err)
+	}
+
+	ticket := order.Tickets[0]
+	refund := models.Refund{Amount: ticket.Price.Amount, Currency: ticket.Price.Currency, Reason: models.RefundReasonPolicy}
+	if err := s.Tickets.Cancel(ticket.ID, &refund, testNow); err != nil {
+		t.Fatal(err)
+	}
+	if !seatAvailable(t, s, event.ID, seats[0].ID) {
+		t.Error("seat of the cancelled ticket is still taken")
+	}
+	resold, err := orderSeats(s, second.ID, event.ID, seats[0].ID)
+	if err != nil {
+		t.Fatalf("ordering the freed seat = %v, want nil", err)
+	}
+	if resold.Tickets[0].SeatID == nil || *resold.Tickets[0].SeatID != seats[0].ID {
+		t.Errorf("new ticket has seat %v, want %d", resold.Tickets[0].SeatID, seats[0].ID)
+	}
+	if cancelled, err := s.Tickets.Get(ticket.ID); err != nil || cancelled.Status != models.TicketCancelled || cancelled.SeatID == nil {
+		t.Errorf("Get of the cancelled ticket = %+v, %v, want it cancelled on its seat", cancelled, err)
+	}
+}
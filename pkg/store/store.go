@@ -0,0 +1,615 @@
+package store
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/mgr1054/go-ticket/pkg/models"
+)
+
+var (
+	ErrNotFound = errors.New("record not found")
+	ErrDuplicate = errors.New("record already exists")
+	ErrSoldOut = errors.New("event is sold out")
+	ErrHoldNotActive = errors.New("hold is expired, confirmed or released")
+	ErrPaymentSettled = errors.New("payment is already settled")
+	ErrTicketNotIssued = errors.New("ticket is not issued or its order is not paid")
+	ErrRefundExceeded = errors.New("refunds exceed the ticket price")
+	ErrRefundSettled = errors.New("refund is already settled")
+	ErrVenueInUse = errors.New("venue still has events")
+	ErrEventInUse = errors.New("event has tickets")
+	ErrUserInUse = errors.New("user has orders, tickets or payouts")
+	ErrSeatNotFound = errors.New("seat does not belong to the event")
+	ErrSeatTaken = errors.New("seat is already sold")
+	ErrSeatsSold = errors.New("seats of the event are already sold")
+	ErrTicketTypeNotFound = errors.New("ticket type does not belong to the event")
+	ErrTicketTypeRequired = errors.New("event sells its tickets in ticket types")
+	ErrNotOnSale = errors.New("tickets are not on sale")
+	ErrQuotaConflict = errors.New("quotas exceed the event capacity or fall below the sold tickets")
+	ErrCapacityConflict = errors.New("capacity is below the issued and held tickets")
+	ErrTicketTypeInUse = errors.New("ticket type has tickets or holds")
+	ErrPresaleCodeRejected = errors.New("presale code is unknown, not valid for the tickets or used up")
+	ErrPromotionRejected = errors.New("promo code is unknown, not active, used up or not valid for the tickets")
+	ErrWaitlistClosed = errors.New("waitlist entry is not waiting or offered anymore")
+	ErrWrongEvent = errors.New("ticket belongs to another event")
+	ErrAlreadyAdmitted = errors.New("ticket was already admitted")
+	ErrNotAdmitted = errors.New("ticket is not inside the event")
+	ErrReentryNotAllowed = errors.New("event does not allow re-entry")
+	ErrTicketUsed = errors.New("ticket was already used at the door")
+	ErrTransferClosed = errors.New("transfer is not pending anymore")
+	ErrTicketListed = errors.New("ticket is listed for resale")
+	ErrListingClosed = errors.New("listing is not active anymore")
+	ErrAboveFaceValue = errors.New("price exceeds the face value of the ticket")
+	ErrTokenExpired = errors.New("refresh token is expired")
+	ErrTokenRevoked = errors.New("refresh token was revoked")
+	ErrTokenReused = errors.New("refresh token was already used")
+)
+
+// bundles all repositories the handlers depend on
+type Store struct {
+	Events	EventStore
+	Tickets	TicketStore
+	Users	UserStore
+	Orders	OrderStore
+	Holds	HoldStore
+	Payments PaymentStore
+	Refunds	RefundStore
+	Venues	VenueStore
+	Seats	SeatStore
+	TicketTypes TicketTypeStore
+	PresaleCodes PresaleCodeStore
+	Promotions	PromotionStore
+	Waitlist	WaitlistStore
+	CheckIns	CheckInStore
+	Transfers	TransferStore
+	Listings	ListingStore
+	Tokens		TokenStore
+	SigningKeys	SigningKeyStore
+}
+
+type EventStore interface {
+	List() ([]models.Event, error)
+	Get(id uint) (models.Event, error)
+	// events at the venue named location, ignoring case and surrounding spaces
+	FindByLocation(location string) ([]models.Event, error)
+	// events starting within r, ordered by their start
+	FindByStart(r EventRange) ([]models.Event, error)
+	// stores the event with a seat inventory copied from the seat map of its venue
+	Create(event *models.Event) error
+	// applies all non-zero fields of changes to the event with id, a new venue replaces
+	// the seat inventory and returns ErrSeatsSold once seats were sold; returns
+	// ErrQuotaConflict when the capacity falls below the quotas of the ticket types and
+	// ErrCapacityConflict when it falls below the tickets issued or held at now
+	Update(id uint, changes models.Event, now time.Time) (models.Event, error)
+	// returns ErrEventInUse once tickets of the event exist, they stay on record
+	// together with their orders, payments and refunds
+	Delete(id uint) error
+}
+
+// bounds of a query for events by their start, To is exclusive and zero bounds are open
+type EventRange struct {
+	From	time.Time
+	To		time.Time
+	// compares the wall clock of From and To with the start at the venue instead of
+	// the instants, so a date matches the events taking place on that local date
+	Local	bool
+}
+
+// reports whether the event starts within r
+func (r EventRange) contains(event models.Event) bool {
+	if r.Local {
+		from, to := r.From.Format(models.LocalTimeLayout), r.To.Format(models.LocalTimeLayout)
+		return (r.From.IsZero() || event.StartsLocal >= from) && (r.To.IsZero() || event.StartsLocal < to)
+	}
+	return (r.From.IsZero() || !event.StartsAt.Before(r.From)) && (r.To.IsZero() || event.StartsAt.Before(r.To))
+}
+
+type VenueStore interface {
+	// returns the venues including their sections
+	List() ([]models.Venue, error)
+	Get(id uint) (models.Venue, error)
+	// finds a venue by name ignoring case and surrounding spaces
+	FindByName(name string) (models.Venue, error)
+	// stores the venue with its sections, returns ErrDuplicate when the name is taken
+	Create(venue *models.Venue) error
+	// replaces the fields and sections of the venue, sections without a known id are added
+	// and missing ones removed; events at the venue take over a new name
+	Update(id uint, venue models.Venue) (models.Venue, error)
+	// returns ErrVenueInUse while events take place at the venue
+	Delete(id uint) error
+}
+
+type SeatStore interface {
+	// seat map of the venue in the order the seats were added
+	ListByVenue(venueID uint) ([]models.Seat, error)
+	// replaces the seat map of the venue, the seat inventories of existing events stay
+	ReplaceForVenue(venueID uint, seats []models.Seat) ([]models.Seat, error)
+	// seat inventory of the event with the availability of every seat
+	ListByEvent(eventID uint) ([]models.EventSeat, error)
+	// copies the seat map of the venue into the inventory of the event again,
+	// returns ErrSeatsSold once a seat of the event was sold
+	ResetEvent(eventID uint) ([]models.EventSeat, error)
+}
+
+type TicketTypeStore interface {
+	// ticket types of the event with the number of tickets available at now
+	ListByEvent(eventID uint, now time.Time) ([]models.TicketType, error)
+	Get(id uint) (models.TicketType, error)
+	// returns ErrQuotaConflict when the quotas of the event would exceed its capacity
+	// and ErrDuplicate when the event has a ticket type of that name
+	Create(ticketType *models.TicketType) error
+	// replaces name, price, quota and sale window; returns ErrQuotaConflict when the quotas
+	// would exceed the event capacity or the quota falls below the tickets already sold
+	Update(id uint, ticketType models.TicketType) (models.TicketType, error)
+	// returns ErrTicketTypeInUse once tickets or holds of the type exist
+	Delete(id uint) error
+}
+
+type PresaleCodeStore interface {
+	// presale codes of the event with their uses at now
+	ListByEvent(eventID uint, now time.Time) ([]models.PresaleCode, error)
+	// returns the presale code with its uses at now
+	Get(id uint, now time.Time) (models.PresaleCode, error)
+	// returns ErrDuplicate when the event already has the code and
+	// ErrTicketTypeNotFound when the ticket type belongs to another event
+	Create(code *models.PresaleCode) error
+	// replaces ticket type, code, uses limit and validity of the presale code
+	Update(id uint, code models.PresaleCode) (models.PresaleCode, error)
+	// orders and holds bought with the code keep their tickets
+	Delete(id uint) error
+}
+
+type PromotionStore interface {
+	// all promotions with their uses
+	List() ([]models.Promotion, error)
+	// returns the promotion with its uses
+	Get(id uint) (models.Promotion, error)
+	// returns ErrDuplicate when the code is taken and ErrNotFound when the event does not exist
+	Create(promotion *models.Promotion) error
+	// replaces all fields but the id and creation time of the promotion
+	Update(id uint, promotion models.Promotion) (models.Promotion, error)
+	// orders discounted with the promotion keep their discount
+	Delete(id uint) error
+}
+
+type WaitlistStore interface {
+	// adds the entry to the end of the waitlist of its event; returns ErrDuplicate while
+	// the user has an open entry for the event, the ticket type errors of holds and
+	// ErrNotOnSale once the sale of the tickets ended
+	Join(entry *models.WaitlistEntry, now time.Time) error
+	// returns the entry with its position
+	Get(id uint) (models.WaitlistEntry, error)
+	// entries of the event in the order they joined, with their positions
+	ListByEvent(eventID uint) ([]models.WaitlistEntry, error)
+	ListByUser(userID uint) ([]models.WaitlistEntry, error)
+	// removes an open entry from the waitlist and releases the hold of its offer,
+	// returns ErrWaitlistClosed when the entry is not open anymore
+	Leave(id uint, now time.Time) error
+	// settles the offers of the event whose hold is used up, then offers the tickets
+	// left at now to the waiting entries in the order they joined, each as a hold
+	// valid for ttl; an entry that does not fit blocks the later entries of its
+	// ticket type, or of the whole event when the event capacity is short
+	Offer(eventID uint, now time.Time, ttl time.Duration) ([]models.WaitlistEntry, error)
+	// runs Offer for all events with open entries, returns the new offers
+	OfferAll(now time.Time, ttl time.Duration) ([]models.WaitlistEntry, error)
+}
+
+type CheckInStore interface {
+	// admits the ticket with the entry code at the event, gate, scanner and time of scan; a ticket
+	// enters once unless the event allows re-entry and it was scanned out since; returns
+	// ErrNotFound for unknown codes, ErrWrongEvent, ErrTicketNotIssued for cancelled and unpaid
+	// tickets, ErrTicketListed while the ticket is up for resale and ErrAlreadyAdmitted with the
+	// check-in that let the ticket in while it is inside, the rejected scan is recorded as duplicate then
+	Admit(scan models.CheckIn, code string) (models.CheckIn, error)
+	// scans the ticket out so it can enter again, returns ErrReentryNotAllowed when the event
+	// admits tickets only once and ErrNotAdmitted when the ticket is not inside
+	Exit(scan models.CheckIn, code string) (models.CheckIn, error)
+	// records the scans a scanner made offline; the scans of each ticket are replayed together with
+	// the recorded ones in the order they happened, so the first admission wins and every later one
+	// is flagged as duplicate, recorded ones included; scans uploaded before are not recorded twice;
+	// returns one result per scan and the recorded scans whose result changed
+	Sync(eventID uint, scans []OfflineScan) ([]SyncResult, []models.CheckIn, error)
+	// issued tickets of paid orders of the event that are not up for resale, the ones scanners
+	// admit; returns ErrNotFound when the event does not exist
+	Admissible(eventID uint) ([]models.Ticket, error)
+	// scans of the ticket in the order they happened
+	ListByTicket(ticketID uint) ([]models.CheckIn, error)
+	// returns ErrNotFound when the event does not exist
+	Stats(eventID uint) (models.CheckInStats, error)
+}
+
+// scan from the offline log of a scanner
+type OfflineScan struct {
+	// entry code of the scanned ticket
+	Code	string
+	// event, gate, scanner, direction and time of the scan
+	Scan	models.CheckIn
+}
+
+// outcome of an uploaded scan
+type SyncResult struct {
+	// the recorded scan with its result
+	CheckIn		models.CheckIn
+	// for duplicates the admission that let the ticket in before
+	Admission	*models.CheckIn
+	// ErrNotFound, ErrWrongEvent, ErrTicketNotIssued or ErrTicketListed, the scan is not recorded then
+	Err			error
+}
+
+type TransferStore interface {
+	// offers the ticket to the recipient until the transfer expires; returns ErrTicketNotIssued
+	// for cancelled and unpaid tickets, ErrTicketUsed once the ticket was admitted, ErrTicketListed
+	// while it is up for resale, ErrTransferClosed
+	// when the sender does not own the ticket and ErrDuplicate while another transfer of it is pending
+	Create(transfer *models.Transfer, now time.Time) error
+	Get(id uint) (models.Transfer, error)
+	// transfers of the ticket in the order they were made, the accepted ones are the chain of its owners
+	ListByTicket(ticketID uint) ([]models.Transfer, error)
+	// transfers the user sent or received
+	ListByUser(userID uint) ([]models.Transfer, error)
+	// hands the ticket to the recipient with a new entry code in one atomic step, so passes of the
+	// old code stop working; returns ErrTransferClosed when the transfer is not pending at now or the
+	// sender does not own the ticket anymore and the errors of Create when it cannot be transferred
+	Accept(id uint, now time.Time) (models.Transfer, error)
+	// closes a pending transfer as declined or cancelled, returns ErrTransferClosed when it is not pending at now
+	Close(id uint, status string, now time.Time) (models.Transfer, error)
+	// marks all pending transfers that expired before now, returns their number
+	ExpireAll(now time.Time) (int64, error)
+}
+
+type ListingStore interface {
+	// puts the ticket up for resale at the price, its face value is the price of the ticket
+	// before any discount at its first sale; returns the errors of transfers when the ticket cannot change hands,
+	// ErrTransferClosed when the seller does not own it, ErrDuplicate while it is listed
+	// already and ErrAboveFaceValue for prices above the face value or in another currency
+	Create(listing *models.Listing, now time.Time) error
+	Get(id uint) (models.Listing, error)
+	// active listings of the event, cheapest first
+	ListByEvent(eventID uint) ([]models.Listing, error)
+	// listings the user put up for resale
+	ListBySeller(sellerID uint) ([]models.Listing, error)
+	// takes an active listing off the marketplace, returns ErrListingClosed when it is not active
+	Withdraw(id uint, now time.Time) (models.Listing, error)
+	// reserves an active listing for the buyer and creates the pending order that pays
+	// for it; returns ErrListingClosed when the listing is not active or its event started
+	// at now. Settling the payment of the order hands the ticket to the buyer with a new
+	// entry code and the listing price and records the payout of the seller, the ticket keeps
+	// the order of its first sale; a failed payment activates the listing again
+	Buy(id uint, buyerID uint, now time.Time) (models.Order, error)
+	// withdraws all active listings of events that started before now, returns their number
+	WithdrawStarted(now time.Time) (int64, error)
+	// payouts owed to the user for resold tickets
+	ListPayouts(userID uint) ([]models.Payout, error)
+}
+
+type TokenStore interface {
+	// stores the refresh token of a new login
+	Create(token *models.RefreshToken, now time.Time) error
+	// exchanges the refresh token with hash for next, which joins its user and family; returns
+	// ErrNotFound for unknown tokens, ErrTokenExpired and ErrTokenRevoked. A token that was
+	// exchanged before is a replay of a stolen token: the whole family is revoked until
+	// revokeUntil and ErrTokenReused returned, next names the user and family then
+	Rotate(hash string, next *models.RefreshToken, now time.Time, revokeUntil time.Time) error
+	// revokes the refresh tokens of the family and rejects its access tokens until the given time
+	RevokeFamily(familyID string, now time.Time, until time.Time) error
+	// revokes every family of the user, like RevokeFamily
+	RevokeUser(userID uint, now time.Time, until time.Time) error
+	// reports whether access tokens of the family are on the revocation list at now
+	IsRevoked(familyID string, now time.Time) (bool, error)
+	// deletes expired refresh tokens and revocations, returns their number
+	Purge(now time.Time) (int64, error)
+}
+
+type SigningKeyStore interface {
+	// keys that have not expired at now, in the order they activate
+	List(now time.Time) ([]models.SigningKey, error)
+	Create(key *models.SigningKey) error
+	// deletes the keys that expired before now, returns their number
+	DeleteExpired(now time.Time) (int64, error)
+}
+
+type TicketStore interface {
+	Get(id uint) (models.Ticket, error)
+	// number of issued tickets of the event
+	CountByEvent(eventID uint) (int64, error)
+	ListByUser(userID uint) ([]models.Ticket, error)
+	// tickets of the event in every status
+	ListByEvent(eventID uint) ([]models.Ticket, error)
+	// cancels an issued ticket of a paid order and records its pending refund in one
+	// atomic step, the seat is available again afterwards; returns ErrTicketNotIssued
+	// when the ticket is already cancelled or its order is not completed, ErrTicketUsed
+	// once it was admitted at the door and ErrTicketListed while it is up for resale
+	Cancel(id uint, refund *models.Refund, now time.Time) error
+}
+
+// tickets requested for one event in an order
+type OrderItem struct {
+	EventID		uint
+	// tier of the tickets, required for events with ticket types
+	TicketTypeID uint
+	// number of tickets without a seat
+	Quantity	int
+	// seats of the event to buy one ticket for each
+	SeatIDs		[]uint
+}
+
+// codes entered at checkout, both are optional
+type OrderCodes struct {
+	// unlocks tickets before the general sale of their event
+	Presale		string
+	// discounts the tickets of the order
+	Promotion	string
+}
+
+type OrderStore interface {
+	// creates the pending order and all of its tickets in one atomic step, nothing
+	// is created when one of the events has not enough capacity left (ErrSoldOut)
+	// or one of the seats is sold already (ErrSeatTaken); events and ticket types have
+	// to be on sale at now (ErrNotOnSale) and ticket types have quota left; holds active
+	// at now count as used capacity; before the general sale the presale code has to
+	// unlock the tickets and have uses left (ErrPresaleCodeRejected); the promotion has to be
+	// active at now, within its limits and discount at least one ticket (ErrPromotionRejected)
+	Create(userID uint, items []OrderItem, codes OrderCodes, now time.Time) (models.Order, error)
+	// returns the order including its tickets, the resold ticket for orders of a listing
+	Get(id uint) (models.Order, error)
+	ListByUser(userID uint) ([]models.Order, error)
+}
+
+type HoldStore interface {
+	// reserves quantity tickets without a seat of the event and ticket type for ttl,
+	// returns ErrSoldOut when the event or ticket type has not enough capacity left;
+	// the sale is checked like for orders, the hold uses the presale code once
+	Create(userID uint, eventID uint, ticketTypeID uint, quantity int, presaleCode string, now time.Time, ttl time.Duration) (models.Hold, error)
+	Get(id uint) (models.Hold, error)
+	ListByUser(userID uint) ([]models.Hold, error)
+	// turns an active hold into a pending order with its tickets discounted by the
+	// promotion with promoCode if given, returns ErrHoldNotActive when the hold cannot
+	// be used anymore and ErrPromotionRejected like creating an order
+	Confirm(id uint, promoCode string, now time.Time) (models.Order, error)
+	// gives the reserved tickets back, returns ErrHoldNotActive when the hold cannot be used anymore
+	Release(id uint, now time.Time) error
+	// marks all holds that expired before now, returns their number
+	ExpireAll(now time.Time) (int64, error)
+}
+
+type PaymentStore interface {
+	// stores the payment as created at its CreatedAt
+	Create(payment *models.Payment) error
+	Get(id uint) (models.Payment, error)
+	GetByIntent(provider string, intentID string) (models.Payment, error)
+	// moves a pending payment to succeeded or failed and completes or fails its
+	// order in one atomic step, tickets of failed orders are given back;
+	// returns ErrPaymentSettled when the payment is not pending anymore
+	Settle(id uint, status string, now time.Time) (models.Payment, error)
+	// fails all payments that are pending for longer than timeout at now, returns their number
+	ExpirePending(now time.Time, timeout time.Duration) (int64, error)
+}
+
+type RefundStore interface {
+	// records a pending refund for a ticket in any state, issued tickets are cancelled;
+	// returns ErrRefundExceeded when the pending and succeeded refunds of the ticket
+	// would add up to more than its price and ErrTicketListed while it is up for resale
+	Create(refund *models.Refund, now time.Time) error
+	Get(id uint) (models.Refund, error)
+	List() ([]models.Refund, error)
+	ListByTicket(ticketID uint) ([]models.Refund, error)
+	// stores the result of the provider, the ticket becomes refunded when the refund
+	// succeeded; returns ErrRefundSettled when the refund is not pending anymore
+	Complete(id uint, status string, providerRefundID string, now time.Time) (models.Refund, error)
+	// returns ErrNotFound when the event has no policy of its own
+	GetPolicy(eventID uint) (models.RefundPolicy, error)
+	SavePolicy(policy models.RefundPolicy) error
+}
+
+type UserStore interface {
+	Get(id uint) (models.User, error)
+	GetByUsername(username string) (models.User, error)
+	GetByEmail(email string) (models.User, error)
+	// returns ErrDuplicate when the username or email is taken
+	Create(user *models.User) error
+	// applies all non-zero fields of changes to the user with id, returns ErrDuplicate
+	// when the new username or email is taken
+	Update(id uint, changes models.User) (models.User, error)
+	// marks the email address of the user as verified at now unless it already is; returns
+	// ErrNotFound when the user does not exist or changed the email address meanwhile
+	Verify(id uint, email string, now time.Time) (models.User, error)
+	// returns ErrUserInUse once the user has orders, tickets or payouts, which stay on record
+	Delete(id uint) error
+}
+
+// sums up the quantities and seats of items per event and ticket type, ordered by
+// event id so concurrent orders lock their events in the same order
+func mergeOrderItems(items []OrderItem) []OrderItem {
+	type key struct{ event, ticketType uint }
+	byKey := map[key]OrderItem{}
+	for _, item := range items {
+		k := key{item.EventID, item.TicketTypeID}
+		merged := byKey[k]
+		merged.EventID, merged.TicketTypeID = item.EventID, item.TicketTypeID
+		merged.Quantity += item.Quantity
+		merged.SeatIDs = append(merged.SeatIDs, item.SeatIDs...)
+		byKey[k] = merged
+	}
+	merged := make([]OrderItem, 0, len(byKey))
+	for _, item := range byKey {
+		merged = append(merged, item)
+	}
+	sort.Slice(merged, func(i, j int) bool {
+		if merged[i].EventID != merged[j].EventID {
+			return merged[i].EventID < merged[j].EventID
+		}
+		return merged[i].TicketTypeID < merged[j].TicketTypeID
+	})
+	return merged
+}
+
+// all tickets the items ask for at the event, without and with a seat
+func eventDemand(items []OrderItem, eventID uint) (int, []uint) {
+	quantity, seatIDs := 0, []uint{}
+	for _, item := range items {
+		if item.EventID == eventID {
+			quantity += item.Quantity
+			seatIDs = append(seatIDs, item.SeatIDs...)
+		}
+	}
+	return quantity, seatIDs
+}
+
+// id of the presale code that unlocked tickets, nil when none was needed
+func presaleCodeID(code *models.PresaleCode) *uint {
+	if code == nil {
+		return nil
+	}
+	return &code.ID
+}
+
+// reports whether an id occurs more than once
+func hasDuplicates(ids []uint) bool {
+	seen := map[uint]bool{}
+	for _, id := range ids {
+		if seen[id] {
+			return true
+		}
+		seen[id] = true
+	}
+	return false
+}
+
+// tickets of a ticket type with quota that can still be bought when used of them
+// are taken and left tickets of the event remain
+func availableTickets(quota int, used int64, left int64) int {
+	available := int64(quota) - used
+	if left < available {
+		available = left
+	}
+	if available < 0 {
+		return 0
+	}
+	return int(available)
+}
+
+// indexes of the scans grouped by entry code, ordered by code so concurrent uploads
+// lock the tickets in the same order and cannot deadlock
+func groupByCode(scans []OfflineScan) [][]int {
+	groups := [][]int{}
+	byCode := map[string]int{}
+	for i, scan := range scans {
+		g, ok := byCode[scan.Code]
+		if !ok {
+			g = len(groups)
+			byCode[scan.Code] = g
+			groups = append(groups, nil)
+		}
+		groups[g] = append(groups[g], i)
+	}
+	sort.Slice(groups, func(i, j int) bool { return scans[groups[i][0]].Code < scans[groups[j][0]].Code })
+	return groups
+}
+
+// replays the scans of a ticket in the order they happened, ties keep their order, and sets
+// their results: a ticket is admitted once, or again after it was scanned out when reentry is
+// true; returns the first admission, whether the ticket is inside after the last scan and
+// for every duplicate the admission that let the ticket in before
+func replayScans(scans []*models.CheckIn, reentry bool) (*models.CheckIn, bool, map[*models.CheckIn]*models.CheckIn) {
+	sort.SliceStable(scans, func(i, j int) bool { return scans[i].ScannedAt.Before(scans[j].ScannedAt) })
+
+	var first, last *models.CheckIn
+	inside := false
+	admissions := map[*models.CheckIn]*models.CheckIn{}
+	for _, scan := range scans {
+		switch {
+		case scan.Direction == models.DirectionOut && reentry && inside:
+			scan.Result = models.CheckInExited
+			inside = false
+		case scan.Direction == models.DirectionOut:
+			scan.Result = models.CheckInRejected
+		case !inside && (first == nil || reentry):
+			scan.Result = models.CheckInAdmitted
+			inside = true
+			last = scan
+			if first == nil {
+				first = scan
+			}
+		default:
+			scan.Result = models.CheckInDuplicate
+			admissions[scan] = last
+		}
+	}
+	return first, inside, admissions
+}
+
+// uploaded scans of one ticket merged into its recorded ones
+type ticketSync struct {
+	// recorded and uploaded scans in the order they happened
+	history		[]*models.CheckIn
+	// scan of each uploaded one by its index in the upload
+	uploaded	map[int]*models.CheckIn
+	// uploaded scans to record
+	fresh		[]*models.CheckIn
+	// recorded scans whose result changed
+	changed		[]*models.CheckIn
+	admissions	map[*models.CheckIn]*models.CheckIn
+	// new state of the ticket
+	admittedAt	*time.Time
+	inside		bool
+}
+
+// merges the scans of the group into the recorded scans of the ticket, in the order
+// (scanned_at, id), and replays them
+func newTicketSync(ticket models.Ticket, recorded []models.CheckIn, scans []OfflineScan, group []int, reentry bool) *ticketSync {
+	t := &ticketSync{uploaded: map[int]*models.CheckIn{}}
+	results := make([]string, len(recorded))
+	for i := range recorded {
+		t.history = append(t.history, &recorded[i])
+		results[i] = recorded[i].Result
+	}
+
+	for _, i := range group {
+		scan := scans[i].Scan
+		scan.TicketID = ticket.ID
+		scan.ScannedAt = scan.ScannedAt.UTC()
+		scan.Offline = true
+		if known := t.find(scan); known != nil {
+			// a retried upload
+			t.uploaded[i] = known
+			continue
+		}
+		t.uploaded[i] = &scan
+		t.history = append(t.history, &scan)
+		t.fresh = append(t.fresh, &scan)
+	}
+
+	first, inside, admissions := replayScans(t.history, reentry)
+	t.admissions, t.inside = admissions, inside
+	if first != nil {
+		t.admittedAt = &first.ScannedAt
+	}
+	for i := range recorded {
+		if recorded[i].Result != results[i] {
+			t.changed = append(t.changed, &recorded[i])
+		}
+	}
+	return t
+}
+
+// the offline scan with the same gate, direction and time
+func (t *ticketSync) find(scan models.CheckIn) *models.CheckIn {
+	for _, known := range t.history {
+		if known.Offline && known.Gate == scan.Gate && known.Direction == scan.Direction && known.ScannedAt.Equal(scan.ScannedAt) {
+			return known
+		}
+	}
+	return nil
+}
+
+// copies the scans of the group into the results once the fresh ones are recorded
+func (t *ticketSync) report(group []int, results []SyncResult) {
+	for _, i := range group {
+		results[i].CheckIn = *t.uploaded[i]
+		if admission := t.admissions[t.uploaded[i]]; admission != nil {
+			copied := *admission
+			results[i].Admission = &copied
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package store_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mgr1054/go-ticket/pkg/models"
+	"github.com/mgr1054/go-ticket/pkg/store"
+)
+
+// conformance suite, every implementation of the stores has to pass each case;
+// new store behaviour gets a case here instead of a test of a single backend
+var storeTests = []struct {
+	name	string
+	run		func(t *testing.T, s *store.Store)
+}{
+	{"users", testUsers},
+	{"venues", testVenues},
+	{"events", testEvents},
+	{"delete in use", testDeleteInUse},
+	{"refund policy", testRefundPolicy},
+	{"orders", testOrders},
+	{"concurrent orders", testConcurrentOrders},
+	{"hold capacity", testHoldCapacity},
+	{"hold confirm", testHoldConfirm},
+	{"hold expiry", testHoldExpiry},
+	{"payment expiry", testPaymentExpiry},
+	{"waitlist offers", testWaitlistOffers},
+	{"concurrent sync", testConcurrentSync},
+	{"concurrent admit", testConcurrentAdmit},
+	{"resale", testResale},
+	{"concurrent seat", testConcurrentSeat},
+	{"held seat", testHeldSeat},
+	{"seat cancel", testSeatCancel},
+	{"presale", testPresale},
+	{"presale ticket type", testPresaleTicketType},
+	{"promotion limits", testPromotionLimits},
+	{"promotion expiry", testPromotionExpiry},
+	{"transfer accept", testTransferAccept},
+	{"transfer cancel", testTransferCancel},
+	{"token rotate", testTokenRotate},
+	{"token revoke", testTokenRevoke},
+	{"token purge", testTokenPurge},
+}
+
+func TestStores(t *testing.T) {
+	for _, backend := range backends {
+		backend := backend
+		t.Run(backend.name, func(t *testing.T) {
+			runStoreTests(t, backend.open)
+		})
+	}
+}
+
+// runs every case of the suite against a fresh store from open
+func runStoreTests(t *testing.T, open func(t *testing.T) *store.Store) {
+	for _, test := range storeTests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			test.run(t, open(t))
+		})
+	}
+}
+
+func testUsers(t *testing.T, s *store.Store) {
+	user := createUser(t, s)
+
+	found, err := s.Users.GetByUsername(user.Username)
+	if err != nil || found.ID != user.ID {
+		t.Fatalf("GetByUsername = %d, %v, want %d", found.ID, err, user.ID)
+	}
+	found, err = s.Users.GetByEmail(user.Email)
+	if err != nil || found.ID != user.ID {
+		t.Fatalf("GetByEmail = %d, %v, want %d", found.ID, err, user.ID)
+	}
+	if _, err := s.Users.Get(user.ID + 1000); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Get of an unknown user = %v, want ErrNotFound", err)
+	}
+
+	taken := models.User{Name: "Other", Username: user.Username, Email: "other@example.com", Password: "hash", Role: "user"}
+	if err := s.Users.Create(&taken); !errors.Is(err, store.ErrDuplicate) {
+		t.Errorf("Create with a taken username = %v, want ErrDuplicate", err)
+	}
+	taken = models.User{Name: "Other", Username: "other", Email: user.Email, Password: "hash", Role: "user"}
+	if err := s.Users.Create(&taken); !errors.Is(err, store.ErrDuplicate) {
+		t.Errorf("Create with a taken email = %v, want ErrDuplicate", err)
+	}
+
+	other := createUser(t, s)
+	if _, err := s.Users.Update(other.ID, models.User{Email: user.Email}); !errors.Is(err, store.ErrDuplicate) {
+		t.Errorf("Update to a taken email = %v, want ErrDuplicate", err)
+	}
+	updated, err := s.Users.Update(other.ID, models.User{Name: "Renamed"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Name != "Renamed" || updated.Username != other.Username || updated.Email != other.Email {
+		t.Errorf("Update changed %+v, want only the name", updated)
+	}
+	if _, err := s.Users.Update(other.ID, models.User{Username: other.Username}); err != nil {
+		t.Errorf("Update to the own username = %v, want nil", err)
+	}
+
+	if err := s.Users.Delete(other.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Users.Get(other.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Get of a deleted user = %v, want ErrNotFound", err)
+	}
+	if err := s.Users.Delete(other.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Delete of a deleted user = %v, want ErrNotFound", err)
+	}
+}
+
+func testVenues(t *testing.T, s *store.Store) {
+	venue := models.Venue{Name: "Olympiahalle", Timezone: "Europe/Berlin", DefaultCapacity: 100}
+	if err := s.Venues.Create(&venue); err != nil {
+		t.Fatal(err)
+	}
+	duplicate := models.Venue{Name: "olympiahalle", Timezone: "Europe/Berlin", DefaultCapacity: 100}
+	if err := s.Venues.Create(&duplicate); !errors.Is(err, store.ErrDuplicate) {
+		t.Errorf("Create with a taken name = %v, want ErrDuplicate", err)
+	}
+	found, err := s.Venues.FindByName("  OLYMPIAHALLE ")
+	if err != nil || found.ID != venue.ID {
+		t.Errorf("FindByName = %d, %v, want %d", found.ID, err, venue.ID)
+	}
+
+	event := createEvent(t, s, 10)
+	if err := s.Venues.Delete(event.VenueID); !errors.Is(err, store.ErrVenueInUse) {
+		t.Errorf("Delete of a venue with events = %v, want ErrVenueInUse", err)
+	}
+	if err := s.Venues.Delete(venue.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Venues.Get(venue.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Get of a deleted venue = %v, want ErrNotFound", err)
+	}
+}
+
+func testEvents(t *testing.T, s *store.Store) {
+	later := createEvent(t, s, 10)
+	if _, err := s.Events.Update(later.ID, models.Event{StartsAt: later.StartsAt.AddDate(0, 0, 7), EndsAt: later.EndsAt.AddDate(0, 0, 7)}, testNow); err != nil {
+		t.Fatal(err)
+	}
+	sooner := createEvent(t, s, 10)
+
+	found, err := s.Events.FindByStart(store.EventRange{From: testNow, To: testNow.AddDate(2, 0, 0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 2 || found[0].ID != sooner.ID || found[1].ID != later.ID {
+		t.Errorf("FindByStart = %v, want events %d and %d in order", eventIDs(found), sooner.ID, later.ID)
+	}
+	found, err = s.Events.FindByLocation(" " + sooner.Location + " ")
+	if err != nil || len(found) != 1 || found[0].ID != sooner.ID {
+		t.Errorf("FindByLocation = %v, %v, want event %d", eventIDs(found), err, sooner.ID)
+	}
+
+	updated, err := s.Events.Update(sooner.ID, models.Event{Capacity: 20}, testNow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Capacity != 20 || updated.Band_Name != sooner.Band_Name {
+		t.Errorf("Update = capacity %d band %q, want 20 and %q", updated.Capacity, updated.Band_Name, sooner.Band_Name)
+	}
+	testCapacityBelowUsed(t, s)
+
+	if err := s.Events.Delete(sooner.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Events.Get(sooner.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Get of a deleted event = %v, want ErrNotFound", err)
+	}
+}
+
+// the capacity cannot fall below the issued tickets and active holds
+func testCapacityBelowUsed(t *testing.T, s *store.Store) {
+	event := createEvent(t, s, 10)
+	paidTickets(t, s, event.ID, 2)
+	user := createUser(t, s)
+	if _, err := s.Holds.Create(user.ID, event.ID, 0, 2, "", testNow, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.Events.Update(event.ID, models.Event{Capacity: 3}, testNow); !errors.Is(err, store.ErrCapacityConflict) {
+		t.Errorf("Update below the sold and held tickets = %v, want ErrCapacityConflict", err)
+	}
+	if got, err := s.Events.Get(event.ID); err != nil || got.Capacity != 10 {
+		t.Errorf("capacity %d, %v after a rejected update, want 10", got.Capacity, err)
+	}
+	if _, err := s.Events.Update(event.ID, models.Event{Capacity: 4}, testNow); err != nil {
+		t.Errorf("Update to the sold and held tickets = %v, want nil", err)
+	}
+	// an expired hold gives its tickets back
+	if _, err := s.Events.Update(event.ID, models.Event{Capacity: 2}, testNow.Add(time.Hour)); err != nil {
+		t.Errorf("Update after the hold expired = %v, want nil", err)
+	}
+}
+
+// users and events with tickets stay on record together with their orders and payments
+func testDeleteInUse(t *testing.T, s *store.Store) {
+	event := createEvent(t, s, 10)
+	ticket := paidTickets(t, s, event.ID, 1)[0]
+
+	if err := s.Users.Delete(ticket.UserID); !errors.Is(err, store.ErrUserInUse) {
+		t.Errorf("Delete of a user with tickets = %v, want ErrUserInUse", err)
+	}
+	if err := s.Events.Delete(event.ID); !errors.Is(err, store.ErrEventInUse) {
+		t.Errorf("Delete of an event with tickets = %v, want ErrEventInUse", err)
+	}
+
+	refund := models.Refund{Amount: ticket.Price.Amount, Currency: ticket.Price.Currency, Reason: models.RefundReasonEventCancelled}
+	if err := s.Tickets.Cancel(ticket.ID, &refund, testNow); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Events.Delete(event.ID); !errors.Is(err, store.ErrEventInUse) {
+		t.Errorf("Delete of an event with cancelled tickets = %v, want ErrEventInUse", err)
+	}
+	if _, err := s.Orders.Get(*ticket.OrderID); err != nil {
+		t.Errorf("Get of the order = %v, want nil", err)
+	}
+	if refunds, err := s.Refunds.ListByTicket(ticket.ID); err != nil || len(refunds) != 1 {
+		t.Errorf("ListByTicket = %d refunds, %v, want 1", len(refunds), err)
+	}
+	if tickets, err := s.Tickets.ListByEvent(event.ID); err != nil || len(tickets) != 1 || tickets[0].Status != models.TicketCancelled {
+		t.Errorf("ListByEvent = %+v, %v, want the cancelled ticket", tickets, err)
+	}
+
+	// users without orders go, with their holds
+	other := createUser(t, s)
+	if _, err := s.Holds.Create(other.ID, event.ID, 0, 1, "", testNow, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Users.Delete(other.ID); err != nil {
+		t.Errorf("Delete of a user with a hold = %v, want nil", err)
+	}
+}
+
+func eventIDs(events []models.Event) []uint {
+	ids := make([]uint, 0, len(events))
+	for _, event := range events {
+		ids = append(ids, event.ID)
+	}
+	return ids
+}
@@ -0,0 +1,144 @@
+package store_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/mgr1054/go-ticket/pkg/models"
+	"github.com/mgr1054/go-ticket/pkg/store"
+)
+
+func createRefreshToken(t *testing.T, s *store.Store, userID uint, familyID string, hash string, expiresAt time.Time) models.RefreshToken {
+	t.Helper()
+	token := models.RefreshToken{UserID: userID, FamilyID: familyID, TokenHash: hash, ExpiresAt: expiresAt}
+	if err := s.Tokens.Create(&token, testNow); err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func isRevoked(t *testing.T, s *store.Store, familyID string, now time.Time) bool {
+	t.Helper()
+	revoked, err := s.Tokens.IsRevoked(familyID, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return revoked
+}
+
+// rotating hands out the successor once, a replay of a used token revokes the whole family
+func testTokenRotate(t *testing.T, s *store.Store) {
+	user := createUser(t, s)
+	expiresAt := testNow.Add(24 * time.Hour)
+	revokeUntil := testNow.Add(15 * time.Minute)
+	createRefreshToken(t, s, user.ID, "family", "first", expiresAt)
+
+	second := models.RefreshToken{TokenHash: "second", ExpiresAt: expiresAt}
+	if err := s.Tokens.Rotate("first", &second, testNow, revokeUntil); err != nil {
+		t.Fatal(err)
+	}
+	if second.UserID != user.ID || second.FamilyID != "family" {
+		t.Errorf("successor of user %d in family %q, want %d in family", second.UserID, second.FamilyID, user.ID)
+	}
+	third := models.RefreshToken{TokenHash: "third", ExpiresAt: expiresAt}
+	if err := s.Tokens.Rotate("second", &third, testNow.Add(time.Minute), revokeUntil); err != nil {
+		t.Fatal(err)
+	}
+	if isRevoked(t, s, "family", testNow.Add(time.Minute)) {
+		t.Fatal("family revoked by regular rotations")
+	}
+
+	// the thief replays the first token, the legitimate successor stops working too
+	replay := models.RefreshToken{TokenHash: "replay", ExpiresAt: expiresAt}
+	if err := s.Tokens.Rotate("first", &replay, testNow.Add(2*time.Minute), revokeUntil); !errors.Is(err, store.ErrTokenReused) {
+		t.Fatalf("Rotate of a used token = %v, want ErrTokenReused", err)
+	}
+	if replay.UserID != user.ID || replay.FamilyID != "family" {
+		t.Errorf("replay names user %d in family %q, want %d in family", replay.UserID, replay.FamilyID, user.ID)
+	}
+	if err := s.Tokens.Rotate("third", &models.RefreshToken{TokenHash: "fourth", ExpiresAt: expiresAt}, testNow.Add(3*time.Minute), revokeUntil); !errors.Is(err, store.ErrTokenRevoked) {
+		t.Errorf("Rotate of the latest token after a replay = %v, want ErrTokenRevoked", err)
+	}
+	if err := s.Tokens.Rotate("replay", &models.RefreshToken{TokenHash: "fifth", ExpiresAt: expiresAt}, testNow.Add(3*time.Minute), revokeUntil); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Rotate of the rejected successor = %v, want ErrNotFound", err)
+	}
+	if !isRevoked(t, s, "family", testNow.Add(3*time.Minute)) {
+		t.Error("access tokens of the family are not revoked after a replay")
+	}
+	if isRevoked(t, s, "family", revokeUntil) {
+		t.Error("revocation outlives its end")
+	}
+
+	createRefreshToken(t, s, user.ID, "short", "short", testNow.Add(time.Hour))
+	if err := s.Tokens.Rotate("short", &models.RefreshToken{TokenHash: "late", ExpiresAt: expiresAt}, testNow.Add(time.Hour), revokeUntil); !errors.Is(err, store.ErrTokenExpired) {
+		t.Errorf("Rotate of an expired token = %v, want ErrTokenExpired", err)
+	}
+	if err := s.Tokens.Rotate("unknown", &models.RefreshToken{TokenHash: "next", ExpiresAt: expiresAt}, testNow, revokeUntil); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Rotate of an unknown token = %v, want ErrNotFound", err)
+	}
+}
+
+// revoking a family or a user leaves the other families alone
+func testTokenRevoke(t *testing.T, s *store.Store) {
+	user, other := createUser(t, s), createUser(t, s)
+	expiresAt := testNow.Add(24 * time.Hour)
+	revokeUntil := testNow.Add(15 * time.Minute)
+	for i, family := range []string{"phone", "laptop"} {
+		createRefreshToken(t, s, user.ID, family, fmt.Sprintf("user-%d", i), expiresAt)
+	}
+	createRefreshToken(t, s, other.ID, "other", "other", expiresAt)
+
+	if err := s.Tokens.RevokeFamily("phone", testNow, revokeUntil); err != nil {
+		t.Fatal(err)
+	}
+	if !isRevoked(t, s, "phone", testNow) || isRevoked(t, s, "laptop", testNow) {
+		t.Error("RevokeFamily did not revoke exactly its family")
+	}
+	if err := s.Tokens.Rotate("user-0", &models.RefreshToken{TokenHash: "phone-next", ExpiresAt: expiresAt}, testNow, revokeUntil); !errors.Is(err, store.ErrTokenRevoked) {
+		t.Errorf("Rotate in a revoked family = %v, want ErrTokenRevoked", err)
+	}
+
+	if err := s.Tokens.RevokeUser(user.ID, testNow, revokeUntil); err != nil {
+		t.Fatal(err)
+	}
+	if !isRevoked(t, s, "laptop", testNow) || isRevoked(t, s, "other", testNow) {
+		t.Error("RevokeUser did not revoke exactly the families of the user")
+	}
+	if err := s.Tokens.Rotate("user-1", &models.RefreshToken{TokenHash: "laptop-next", ExpiresAt: expiresAt}, testNow, revokeUntil); !errors.Is(err, store.ErrTokenRevoked) {
+		t.Errorf("Rotate after RevokeUser = %v, want ErrTokenRevoked", err)
+	}
+	if err := s.Tokens.Rotate("other", &models.RefreshToken{TokenHash: "other-next", ExpiresAt: expiresAt}, testNow, revokeUntil); err != nil {
+		t.Errorf("Rotate of another user = %v, want nil", err)
+	}
+}
+
+// purging drops expired refresh tokens and revocations only
+func testTokenPurge(t *testing.T, s *store.Store) {
+	user := createUser(t, s)
+	createRefreshToken(t, s, user.ID, "old", "old", testNow.Add(time.Hour))
+	createRefreshToken(t, s, user.ID, "new", "new", testNow.Add(48*time.Hour))
+	if err := s.Tokens.RevokeFamily("gone", testNow, testNow.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Tokens.RevokeFamily("kept", testNow, testNow.Add(48*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	if purged, err := s.Tokens.Purge(testNow); err != nil || purged != 0 {
+		t.Errorf("Purge before anything expired = %d, %v, want 0", purged, err)
+	}
+	if purged, err := s.Tokens.Purge(testNow.Add(24 * time.Hour)); err != nil || purged != 2 {
+		t.Errorf("Purge = %d, %v, want the expired token and revocation", purged, err)
+	}
+	if err := s.Tokens.Rotate("old", &models.RefreshToken{TokenHash: "old-next", ExpiresAt: testNow.Add(48 * time.Hour)}, testNow.Add(24*time.Hour), testNow.Add(25*time.Hour)); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Rotate of a purged token = %v, want ErrNotFound", err)
+	}
+	if err := s.Tokens.Rotate("new", &models.RefreshToken{TokenHash: "new-next", ExpiresAt: testNow.Add(48 * time.Hour)}, testNow.Add(24*time.Hour), testNow.Add(25*time.Hour)); err != nil {
+		t.Errorf("Rotate of a kept token = %v, want nil", err)
+	}
+	if !isRevoked(t, s, "kept", testNow.Add(24*time.Hour)) {
+		t.Error("Purge dropped a revocation that has not expired")
+	}
+}
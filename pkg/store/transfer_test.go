@@ -0,0 +1,130 @@
+package store_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mgr1054/go-ticket/pkg/models"
+	"github.com/mgr1054/go-ticket/pkg/store"
+)
+
+func createTransfer(s *store.Store, ticket models.Ticket, toUserID uint, now time.Time) (models.Transfer, error) {
+	transfer := models.Transfer{TicketID: ticket.ID, FromUserID: ticket.UserID, ToUserID: toUserID, ExpiresAt: now.Add(24 * time.Hour)}
+	err := s.Transfers.Create(&transfer, now)
+	return transfer, err
+}
+
+// accepting hands the ticket over with a new entry code, passes of the old one stop working
+func testTransferAccept(t *testing.T, s *store.Store) {
+	event := createEvent(t, s, 10)
+	ticket := paidTickets(t, s, event.ID, 1)[0]
+	recipient, stranger := createUser(t, s), createUser(t, s)
+
+	if err := s.Transfers.Create(&models.Transfer{TicketID: ticket.ID, FromUserID: stranger.ID, ToUserID: recipient.ID, ExpiresAt: testNow.Add(time.Hour)}, testNow); !errors.Is(err, store.ErrTransferClosed) {
+		t.Errorf("Create by another user = %v, want ErrTransferClosed", err)
+	}
+	transfer, err := createTransfer(s, ticket, recipient.ID, testNow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transfer.Status != models.TransferPending {
+		t.Errorf("new transfer is %s, want %s", transfer.Status, models.TransferPending)
+	}
+	if _, err := createTransfer(s, ticket, stranger.ID, testNow); !errors.Is(err, store.ErrDuplicate) {
+		t.Errorf("second pending transfer = %v, want ErrDuplicate", err)
+	}
+
+	accepted, err := s.Transfers.Accept(transfer.ID, testNow.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if accepted.Status != models.TransferAccepted || accepted.ClosedAt == nil {
+		t.Errorf("Accept = %s closed at %v, want accepted with a time", accepted.Status, accepted.ClosedAt)
+	}
+	if _, err := s.Transfers.Accept(transfer.ID, testNow.Add(time.Hour)); !errors.Is(err, store.ErrTransferClosed) {
+		t.Errorf("second Accept = %v, want ErrTransferClosed", err)
+	}
+
+	owned, err := s.Tickets.Get(ticket.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if owned.UserID != recipient.ID {
+		t.Errorf("ticket belongs to user %d, want the recipient %d", owned.UserID, recipient.ID)
+	}
+	if owned.Code == "" || owned.Code == ticket.Code {
+		t.Fatalf("ticket kept its entry code %q", owned.Code)
+	}
+	if _, err := createTransfer(s, ticket, stranger.ID, testNow.Add(time.Hour)); !errors.Is(err, store.ErrTransferClosed) {
+		t.Errorf("Create by the former owner = %v, want ErrTransferClosed", err)
+	}
+
+	scan := models.CheckIn{EventID: event.ID, Gate: "North", ScannedAt: event.StartsAt.Add(-time.Hour)}
+	if _, err := s.CheckIns.Admit(scan, ticket.Code); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Admit with the old code = %v, want ErrNotFound", err)
+	}
+	if _, err := s.CheckIns.Admit(scan, owned.Code); err != nil {
+		t.Errorf("Admit with the new code = %v, want nil", err)
+	}
+
+	// an admitted ticket cannot change hands anymore
+	if _, err := createTransfer(s, owned, stranger.ID, testNow.Add(time.Hour)); !errors.Is(err, store.ErrTicketUsed) {
+		t.Errorf("Create of an admitted ticket = %v, want ErrTicketUsed", err)
+	}
+
+	chain, err := s.Transfers.ListByTicket(ticket.ID)
+	if err != nil || len(chain) != 1 || chain[0].ID != transfer.ID {
+		t.Errorf("ListByTicket = %d transfers, %v, want transfer %d", len(chain), err, transfer.ID)
+	}
+}
+
+// cancelled, declined and expired transfers leave the ticket with its owner and code
+func testTransferCancel(t *testing.T, s *store.Store) {
+	event := createEvent(t, s, 10)
+	ticket := paidTickets(t, s, event.ID, 1)[0]
+	recipient := createUser(t, s)
+
+	for _, status := range []string{models.TransferCancelled, models.TransferDeclined} {
+		transfer, err := createTransfer(s, ticket, recipient.ID, testNow)
+		if err != nil {
+			t.Fatal(err)
+		}
+		closed, err := s.Transfers.Close(transfer.ID, status, testNow.Add(time.Minute))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if closed.Status != status || closed.ClosedAt == nil {
+			t.Errorf("Close = %s closed at %v, want %s with a time", closed.Status, closed.ClosedAt, status)
+		}
+		if _, err := s.Transfers.Close(transfer.ID, models.TransferCancelled, testNow.Add(time.Minute)); !errors.Is(err, store.ErrTransferClosed) {
+			t.Errorf("Close of a %s transfer = %v, want ErrTransferClosed", status, err)
+		}
+		if _, err := s.Transfers.Accept(transfer.ID, testNow.Add(time.Minute)); !errors.Is(err, store.ErrTransferClosed) {
+			t.Errorf("Accept of a %s transfer = %v, want ErrTransferClosed", status, err)
+		}
+	}
+
+	expiring, err := createTransfer(s, ticket, recipient.ID, testNow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Transfers.Accept(expiring.ID, expiring.ExpiresAt); !errors.Is(err, store.ErrTransferClosed) {
+		t.Errorf("Accept at the expiry = %v, want ErrTransferClosed", err)
+	}
+	if expired, err := s.Transfers.ExpireAll(expiring.ExpiresAt); err != nil || expired != 1 {
+		t.Errorf("ExpireAll = %d, %v, want 1", expired, err)
+	}
+
+	kept, err := s.Tickets.Get(ticket.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kept.UserID != ticket.UserID || kept.Code != ticket.Code {
+		t.Errorf("ticket of user %d with code %q, want user %d with %q", kept.UserID, kept.Code, ticket.UserID, ticket.Code)
+	}
+	transfers, err := s.Transfers.ListByTicket(ticket.ID)
+	if err != nil || len(transfers) != 3 || transfers[2].Status != models.TransferExpired {
+		t.Errorf("ListByTicket = %d transfers, %v, want 3 with the last expired", len(transfers), err)
+	}
+}
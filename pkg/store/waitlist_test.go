@@ -0,0 +1,110 @@
+package store_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mgr1054/go-ticket/pkg/clock"
+	"github.com/mgr1054/go-ticket/pkg/models"
+	"github.com/mgr1054/go-ticket/pkg/store"
+)
+
+const offerTTL = 30 * time.Minute
+
+func joinWaitlist(t *testing.T, s *store.Store, eventID uint, quantity int, now time.Time) models.WaitlistEntry {
+	t.Helper()
+	entry := models.WaitlistEntry{EventID: eventID, UserID: createUser(t, s).ID, Quantity: quantity}
+	if err := s.Waitlist.Join(&entry, now); err != nil {
+		t.Fatal(err)
+	}
+	return entry
+}
+
+func waitlistEntry(t *testing.T, s *store.Store, id uint) models.WaitlistEntry {
+	t.Helper()
+	entry, err := s.Waitlist.Get(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return entry
+}
+
+// tickets that free up go to the waiting entries in the order they joined, an entry
+// that does not fit holds back the later ones; offers expire with their hold and the
+// tickets go to the next entry
+func testWaitlistOffers(t *testing.T, s *store.Store) {
+	fake := clock.NewFake(testNow)
+	event := createEvent(t, s, 4)
+	buyer := createUser(t, s)
+	first, err := s.Holds.Create(buyer.ID, event.ID, 0, 2, "", fake.Now(), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := s.Holds.Create(buyer.ID, event.ID, 0, 2, "", fake.Now(), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := joinWaitlist(t, s, event.ID, 3, fake.Now())
+	b := joinWaitlist(t, s, event.ID, 1, fake.Now())
+	c := joinWaitlist(t, s, event.ID, 1, fake.Now())
+	if a.Position != 1 || b.Position != 2 || c.Position != 3 {
+		t.Fatalf("positions %d, %d, %d, want 1, 2, 3", a.Position, b.Position, c.Position)
+	}
+
+	// two tickets are not enough for the first entry, the later ones have to wait
+	if err := s.Holds.Release(first.ID, fake.Now()); err != nil {
+		t.Fatal(err)
+	}
+	offers, err := s.Waitlist.Offer(event.ID, fake.Now(), offerTTL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(offers) != 0 {
+		t.Fatalf("offered entries %v while the first entry does not fit, want none", entryIDs(offers))
+	}
+
+	if err := s.Holds.Release(second.ID, fake.Now()); err != nil {
+		t.Fatal(err)
+	}
+	offers, err = s.Waitlist.Offer(event.ID, fake.Now(), offerTTL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(offers) != 2 || offers[0].ID != a.ID || offers[1].ID != b.ID {
+		t.Fatalf("offered entries %v, want %d and %d", entryIDs(offers), a.ID, b.ID)
+	}
+	if c = waitlistEntry(t, s, c.ID); c.Status != models.WaitlistWaiting || c.Position != 1 {
+		t.Fatalf("last entry is %q at position %d, want %q at 1", c.Status, c.Position, models.WaitlistWaiting)
+	}
+	if hold, err := s.Holds.Get(*offers[0].HoldID); err != nil || hold.UserID != a.UserID || hold.Quantity != 3 {
+		t.Errorf("hold of the first offer = user %d quantity %d, %v, want user %d quantity 3", hold.UserID, hold.Quantity, err, a.UserID)
+	}
+
+	// once the offers expired their tickets go to the next entry
+	fake.Advance(offerTTL + time.Second)
+	offers, err = s.Waitlist.OfferAll(fake.Now(), offerTTL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(offers) != 1 || offers[0].ID != c.ID || offers[0].HoldID == nil {
+		t.Fatalf("offered entries %v after the expiry, want %d", entryIDs(offers), c.ID)
+	}
+	for _, id := range []uint{a.ID, b.ID} {
+		if entry := waitlistEntry(t, s, id); entry.Status != models.WaitlistExpired {
+			t.Errorf("entry %d is %q after its offer expired, want %q", id, entry.Status, models.WaitlistExpired)
+		}
+	}
+	order, err := s.Holds.Confirm(*offers[0].HoldID, "", fake.Now())
+	if err != nil || order.UserID != c.UserID || len(order.Tickets) != 1 {
+		t.Errorf("Confirm of the offer = user %d with %d tickets, %v, want user %d with 1", order.UserID, len(order.Tickets), err, c.UserID)
+	}
+}
+
+func entryIDs(entries []models.WaitlistEntry) []uint {
+	ids := make([]uint, 0, len(entries))
+	for _, entry := range entries {
+		ids = append(ids, entry.ID)
+	}
+	return ids
+}
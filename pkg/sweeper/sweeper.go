@@ -0,0 +1,58 @@
+package sweeper
+
+import (
+	"time"
+
+	"github.com/mgr1054/go-ticket/pkg/clock"
+	log "github.com/sirupsen/logrus"
+)
+
+// cleans up expired records, returns the number of affected records
+type Job func(now time.Time) (int64, error)
+
+// runs cleanup jobs in a fixed interval in the background
+type Sweeper struct {
+	clock		clock.Clock
+	interval	time.Duration
+	names		[]string
+	jobs		[]Job
+}
+
+func New(clk clock.Clock, interval time.Duration) *Sweeper {
+	return &Sweeper{clock: clk, interval: interval}
+}
+
+func (s *Sweeper) Add(name string, job Job) {
+	s.names = append(s.names, name)
+	s.jobs = append(s.jobs, job)
+}
+
+// runs all jobs once
+func (s *Sweeper) Sweep() {
+	now := s.clock.Now()
+	for i, job := range s.jobs {
+		count, err := job(now)
+		if err != nil {
+			log.Error("Sweeper job ", s.names[i], " failed: ", err)
+			continue
+		}
+		if count > 0 {
+			log.Infof("Sweeper job %s cleaned up %d records", s.names[i], count)
+		}
+	}
+}
+
+// sweeps in the configured interval until stop is closed
+func (s *Sweeper) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.Sweep()
+		case <-stop:
+			return
+		}
+	}
+}
@@ -1,46 +1,70 @@
 package utils
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"time"
 	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
 )
 
-// used to generate JWTs
-var jwtKey = []byte("supersecretkey")
-
 // struct payload of JWT 
 type JWTClaim struct {
 	Username string `json:"username"`
 	Email    string `json:"email"`
 	Role	 string `json:"role"`
+	// refresh token family the access token was issued for, revoking it rejects the token
+	Family	 string `json:"fam"`
 	jwt.StandardClaims
 }
 
-// generate token with HS256 Signing, expiration 1 hour
-func GenerateJWT(email string, username string, role string) (tokenString string, err error) {
-	expirationTime := time.Now().Add(1 * time.Hour)
+// generate short-lived token signed with the current signing key, expiration after ttl
+func GenerateJWT(email string, username string, role string, family string, ttl time.Duration) (tokenString string, err error) {
+	key, err := currentSigningKey()
+	if err != nil {
+		return
+	}
+	id, err := NewTokenID()
+	if err != nil {
+		return
+	}
+	now := time.Now()
 	claims:= &JWTClaim{
 		Email: email,
 		Username: username,
 		Role: role,
+		Family: family,
 		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: expirationTime.Unix(),
+			Id: id,
+			IssuedAt: now.Unix(),
+			ExpiresAt: now.Add(ttl).Unix(),
 		},
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err = token.SignedString(jwtKey)
+	token := jwt.NewWithClaims(signingMethod(key.Algorithm), claims)
+	token.Header["kid"] = key.ID
+	tokenString, err = token.SignedString(key.Signer)
 	return
 }
 
-// validate token, check if expired
+// validate token against the still valid key named in its kid header, check if expired
 func ValidateToken(signedToken string) (claims *JWTClaim, err error) {
 	token, err := jwt.ParseWithClaims(
 		signedToken,
 		&JWTClaim{},
 		func(token *jwt.Token) (interface{}, error) {
-			return []byte(jwtKey), nil
+			kid, _ := token.Header["kid"].(string)
+			key, ok := verifyingKey(kid, time.Now())
+			if !ok {
+				return nil, errors.New("token is signed with an unknown or expired key")
+			}
+			// the key decides the algorithm, never the token
+			if token.Method.Alg() != key.Algorithm {
+				return nil, errors.New("token algorithm does not match its key")
+			}
+			return key.Public, nil
 		},
 	)
 	if err != nil {
@@ -58,6 +82,31 @@ func ValidateToken(signedToken string) (claims *JWTClaim, err error) {
 	return claims, err
 }
 
+// random identifier of tokens and token families
+func NewTokenID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
+
+// opaque refresh token for the client and the hash that is stored in its place
+func NewRefreshToken() (token string, hash string, err error) {
+	secret := make([]byte, 32)
+	if _, err = rand.Read(secret); err != nil {
+		return
+	}
+	token = base64.RawURLEncoding.EncodeToString(secret)
+	return token, HashToken(token), nil
+}
+
+// hash under which a refresh token is stored, the token has enough entropy for plain SHA-256
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
 func CheckUserType(c *gin.Context, role string)(err error){
 	
 	userRole := c.GetString("role")
@@ -1,34 +1,40 @@
 package utils
 
 import (
+	"errors"
+	"time"
 
-	"github.com/mgr1054/go-ticket/pkg/db"
+	"github.com/mgr1054/go-ticket/pkg/config"
 	"github.com/mgr1054/go-ticket/pkg/models"
+	"github.com/mgr1054/go-ticket/pkg/store"
 	log "github.com/sirupsen/logrus"
 )
 
-func InitAdmin(){
-	var admin models.User
-	var exists bool
+func InitAdmin(users store.UserStore, cfg config.Admin){
+	_, err := users.GetByUsername("admin")
 
-	db.DB.Model(&admin).Select("count (*) > 0").Where("username = ?", "admin").Find(&exists)
-
-	if exists {
+	if err == nil {
 		log.Info("Admin found")
 		return
 	}
 
+	if !errors.Is(err, store.ErrNotFound) {
+		log.Fatalln("Error while looking up Admin")
+	}
+
 	log.Info("Admin not found, creating new Admin")
-	admin = models.User{Name: "admin", Username:"admin", Email:"admin@go-ticket.com", Password: "p", Role: "admin"}
+	// the configured address counts as verified
+	verifiedAt := time.Now().UTC()
+	admin := models.User{Name: "admin", Username:"admin", Email: cfg.Email, Password: cfg.Password, Role: "admin", VerifiedAt: &verifiedAt}
 
 	if err := admin.HashPassword(admin.Password); err != nil {
 		log.Fatal("Password could not be hashed")
 		return
 	}
 
-	err1 := db.DB.Create(&admin).Error; if err1 != nil {
+	err1 := users.Create(&admin); if err1 != nil {
 		log.Fatalln("Error while creating Admin")
 	}
 
 	log.Info("Admin created")
-}
\ No newline at end of file
+}
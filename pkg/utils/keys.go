@@ -0,0 +1,152 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// algorithms access tokens can be signed with
+const (
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+// key that signs or verifies access tokens, tokens name it in their kid header
+type JWTKey struct {
+	ID			string
+	Algorithm	string
+	// nil for keys that only verify
+	Signer		crypto.Signer
+	Public		crypto.PublicKey
+	// tokens signed with the key are rejected afterwards
+	ExpiresAt	time.Time
+}
+
+// keys of the running instance, replaced by the key rotation
+var (
+	keysMu			sync.RWMutex
+	signingKey		*JWTKey
+	verifyingKeys	[]JWTKey
+)
+
+// sets the key that signs new tokens and all keys tokens are accepted from
+func SetJWTKeys(signing JWTKey, keys []JWTKey) {
+	keysMu.Lock()
+	defer keysMu.Unlock()
+	signingKey = &signing
+	verifyingKeys = keys
+}
+
+func currentSigningKey() (JWTKey, error) {
+	keysMu.RLock()
+	defer keysMu.RUnlock()
+	if signingKey == nil {
+		return JWTKey{}, errors.New("no signing key loaded")
+	}
+	return *signingKey, nil
+}
+
+// looks up the key with kid that still verifies tokens at now
+func verifyingKey(kid string, now time.Time) (JWTKey, bool) {
+	keysMu.RLock()
+	defer keysMu.RUnlock()
+	for _, key := range verifyingKeys {
+		if key.ID == kid && key.ExpiresAt.After(now) {
+			return key, true
+		}
+	}
+	return JWTKey{}, false
+}
+
+func signingMethod(algorithm string) jwt.SigningMethod {
+	if algorithm == AlgorithmEdDSA {
+		return SigningMethodEdDSA
+	}
+	return jwt.SigningMethodRS256
+}
+
+// EdDSA with Ed25519 keys (RFC 8037), jwt-go v3 only ships HMAC, RSA and ECDSA
+type signingMethodEd25519 struct{}
+
+var SigningMethodEdDSA = &signingMethodEd25519{}
+
+func init() {
+	jwt.RegisterSigningMethod(AlgorithmEdDSA, func() jwt.SigningMethod { return SigningMethodEdDSA })
+}
+
+func (m *signingMethodEd25519) Alg() string {
+	return AlgorithmEdDSA
+}
+
+func (m *signingMethodEd25519) Sign(signingString string, key interface{}) (string, error) {
+	private, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return "", jwt.ErrInvalidKeyType
+	}
+	return jwt.EncodeSegment(ed25519.Sign(private, []byte(signingString))), nil
+}
+
+func (m *signingMethodEd25519) Verify(signingString string, signature string, key interface{}) error {
+	public, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return jwt.ErrInvalidKeyType
+	}
+	decoded, err := jwt.DecodeSegment(signature)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(public, []byte(signingString), decoded) {
+		return jwt.ErrSignatureInvalid
+	}
+	return nil
+}
+
+// public key in the JSON Web Key format (RFC 7517)
+type JWK struct {
+	KeyType		string	`json:"kty"`
+	ID			string	`json:"kid"`
+	Use			string	`json:"use"`
+	Algorithm	string	`json:"alg"`
+	// Ed25519 keys
+	Curve		string	`json:"crv,omitempty"`
+	X			string	`json:"x,omitempty"`
+	// RSA keys
+	N			string	`json:"n,omitempty"`
+	E			string	`json:"e,omitempty"`
+}
+
+// public keys that verify tokens at now, including keys published ahead of their use
+func JWKS(now time.Time) []JWK {
+	keysMu.RLock()
+	defer keysMu.RUnlock()
+
+	jwks := []JWK{}
+	for _, key := range verifyingKeys {
+		if !key.ExpiresAt.After(now) {
+			continue
+		}
+		jwk := JWK{ID: key.ID, Use: "sig", Algorithm: key.Algorithm}
+		switch public := key.Public.(type) {
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		default:
+			continue
+		}
+		jwks = append(jwks, jwk)
+	}
+	return jwks
+}
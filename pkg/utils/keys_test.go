@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+func newEd25519Key(t *testing.T, id string, expiresAt time.Time) JWTKey {
+	t.Helper()
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return JWTKey{ID: id, Algorithm: AlgorithmEdDSA, Signer: private, Public: public, ExpiresAt: expiresAt}
+}
+
+func TestSigningMethodEdDSA(t *testing.T) {
+	key := newEd25519Key(t, "key", time.Now().Add(time.Hour))
+	other := newEd25519Key(t, "other", time.Now().Add(time.Hour))
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signature, err := SigningMethodEdDSA.Sign("header.payload", key.Signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SigningMethodEdDSA.Sign("header.payload", rsaKey); !errors.Is(err, jwt.ErrInvalidKeyType) {
+		t.Errorf("Sign with an RSA key = %v, want ErrInvalidKeyType", err)
+	}
+
+	tests := []struct {
+		name		string
+		signing		string
+		signature	string
+		key			interface{}
+		want		error
+	}{
+		{"round trip", "header.payload", signature, key.Public, nil},
+		{"other payload", "header.tampered", signature, key.Public, jwt.ErrSignatureInvalid},
+		{"other key", "header.payload", signature, other.Public, jwt.ErrSignatureInvalid},
+		{"truncated signature", "header.payload", signature[:len(signature)-4], key.Public, jwt.ErrSignatureInvalid},
+		{"RSA key", "header.payload", signature, &rsaKey.PublicKey, jwt.ErrInvalidKeyType},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			if err := SigningMethodEdDSA.Verify(test.signing, test.signature, test.key); !errors.Is(err, test.want) {
+				t.Errorf("Verify = %v, want %v", err, test.want)
+			}
+		})
+	}
+}
+
+func TestValidateTokenKeys(t *testing.T) {
+	current := newEd25519Key(t, "current", time.Now().Add(time.Hour))
+	previous := newEd25519Key(t, "previous", time.Now().Add(time.Hour))
+	retired := newEd25519Key(t, "retired", time.Now().Add(time.Hour))
+	unknown := newEd25519Key(t, "unknown", time.Now().Add(time.Hour))
+	mislabeled := newEd25519Key(t, "mislabeled", time.Now().Add(time.Hour))
+
+	sign := func(key JWTKey) string {
+		t.Helper()
+		SetJWTKeys(key, []JWTKey{key})
+		token, err := GenerateJWT("max@example.com", "max", "user", "family", time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	tokens := map[string]string{}
+	for _, key := range []JWTKey{current, previous, retired, unknown, mislabeled} {
+		tokens[key.ID] = sign(key)
+	}
+
+	// the previous key verifies until its tokens expired, the retired one already did
+	retired.ExpiresAt = time.Now().Add(-time.Second)
+	mislabeled.Algorithm = AlgorithmRS256
+	SetJWTKeys(current, []JWTKey{current, previous, retired, mislabeled})
+
+	tests := []struct {
+		kid		string
+		valid	bool
+	}{
+		{"current", true},
+		{"previous", true},
+		{"retired", false},
+		{"unknown", false},
+		{"mislabeled", false},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.kid, func(t *testing.T) {
+			claims, err := ValidateToken(tokens[test.kid])
+			if test.valid && (err != nil || claims.Username != "max") {
+				t.Errorf("ValidateToken = %v, %v, want the claims of max", claims, err)
+			}
+			if !test.valid && err == nil {
+				t.Error("ValidateToken accepted the token")
+			}
+		})
+	}
+}
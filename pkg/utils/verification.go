@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidVerification = errors.New("verification token is malformed")
+
+// token of a verification link:
+//
+//	<user id>.<expiry as unix time>.<signature>
+//
+// the signature is the HMAC-SHA256 of the first two parts and the email address in
+// unpadded base64url, so the link stops working when the address changes
+type VerificationToken struct {
+	UserID		uint
+	ExpiresAt	time.Time
+	signature	[]byte
+}
+
+func verificationMAC(secret string, userID uint, expiresAt int64, email string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.%d.%s", userID, expiresAt, email)
+	return mac.Sum(nil)
+}
+
+// returns the token that verifies email for the user until expiresAt
+func SignVerification(secret string, userID uint, email string, expiresAt time.Time) string {
+	signature := verificationMAC(secret, userID, expiresAt.Unix(), email)
+	return fmt.Sprintf("%d.%d.%s", userID, expiresAt.Unix(), base64.RawURLEncoding.EncodeToString(signature))
+}
+
+// splits the token, the signature is checked with Valid once the email of the user is known
+func ParseVerification(token string) (VerificationToken, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return VerificationToken{}, ErrInvalidVerification
+	}
+	userID, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return VerificationToken{}, ErrInvalidVerification
+	}
+	expiresAt, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return VerificationToken{}, ErrInvalidVerification
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return VerificationToken{}, ErrInvalidVerification
+	}
+	return VerificationToken{UserID: uint(userID), ExpiresAt: time.Unix(expiresAt, 0), signature: signature}, nil
+}
+
+// reports whether the token was signed with secret for the email address
+func (t VerificationToken) Valid(secret string, email string) bool {
+	return hmac.Equal(t.signature, verificationMAC(secret, t.UserID, t.ExpiresAt.Unix(), email))
+}